@@ -42,6 +42,7 @@ func NewClients(config Config) Clients {
 	awsCfg := &aws.Config{
 		Credentials: credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, ""),
 		Region:      aws.String(config.Region),
+		Retryer:     NewRetryer(),
 	}
 	s := session.New(awsCfg)
 	clients := Clients{
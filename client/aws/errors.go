@@ -3,12 +3,12 @@ package aws
 import "github.com/juju/errgo"
 
 const (
-	AlreadyAssociated        = "Resource.AlreadyAssociated"
-	InvalidSubnetConflict    = "InvalidSubnet.Conflict"
-	KeyPairDuplicate         = "InvalidKeyPair.Duplicate"
-	SecurityGroupDuplicate   = "InvalidGroup.Duplicate"
-	ELBAlreadyExists         = "DuplicateLoadBalancerName"
-	ELBConfigurationMismatch = "already exists and it is configured with different parameters"
+	AlreadyAssociated          = "Resource.AlreadyAssociated"
+	InvalidParameterValue      = "InvalidParameterValue"
+	InvalidSubnetConflict      = "InvalidSubnet.Conflict"
+	KeyPairDuplicate           = "InvalidKeyPair.Duplicate"
+	SecurityGroupDuplicate     = "InvalidGroup.Duplicate"
+	SecurityGroupRuleDuplicate = "InvalidPermission.Duplicate"
 )
 
 var malformedAmazonAccountIDError = errgo.New("malformed amazon account ID")
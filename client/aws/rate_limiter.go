@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// readOnlyOperationPrefixes are the prefixes of AWS API operations which do not
+// modify any resources. They are not rate limited.
+var readOnlyOperationPrefixes = []string{
+	"Describe",
+	"Get",
+	"Head",
+	"List",
+}
+
+// RateLimiter is a token bucket limiting the rate of mutating AWS API calls.
+// A single RateLimiter is meant to be shared by all the clients of an
+// operator, so that the API pressure is smoothed out account-wide.
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewRateLimiter creates a RateLimiter allowing rate calls per second on
+// average, and bursts of up to rate calls.
+func NewRateLimiter(rate float64) *RateLimiter {
+	burst := math.Max(1, math.Ceil(rate))
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// Wait blocks until the caller is allowed to perform a call.
+func (r *RateLimiter) Wait() {
+	r.sleep(r.reserve())
+}
+
+// reserve takes a token from the bucket and returns how long the caller has to
+// wait for it. Tokens can be taken in advance, so concurrent callers queue up
+// instead of competing for the next token.
+func (r *RateLimiter) reserve() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	r.tokens = math.Min(r.burst, r.tokens+now.Sub(r.last).Seconds()*r.rate)
+	r.last = now
+
+	r.tokens--
+	if r.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-r.tokens / r.rate * float64(time.Second))
+}
+
+// Limit makes the given clients wait for the rate limiter before sending any
+// mutating request. A nil RateLimiter does not limit anything.
+func (r *RateLimiter) Limit(clients Clients) {
+	if r == nil {
+		return
+	}
+
+	for _, handlers := range []*request.Handlers{
+		&clients.EC2.Handlers,
+		&clients.IAM.Handlers,
+		&clients.S3.Handlers,
+		&clients.KMS.Handlers,
+		&clients.ELB.Handlers,
+		&clients.Route53.Handlers,
+	} {
+		handlers.Send.PushFront(func(req *request.Request) {
+			if isMutatingOperation(req.Operation.Name) {
+				r.Wait()
+			}
+		})
+	}
+}
+
+func isMutatingOperation(name string) bool {
+	for _, prefix := range readOnlyOperationPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,103 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		rate   float64
+		calls  int
+		delays []time.Duration
+	}{
+		{
+			name:   "calls within the burst are not throttled",
+			rate:   3,
+			calls:  3,
+			delays: []time.Duration{0, 0, 0},
+		},
+		{
+			name:  "calls beyond the burst are spread at the configured rate",
+			rate:  2,
+			calls: 5,
+			delays: []time.Duration{
+				0,
+				0,
+				500 * time.Millisecond,
+				time.Second,
+				1500 * time.Millisecond,
+			},
+		},
+		{
+			name:  "rates below one call per second",
+			rate:  0.5,
+			calls: 3,
+			delays: []time.Duration{
+				0,
+				2 * time.Second,
+				4 * time.Second,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		now := time.Unix(0, 0)
+
+		var delays []time.Duration
+		r := NewRateLimiter(tc.rate)
+		r.last = now
+		r.now = func() time.Time { return now }
+		r.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+		for i := 0; i < tc.calls; i++ {
+			r.Wait()
+		}
+
+		assert.Equal(t, tc.delays, delays, fmt.Sprintf("[%s] The input values didn't produce the expected delays", tc.name))
+	}
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+
+	var delays []time.Duration
+	r := NewRateLimiter(1)
+	r.last = now
+	r.now = func() time.Time { return now }
+	r.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	r.Wait()
+	r.Wait()
+	now = now.Add(10 * time.Second)
+	r.Wait()
+	r.Wait()
+
+	expected := []time.Duration{0, time.Second, 0, time.Second}
+	assert.Equal(t, expected, delays, "Idle time should only refill the bucket up to its burst")
+}
+
+func TestIsMutatingOperation(t *testing.T) {
+	tests := []struct {
+		operation string
+		res       bool
+	}{
+		{operation: "RunInstances", res: true},
+		{operation: "CreateVpc", res: true},
+		{operation: "PutObject", res: true},
+		{operation: "DeleteKeyPair", res: true},
+		{operation: "DescribeInstances", res: false},
+		{operation: "GetUser", res: false},
+		{operation: "ListGrants", res: false},
+		{operation: "HeadBucket", res: false},
+	}
+
+	for _, tc := range tests {
+		res := isMutatingOperation(tc.operation)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.operation))
+	}
+}
@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/juju/errgo"
+)
+
+const (
+	// defaultMaxRetries is how often the SDK retries failed calls by default.
+	defaultMaxRetries = 3
+	// maxTransientRetries is how often calls failing with transient errors are
+	// retried.
+	maxTransientRetries = 8
+	// transientRetryBaseDelay is the delay before the first retry of a call
+	// failing with a transient error. It doubles with every retry, up to
+	// transientRetryMaxDelay.
+	transientRetryBaseDelay = 500 * time.Millisecond
+	transientRetryMaxDelay  = 20 * time.Second
+)
+
+// transientErrorCodes are the codes of the errors AWS returns when a call was
+// throttled, or the service was unavailable.
+var transientErrorCodes = map[string]bool{
+	"PriorRequestNotComplete": true,
+	"RequestLimitExceeded":    true,
+	"RequestThrottled":        true,
+	"ServiceUnavailable":      true,
+	"SlowDown":                true,
+	"Throttling":              true,
+	"ThrottlingException":     true,
+	"Unavailable":             true,
+}
+
+// IsTransient asserts the errors of AWS API calls which were throttled, or
+// which the service was unavailable for.
+func IsTransient(err error) bool {
+	awsErr, ok := errgo.Cause(err).(awserr.Error)
+	return ok && transientErrorCodes[awsErr.Code()]
+}
+
+// Retryer retries the AWS API calls failing with transient errors, with a
+// jittered exponential backoff. The calls weren't carried out by AWS, so
+// retrying them is safe, even when they aren't idempotent. Calls failing with
+// other errors are retried like the SDK does by default.
+type Retryer struct {
+	client.DefaultRetryer
+
+	maxTransientRetries int
+	baseDelay           time.Duration
+	maxDelay            time.Duration
+}
+
+// NewRetryer creates a Retryer, to be set as the request.Retryer of AWS
+// clients.
+func NewRetryer() Retryer {
+	return Retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: defaultMaxRetries},
+
+		maxTransientRetries: maxTransientRetries,
+		baseDelay:           transientRetryBaseDelay,
+		maxDelay:            transientRetryMaxDelay,
+	}
+}
+
+// MaxRetries returns how often calls are retried at most.
+func (r Retryer) MaxRetries() int {
+	if r.maxTransientRetries > r.DefaultRetryer.MaxRetries() {
+		return r.maxTransientRetries
+	}
+
+	return r.DefaultRetryer.MaxRetries()
+}
+
+// ShouldRetry returns whether the given failed call is retried.
+func (r Retryer) ShouldRetry(req *request.Request) bool {
+	if isTransientRequest(req) {
+		return true
+	}
+
+	return req.RetryCount < r.DefaultRetryer.MaxRetries() && r.DefaultRetryer.ShouldRetry(req)
+}
+
+// RetryRules returns the delay before the given failed call is retried. For
+// transient errors, it is picked at random between half and all of the
+// exponential backoff, so the callers throttled together don't retry together.
+func (r Retryer) RetryRules(req *request.Request) time.Duration {
+	if !isTransientRequest(req) {
+		return r.DefaultRetryer.RetryRules(req)
+	}
+
+	delay := r.maxDelay
+	if req.RetryCount < 32 && r.baseDelay<<uint(req.RetryCount) < r.maxDelay {
+		delay = r.baseDelay << uint(req.RetryCount)
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func isTransientRequest(req *request.Request) bool {
+	if req.HTTPResponse != nil && req.HTTPResponse.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+
+	return IsTransient(req.Error)
+}
@@ -0,0 +1,192 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/juju/errgo"
+	"github.com/stretchr/testify/assert"
+)
+
+const describeVpcsResponse = `<DescribeVpcsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>request-1</requestId>
+  <vpcSet>
+    <item>
+      <vpcId>vpc-1</vpcId>
+    </item>
+  </vpcSet>
+</DescribeVpcsResponse>`
+
+// fakeEC2Error is an error response of the EC2 API.
+type fakeEC2Error struct {
+	status int
+	code   string
+}
+
+// fakeEC2API fails the calls with the given errors in order, and succeeds
+// afterwards.
+type fakeEC2API struct {
+	mutex  sync.Mutex
+	calls  int
+	errors []fakeEC2Error
+}
+
+func (f *fakeEC2API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.calls++
+	if len(f.errors) == 0 {
+		fmt.Fprint(w, describeVpcsResponse)
+		return
+	}
+
+	e := f.errors[0]
+	f.errors = f.errors[1:]
+	w.WriteHeader(e.status)
+	fmt.Fprintf(w, "<Response><Errors><Error><Code>%s</Code><Message>failed</Message></Error></Errors><RequestID>request-1</RequestID></Response>", e.code)
+}
+
+func repeatEC2Error(e fakeEC2Error, n int) []fakeEC2Error {
+	var errors []fakeEC2Error
+	for i := 0; i < n; i++ {
+		errors = append(errors, e)
+	}
+
+	return errors
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		res  bool
+	}{
+		{name: "EC2 throttling", err: awserr.New("RequestLimitExceeded", "", nil), res: true},
+		{name: "IAM throttling", err: awserr.New("Throttling", "", nil), res: true},
+		{name: "Route53 throttling", err: awserr.New("PriorRequestNotComplete", "", nil), res: true},
+		{name: "unavailable service", err: awserr.New("ServiceUnavailable", "", nil), res: true},
+		{name: "masked throttling", err: errgo.Mask(awserr.New("RequestLimitExceeded", "", nil), errgo.Any), res: true},
+		{name: "invalid parameter", err: awserr.New(InvalidParameterValue, "", nil), res: false},
+		{name: "other error", err: errgo.New("failed"), res: false},
+		{name: "no error", err: nil, res: false},
+	}
+
+	for _, tc := range tests {
+		res := IsTransient(tc.err)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.name))
+	}
+}
+
+func TestRetryerRetries(t *testing.T) {
+	throttled := fakeEC2Error{status: http.StatusServiceUnavailable, code: "RequestLimitExceeded"}
+	unavailable := fakeEC2Error{status: http.StatusServiceUnavailable, code: "Unavailable"}
+	internal := fakeEC2Error{status: http.StatusInternalServerError, code: "InternalError"}
+	invalid := fakeEC2Error{status: http.StatusBadRequest, code: InvalidParameterValue}
+
+	tests := []struct {
+		name     string
+		errors   []fakeEC2Error
+		resCalls int
+		resCode  string
+	}{
+		{
+			name:     "successful call",
+			resCalls: 1,
+		},
+		{
+			name:     "throttled call eventually succeeds",
+			errors:   []fakeEC2Error{throttled, throttled, unavailable},
+			resCalls: 4,
+		},
+		{
+			name:     "throttled call succeeds after more retries than the default ones",
+			errors:   repeatEC2Error(throttled, maxTransientRetries),
+			resCalls: maxTransientRetries + 1,
+		},
+		{
+			name:     "throttled call gives up",
+			errors:   repeatEC2Error(throttled, maxTransientRetries+1),
+			resCalls: maxTransientRetries + 1,
+			resCode:  "RequestLimitExceeded",
+		},
+		{
+			name:     "internal errors are retried by default",
+			errors:   repeatEC2Error(internal, defaultMaxRetries+1),
+			resCalls: defaultMaxRetries + 1,
+			resCode:  "InternalError",
+		},
+		{
+			name:     "invalid call isn't retried",
+			errors:   []fakeEC2Error{invalid},
+			resCalls: 1,
+			resCode:  InvalidParameterValue,
+		},
+	}
+
+	for _, tc := range tests {
+		api := &fakeEC2API{errors: tc.errors}
+		server := httptest.NewServer(api)
+
+		var delays []time.Duration
+		client := ec2.New(session.New(&aws.Config{
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+			Endpoint:    aws.String(server.URL),
+			Region:      aws.String("eu-central-1"),
+			Retryer:     NewRetryer(),
+			SleepDelay:  func(d time.Duration) { delays = append(delays, d) },
+		}))
+
+		resp, err := client.DescribeVpcs(&ec2.DescribeVpcsInput{})
+		server.Close()
+
+		assert.Equal(t, tc.resCalls, api.calls, fmt.Sprintf("[%s] Wrong number of calls", tc.name))
+		assert.Len(t, delays, tc.resCalls-1, fmt.Sprintf("[%s] Wrong number of delays", tc.name))
+		if tc.resCode != "" {
+			awsErr, ok := err.(awserr.Error)
+			assert.True(t, ok, fmt.Sprintf("[%s] Unexpected error: %v", tc.name, err))
+			if ok {
+				assert.Equal(t, tc.resCode, awsErr.Code(), fmt.Sprintf("[%s] Wrong error", tc.name))
+			}
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.name))
+		if assert.Len(t, resp.Vpcs, 1, fmt.Sprintf("[%s] Wrong VPCs", tc.name)) {
+			assert.Equal(t, "vpc-1", *resp.Vpcs[0].VpcId, fmt.Sprintf("[%s] Wrong VPC", tc.name))
+		}
+	}
+}
+
+func TestRetryerRetryRules(t *testing.T) {
+	retryer := NewRetryer()
+	client := ec2.New(session.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Region:      aws.String("eu-central-1"),
+	}))
+
+	for retryCount := 0; retryCount < maxTransientRetries; retryCount++ {
+		req, _ := client.DescribeVpcsRequest(&ec2.DescribeVpcsInput{})
+		req.Error = awserr.New("RequestLimitExceeded", "", nil)
+		req.RetryCount = retryCount
+
+		backoff := transientRetryBaseDelay << uint(retryCount)
+		if backoff > transientRetryMaxDelay {
+			backoff = transientRetryMaxDelay
+		}
+
+		// The delays are jittered, so they are checked repeatedly.
+		for i := 0; i < 10; i++ {
+			delay := retryer.RetryRules(req)
+			assert.True(t, delay >= backoff/2 && delay <= backoff, fmt.Sprintf("[retry %d] Delay %s out of [%s, %s]", retryCount, delay, backoff/2, backoff))
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package logger creates the logger used by the operator, writing either
+// logfmt or JSON lines.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	microerror "github.com/giantswarm/microkit/error"
+	micrologger "github.com/giantswarm/microkit/logger"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-stack/stack"
+)
+
+const (
+	// LogFormatJSON writes every message as a JSON object, suitable for
+	// structured log ingestion, e.g. by Elasticsearch.
+	LogFormatJSON = "json"
+	// LogFormatLogfmt writes every message as logfmt key/value pairs.
+	LogFormatLogfmt = "logfmt"
+)
+
+// Config represents the configuration used to create a new logger.
+type Config struct {
+	// Settings.
+	Caller   kitlog.Valuer
+	IOWriter io.Writer
+	// LogFormat is either LogFormatJSON or LogFormatLogfmt.
+	LogFormat          string
+	TimestampFormatter kitlog.Valuer
+}
+
+// DefaultConfig provides a default configuration to create a new logger by best
+// effort.
+func DefaultConfig() Config {
+	return Config{
+		// Settings.
+		Caller: func() interface{} {
+			return fmt.Sprintf("%+v", stack.Caller(4))
+		},
+		IOWriter:  ioutil.Discard,
+		LogFormat: LogFormatJSON,
+		TimestampFormatter: func() interface{} {
+			return time.Now().UTC().Format("06-01-02 15:04:05.000")
+		},
+	}
+}
+
+// New creates a new configured logger.
+func New(config Config) (micrologger.Logger, error) {
+	// Settings.
+	if config.Caller == nil {
+		return nil, microerror.MaskAnyf(invalidConfigError, "caller must not be empty")
+	}
+	if config.IOWriter == nil {
+		return nil, microerror.MaskAnyf(invalidConfigError, "IO writer must not be empty")
+	}
+	if config.TimestampFormatter == nil {
+		return nil, microerror.MaskAnyf(invalidConfigError, "timestamp formatter must not be empty")
+	}
+
+	var kitLogger kitlog.Logger
+	switch config.LogFormat {
+	case LogFormatJSON:
+		kitLogger = kitlog.NewJSONLogger(kitlog.NewSyncWriter(config.IOWriter))
+	case LogFormatLogfmt:
+		kitLogger = kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(config.IOWriter))
+	default:
+		return nil, microerror.MaskAnyf(invalidConfigError, "log format must be '%s' or '%s', got '%s'", LogFormatJSON, LogFormatLogfmt, config.LogFormat)
+	}
+	kitLogger = kitlog.NewContext(kitLogger).With(
+		"caller", config.Caller,
+		"time", config.TimestampFormatter,
+	)
+
+	newLogger := &logger{
+		Logger: kitLogger,
+	}
+
+	return newLogger, nil
+}
+
+type logger struct {
+	Logger kitlog.Logger
+}
+
+func (l *logger) Log(keyvals ...interface{}) error {
+	return l.Logger.Log(keyvals...)
+}
@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogFormat(t *testing.T) {
+	tests := []struct {
+		desc         string
+		logFormat    string
+		assert       func(line string) error
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:      "json",
+			logFormat: LogFormatJSON,
+			assert: func(line string) error {
+				var message map[string]string
+				if err := json.Unmarshal([]byte(line), &message); err != nil {
+					return err
+				}
+				if message["info"] != "created cluster 'foo'" || message["time"] != "now" || message["caller"] != "here" {
+					return fmt.Errorf("unexpected message %#v", message)
+				}
+				return nil
+			},
+		},
+		{
+			desc:      "logfmt",
+			logFormat: LogFormatLogfmt,
+			assert: func(line string) error {
+				expected := `caller=here time=now info="created cluster 'foo'"`
+				if line != expected {
+					return fmt.Errorf("expected %q, got %q", expected, line)
+				}
+				return nil
+			},
+		},
+		{
+			desc:         "unknown format",
+			logFormat:    "xml",
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			desc:         "empty format",
+			logFormat:    "",
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tests {
+		var buf bytes.Buffer
+
+		config := DefaultConfig()
+		config.Caller = func() interface{} { return "here" }
+		config.IOWriter = &buf
+		config.LogFormat = tc.logFormat
+		config.TimestampFormatter = func() interface{} { return "now" }
+
+		logger, err := New(config)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the logger", tc.desc))
+
+		err = logger.Log("info", "created cluster 'foo'")
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error logging", tc.desc))
+
+		line := strings.TrimSpace(buf.String())
+		assert.Nil(t, tc.assert(line), fmt.Sprintf("[%s] The configured format wasn't applied", tc.desc))
+	}
+}
+
+func TestDefaultConfigLogFormat(t *testing.T) {
+	assert.Equal(t, LogFormatJSON, DefaultConfig().LogFormat, "JSON must stay the default format")
+}
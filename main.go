@@ -3,15 +3,18 @@ package main
 import (
 	"os"
 	"path"
+	"time"
 
 	"github.com/giantswarm/microkit/command"
-	"github.com/giantswarm/microkit/logger"
+	micrologger "github.com/giantswarm/microkit/logger"
 	microserver "github.com/giantswarm/microkit/server"
 
 	awsclient "github.com/giantswarm/aws-operator/client/aws"
 	k8sclient "github.com/giantswarm/aws-operator/client/k8s"
+	"github.com/giantswarm/aws-operator/logger"
 	"github.com/giantswarm/aws-operator/server"
 	"github.com/giantswarm/aws-operator/service"
+	"github.com/giantswarm/aws-operator/service/create"
 )
 
 var (
@@ -31,6 +34,8 @@ var Flags = struct {
 			Secret string
 		}
 		PubKeyFile string
+		RateLimit  float64
+		Region     string
 	}
 	Kubernetes struct {
 		InCluster   bool
@@ -45,13 +50,62 @@ var Flags = struct {
 		}
 		Insecure bool
 	}
+	Log struct {
+		Format string
+	}
+	Service struct {
+		AnnotationTags  []string
+		ClusterSelector string
+		DNS             struct {
+			CheckDelegation bool
+			Components      []string
+			Concurrency     int
+		}
+		Drain struct {
+			Enabled bool
+			Timeout time.Duration
+		}
+		CloudConfigEncoding   string
+		CloudConfigValidation bool
+		DeprecatedImages      string
+		Ingress               struct {
+			SourceCIDRs []string
+		}
+		LeaderElection struct {
+			Enabled       bool
+			Identity      string
+			LeaseDuration time.Duration
+			LockName      string
+			LockNamespace string
+			RenewDeadline time.Duration
+			RetryPeriod   time.Duration
+		}
+		APIHealthCheckPath      string
+		InstanceHostnames       bool
+		InstanceRunningTimeout  time.Duration
+		InternalAPILoadBalancer bool
+		LaunchConcurrency       int
+		MaxClusters             int
+		NetworkPolicies         bool
+		OperatorID              string
+		ReconcileCertSecrets    bool
+		ResourcePrefix          string
+		S3VPCEndpoint           bool
+		FlowLogs                bool
+		DHCPOptions             bool
+		ShutdownTimeout         time.Duration
+		TerminateStuckInstances bool
+		WaitForMastersReady     bool
+		WorkerSpotMaxPrice      string
+	}
 }{}
 
 func main() {
 	var err error
 
-	// Create a new logger which is used by all packages.
-	var newLogger logger.Logger
+	// Create a new logger which is used by the command. The command flags are
+	// not parsed yet, so it uses the default log format.
+	var newLogger micrologger.Logger
 	{
 		loggerConfig := logger.DefaultConfig()
 		loggerConfig.IOWriter = os.Stdout
@@ -64,6 +118,19 @@ func main() {
 	// We define a server factory to create the custom server once all command
 	// line flags are parsed and all microservice configuration is storted out.
 	newServerFactory := func() microserver.Server {
+		// Create a new logger in the configured format, which is used by all
+		// packages.
+		var newLogger micrologger.Logger
+		{
+			loggerConfig := logger.DefaultConfig()
+			loggerConfig.IOWriter = os.Stdout
+			loggerConfig.LogFormat = Flags.Log.Format
+			newLogger, err = logger.New(loggerConfig)
+			if err != nil {
+				panic(err)
+			}
+		}
+
 		// Create a new custom service which implements business logic.
 		var newService *service.Service
 		{
@@ -91,6 +158,52 @@ func main() {
 			}
 
 			serviceConfig.PubKeyFile = Flags.Aws.PubKeyFile
+			serviceConfig.ReconcileCertSecrets = Flags.Service.ReconcileCertSecrets
+			serviceConfig.AwsRateLimit = Flags.Aws.RateLimit
+
+			serviceConfig.ClusterSelector = Flags.Service.ClusterSelector
+			serviceConfig.OperatorID = Flags.Service.OperatorID
+			serviceConfig.DefaultRegion = Flags.Aws.Region
+			serviceConfig.MaxClusters = Flags.Service.MaxClusters
+
+			serviceConfig.AnnotationTags = Flags.Service.AnnotationTags
+
+			serviceConfig.ResourcePrefix = Flags.Service.ResourcePrefix
+
+			serviceConfig.CheckZoneDelegation = Flags.Service.DNS.CheckDelegation
+			serviceConfig.DNSComponents = Flags.Service.DNS.Components
+			serviceConfig.DNSConcurrency = Flags.Service.DNS.Concurrency
+
+			serviceConfig.DrainNodes = Flags.Service.Drain.Enabled
+			serviceConfig.DrainTimeout = Flags.Service.Drain.Timeout
+
+			serviceConfig.CloudConfigEncoding = Flags.Service.CloudConfigEncoding
+			serviceConfig.CloudConfigValidation = Flags.Service.CloudConfigValidation
+			serviceConfig.DeprecatedImages = Flags.Service.DeprecatedImages
+			serviceConfig.InstanceHostnames = Flags.Service.InstanceHostnames
+			serviceConfig.InstanceRunningTimeout = Flags.Service.InstanceRunningTimeout
+			serviceConfig.LaunchConcurrency = Flags.Service.LaunchConcurrency
+			serviceConfig.TerminateStuckInstances = Flags.Service.TerminateStuckInstances
+			serviceConfig.WaitForMastersReady = Flags.Service.WaitForMastersReady
+			serviceConfig.WorkerSpotMaxPrice = Flags.Service.WorkerSpotMaxPrice
+
+			serviceConfig.APIHealthCheckPath = Flags.Service.APIHealthCheckPath
+			serviceConfig.IngressSourceCIDRs = Flags.Service.Ingress.SourceCIDRs
+			serviceConfig.InternalAPILoadBalancer = Flags.Service.InternalAPILoadBalancer
+			serviceConfig.NetworkPolicies = Flags.Service.NetworkPolicies
+			serviceConfig.S3VPCEndpoint = Flags.Service.S3VPCEndpoint
+			serviceConfig.FlowLogs = Flags.Service.FlowLogs
+			serviceConfig.DHCPOptions = Flags.Service.DHCPOptions
+
+			serviceConfig.ShutdownTimeout = Flags.Service.ShutdownTimeout
+
+			serviceConfig.LeaderElection = Flags.Service.LeaderElection.Enabled
+			serviceConfig.LeaderElectionIdentity = Flags.Service.LeaderElection.Identity
+			serviceConfig.LeaderElectionLeaseDuration = Flags.Service.LeaderElection.LeaseDuration
+			serviceConfig.LeaderElectionLockName = Flags.Service.LeaderElection.LockName
+			serviceConfig.LeaderElectionLockNamespace = Flags.Service.LeaderElection.LockNamespace
+			serviceConfig.LeaderElectionRenewDeadline = Flags.Service.LeaderElection.RenewDeadline
+			serviceConfig.LeaderElectionRetryPeriod = Flags.Service.LeaderElection.RetryPeriod
 
 			serviceConfig.Description = description
 			serviceConfig.GitCommit = gitCommit
@@ -147,6 +260,8 @@ func main() {
 	daemonCommand.PersistentFlags().StringVar(&Flags.Aws.AccessKey.ID, "aws.accesskey.id", "", "ID of the AWS access key")
 	daemonCommand.PersistentFlags().StringVar(&Flags.Aws.AccessKey.Secret, "aws.accesskey.secret", "", "Secret of the AWS access key")
 	// TODO(nhlfr): Deprecate these options when cert-operator will be implemented.
+	daemonCommand.PersistentFlags().Float64Var(&Flags.Aws.RateLimit, "aws.ratelimit", 0, "Maximum number of mutating AWS API calls per second across all clusters (0 means unlimited)")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Aws.Region, "aws.region", "", "Default AWS region for clusters which do not define one in their spec")
 	daemonCommand.PersistentFlags().StringVar(&Flags.Aws.PubKeyFile, "aws.pubkeyfile", path.Join(os.Getenv("HOME"), ".ssh", "id_rsa.pub"), "Public key to be imported as a keypair in AWS")
 
 	daemonCommand.PersistentFlags().BoolVar(&Flags.Kubernetes.InCluster, "kubernetes.incluster", false, "Whether to use the in-cluster config to authenticate with Kubernetes")
@@ -159,5 +274,45 @@ func main() {
 	daemonCommand.PersistentFlags().StringVar(&Flags.Kubernetes.TLS.CaFile, "kubernetes.tls.cafile", "", "TLS Authority certificate file")
 	daemonCommand.PersistentFlags().BoolVar(&Flags.Kubernetes.Insecure, "kubernetes.insecure", false, "Insecure SSL connection")
 
+	daemonCommand.PersistentFlags().StringVar(&Flags.Log.Format, "log.format", logger.LogFormatJSON, "Format of the log output, either 'json' or 'logfmt'")
+
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.ClusterSelector, "service.clusterselector", "", "Label selector restricting the clusters managed by this operator, e.g. 'operator=aws'")
+	daemonCommand.PersistentFlags().IntVar(&Flags.Service.MaxClusters, "service.maxclusters", 0, "Maximum number of clusters provisioned by this operator, protecting shared accounts from runaway cluster creation (0 means no limit)")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.OperatorID, "service.operatorid", "", "ID of this operator, used to tag and find the EC2 resources it manages when several operators share an AWS account")
+	daemonCommand.PersistentFlags().StringSliceVar(&Flags.Service.AnnotationTags, "service.annotationtags", nil, "Comma separated keys of the annotations of cluster custom objects copied to the tags of their AWS resources, e.g. 'owner,team'")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.ResourcePrefix, "service.resourceprefix", "", "Prefix of the names of the buckets, IAM roles, ELBs, KMS aliases and instances of clusters, e.g. 'acme-'. Changing it orphans the resources of existing clusters")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.DNS.CheckDelegation, "service.dns.checkdelegation", false, "Whether to warn about public hosted zones of clusters whose parent zone doesn't delegate to them")
+	daemonCommand.PersistentFlags().StringSliceVar(&Flags.Service.DNS.Components, "service.dns.components", create.DNSComponents, "Comma separated components of clusters whose hosted zones and DNS records are created, out of 'api,etcd,ingress'")
+	daemonCommand.PersistentFlags().IntVar(&Flags.Service.DNS.Concurrency, "service.dns.concurrency", 3, "Number of DNS records changed at once, paced within the Route53 limit of 5 requests per second")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.Drain.Enabled, "service.drain.enabled", false, "Whether to drain worker nodes before terminating their instances")
+	daemonCommand.PersistentFlags().DurationVar(&Flags.Service.Drain.Timeout, "service.drain.timeout", 5*time.Minute, "Maximum time to wait for the pods of a worker node to be evicted")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.CloudConfigEncoding, "service.cloudconfigencoding", create.CloudConfigEncodingGzipBase64, "Encoding of the cloudconfig uploaded to S3, either 'gzip+base64' or 'base64'")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.CloudConfigValidation, "service.cloudconfigvalidation", true, "Whether to check the rendered cloudconfigs before uploading them to S3")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.DeprecatedImages, "service.deprecatedimages", create.DeprecatedImagesIgnore, "What to do about clusters whose machines use deprecated AMIs, either 'ignore', 'warn' with an event, or 'refuse' to create them")
+	daemonCommand.PersistentFlags().StringSliceVar(&Flags.Service.Ingress.SourceCIDRs, "service.ingress.sourcecidrs", nil, "Comma separated CIDRs allowed to reach the ingress ELBs (defaults to 0.0.0.0/0)")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.ReconcileCertSecrets, "service.reconcilecertsecrets", false, "Whether to update the cloudconfigs of a cluster when its certificate secrets change")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.APIHealthCheckPath, "service.apihealthcheckpath", create.DefaultAPIHealthCheckPath, "Path of the HTTPS health check of the API load balancers, e.g. '/readyz' for clusters running Kubernetes 1.16 or later")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.InternalAPILoadBalancer, "service.internalapiloadbalancer", false, "Whether to create an internal load balancer for the API servers, reachable under 'internal-<API domain>'")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.NetworkPolicies, "service.networkpolicies", false, "Whether to isolate the namespaces of clusters with NetworkPolicies, only allowing traffic between the pods of a namespace")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.S3VPCEndpoint, "service.s3vpcendpoint", false, "Whether to create an S3 gateway endpoint in the VPC of clusters, keeping S3 traffic within AWS")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.FlowLogs, "service.flowlogs", false, "Whether to log the IP traffic of the VPCs of clusters to the S3 buckets of the clusters, for troubleshooting")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.DHCPOptions, "service.dhcpoptions", false, "Whether to give the VPCs of clusters a DHCP options set making the hosted zone of the cluster their search domain")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.WorkerSpotMaxPrice, "service.workerspotmaxprice", "", "Maximum price per hour in US dollars paid for workers launched as spot instances, e.g. '0.05'. Workers are on-demand instances when empty")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.TerminateStuckInstances, "service.terminatestuckinstances", false, "Whether to terminate new instances which don't run within the instance running timeout, so they are launched again on the next reconcile")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.WaitForMastersReady, "service.waitformastersready", false, "Whether to create the workers of a cluster only once all its masters are in service behind the API load balancer")
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.InstanceHostnames, "service.instancehostnames", false, "Whether to set the hostname of instances to their instance name, e.g. 'mycluster-worker-0'")
+	daemonCommand.PersistentFlags().DurationVar(&Flags.Service.ShutdownTimeout, "service.shutdowntimeout", 5*time.Minute, "Maximum time to wait for active reconciles to finish when shutting down")
+	// Pods are named after their hostname, which identifies the replicas.
+	hostname, _ := os.Hostname()
+	daemonCommand.PersistentFlags().BoolVar(&Flags.Service.LeaderElection.Enabled, "service.leaderelection.enabled", false, "Whether to elect a leader amongst the replicas of the operator, so only one of them reconciles the clusters")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.LeaderElection.Identity, "service.leaderelection.identity", hostname, "Identity of the replica in the leader election, defaults to the hostname")
+	daemonCommand.PersistentFlags().DurationVar(&Flags.Service.LeaderElection.LeaseDuration, "service.leaderelection.leaseduration", 15*time.Second, "Time the replicas on standby wait for the leader to renew its lease before taking over")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.LeaderElection.LockName, "service.leaderelection.lockname", "aws-operator", "Name of the config map holding the lease of the leader")
+	daemonCommand.PersistentFlags().StringVar(&Flags.Service.LeaderElection.LockNamespace, "service.leaderelection.locknamespace", "default", "Namespace of the config map holding the lease of the leader")
+	daemonCommand.PersistentFlags().DurationVar(&Flags.Service.LeaderElection.RenewDeadline, "service.leaderelection.renewdeadline", 10*time.Second, "Time the leader retries renewing its lease before giving up its leadership, must be shorter than the lease duration")
+	daemonCommand.PersistentFlags().DurationVar(&Flags.Service.LeaderElection.RetryPeriod, "service.leaderelection.retryperiod", 2*time.Second, "Delay between the attempts to acquire or renew the lease")
+	daemonCommand.PersistentFlags().DurationVar(&Flags.Service.InstanceRunningTimeout, "service.instancerunningtimeout", 10*time.Minute, "Maximum time to wait for a new instance to run, before registering it with the load balancers")
+	daemonCommand.PersistentFlags().IntVar(&Flags.Service.LaunchConcurrency, "service.launchconcurrency", 1, "Maximum number of masters or workers of a cluster launched at once")
+
 	newCommand.CobraCommand().Execute()
 }
@@ -29,6 +29,36 @@ func NewCustomExponentialBackoff() *backoff.ExponentialBackOff {
 	return b
 }
 
+// maxRetriesBackOff stops retrying after a maximum number of retries.
+type maxRetriesBackOff struct {
+	backOff    backoff.BackOff
+	maxRetries int
+	retries    int
+}
+
+// NewMaxRetriesBackOff returns a backoff.BackOff retrying like the given one,
+// but at most maxRetries times.
+func NewMaxRetriesBackOff(b backoff.BackOff, maxRetries int) backoff.BackOff {
+	return &maxRetriesBackOff{
+		backOff:    b,
+		maxRetries: maxRetries,
+	}
+}
+
+func (b *maxRetriesBackOff) Reset() {
+	b.retries = 0
+	b.backOff.Reset()
+}
+
+func (b *maxRetriesBackOff) NextBackOff() time.Duration {
+	if b.retries >= b.maxRetries {
+		return backoff.Stop
+	}
+	b.retries++
+
+	return b.backOff.NextBackOff()
+}
+
 func NewNotify(logger micrologger.Logger, operationName string) func(error, time.Duration) {
 	return func(err error, delay time.Duration) {
 		logger.Log("error", fmt.Sprintf("%s failed, retrying with delay %.0fm%.0fs: %v", operationName, delay.Minutes(), delay.Seconds(), errgo.Details(err)))
@@ -1,6 +1,7 @@
 package aws
 
 import (
+	"bytes"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -10,6 +11,18 @@ import (
 	"github.com/juju/errgo"
 )
 
+const (
+	// defaultMultipartThreshold is the object size from which bucket objects
+	// are uploaded in multiple parts.
+	defaultMultipartThreshold = 16 * 1024 * 1024
+	// multipartPartSize is the size of every part but the last one of a
+	// multipart upload. S3 requires it to be at least 5MB.
+	multipartPartSize = 5 * 1024 * 1024
+	// Regions of the legacy location constraints returned for buckets.
+	bucketLocationDefaultRegion = "us-east-1"
+	bucketLocationEURegion      = "eu-west-1"
+)
+
 type Bucket struct {
 	Name string
 	AWSEntity
@@ -45,6 +58,39 @@ func (b *Bucket) CreateOrFail() error {
 	return nil
 }
 
+// CheckRegion makes sure the bucket is in the given region. Buckets can't be
+// moved, so a bucket in another region must be recreated by hand, otherwise
+// the instances fetch their cloudconfigs across regions, or fail to.
+func (b Bucket) CheckRegion(region string) error {
+	resp, err := b.Clients.S3.GetBucketLocation(&s3.GetBucketLocationInput{
+		Bucket: aws.String(b.Name),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	bucketRegion := bucketLocationRegion(aws.StringValue(resp.LocationConstraint))
+	if bucketRegion != region {
+		return microerror.MaskAnyf(bucketRegionMismatchError, "bucket '%s' is in region '%s', expected '%s'", b.Name, bucketRegion, region)
+	}
+
+	return nil
+}
+
+// bucketLocationRegion returns the region of a bucket location constraint.
+// Buckets in us-east-1 have no location constraint, and old buckets in
+// eu-west-1 have the legacy EU one.
+func bucketLocationRegion(locationConstraint string) string {
+	switch locationConstraint {
+	case "":
+		return bucketLocationDefaultRegion
+	case s3.BucketLocationConstraintEu:
+		return bucketLocationEURegion
+	}
+
+	return locationConstraint
+}
+
 func (b *Bucket) Delete() error {
 	if _, err := b.Clients.S3.DeleteBucket(&s3.DeleteBucketInput{
 		Bucket: aws.String(b.Name),
@@ -59,6 +105,9 @@ type BucketObject struct {
 	Name   string
 	Data   string
 	Bucket *Bucket
+	// MultipartThreshold is the size in bytes from which the object is
+	// uploaded in multiple parts. Defaults to defaultMultipartThreshold.
+	MultipartThreshold int
 	AWSEntity
 }
 
@@ -71,6 +120,14 @@ func (bo *BucketObject) CreateOrFail() error {
 		return microerror.MaskAny(noBucketInBucketObjectError)
 	}
 
+	if len(bo.Data) >= bo.multipartThreshold() {
+		if err := bo.uploadMultipart(); err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		return nil
+	}
+
 	if _, err := bo.Clients.S3.PutObject(&s3.PutObjectInput{
 		Body:          strings.NewReader(bo.Data),
 		Bucket:        aws.String(bo.Bucket.Name),
@@ -83,6 +140,73 @@ func (bo *BucketObject) CreateOrFail() error {
 	return nil
 }
 
+func (bo *BucketObject) multipartThreshold() int {
+	if bo.MultipartThreshold > 0 {
+		return bo.MultipartThreshold
+	}
+
+	return defaultMultipartThreshold
+}
+
+// uploadMultipart uploads the object in parts of multipartPartSize. The upload
+// is aborted when any of the parts fails, so no orphaned parts are left in the
+// bucket.
+func (bo *BucketObject) uploadMultipart() error {
+	upload, err := bo.Clients.S3.CreateMultipartUpload(&s3.CreateMultipartUploadInput{
+		Bucket: aws.String(bo.Bucket.Name),
+		Key:    aws.String(bo.Name),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	var completedParts []*s3.CompletedPart
+	for i := 0; i*multipartPartSize < len(bo.Data); i++ {
+		start := i * multipartPartSize
+		end := start + multipartPartSize
+		if end > len(bo.Data) {
+			end = len(bo.Data)
+		}
+		partNumber := aws.Int64(int64(i + 1))
+
+		part, err := bo.Clients.S3.UploadPart(&s3.UploadPartInput{
+			Body:          bytes.NewReader([]byte(bo.Data[start:end])),
+			Bucket:        aws.String(bo.Bucket.Name),
+			ContentLength: aws.Int64(int64(end - start)),
+			Key:           aws.String(bo.Name),
+			PartNumber:    partNumber,
+			UploadId:      upload.UploadId,
+		})
+		if err != nil {
+			bo.Clients.S3.AbortMultipartUpload(&s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bo.Bucket.Name),
+				Key:      aws.String(bo.Name),
+				UploadId: upload.UploadId,
+			})
+
+			return microerror.MaskAny(err)
+		}
+
+		completedParts = append(completedParts, &s3.CompletedPart{
+			ETag:       part.ETag,
+			PartNumber: partNumber,
+		})
+	}
+
+	if _, err := bo.Clients.S3.CompleteMultipartUpload(&s3.CompleteMultipartUploadInput{
+		Bucket: aws.String(bo.Bucket.Name),
+		Key:    aws.String(bo.Name),
+		MultipartUpload: &s3.CompletedMultipartUpload{
+			Parts: completedParts,
+		},
+		UploadId: upload.UploadId,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
 func (bo *BucketObject) Delete() error {
 	if _, err := bo.Clients.S3.DeleteObject(&s3.DeleteObjectInput{
 		Bucket: aws.String(bo.Bucket.Name),
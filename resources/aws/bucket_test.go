@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketObjectCreateOrFail(t *testing.T) {
+	tests := []struct {
+		desc       string
+		data       string
+		threshold  int
+		operations []string
+		partSizes  []int64
+	}{
+		{
+			desc:       "small object is put in a single request",
+			data:       "foo",
+			operations: []string{"PutObject"},
+		},
+		{
+			desc:       "object below the threshold is put in a single request",
+			data:       strings.Repeat("a", multipartPartSize),
+			threshold:  multipartPartSize + 1,
+			operations: []string{"PutObject"},
+		},
+		{
+			desc:      "object above the threshold is uploaded in parts",
+			data:      strings.Repeat("a", 2*multipartPartSize+1),
+			threshold: multipartPartSize,
+			operations: []string{
+				"CreateMultipartUpload",
+				"UploadPart",
+				"UploadPart",
+				"UploadPart",
+				"CompleteMultipartUpload",
+			},
+			partSizes: []int64{multipartPartSize, multipartPartSize, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+
+		bo := &BucketObject{
+			Name:               "foo/cloudconfig/master",
+			Data:               tc.data,
+			Bucket:             &Bucket{Name: "bar"},
+			MultipartThreshold: tc.threshold,
+			AWSEntity:          AWSEntity{Clients: clients},
+		}
+
+		err := bo.CreateOrFail()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.operations, fake.operations(), fmt.Sprintf("[%s] The input values didn't produce the expected API calls", tc.desc))
+
+		var partSizes []int64
+		for _, params := range fake.paramsOf("UploadPart") {
+			partSizes = append(partSizes, *params.(*s3.UploadPartInput).ContentLength)
+		}
+		assert.Equal(t, tc.partSizes, partSizes, fmt.Sprintf("[%s] The input values didn't produce the expected parts", tc.desc))
+	}
+}
+
+func TestBucketObjectMultipartAbort(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("UploadPart", func(params, output interface{}) error {
+		return fmt.Errorf("upload failed")
+	})
+
+	bo := &BucketObject{
+		Name:               "foo/cloudconfig/master",
+		Data:               strings.Repeat("a", multipartPartSize+1),
+		Bucket:             &Bucket{Name: "bar"},
+		MultipartThreshold: multipartPartSize,
+		AWSEntity:          AWSEntity{Clients: clients},
+	}
+
+	err := bo.CreateOrFail()
+	assert.NotNil(t, err, "Expected the upload to fail")
+	assert.Equal(t, []string{"CreateMultipartUpload", "UploadPart", "AbortMultipartUpload"}, fake.operations(), "The failed upload was not aborted")
+}
+
+func TestBucketCheckRegion(t *testing.T) {
+	tests := []struct {
+		desc               string
+		locationConstraint *string
+		region             string
+		errorMatcher       func(error) bool
+	}{
+		{
+			desc:               "matching region",
+			locationConstraint: aws.String("eu-central-1"),
+			region:             "eu-central-1",
+		},
+		{
+			desc:               "mismatched region",
+			locationConstraint: aws.String("eu-central-1"),
+			region:             "us-west-2",
+			errorMatcher:       IsBucketRegionMismatch,
+		},
+		{
+			desc:               "bucket without location constraint is in us-east-1",
+			locationConstraint: nil,
+			region:             "us-east-1",
+		},
+		{
+			desc:               "bucket created without location constraint outside us-east-1",
+			locationConstraint: nil,
+			region:             "eu-central-1",
+			errorMatcher:       IsBucketRegionMismatch,
+		},
+		{
+			desc:               "legacy EU location constraint is eu-west-1",
+			locationConstraint: aws.String(s3.BucketLocationConstraintEu),
+			region:             "eu-west-1",
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("GetBucketLocation", func(params, output interface{}) error {
+			output.(*s3.GetBucketLocationOutput).LocationConstraint = tc.locationConstraint
+			return nil
+		})
+
+		bucket := &Bucket{
+			Name:      "foo-bucket",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := bucket.CheckRegion(tc.region)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+	}
+}
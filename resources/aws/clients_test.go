@@ -0,0 +1,160 @@
+package aws
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+)
+
+// fakeCall is an AWS API call recorded by fakeAWS.
+type fakeCall struct {
+	Operation string
+	Params    interface{}
+	Region    string
+}
+
+// fakeResponse populates the output of a faked AWS API call, or returns the
+// error the call should fail with.
+type fakeResponse func(params, output interface{}) error
+
+// fakeAWS records the AWS API calls made through the clients returned by
+// newFakeClients, without ever reaching AWS. Calls succeed with an empty output
+// unless a response was registered for the operation.
+type fakeAWS struct {
+	mutex     sync.Mutex
+	calls     []fakeCall
+	responses map[string][]fakeResponse
+}
+
+func newFakeClients() (awsutil.Clients, *fakeAWS) {
+	fake := &fakeAWS{
+		responses: map[string][]fakeResponse{},
+	}
+
+	s := session.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Region:      aws.String("eu-central-1"),
+	})
+	clients := awsutil.Clients{
+		EC2:     ec2.New(s),
+		IAM:     iam.New(s),
+		S3:      s3.New(s),
+		KMS:     kms.New(s),
+		ELB:     elb.New(s),
+		Route53: route53.New(s),
+	}
+
+	for _, handlers := range []*request.Handlers{
+		&clients.EC2.Handlers,
+		&clients.IAM.Handlers,
+		&clients.S3.Handlers,
+		&clients.KMS.Handlers,
+		&clients.ELB.Handlers,
+		&clients.Route53.Handlers,
+	} {
+		handlers.Clear()
+		handlers.Send.PushBack(fake.handle)
+	}
+
+	return clients, fake
+}
+
+// on registers responses for the given operation. They are used in order, the
+// last one being reused for all subsequent calls.
+func (f *fakeAWS) on(operation string, responses ...fakeResponse) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.responses[operation] = append(f.responses[operation], responses...)
+}
+
+func (f *fakeAWS) handle(r *request.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.calls = append(f.calls, fakeCall{
+		Operation: r.Operation.Name,
+		Params:    r.Params,
+		Region:    aws.StringValue(r.Config.Region),
+	})
+
+	// Some clients register per operation handlers reading the response, so
+	// we always hand them an empty one.
+	r.HTTPResponse = &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+	}
+
+	responses := f.responses[r.Operation.Name]
+	if len(responses) == 0 {
+		return
+	}
+	response := responses[0]
+	if len(responses) > 1 {
+		f.responses[r.Operation.Name] = responses[1:]
+	}
+
+	if err := response(r.Params, r.Data); err != nil {
+		r.Error = err
+	}
+}
+
+// operations returns the names of the recorded operations, in call order.
+func (f *fakeAWS) operations() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var operations []string
+	for _, call := range f.calls {
+		operations = append(operations, call.Operation)
+	}
+
+	return operations
+}
+
+// regionsOf returns the regions of all the recorded calls of the given
+// operation.
+func (f *fakeAWS) regionsOf(operation string) []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var regions []string
+	for _, call := range f.calls {
+		if call.Operation == operation {
+			regions = append(regions, call.Region)
+		}
+	}
+
+	return regions
+}
+
+// paramsOf returns the input params of all the recorded calls of the given
+// operation.
+func (f *fakeAWS) paramsOf(operation string) []interface{} {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var params []interface{}
+	for _, call := range f.calls {
+		if call.Operation == operation {
+			params = append(params, call.Params)
+		}
+	}
+
+	return params
+}
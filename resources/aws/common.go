@@ -1,9 +1,121 @@
 package aws
 
 import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+
 	awsutil "github.com/giantswarm/aws-operator/client/aws"
 )
 
 type AWSEntity struct {
 	Clients awsutil.Clients
+	// OperatorID identifies the operator managing the resource. When it is set,
+	// created resources are tagged with it and only resources tagged with it are
+	// found, so operators sharing an account never adopt each other's resources.
+	OperatorID string
+	// Tags are added to the EC2 resources on creation, besides their Name,
+	// Cluster and OperatorID tags, which they can't override.
+	Tags map[string]string
+	// ClusterID scopes the resource to the cluster with the given ID, e.g. in a
+	// VPC shared by several clusters. When it is set, subnets and security
+	// groups are tagged with it on creation and only found when tagged with it,
+	// so clusters never touch each other's resources.
+	ClusterID string
+}
+
+// resourceTags returns the tags added to EC2 resources on creation, besides
+// their Name and Cluster tags.
+func resourceTags(operatorID string, tags map[string]string) []*ec2.Tag {
+	return append(operatorTags(operatorID), extraTags(tags)...)
+}
+
+// extraTags returns the given tags sorted by key, leaving out the ones set by
+// the operator itself.
+func extraTags(tags map[string]string) []*ec2.Tag {
+	var keys []string
+	for key := range tags {
+		switch key {
+		case tagKeyName, tagKeyCluster, tagKeyOperator, tagKeyClusterID:
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var ec2Tags []*ec2.Tag
+	for _, key := range keys {
+		ec2Tags = append(ec2Tags, &ec2.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(tags[key]),
+		})
+	}
+
+	return ec2Tags
+}
+
+// operatorTags returns the tags marking a resource as managed by the operator
+// with the given ID.
+func operatorTags(operatorID string) []*ec2.Tag {
+	if operatorID == "" {
+		return nil
+	}
+
+	return []*ec2.Tag{
+		{
+			Key:   aws.String(tagKeyOperator),
+			Value: aws.String(operatorID),
+		},
+	}
+}
+
+// operatorFilters returns the filters matching the resources managed by the
+// operator with the given ID.
+func operatorFilters(operatorID string) []*ec2.Filter {
+	if operatorID == "" {
+		return nil
+	}
+
+	return []*ec2.Filter{
+		{
+			Name: aws.String(fmt.Sprintf("tag:%s", tagKeyOperator)),
+			Values: []*string{
+				aws.String(operatorID),
+			},
+		},
+	}
+}
+
+// clusterIDTags returns the tags scoping a resource to the cluster with the
+// given ID.
+func clusterIDTags(clusterID string) []*ec2.Tag {
+	if clusterID == "" {
+		return nil
+	}
+
+	return []*ec2.Tag{
+		{
+			Key:   aws.String(tagKeyClusterID),
+			Value: aws.String(clusterID),
+		},
+	}
+}
+
+// clusterIDFilters returns the filters matching the resources scoped to the
+// cluster with the given ID.
+func clusterIDFilters(clusterID string) []*ec2.Filter {
+	if clusterID == "" {
+		return nil
+	}
+
+	return []*ec2.Filter{
+		{
+			Name: aws.String(fmt.Sprintf("tag:%s", tagKeyClusterID)),
+			Values: []*string{
+				aws.String(clusterID),
+			},
+		},
+	}
 }
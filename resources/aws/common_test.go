@@ -0,0 +1,229 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperatorScopedDiscovery(t *testing.T) {
+	tests := []struct {
+		desc       string
+		operatorID string
+		filters    []string
+	}{
+		{
+			desc:       "without operator ID all resources are found",
+			operatorID: "",
+			filters:    []string{"tag:Name=foo"},
+		},
+		{
+			desc:       "with operator ID only the operator's resources are found",
+			operatorID: "prod",
+			filters:    []string{"tag:Name=foo", "tag:OperatorID=prod"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		entity := AWSEntity{Clients: clients, OperatorID: tc.operatorID}
+
+		vpc := &VPC{Name: "foo", AWSEntity: entity}
+		vpc.findExisting()
+		subnet := &Subnet{Name: "foo", AWSEntity: entity}
+		subnet.findExisting()
+		gateway := &Gateway{Name: "foo", AWSEntity: entity}
+		gateway.findExisting()
+		routeTable := &RouteTable{Name: "foo", Client: clients.EC2, OperatorID: tc.operatorID}
+		routeTable.findExisting()
+		FindInstances(FindInstancesInput{Clients: clients, OperatorID: tc.operatorID, Pattern: "foo"})
+
+		var inputs []interface{}
+		inputs = append(inputs, fake.paramsOf("DescribeVpcs")[0].(*ec2.DescribeVpcsInput).Filters)
+		inputs = append(inputs, fake.paramsOf("DescribeSubnets")[0].(*ec2.DescribeSubnetsInput).Filters)
+		inputs = append(inputs, fake.paramsOf("DescribeInternetGateways")[0].(*ec2.DescribeInternetGatewaysInput).Filters)
+		inputs = append(inputs, fake.paramsOf("DescribeRouteTables")[0].(*ec2.DescribeRouteTablesInput).Filters)
+
+		for i, input := range inputs {
+			assert.Equal(t, tc.filters, filterStrings(input.([]*ec2.Filter)), fmt.Sprintf("[%s] Call %d didn't use the expected filters", tc.desc, i))
+		}
+
+		instanceFilters := filterStrings(fake.paramsOf("DescribeInstances")[0].(*ec2.DescribeInstancesInput).Filters)
+		if tc.operatorID == "" {
+			assert.NotContains(t, instanceFilters, "tag:OperatorID=", fmt.Sprintf("[%s] Instances filtered by operator", tc.desc))
+		} else {
+			assert.Contains(t, instanceFilters, "tag:OperatorID="+tc.operatorID, fmt.Sprintf("[%s] Instances not filtered by operator", tc.desc))
+		}
+	}
+}
+
+func TestOperatorTagging(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("CreateInternetGateway", func(params, output interface{}) error {
+		output.(*ec2.CreateInternetGatewayOutput).InternetGateway = &ec2.InternetGateway{InternetGatewayId: aws.String("igw-1")}
+		return nil
+	})
+	fake.on("CreateSecurityGroup", func(params, output interface{}) error {
+		output.(*ec2.CreateSecurityGroupOutput).GroupId = aws.String("sg-1")
+		return nil
+	})
+
+	entity := AWSEntity{Clients: clients, OperatorID: "prod"}
+	gateway := &Gateway{Name: "foo", VpcID: "vpc-1", AWSEntity: entity}
+	err := gateway.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error creating the gateway")
+	sg := &SecurityGroup{GroupName: "foo", AWSEntity: entity}
+	err = sg.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error creating the security group")
+
+	for _, params := range fake.paramsOf("CreateTags") {
+		var tags []string
+		for _, tag := range params.(*ec2.CreateTagsInput).Tags {
+			tags = append(tags, fmt.Sprintf("%s=%s", *tag.Key, *tag.Value))
+		}
+		assert.Contains(t, tags, "OperatorID=prod", "Created resource not tagged with the operator ID")
+	}
+	assert.Len(t, fake.paramsOf("CreateTags"), 2, "Expected both resources to be tagged")
+}
+
+func TestClusterScopedDiscovery(t *testing.T) {
+	tests := []struct {
+		desc      string
+		clusterID string
+		filters   []string
+	}{
+		{
+			desc:      "without cluster ID all resources are found",
+			clusterID: "",
+			filters:   []string{"tag:Name=foo", "tag:OperatorID=prod"},
+		},
+		{
+			desc:      "with cluster ID only the cluster's resources are found",
+			clusterID: "abc12",
+			filters:   []string{"tag:Name=foo", "tag:OperatorID=prod", "tag:ClusterID=abc12"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		entity := AWSEntity{Clients: clients, OperatorID: "prod", ClusterID: tc.clusterID}
+
+		subnet := &Subnet{Name: "foo", AWSEntity: entity}
+		subnet.findExisting()
+		routeTable := &RouteTable{Name: "foo", Client: clients.EC2, OperatorID: "prod", ClusterID: tc.clusterID}
+		routeTable.findExisting()
+		securityGroup := &SecurityGroup{Description: "foo", GroupName: "foo", AWSEntity: entity}
+		securityGroup.findExisting()
+
+		assert.Equal(t, tc.filters, filterStrings(fake.paramsOf("DescribeSubnets")[0].(*ec2.DescribeSubnetsInput).Filters), fmt.Sprintf("[%s] Subnet not found with the expected filters", tc.desc))
+		assert.Equal(t, tc.filters, filterStrings(fake.paramsOf("DescribeRouteTables")[0].(*ec2.DescribeRouteTablesInput).Filters), fmt.Sprintf("[%s] Route table not found with the expected filters", tc.desc))
+		sgFilters := filterStrings(fake.paramsOf("DescribeSecurityGroups")[0].(*ec2.DescribeSecurityGroupsInput).Filters)
+		if tc.clusterID == "" {
+			assert.NotContains(t, sgFilters, "tag:ClusterID=", fmt.Sprintf("[%s] Security group filtered by cluster", tc.desc))
+		} else {
+			assert.Contains(t, sgFilters, "tag:ClusterID="+tc.clusterID, fmt.Sprintf("[%s] Security group not filtered by cluster", tc.desc))
+		}
+	}
+}
+
+func TestClusterScopedTagging(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("CreateSubnet", func(params, output interface{}) error {
+		output.(*ec2.CreateSubnetOutput).Subnet = &ec2.Subnet{SubnetId: aws.String("subnet-1")}
+		return nil
+	})
+	fake.on("DescribeSubnets", func(params, output interface{}) error {
+		output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-1"), State: aws.String(ec2.SubnetStateAvailable)},
+		}
+		return nil
+	})
+	fake.on("CreateRouteTable", func(params, output interface{}) error {
+		output.(*ec2.CreateRouteTableOutput).RouteTable = &ec2.RouteTable{RouteTableId: aws.String("rtb-1")}
+		return nil
+	})
+	fake.on("CreateSecurityGroup", func(params, output interface{}) error {
+		output.(*ec2.CreateSecurityGroupOutput).GroupId = aws.String("sg-1")
+		return nil
+	})
+
+	entity := AWSEntity{Clients: clients, ClusterID: "abc12"}
+	subnet := &Subnet{Name: "foo-public", VpcID: "vpc-shared", AWSEntity: entity}
+	err := subnet.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error creating the subnet")
+	routeTable := &RouteTable{Name: "foo", VpcID: "vpc-shared", Client: clients.EC2, ClusterID: "abc12"}
+	err = routeTable.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error creating the route table")
+	sg := &SecurityGroup{GroupName: "foo", VpcID: "vpc-shared", AWSEntity: entity}
+	err = sg.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error creating the security group")
+
+	assert.Len(t, fake.paramsOf("CreateTags"), 3, "Expected all resources to be tagged")
+	for _, params := range fake.paramsOf("CreateTags") {
+		var tags []string
+		for _, tag := range params.(*ec2.CreateTagsInput).Tags {
+			tags = append(tags, fmt.Sprintf("%s=%s", *tag.Key, *tag.Value))
+		}
+		assert.Contains(t, tags, "ClusterID=abc12", "Created resource not tagged with the cluster ID")
+	}
+}
+
+func filterStrings(filters []*ec2.Filter) []string {
+	var res []string
+	for _, filter := range filters {
+		for _, value := range filter.Values {
+			res = append(res, fmt.Sprintf("%s=%s", *filter.Name, *value))
+		}
+	}
+
+	return res
+}
+
+func TestResourceTags(t *testing.T) {
+	tests := []struct {
+		desc       string
+		operatorID string
+		tags       map[string]string
+		res        []*ec2.Tag
+	}{
+		{
+			desc: "no tags",
+			res:  nil,
+		},
+		{
+			desc:       "extra tags follow the operator tag, sorted by key",
+			operatorID: "prod",
+			tags: map[string]string{
+				"team":  "platform",
+				"owner": "alice",
+			},
+			res: []*ec2.Tag{
+				{Key: aws.String("OperatorID"), Value: aws.String("prod")},
+				{Key: aws.String("owner"), Value: aws.String("alice")},
+				{Key: aws.String("team"), Value: aws.String("platform")},
+			},
+		},
+		{
+			desc:       "extra tags can't override the operator's tags",
+			operatorID: "prod",
+			tags: map[string]string{
+				"Cluster":    "bar",
+				"Name":       "bar",
+				"OperatorID": "staging",
+				"owner":      "alice",
+			},
+			res: []*ec2.Tag{
+				{Key: aws.String("OperatorID"), Value: aws.String("prod")},
+				{Key: aws.String("owner"), Value: aws.String("alice")},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		res := resourceTags(tc.operatorID, tc.tags)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected tags", tc.desc))
+	}
+}
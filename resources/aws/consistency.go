@@ -0,0 +1,105 @@
+package aws
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// consistencyWindow is how long after their creation resources might not be
+// found yet, because of the eventual consistency of the EC2 API.
+const consistencyWindow = 2 * time.Minute
+
+// newConsistencyBackOff returns the backoff of the lookups of resources
+// created recently, retrying up to the given time.
+var newConsistencyBackOff = func(maxElapsedTime time.Duration) backoff.BackOff {
+	b := NewCustomExponentialBackoff()
+	b.MaxElapsedTime = maxElapsedTime
+
+	return b
+}
+
+// creationLog remembers when resources were created, so lookups right after
+// their creation can wait for them to become visible, instead of reporting
+// them as missing and getting them created twice.
+type creationLog struct {
+	mutex   sync.Mutex
+	created map[string]time.Time
+}
+
+// recentCreations are the resources created by this operator within the
+// consistency window.
+var recentCreations = &creationLog{
+	created: make(map[string]time.Time),
+}
+
+// creationKey identifies a resource in the creation log.
+func creationKey(rType resourceType, operatorID, name string) string {
+	return fmt.Sprintf("%s/%s/%s", rType, operatorID, name)
+}
+
+// add records the creation of the given resource.
+func (l *creationLog) add(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.created[key] = time.Now()
+}
+
+// remove forgets the given resource, e.g. once it is deleted.
+func (l *creationLog) remove(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.created, key)
+}
+
+// remaining returns how long the given resource might still be invisible. It
+// is zero for resources created outside of the consistency window.
+func (l *creationLog) remaining(key string) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	created, ok := l.created[key]
+	if !ok {
+		return 0
+	}
+	remaining := consistencyWindow - time.Since(created)
+	if remaining <= 0 {
+		delete(l.created, key)
+		return 0
+	}
+
+	return remaining
+}
+
+// findCreated calls find, which looks up the given resource. When it isn't
+// found but was created within the consistency window, find is retried until
+// the resource shows up or the window closes.
+func findCreated(key string, find func() error) error {
+	err := find()
+	if !IsNotFound(err) {
+		return microerror.MaskAny(err)
+	}
+	remaining := recentCreations.remaining(key)
+	if remaining == 0 {
+		return microerror.MaskAny(err)
+	}
+
+	b := newConsistencyBackOff(remaining)
+	for {
+		next := b.NextBackOff()
+		if next == backoff.Stop {
+			return microerror.MaskAny(err)
+		}
+		time.Sleep(next)
+
+		err = find()
+		if !IsNotFound(err) {
+			return microerror.MaskAny(err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/cenkalti/backoff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVPCCreateIfNotExistsAfterCreation(t *testing.T) {
+	defaultBackOff := newConsistencyBackOff
+	defer func() { newConsistencyBackOff = defaultBackOff }()
+	newConsistencyBackOff = func(maxElapsedTime time.Duration) backoff.BackOff {
+		return NewMaxRetriesBackOff(&backoff.ZeroBackOff{}, 5)
+	}
+
+	tests := []struct {
+		desc string
+		// created tells whether the VPC was created by the operator just before.
+		created bool
+		// visibleAfter is the number of lookups not finding the VPC. It is never
+		// found when negative.
+		visibleAfter int
+		resCreated   bool
+		resLookups   int
+	}{
+		{
+			desc:         "created VPC becomes visible",
+			created:      true,
+			visibleAfter: 2,
+			resCreated:   false,
+			resLookups:   3,
+		},
+		{
+			desc:         "created VPC never becomes visible",
+			created:      true,
+			visibleAfter: -1,
+			resCreated:   true,
+			resLookups:   6,
+		},
+		{
+			desc:         "unknown VPC is created right away",
+			created:      false,
+			visibleAfter: 1,
+			resCreated:   true,
+			resLookups:   1,
+		},
+	}
+
+	for i, tc := range tests {
+		clients, fake := newFakeClients()
+		var lookups int
+		fake.on("DescribeVpcs", func(params, output interface{}) error {
+			// The waiter of the creation looks the VPC up by ID.
+			if len(params.(*ec2.DescribeVpcsInput).VpcIds) > 0 {
+				output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-456"), State: aws.String(ec2.VpcStateAvailable)}}
+				return nil
+			}
+			lookups++
+			if tc.visibleAfter >= 0 && lookups > tc.visibleAfter {
+				output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-123")}}
+			}
+			return nil
+		})
+		fake.on("CreateVpc", func(params, output interface{}) error {
+			output.(*ec2.CreateVpcOutput).Vpc = &ec2.Vpc{VpcId: aws.String("vpc-456")}
+			return nil
+		})
+
+		vpc := &VPC{
+			Name:      fmt.Sprintf("consistency-%d", i),
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+		if tc.created {
+			recentCreations.add(vpc.creationKey())
+		}
+
+		created, err := vpc.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong creation", tc.desc))
+		assert.Equal(t, tc.resLookups, lookups, fmt.Sprintf("[%s] Wrong number of lookups", tc.desc))
+		if !tc.resCreated {
+			assert.Empty(t, fake.paramsOf("CreateVpc"), fmt.Sprintf("[%s] Duplicate VPC created", tc.desc))
+		}
+	}
+}
+
+func TestGatewayCreateIfNotExistsAfterCreation(t *testing.T) {
+	defaultBackOff := newConsistencyBackOff
+	defer func() { newConsistencyBackOff = defaultBackOff }()
+	newConsistencyBackOff = func(maxElapsedTime time.Duration) backoff.BackOff {
+		return NewMaxRetriesBackOff(&backoff.ZeroBackOff{}, 5)
+	}
+
+	clients, fake := newFakeClients()
+	fake.on("CreateInternetGateway", func(params, output interface{}) error {
+		output.(*ec2.CreateInternetGatewayOutput).InternetGateway = &ec2.InternetGateway{InternetGatewayId: aws.String("igw-123")}
+		return nil
+	})
+	fake.on("DescribeInternetGateways",
+		func(params, output interface{}) error {
+			return nil
+		},
+		func(params, output interface{}) error {
+			output.(*ec2.DescribeInternetGatewaysOutput).InternetGateways = []*ec2.InternetGateway{{InternetGatewayId: aws.String("igw-123")}}
+			return nil
+		},
+	)
+
+	gateway := &Gateway{
+		Name:      "consistency-gateway",
+		VpcID:     "vpc-123",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+	err := gateway.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error creating the gateway")
+
+	// A rerun right after the creation doesn't see the gateway at first.
+	rerun := &Gateway{
+		Name:      "consistency-gateway",
+		VpcID:     "vpc-123",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+	created, err := rerun.CreateIfNotExists()
+	assert.Nil(t, err, "Unexpected error")
+	assert.False(t, created, "Expected the gateway to be reused")
+	assert.Len(t, fake.paramsOf("CreateInternetGateway"), 1, "Duplicate gateway created")
+	assert.Len(t, fake.paramsOf("DescribeInternetGateways"), 2, "Expected the lookup to be retried")
+}
+
+func TestCreationLogRemaining(t *testing.T) {
+	log := &creationLog{created: make(map[string]time.Time)}
+
+	assert.Equal(t, time.Duration(0), log.remaining("foo"), "Unknown resource within the window")
+
+	log.add("foo")
+	assert.True(t, log.remaining("foo") > 0, "Created resource outside of the window")
+
+	log.created["foo"] = time.Now().Add(-consistencyWindow)
+	assert.Equal(t, time.Duration(0), log.remaining("foo"), "Old creation within the window")
+
+	log.add("foo")
+	log.remove("foo")
+	assert.Equal(t, time.Duration(0), log.remaining("foo"), "Deleted resource within the window")
+}
@@ -4,6 +4,12 @@ const (
 	// EC2 instance tag keys.
 	tagKeyName    string = "Name"
 	tagKeyCluster string = "Cluster"
+	// tagKeyOperator is the tag key of the ID of the operator managing a
+	// resource.
+	tagKeyOperator string = "OperatorID"
+	// tagKeyClusterID is the tag key of the ID of the cluster a resource is
+	// scoped to, see AWSEntity.ClusterID.
+	tagKeyClusterID string = "ClusterID"
 	// Subnet keys
 	subnetAvailabilityZone string = "availabilityZone"
 	subnetCidrBlock        string = "cidrBlock"
@@ -0,0 +1,180 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/cenkalti/backoff"
+	microerror "github.com/giantswarm/microkit/error"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/pborman/uuid"
+)
+
+// describeEgressOnlyInternetGatewaysInput is the input of
+// DescribeEgressOnlyInternetGateways, with filters. The vendored SDK predates
+// them, so ec2.DescribeEgressOnlyInternetGatewaysInput lacks them.
+type describeEgressOnlyInternetGatewaysInput struct {
+	_ struct{} `type:"structure"`
+
+	Filters []*ec2.Filter `locationName:"Filter" locationNameList:"Filter" type:"list"`
+}
+
+// EgressOnlyGateway is an egress-only internet gateway, which lets the
+// instances of IPv6 subnets reach the internet, without them being reachable
+// from it.
+type EgressOnlyGateway struct {
+	Name  string
+	VpcID string
+	id    string
+	// Dependencies.
+	Logger micrologger.Logger
+	AWSEntity
+}
+
+// findExisting returns the egress-only gateway named Name. An egress-only
+// gateway created recently is waited for, since it might not be visible yet.
+func (g EgressOnlyGateway) findExisting() (*ec2.EgressOnlyInternetGateway, error) {
+	var gateway *ec2.EgressOnlyInternetGateway
+	err := findCreated(g.creationKey(), func() error {
+		var err error
+		gateway, err = g.describe()
+		return err
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return gateway, nil
+}
+
+func (g EgressOnlyGateway) creationKey() string {
+	return creationKey(EgressOnlyGatewayType, g.OperatorID, g.Name)
+}
+
+func (g EgressOnlyGateway) describe() (*ec2.EgressOnlyInternetGateway, error) {
+	output := &ec2.DescribeEgressOnlyInternetGatewaysOutput{}
+	req := g.Clients.EC2.NewRequest(&request.Operation{
+		Name:       "DescribeEgressOnlyInternetGateways",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &describeEgressOnlyInternetGatewaysInput{
+		Filters: append([]*ec2.Filter{
+			&ec2.Filter{
+				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
+				Values: []*string{
+					aws.String(g.Name),
+				},
+			},
+		}, operatorFilters(g.OperatorID)...),
+	}, output)
+	if err := req.Send(); err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	if len(output.EgressOnlyInternetGateways) < 1 {
+		return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, EgressOnlyGatewayType, g.Name)
+	}
+
+	return output.EgressOnlyInternetGateways[0], nil
+}
+
+func (g *EgressOnlyGateway) checkIfExists() (bool, error) {
+	_, err := g.findExisting()
+	if IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return true, nil
+}
+
+func (g *EgressOnlyGateway) CreateIfNotExists() (bool, error) {
+	exists, err := g.checkIfExists()
+	if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	if exists {
+		return false, nil
+	}
+
+	if err := g.CreateOrFail(); err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return true, nil
+}
+
+// CreateOrFail creates the egress-only gateway, which is attached to the VPC
+// VpcID right away.
+func (g *EgressOnlyGateway) CreateOrFail() error {
+	gateway, err := g.Clients.EC2.CreateEgressOnlyInternetGateway(&ec2.CreateEgressOnlyInternetGatewayInput{
+		ClientToken: aws.String(uuid.New()),
+		VpcId:       aws.String(g.VpcID),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	gatewayID := *gateway.EgressOnlyInternetGateway.EgressOnlyInternetGatewayId
+
+	if _, err := g.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{
+			aws.String(gatewayID),
+		},
+		Tags: append([]*ec2.Tag{
+			{
+				Key:   aws.String(tagKeyName),
+				Value: aws.String(g.Name),
+			},
+		}, resourceTags(g.OperatorID, g.Tags)...),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	g.id = gatewayID
+	recentCreations.add(g.creationKey())
+
+	return nil
+}
+
+// Delete deletes the egress-only gateway, which detaches it from its VPC.
+func (g *EgressOnlyGateway) Delete() error {
+	gateway, err := g.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	deleteOperation := func() error {
+		if _, err := g.Clients.EC2.DeleteEgressOnlyInternetGateway(&ec2.DeleteEgressOnlyInternetGatewayInput{
+			EgressOnlyInternetGatewayId: gateway.EgressOnlyInternetGatewayId,
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+		return nil
+	}
+	deleteNotify := NewNotify(g.Logger, "deleting egress-only gateway")
+	if err := backoff.RetryNotify(deleteOperation, NewCustomExponentialBackoff(), deleteNotify); err != nil {
+		return microerror.MaskAny(err)
+	}
+	recentCreations.remove(g.creationKey())
+
+	return nil
+}
+
+// GetID returns the ID of the egress-only gateway, e.g. for the IPv6 routes of
+// the route tables of its VPC.
+func (g EgressOnlyGateway) GetID() (string, error) {
+	if g.id != "" {
+		return g.id, nil
+	}
+
+	gateway, err := g.findExisting()
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return *gateway.EgressOnlyInternetGatewayId, nil
+}
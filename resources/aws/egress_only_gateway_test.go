@@ -0,0 +1,177 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/private/protocol/ec2query"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEgressOnlyGatewayCreateIfNotExists(t *testing.T) {
+	tests := []struct {
+		desc          string
+		gateways      []*ec2.EgressOnlyInternetGateway
+		describeError error
+		errorMatcher  func(error) bool
+		resCreated    bool
+		resID         string
+		resOperations []string
+	}{
+		{
+			desc:          "missing egress-only gateway is created",
+			resCreated:    true,
+			resID:         "eigw-new",
+			resOperations: []string{"DescribeEgressOnlyInternetGateways", "CreateEgressOnlyInternetGateway", "CreateTags"},
+		},
+		{
+			desc:          "existing egress-only gateway is reused",
+			gateways:      []*ec2.EgressOnlyInternetGateway{{EgressOnlyInternetGatewayId: aws.String("eigw-123")}},
+			resCreated:    false,
+			resID:         "eigw-123",
+			resOperations: []string{"DescribeEgressOnlyInternetGateways", "DescribeEgressOnlyInternetGateways"},
+		},
+		{
+			desc:          "failing lookup is not taken for a missing egress-only gateway",
+			describeError: awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil),
+			errorMatcher:  func(err error) bool { return err != nil && !IsNotFound(err) },
+			resOperations: []string{"DescribeEgressOnlyInternetGateways"},
+		},
+	}
+
+	for i, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeEgressOnlyInternetGateways", func(params, output interface{}) error {
+			if tc.describeError != nil {
+				return tc.describeError
+			}
+			output.(*ec2.DescribeEgressOnlyInternetGatewaysOutput).EgressOnlyInternetGateways = tc.gateways
+			return nil
+		})
+		fake.on("CreateEgressOnlyInternetGateway", func(params, output interface{}) error {
+			output.(*ec2.CreateEgressOnlyInternetGatewayOutput).EgressOnlyInternetGateway = &ec2.EgressOnlyInternetGateway{
+				EgressOnlyInternetGatewayId: aws.String("eigw-new"),
+			}
+			return nil
+		})
+
+		gateway := &EgressOnlyGateway{
+			Name:      fmt.Sprintf("egress-only-gateway-%d", i),
+			VpcID:     "vpc-123",
+			AWSEntity: AWSEntity{Clients: clients, OperatorID: "prod"},
+		}
+
+		created, err := gateway.CreateIfNotExists()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong creation", tc.desc))
+
+		id, err := gateway.GetID()
+		recentCreations.remove(gateway.creationKey())
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resID, id, fmt.Sprintf("[%s] Wrong egress-only gateway ID", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		filters := fake.paramsOf("DescribeEgressOnlyInternetGateways")[0].(*describeEgressOnlyInternetGatewaysInput).Filters
+		assert.Contains(t, filters, &ec2.Filter{Name: aws.String("tag:" + tagKeyName), Values: []*string{aws.String(gateway.Name)}}, fmt.Sprintf("[%s] Not filtered by name", tc.desc))
+
+		if tc.resCreated {
+			input := fake.paramsOf("CreateEgressOnlyInternetGateway")[0].(*ec2.CreateEgressOnlyInternetGatewayInput)
+			assert.Equal(t, "vpc-123", aws.StringValue(input.VpcId), fmt.Sprintf("[%s] Wrong VPC", tc.desc))
+			assert.NotEmpty(t, aws.StringValue(input.ClientToken), fmt.Sprintf("[%s] Missing client token", tc.desc))
+
+			tags := fake.paramsOf("CreateTags")[0].(*ec2.CreateTagsInput)
+			assert.Equal(t, []*string{aws.String("eigw-new")}, tags.Resources, fmt.Sprintf("[%s] Wrong tagged resource", tc.desc))
+			assert.Contains(t, tags.Tags, &ec2.Tag{Key: aws.String(tagKeyName), Value: aws.String(gateway.Name)}, fmt.Sprintf("[%s] Missing name tag", tc.desc))
+		}
+	}
+}
+
+func TestEgressOnlyGatewayDelete(t *testing.T) {
+	tests := []struct {
+		desc          string
+		gateways      []*ec2.EgressOnlyInternetGateway
+		errorMatcher  func(error) bool
+		resOperations []string
+	}{
+		{
+			desc:          "egress-only gateway is deleted",
+			gateways:      []*ec2.EgressOnlyInternetGateway{{EgressOnlyInternetGatewayId: aws.String("eigw-123")}},
+			resOperations: []string{"DescribeEgressOnlyInternetGateways", "DeleteEgressOnlyInternetGateway"},
+		},
+		{
+			desc:          "missing egress-only gateway is already deleted",
+			errorMatcher:  IsAlreadyDeleted,
+			resOperations: []string{"DescribeEgressOnlyInternetGateways"},
+		},
+	}
+
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeEgressOnlyInternetGateways", func(params, output interface{}) error {
+			output.(*ec2.DescribeEgressOnlyInternetGatewaysOutput).EgressOnlyInternetGateways = tc.gateways
+			return nil
+		})
+
+		gateway := &EgressOnlyGateway{
+			Name:      "foo",
+			Logger:    logger,
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := gateway.Delete()
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		} else {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		}
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("DeleteEgressOnlyInternetGateway") {
+			input := params.(*ec2.DeleteEgressOnlyInternetGatewayInput)
+			assert.Equal(t, "eigw-123", aws.StringValue(input.EgressOnlyInternetGatewayId), fmt.Sprintf("[%s] Wrong egress-only gateway deleted", tc.desc))
+		}
+	}
+}
+
+func TestDescribeEgressOnlyInternetGatewaysInputBuild(t *testing.T) {
+	clients, _ := newFakeClients()
+	req := clients.EC2.NewRequest(&request.Operation{
+		Name:       "DescribeEgressOnlyInternetGateways",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &describeEgressOnlyInternetGatewaysInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("tag:Name"),
+				Values: []*string{aws.String("foo")},
+			},
+		},
+	}, &ec2.DescribeEgressOnlyInternetGatewaysOutput{})
+
+	ec2query.Build(req)
+	assert.Nil(t, req.Error, "Unexpected error")
+
+	body, err := ioutil.ReadAll(req.GetBody())
+	assert.Nil(t, err, "Unexpected error reading the body")
+	values, err := url.ParseQuery(string(body))
+	assert.Nil(t, err, "Unexpected error parsing the body")
+
+	assert.Equal(t, "DescribeEgressOnlyInternetGateways", values.Get("Action"), "Wrong action")
+	assert.Equal(t, "tag:Name", values.Get("Filter.1.Name"), "Filter name not built")
+	assert.Equal(t, "foo", values.Get("Filter.1.Value.1"), "Filter value not built")
+}
@@ -2,25 +2,98 @@ package aws
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/elb"
-	awsclient "github.com/giantswarm/aws-operator/client/aws"
 	microerror "github.com/giantswarm/microkit/error"
 )
 
 // ELB is an Elastic Load Balancer
 type ELB struct {
-	Name          string
-	dnsName       string
-	hostedZoneID  string
-	AZ            string
-	SecurityGroup string
-	SubnetID      string
-	Tags          []string
-	PortsToOpen   PortPairs
-	Client        *elb.ELB
+	Name         string
+	dnsName      string
+	hostedZoneID string
+	// AZ is the availability zone of the ELB. It is ignored when AZs are
+	// given.
+	AZ string
+	// AZs are the availability zones of ELBs outside of a VPC. ELBs in a VPC
+	// span the availability zones of their subnet.
+	AZs []string
+	// CrossZone makes the ELB spread the traffic evenly across the instances
+	// of all its availability zones.
+	CrossZone bool
+	// ConnectionDraining is the number of seconds in-flight requests are kept
+	// open for instances being deregistered or unhealthy, between 1 and 3600.
+	// Connections are closed right away when it is zero.
+	ConnectionDraining int
+	// Scheme is either ELBSchemeInternetFacing or ELBSchemeInternal. The ELB is
+	// internet-facing when it is empty.
+	Scheme string
+	// SecurityGroupID is the ID of the security group of the ELB. AWS assigns
+	// the default security group of the subnet's VPC when it is empty.
+	SecurityGroupID string
+	// SubnetID is the subnet of the ELB. It is ignored when Subnets are given.
+	SubnetID string
+	// Subnets are the subnets of ELBs in a VPC, at most one per availability
+	// zone. The ELB spans their availability zones, AZ and AZs are ignored.
+	Subnets []string
+	// Tags are added to the ELB along with its Name, Cluster and OperatorID
+	// tags, e.g. for cost allocation. They can't override these tags.
+	Tags map[string]string
+	// PortsToOpen are forwarded over TCP by the ELB. They are ignored when
+	// Listeners are given.
+	PortsToOpen PortPairs
+	// Listeners are the listeners of the ELB, with their protocols. The first
+	// one is the primary listener, probed by the default health check.
+	Listeners []ELBListener
+	// ClusterName is the value of the Cluster tag of the ELB, which allows
+	// finding all the ELBs of a cluster regardless of their names.
+	ClusterName string
+	// OperatorID is the value of the OperatorID tag of the ELB.
+	OperatorID string
+	// HealthCheck is the health check of the ELB's instances. It defaults to a
+	// TCP check of the instance port of the first listener.
+	HealthCheck HealthCheck
+	Client      *elb.ELB
+}
+
+// HealthCheck describes how an ELB probes its instances.
+type HealthCheck struct {
+	// Protocol is one of TCP, SSL, HTTP or HTTPS. It defaults to TCP.
+	Protocol string
+	// Port is the instance port probed. It defaults to the instance port of the
+	// first listener.
+	Port int
+	// Path is the path requested by HTTP and HTTPS checks, e.g. /healthz.
+	Path string
+	// Interval is the number of seconds between two checks of an instance,
+	// between 5 and 300. It defaults to 5.
+	Interval int
+	// Timeout is the number of seconds without response after which a check
+	// fails, between 2 and 60 and lower than the interval. It defaults to 3.
+	Timeout int
+	// HealthyThreshold is the number of consecutive successful checks after
+	// which an instance is healthy, between 2 and 10. It defaults to 10.
+	HealthyThreshold int
+	// UnhealthyThreshold is the number of consecutive failed checks after which
+	// an instance is unhealthy, between 2 and 10. It defaults to 2.
+	UnhealthyThreshold int
+}
+
+// ELBListener is a listener of an ELB.
+type ELBListener struct {
+	// Protocol is one of TCP, SSL, HTTP or HTTPS.
+	Protocol string
+	// LoadBalancerPort is the port the ELB listens on.
+	LoadBalancerPort int
+	// InstancePort is the port on the instance the ELB forwards traffic to.
+	InstancePort int
+	// SSLCertificateID is the ARN of the server certificate of SSL and HTTPS
+	// listeners.
+	SSLCertificateID string
 }
 
 // PortPair is a pair of ports.
@@ -34,6 +107,30 @@ type PortPair struct {
 // PortPairs is an array of PortPair.
 type PortPairs []PortPair
 
+// hasInstancePort returns whether one of the port pairs forwards to the given
+// instance port.
+func (p PortPairs) hasInstancePort(port int) bool {
+	for _, portPair := range p {
+		if portPair.PortInstance == port {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	// ELBSchemeInternetFacing is the scheme of ELBs reachable from the internet.
+	ELBSchemeInternetFacing = "internet-facing"
+	// ELBSchemeInternal is the scheme of ELBs only reachable from within the VPC.
+	ELBSchemeInternal = "internal"
+	// Protocols of listeners and health checks.
+	ELBProtocolTCP   = "TCP"
+	ELBProtocolSSL   = "SSL"
+	ELBProtocolHTTP  = "HTTP"
+	ELBProtocolHTTPS = "HTTPS"
+)
+
 const (
 	// proxyProtocolPolicyTypeName is the name of the ProxyProtocolPolicy type.
 	proxyProtocolPolicyTypeName = "ProxyProtocolPolicyType"
@@ -46,6 +143,22 @@ const (
 	healthCheckInterval           = 5
 	healthCheckTimeout            = 3
 	healthCheckUnhealthyThreshold = 2
+	// instanceStateInService is the state of instances passing the health
+	// check of an ELB.
+	instanceStateInService = "InService"
+	// Limits of connection draining timeouts.
+	connectionDrainingMinTimeout = 1
+	connectionDrainingMaxTimeout = 3600
+	// Limits of health checks.
+	healthCheckMinInterval  = 5
+	healthCheckMaxInterval  = 300
+	healthCheckMinTimeout   = 2
+	healthCheckMaxTimeout   = 60
+	healthCheckMinThreshold = 2
+	healthCheckMaxThreshold = 10
+	// describeTagsMaxNames is the maximum number of ELB names a single
+	// DescribeTags request accepts.
+	describeTagsMaxNames = 20
 )
 
 func (lb *ELB) CreateIfNotExists() (bool, error) {
@@ -53,77 +166,381 @@ func (lb *ELB) CreateIfNotExists() (bool, error) {
 		return false, microerror.MaskAny(clientNotInitializedError)
 	}
 
-	if err := lb.CreateOrFail(); err != nil {
-		if strings.Contains(err.Error(), awsclient.ELBConfigurationMismatch) {
+	lbDescription, err := lb.findExisting()
+	if IsNotFound(err) {
+		if err := lb.CreateOrFail(); err != nil {
 			return false, microerror.MaskAny(err)
 		}
-		if strings.Contains(err.Error(), awsclient.ELBAlreadyExists) {
-			return false, nil
-		}
 
+		return true, nil
+	} else if err != nil {
 		return false, microerror.MaskAny(err)
 	}
 
-	return true, nil
+	lb.setDNSFields(*lbDescription)
+
+	// ELBs created before they got tagged must be found on deletion too.
+	if err := lb.tag(); err != nil {
+		return false, microerror.MaskAny(err)
+	}
+	if err := lb.reconcileListeners(*lbDescription); err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return false, nil
 }
 
 func (lb *ELB) CreateOrFail() error {
 	if lb.Client == nil {
 		return microerror.MaskAny(clientNotInitializedError)
 	}
-	if len(lb.PortsToOpen) == 0 {
+	elbListeners := lb.elbListeners()
+	if len(elbListeners) == 0 {
 		return microerror.MaskAnyf(attributeEmptyError, attributeEmptyErrorFormat, "portsToOpen")
 	}
 
+	var listenerPorts PortPairs
+	for _, elbListener := range elbListeners {
+		listenerPorts = append(listenerPorts, PortPair{
+			PortELB:      elbListener.LoadBalancerPort,
+			PortInstance: elbListener.InstancePort,
+		})
+	}
+	healthCheck, err := lb.HealthCheck.healthCheck(listenerPorts)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
 	var listeners []*elb.Listener
-	for _, portPair := range lb.PortsToOpen {
-		listener := &elb.Listener{
-			InstancePort:     aws.Int64(int64(portPair.PortInstance)),
-			LoadBalancerPort: aws.Int64(int64(portPair.PortELB)),
-			// We use TCP and not HTTP(S) because we want to do SSL passthrough and not termination.
-			Protocol: aws.String("TCP"),
+	for _, elbListener := range elbListeners {
+		listener, err := elbListener.listener()
+		if err != nil {
+			return microerror.MaskAny(err)
 		}
 
 		listeners = append(listeners, listener)
 	}
 
-	if _, err := lb.Client.CreateLoadBalancer(&elb.CreateLoadBalancerInput{
+	if lb.ConnectionDraining != 0 && (lb.ConnectionDraining < connectionDrainingMinTimeout || lb.ConnectionDraining > connectionDrainingMaxTimeout) {
+		return microerror.MaskAnyf(invalidConnectionDrainingError, "timeout must be between %d and %d seconds", connectionDrainingMinTimeout, connectionDrainingMaxTimeout)
+	}
+
+	scheme, err := lb.scheme()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	params := &elb.CreateLoadBalancerInput{
 		LoadBalancerName: aws.String(lb.Name),
 		Listeners:        listeners,
-		SecurityGroups: []*string{
-			aws.String(lb.SecurityGroup),
-		},
-		Subnets: []*string{
-			aws.String(lb.SubnetID),
-		},
-	}); err != nil {
+		Scheme:           aws.String(scheme),
+	}
+	// AWS rejects ELBs placed in both subnets and availability zones.
+	if subnets := lb.subnets(); len(subnets) > 0 {
+		params.Subnets = aws.StringSlice(subnets)
+	} else {
+		params.AvailabilityZones = aws.StringSlice(lb.availabilityZones())
+	}
+	if lb.SecurityGroupID != "" {
+		params.SecurityGroups = []*string{
+			aws.String(lb.SecurityGroupID),
+		}
+	}
+
+	if _, err := lb.Client.CreateLoadBalancer(params); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if err := lb.tag(); err != nil {
 		return microerror.MaskAny(err)
 	}
 
 	if _, err := lb.Client.ConfigureHealthCheck(&elb.ConfigureHealthCheckInput{
-		HealthCheck: &elb.HealthCheck{
-			HealthyThreshold:   aws.Int64(int64(healthCheckHealthyThreshold)),
-			Interval:           aws.Int64(int64(healthCheckInterval)),
-			Target:             aws.String(fmt.Sprintf("TCP:%d", lb.PortsToOpen[0].PortELB)),
-			Timeout:            aws.Int64(int64(healthCheckTimeout)),
-			UnhealthyThreshold: aws.Int64(int64(healthCheckUnhealthyThreshold)),
-		},
+		HealthCheck:      healthCheck,
 		LoadBalancerName: aws.String(lb.Name),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
 
+	if lb.CrossZone || lb.ConnectionDraining > 0 {
+		connectionDraining := &elb.ConnectionDraining{
+			Enabled: aws.Bool(lb.ConnectionDraining > 0),
+		}
+		if lb.ConnectionDraining > 0 {
+			connectionDraining.Timeout = aws.Int64(int64(lb.ConnectionDraining))
+		}
+
+		if _, err := lb.Client.ModifyLoadBalancerAttributes(&elb.ModifyLoadBalancerAttributesInput{
+			LoadBalancerAttributes: &elb.LoadBalancerAttributes{
+				ConnectionDraining: connectionDraining,
+				CrossZoneLoadBalancing: &elb.CrossZoneLoadBalancing{
+					Enabled: aws.Bool(lb.CrossZone),
+				},
+			},
+			LoadBalancerName: aws.String(lb.Name),
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
 	// We have to populate some additional fields.
+	if err := lb.Get(); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// ReconcileListeners converges the listeners of the existing ELB to the
+// desired ones, e.g. after they were changed or removed out of band. Listeners
+// are identified by their load balancer port, listeners which differ from the
+// desired ones are replaced and unknown ones are deleted.
+func (lb ELB) ReconcileListeners() error {
+	if lb.Client == nil {
+		return microerror.MaskAny(clientNotInitializedError)
+	}
+
 	lbDescription, err := lb.findExisting()
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
 
-	lb.setDNSFields(*lbDescription)
+	if err := lb.reconcileListeners(*lbDescription); err != nil {
+		return microerror.MaskAny(err)
+	}
 
 	return nil
 }
 
+func (lb ELB) reconcileListeners(desc elb.LoadBalancerDescription) error {
+	var listeners []*elb.Listener
+	desired := make(map[int64]*elb.Listener)
+	for _, elbListener := range lb.elbListeners() {
+		listener, err := elbListener.listener()
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		listeners = append(listeners, listener)
+		desired[aws.Int64Value(listener.LoadBalancerPort)] = listener
+	}
+
+	var obsoletePorts []*int64
+	current := make(map[int64]bool)
+	for _, listenerDesc := range desc.ListenerDescriptions {
+		listener := listenerDesc.Listener
+		if listener == nil {
+			continue
+		}
+		port := aws.Int64Value(listener.LoadBalancerPort)
+		if wanted, ok := desired[port]; ok && sameListener(*listener, *wanted) {
+			current[port] = true
+			continue
+		}
+		obsoletePorts = append(obsoletePorts, aws.Int64(port))
+	}
+
+	var missing []*elb.Listener
+	for _, listener := range listeners {
+		if !current[aws.Int64Value(listener.LoadBalancerPort)] {
+			missing = append(missing, listener)
+		}
+	}
+
+	// Replaced listeners must be deleted first, an ELB can't have two
+	// listeners on the same port.
+	if len(obsoletePorts) > 0 {
+		if _, err := lb.Client.DeleteLoadBalancerListeners(&elb.DeleteLoadBalancerListenersInput{
+			LoadBalancerName:  aws.String(lb.Name),
+			LoadBalancerPorts: obsoletePorts,
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+	if len(missing) > 0 {
+		if _, err := lb.Client.CreateLoadBalancerListeners(&elb.CreateLoadBalancerListenersInput{
+			Listeners:        missing,
+			LoadBalancerName: aws.String(lb.Name),
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// sameListener checks whether an existing listener matches a desired one. The
+// instance protocol is left to AWS, which derives it from the protocol.
+func sameListener(existing, desired elb.Listener) bool {
+	return strings.EqualFold(aws.StringValue(existing.Protocol), aws.StringValue(desired.Protocol)) &&
+		aws.Int64Value(existing.InstancePort) == aws.Int64Value(desired.InstancePort) &&
+		aws.StringValue(existing.SSLCertificateId) == aws.StringValue(desired.SSLCertificateId)
+}
+
+// scheme returns the scheme of the ELB, internet-facing when it has none.
+func (lb ELB) scheme() (string, error) {
+	switch lb.Scheme {
+	case "":
+		return ELBSchemeInternetFacing, nil
+	case ELBSchemeInternetFacing, ELBSchemeInternal:
+		return lb.Scheme, nil
+	default:
+		return "", microerror.MaskAnyf(invalidELBSchemeError, "scheme '%s' must be '%s' or '%s'", lb.Scheme, ELBSchemeInternetFacing, ELBSchemeInternal)
+	}
+}
+
+// subnets returns the subnets of the ELB, or its subnet when it has no
+// subnets.
+func (lb ELB) subnets() []string {
+	if len(lb.Subnets) > 0 {
+		return lb.Subnets
+	}
+	if lb.SubnetID != "" {
+		return []string{lb.SubnetID}
+	}
+
+	return nil
+}
+
+// availabilityZones returns the AZs of the ELB, or its AZ when it has no
+// AZs.
+func (lb ELB) availabilityZones() []string {
+	if len(lb.AZs) > 0 {
+		return lb.AZs
+	}
+	if lb.AZ != "" {
+		return []string{lb.AZ}
+	}
+
+	return nil
+}
+
+// elbListeners returns the listeners of the ELB. Without Listeners, the
+// PortsToOpen are forwarded over TCP.
+func (lb ELB) elbListeners() []ELBListener {
+	if len(lb.Listeners) > 0 {
+		return lb.Listeners
+	}
+
+	var listeners []ELBListener
+	for _, portPair := range lb.PortsToOpen {
+		listeners = append(listeners, ELBListener{
+			// We use TCP and not HTTP(S) because we want to do SSL passthrough and not termination.
+			Protocol:         ELBProtocolTCP,
+			LoadBalancerPort: portPair.PortELB,
+			InstancePort:     portPair.PortInstance,
+		})
+	}
+
+	return listeners
+}
+
+// listener validates the listener and converts it to its API representation.
+func (l ELBListener) listener() (*elb.Listener, error) {
+	listener := &elb.Listener{
+		InstancePort:     aws.Int64(int64(l.InstancePort)),
+		LoadBalancerPort: aws.Int64(int64(l.LoadBalancerPort)),
+		Protocol:         aws.String(l.Protocol),
+	}
+
+	switch l.Protocol {
+	case ELBProtocolTCP, ELBProtocolHTTP:
+		if l.SSLCertificateID != "" {
+			return nil, microerror.MaskAnyf(invalidListenerError, "%s listeners cannot have a certificate", l.Protocol)
+		}
+	case ELBProtocolSSL, ELBProtocolHTTPS:
+		if l.SSLCertificateID == "" {
+			return nil, microerror.MaskAnyf(invalidListenerError, "%s listeners need a certificate", l.Protocol)
+		}
+		listener.SSLCertificateId = aws.String(l.SSLCertificateID)
+	default:
+		return nil, microerror.MaskAnyf(invalidListenerError, "unknown protocol '%s'", l.Protocol)
+	}
+
+	return listener, nil
+}
+
+// healthCheck validates the health check and converts it to its API
+// representation, applying the defaults. The first listener is the primary one.
+func (hc HealthCheck) healthCheck(listeners PortPairs) (*elb.HealthCheck, error) {
+	target, err := hc.target(listeners)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	interval := valueOrDefault(hc.Interval, healthCheckInterval)
+	timeout := valueOrDefault(hc.Timeout, healthCheckTimeout)
+	healthyThreshold := valueOrDefault(hc.HealthyThreshold, healthCheckHealthyThreshold)
+	unhealthyThreshold := valueOrDefault(hc.UnhealthyThreshold, healthCheckUnhealthyThreshold)
+
+	if interval < healthCheckMinInterval || interval > healthCheckMaxInterval {
+		return nil, microerror.MaskAnyf(invalidHealthCheckError, "interval must be between %d and %d seconds", healthCheckMinInterval, healthCheckMaxInterval)
+	}
+	if timeout < healthCheckMinTimeout || timeout > healthCheckMaxTimeout {
+		return nil, microerror.MaskAnyf(invalidHealthCheckError, "timeout must be between %d and %d seconds", healthCheckMinTimeout, healthCheckMaxTimeout)
+	}
+	if timeout >= interval {
+		return nil, microerror.MaskAnyf(invalidHealthCheckError, "timeout must be lower than the interval")
+	}
+	for _, threshold := range []int{healthyThreshold, unhealthyThreshold} {
+		if threshold < healthCheckMinThreshold || threshold > healthCheckMaxThreshold {
+			return nil, microerror.MaskAnyf(invalidHealthCheckError, "thresholds must be between %d and %d", healthCheckMinThreshold, healthCheckMaxThreshold)
+		}
+	}
+
+	return &elb.HealthCheck{
+		HealthyThreshold:   aws.Int64(int64(healthyThreshold)),
+		Interval:           aws.Int64(int64(interval)),
+		Target:             aws.String(target),
+		Timeout:            aws.Int64(int64(timeout)),
+		UnhealthyThreshold: aws.Int64(int64(unhealthyThreshold)),
+	}, nil
+}
+
+func valueOrDefault(value, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+// target renders the health check target, e.g. TCP:443 or HTTPS:6443/healthz.
+// The port defaults to the instance port of the primary listener, the first
+// one, and must be the instance port of one of the listeners otherwise, so the
+// check probes what the ELB forwards to.
+func (hc HealthCheck) target(listeners PortPairs) (string, error) {
+	if len(listeners) == 0 {
+		return "", microerror.MaskAnyf(invalidHealthCheckError, "no listener to check")
+	}
+
+	protocol := hc.Protocol
+	if protocol == "" {
+		protocol = ELBProtocolTCP
+	}
+	port := hc.Port
+	if port == 0 {
+		port = listeners[0].PortInstance
+	}
+	if !listeners.hasInstancePort(port) {
+		return "", microerror.MaskAnyf(invalidHealthCheckError, "port %d is not the instance port of a listener", port)
+	}
+
+	switch protocol {
+	case ELBProtocolTCP, ELBProtocolSSL:
+		if hc.Path != "" {
+			return "", microerror.MaskAnyf(invalidHealthCheckError, "%s health checks cannot have a path", protocol)
+		}
+		return fmt.Sprintf("%s:%d", protocol, port), nil
+	case ELBProtocolHTTP, ELBProtocolHTTPS:
+		if !strings.HasPrefix(hc.Path, "/") {
+			return "", microerror.MaskAnyf(invalidHealthCheckError, "%s health checks need a path starting with '/'", protocol)
+		}
+		return fmt.Sprintf("%s:%d%s", protocol, port, hc.Path), nil
+	}
+
+	return "", microerror.MaskAnyf(invalidHealthCheckError, "unknown protocol '%s'", protocol)
+}
+
 func (lb ELB) Delete() error {
 	if lb.Client == nil {
 		return microerror.MaskAny(clientNotInitializedError)
@@ -138,6 +555,134 @@ func (lb ELB) Delete() error {
 	return nil
 }
 
+// tags returns the tags of the ELB, sorted by key. Besides its own tags, these
+// are its name and the cluster and operator it belongs to.
+func (lb ELB) tags() []*elb.Tag {
+	values := make(map[string]string, len(lb.Tags)+3)
+	for key, value := range lb.Tags {
+		values[key] = value
+	}
+	if lb.Name != "" {
+		values[tagKeyName] = lb.Name
+	}
+	if lb.ClusterName != "" {
+		values[tagKeyCluster] = lb.ClusterName
+	}
+	if lb.OperatorID != "" {
+		values[tagKeyOperator] = lb.OperatorID
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := make([]*elb.Tag, 0, len(keys))
+	for _, key := range keys {
+		tags = append(tags, &elb.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(values[key]),
+		})
+	}
+
+	return tags
+}
+
+// tag adds the tags to an existing ELB. Existing tags with the same keys are
+// overwritten.
+func (lb ELB) tag() error {
+	tags := lb.tags()
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := lb.Client.AddTags(&elb.AddTagsInput{
+		LoadBalancerNames: []*string{
+			aws.String(lb.Name),
+		},
+		Tags: tags,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// FindClusterELBs returns all the ELBs tagged as belonging to the given
+// cluster, and to the given operator when its ID is not empty. ELBs are
+// discovered by tag, so ELBs whose names don't follow the current naming
+// scheme are found as well.
+func FindClusterELBs(client *elb.ELB, clusterName, operatorID string) ([]*ELB, error) {
+	if client == nil {
+		return nil, microerror.MaskAny(clientNotInitializedError)
+	}
+	// Without the cluster name every ELB of the region would match.
+	if clusterName == "" {
+		return nil, microerror.MaskAnyf(attributeEmptyError, attributeEmptyErrorFormat, "clusterName")
+	}
+
+	var names []*string
+	if err := client.DescribeLoadBalancersPages(&elb.DescribeLoadBalancersInput{}, func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+		for _, desc := range page.LoadBalancerDescriptions {
+			names = append(names, desc.LoadBalancerName)
+		}
+		return true
+	}); err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	wanted := ELB{
+		ClusterName: clusterName,
+		OperatorID:  operatorID,
+	}
+
+	var lbs []*ELB
+	for start := 0; start < len(names); start += describeTagsMaxNames {
+		end := start + describeTagsMaxNames
+		if end > len(names) {
+			end = len(names)
+		}
+
+		resp, err := client.DescribeTags(&elb.DescribeTagsInput{
+			LoadBalancerNames: names[start:end],
+		})
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+
+		for _, desc := range resp.TagDescriptions {
+			if hasELBTags(desc.Tags, wanted.tags()) {
+				lbs = append(lbs, &ELB{
+					Name:        aws.StringValue(desc.LoadBalancerName),
+					ClusterName: clusterName,
+					OperatorID:  operatorID,
+					Client:      client,
+				})
+			}
+		}
+	}
+
+	return lbs, nil
+}
+
+// hasELBTags checks whether all the wanted tags are amongst the given ones.
+func hasELBTags(tags, wanted []*elb.Tag) bool {
+	values := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		values[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	for _, tag := range wanted {
+		value, ok := values[aws.StringValue(tag.Key)]
+		if !ok || value != aws.StringValue(tag.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (lb *ELB) RegisterInstances(instanceIDs []string) error {
 	var instances []*elb.Instance
 
@@ -158,6 +703,84 @@ func (lb *ELB) RegisterInstances(instanceIDs []string) error {
 	return nil
 }
 
+// DeregisterInstances deregisters the given instances from the ELB. Instances
+// which aren't registered with it are skipped.
+func (lb ELB) DeregisterInstances(instanceIDs []string) error {
+	if lb.Client == nil {
+		return microerror.MaskAny(clientNotInitializedError)
+	}
+
+	desc, err := lb.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	registered := make(map[string]bool)
+	for _, instance := range desc.Instances {
+		registered[aws.StringValue(instance.InstanceId)] = true
+	}
+
+	var instances []*elb.Instance
+	for _, id := range instanceIDs {
+		if registered[id] {
+			instances = append(instances, &elb.Instance{
+				InstanceId: aws.String(id),
+			})
+		}
+	}
+	if len(instances) == 0 {
+		return nil
+	}
+
+	if _, err := lb.Client.DeregisterInstancesFromLoadBalancer(&elb.DeregisterInstancesFromLoadBalancerInput{
+		Instances:        instances,
+		LoadBalancerName: aws.String(lb.Name),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// InstancesNotInService returns the IDs of the given instances which are not
+// InService yet, i.e. not passing the health check of the ELB.
+func (lb ELB) InstancesNotInService(instanceIDs []string) ([]string, error) {
+	if lb.Client == nil {
+		return nil, microerror.MaskAny(clientNotInitializedError)
+	}
+
+	var instances []*elb.Instance
+	for _, id := range instanceIDs {
+		instances = append(instances, &elb.Instance{
+			InstanceId: aws.String(id),
+		})
+	}
+
+	resp, err := lb.Client.DescribeInstanceHealth(&elb.DescribeInstanceHealthInput{
+		Instances:        instances,
+		LoadBalancerName: aws.String(lb.Name),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	inService := make(map[string]bool)
+	for _, state := range resp.InstanceStates {
+		if aws.StringValue(state.State) == instanceStateInService {
+			inService[aws.StringValue(state.InstanceId)] = true
+		}
+	}
+
+	var notInService []string
+	for _, id := range instanceIDs {
+		if !inService[id] {
+			notInService = append(notInService, id)
+		}
+	}
+
+	return notInService, nil
+}
+
 // AssignProxyPolicy creates a ProxyProtocol policy and assigns it to the Load Balancer.
 // This is needed for ELBs that listen/forward over TCP, in order to add
 // a header with the address, port of the source and destination.
@@ -200,14 +823,29 @@ func NewELBFromExisting(name string, client *elb.ELB) (*ELB, error) {
 		Client: client,
 	}
 
+	if err := lb.Get(); err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return &lb, nil
+}
+
+// Get fetches the ELB named Name and populates the fields returned by DNSName
+// and HostedZoneID, so DNS records can alias it. It returns a notFoundError
+// when the ELB doesn't exist.
+func (lb *ELB) Get() error {
+	if lb.Client == nil {
+		return microerror.MaskAny(clientNotInitializedError)
+	}
+
 	lbDescription, err := lb.findExisting()
 	if err != nil {
-		return nil, err
+		return microerror.MaskAny(err)
 	}
 
 	lb.setDNSFields(*lbDescription)
 
-	return &lb, nil
+	return nil
 }
 
 func (lb ELB) findExisting() (*elb.LoadBalancerDescription, error) {
@@ -217,8 +855,9 @@ func (lb ELB) findExisting() (*elb.LoadBalancerDescription, error) {
 		},
 		PageSize: aws.Int64(1),
 	})
-
-	if err != nil {
+	if awserr, ok := err.(awserr.Error); ok && awserr.Code() == elb.ErrCodeAccessPointNotFoundException {
+		return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, ELBType, lb.Name)
+	} else if err != nil {
 		return nil, microerror.MaskAny(err)
 	}
 
@@ -232,6 +871,6 @@ func (lb ELB) findExisting() (*elb.LoadBalancerDescription, error) {
 }
 
 func (lb *ELB) setDNSFields(desc elb.LoadBalancerDescription) {
-	lb.dnsName = *desc.DNSName
-	lb.hostedZoneID = *desc.CanonicalHostedZoneNameID
+	lb.dnsName = aws.StringValue(desc.DNSName)
+	lb.hostedZoneID = aws.StringValue(desc.CanonicalHostedZoneNameID)
 }
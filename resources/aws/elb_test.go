@@ -0,0 +1,993 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestELBCreateOrFailScheme(t *testing.T) {
+	tests := []struct {
+		desc         string
+		scheme       string
+		res          *string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "default scheme",
+			res:  aws.String("internet-facing"),
+		},
+		{
+			desc:   "internet-facing scheme",
+			scheme: ELBSchemeInternetFacing,
+			res:    aws.String("internet-facing"),
+		},
+		{
+			desc:   "internal scheme",
+			scheme: ELBSchemeInternal,
+			res:    aws.String("internal"),
+		},
+		{
+			desc:         "invalid scheme",
+			scheme:       "private",
+			errorMatcher: IsInvalidELBScheme,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+				{
+					CanonicalHostedZoneNameID: aws.String("Z1"),
+					DNSName:                   aws.String("foo-api.elb.amazonaws.com"),
+				},
+			}
+			return nil
+		})
+
+		lb := &ELB{
+			Name:            "foo-api",
+			Scheme:          tc.scheme,
+			SecurityGroupID: "sg-masters",
+			SubnetID:        "subnet-public",
+			PortsToOpen: PortPairs{
+				{PortELB: 443, PortInstance: 443},
+			},
+			Client: clients.ELB,
+		}
+
+		err := lb.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Nil(t, fake.operations(), fmt.Sprintf("[%s] No ELB must be created", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		params := fake.paramsOf("CreateLoadBalancer")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single load balancer", tc.desc))
+		assert.Equal(t, tc.res, params[0].(*elb.CreateLoadBalancerInput).Scheme, fmt.Sprintf("[%s] The input values didn't produce the expected scheme", tc.desc))
+		assert.Equal(t, "foo-api.elb.amazonaws.com", lb.DNSName(), fmt.Sprintf("[%s] Unexpected DNS name", tc.desc))
+	}
+}
+
+func TestHealthCheckTarget(t *testing.T) {
+	listeners := PortPairs{
+		{PortELB: 443, PortInstance: 30011},
+		{PortELB: 10254, PortInstance: 10254},
+		{PortELB: 6443, PortInstance: 6443},
+	}
+
+	tests := []struct {
+		desc         string
+		healthCheck  HealthCheck
+		res          string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "defaults to TCP on the instance port of the primary listener",
+			res:  "TCP:30011",
+		},
+		{
+			desc:        "explicit TCP port",
+			healthCheck: HealthCheck{Protocol: "TCP", Port: 10254},
+			res:         "TCP:10254",
+		},
+		{
+			desc:        "SSL",
+			healthCheck: HealthCheck{Protocol: "SSL"},
+			res:         "SSL:30011",
+		},
+		{
+			desc:        "HTTP with path",
+			healthCheck: HealthCheck{Protocol: "HTTP", Port: 10254, Path: "/healthz"},
+			res:         "HTTP:10254/healthz",
+		},
+		{
+			desc:        "HTTPS with path",
+			healthCheck: HealthCheck{Protocol: "HTTPS", Port: 6443, Path: "/healthz"},
+			res:         "HTTPS:6443/healthz",
+		},
+		{
+			desc:        "HTTPS readiness on the default port",
+			healthCheck: HealthCheck{Protocol: "HTTPS", Path: "/readyz"},
+			res:         "HTTPS:30011/readyz",
+		},
+		{
+			desc:         "HTTP without path",
+			healthCheck:  HealthCheck{Protocol: "HTTP"},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "HTTPS with relative path",
+			healthCheck:  HealthCheck{Protocol: "HTTPS", Path: "healthz"},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "TCP with path",
+			healthCheck:  HealthCheck{Path: "/healthz"},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "unknown protocol",
+			healthCheck:  HealthCheck{Protocol: "UDP"},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "port of no listener",
+			healthCheck:  HealthCheck{Protocol: "HTTPS", Port: 8443, Path: "/healthz"},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "ELB port instead of the instance port",
+			healthCheck:  HealthCheck{Port: 443},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+	}
+
+	for _, tc := range tests {
+		res, err := tc.healthCheck.target(listeners)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected target", tc.desc))
+	}
+}
+
+func TestELBCreateOrFailInvalidHealthCheck(t *testing.T) {
+	clients, fake := newFakeClients()
+
+	lb := &ELB{
+		Name: "foo-ingress",
+		PortsToOpen: PortPairs{
+			{PortELB: 443, PortInstance: 30011},
+		},
+		HealthCheck: HealthCheck{Protocol: "HTTPS"},
+		Client:      clients.ELB,
+	}
+
+	err := lb.CreateOrFail()
+	assert.True(t, IsInvalidHealthCheck(err), fmt.Sprintf("Unexpected error: %v", err))
+	assert.Empty(t, fake.operations(), "No ELB must be created with an invalid health check")
+}
+
+func TestFindClusterELBs(t *testing.T) {
+	// describeLoadBalancers answers with the given ELB names.
+	describeLoadBalancers := func(names ...string) fakeResponse {
+		return func(params, output interface{}) error {
+			for _, name := range names {
+				output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = append(output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions, &elb.LoadBalancerDescription{
+					LoadBalancerName: aws.String(name),
+				})
+			}
+			return nil
+		}
+	}
+	// describeTags answers with the tags of the requested ELBs.
+	describeTags := func(tags map[string]map[string]string) fakeResponse {
+		return func(params, output interface{}) error {
+			for _, name := range params.(*elb.DescribeTagsInput).LoadBalancerNames {
+				desc := &elb.TagDescription{LoadBalancerName: name}
+				for key, value := range tags[*name] {
+					desc.Tags = append(desc.Tags, &elb.Tag{Key: aws.String(key), Value: aws.String(value)})
+				}
+				output.(*elb.DescribeTagsOutput).TagDescriptions = append(output.(*elb.DescribeTagsOutput).TagDescriptions, desc)
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		desc       string
+		names      []string
+		tags       map[string]map[string]string
+		operatorID string
+		res        []string
+	}{
+		{
+			desc:  "ELBs with unexpected names are found by tag",
+			names: []string{"foo-api", "foo-legacy-ingress", "bar-api", "untagged"},
+			tags: map[string]map[string]string{
+				"foo-api":            {"Cluster": "foo"},
+				"foo-legacy-ingress": {"Cluster": "foo"},
+				"bar-api":            {"Cluster": "bar"},
+			},
+			res: []string{"foo-api", "foo-legacy-ingress"},
+		},
+		{
+			desc:  "ELBs of other operators are ignored",
+			names: []string{"foo-api", "foo-etcd"},
+			tags: map[string]map[string]string{
+				"foo-api":  {"Cluster": "foo", "OperatorID": "blue"},
+				"foo-etcd": {"Cluster": "foo", "OperatorID": "green"},
+			},
+			operatorID: "blue",
+			res:        []string{"foo-api"},
+		},
+		{
+			desc:  "no ELBs",
+			names: nil,
+			res:   nil,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", describeLoadBalancers(tc.names...))
+		fake.on("DescribeTags", describeTags(tc.tags))
+
+		lbs, err := FindClusterELBs(clients.ELB, "foo", tc.operatorID)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		var names []string
+		for _, lb := range lbs {
+			names = append(names, lb.Name)
+
+			err := lb.Delete()
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error deleting ELB '%s'", tc.desc, lb.Name))
+		}
+		assert.Equal(t, tc.res, names, fmt.Sprintf("[%s] The input values didn't produce the expected ELBs", tc.desc))
+
+		var deleted []string
+		for _, params := range fake.paramsOf("DeleteLoadBalancer") {
+			deleted = append(deleted, *params.(*elb.DeleteLoadBalancerInput).LoadBalancerName)
+		}
+		assert.Equal(t, tc.res, deleted, fmt.Sprintf("[%s] The found ELBs weren't deleted", tc.desc))
+	}
+}
+
+func TestFindClusterELBsBatchesDescribeTags(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+		for i := 0; i < 45; i++ {
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = append(output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions, &elb.LoadBalancerDescription{
+				LoadBalancerName: aws.String(fmt.Sprintf("elb-%d", i)),
+			})
+		}
+		return nil
+	})
+
+	_, err := FindClusterELBs(clients.ELB, "foo", "")
+	assert.Nil(t, err, "Unexpected error")
+
+	var batchSizes []int
+	for _, params := range fake.paramsOf("DescribeTags") {
+		batchSizes = append(batchSizes, len(params.(*elb.DescribeTagsInput).LoadBalancerNames))
+	}
+	assert.Equal(t, []int{20, 20, 5}, batchSizes, "DescribeTags must be requested for at most 20 ELBs at once")
+}
+
+func TestFindClusterELBsEmptyClusterName(t *testing.T) {
+	clients, fake := newFakeClients()
+
+	_, err := FindClusterELBs(clients.ELB, "", "")
+	assert.True(t, IsAttributeEmpty(err), "Expected an empty cluster name to be rejected")
+	assert.Nil(t, fake.operations(), "No ELB must be looked up without a cluster name")
+}
+
+func TestELBCreateOrFailTags(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+		output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+			{
+				CanonicalHostedZoneNameID: aws.String("Z1"),
+				DNSName:                   aws.String("foo-api.elb.amazonaws.com"),
+			},
+		}
+		return nil
+	})
+
+	lb := &ELB{
+		Name:            "foo-api",
+		SecurityGroupID: "sg-masters",
+		SubnetID:        "subnet-public",
+		PortsToOpen: PortPairs{
+			{PortELB: 443, PortInstance: 443},
+		},
+		Tags: map[string]string{
+			"CostCenter": "team-a",
+			"Name":       "overridden",
+		},
+		ClusterName: "foo",
+		OperatorID:  "blue",
+		Client:      clients.ELB,
+	}
+
+	err := lb.CreateOrFail()
+	assert.Nil(t, err, "Unexpected error")
+
+	assert.Equal(t, []string{"CreateLoadBalancer", "AddTags"}, fake.operations()[:2], "The ELB must be tagged right after being created")
+	params := fake.paramsOf("AddTags")
+	assert.Len(t, params, 1, "Expected a single AddTags call")
+	assert.Equal(t, []*string{aws.String("foo-api")}, params[0].(*elb.AddTagsInput).LoadBalancerNames, "The wrong ELB was tagged")
+	assert.Equal(t, []*elb.Tag{
+		{Key: aws.String("Cluster"), Value: aws.String("foo")},
+		{Key: aws.String("CostCenter"), Value: aws.String("team-a")},
+		{Key: aws.String("Name"), Value: aws.String("foo-api")},
+		{Key: aws.String("OperatorID"), Value: aws.String("blue")},
+	}, params[0].(*elb.AddTagsInput).Tags, "The ELB wasn't tagged with its name and cluster")
+}
+
+func TestELBCreateOrFailSecurityGroup(t *testing.T) {
+	tests := []struct {
+		desc            string
+		securityGroupID string
+		res             []*string
+	}{
+		{
+			desc:            "configured security group",
+			securityGroupID: "sg-ingress",
+			res:             []*string{aws.String("sg-ingress")},
+		},
+		{
+			desc:            "default security group of the VPC",
+			securityGroupID: "",
+			res:             nil,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+				{
+					CanonicalHostedZoneNameID: aws.String("Z1"),
+					DNSName:                   aws.String("foo-ingress.elb.amazonaws.com"),
+				},
+			}
+			return nil
+		})
+
+		lb := &ELB{
+			Name:            "foo-ingress",
+			SecurityGroupID: tc.securityGroupID,
+			SubnetID:        "subnet-public",
+			PortsToOpen: PortPairs{
+				{PortELB: 443, PortInstance: 30011},
+			},
+			Client: clients.ELB,
+		}
+
+		err := lb.CreateOrFail()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		params := fake.paramsOf("CreateLoadBalancer")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single CreateLoadBalancer call", tc.desc))
+		assert.Equal(t, tc.res, params[0].(*elb.CreateLoadBalancerInput).SecurityGroups, fmt.Sprintf("[%s] The input values didn't produce the expected security groups", tc.desc))
+	}
+}
+
+func TestELBCreateOrFailListeners(t *testing.T) {
+	certificateARN := "arn:aws:acm:eu-central-1:123456789012:certificate/foo"
+
+	tests := []struct {
+		desc         string
+		portsToOpen  PortPairs
+		listeners    []ELBListener
+		res          []*elb.Listener
+		resTarget    string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "ports to open are forwarded over TCP",
+			portsToOpen: PortPairs{
+				{PortELB: 443, PortInstance: 443},
+			},
+			res: []*elb.Listener{
+				{Protocol: aws.String("TCP"), LoadBalancerPort: aws.Int64(443), InstancePort: aws.Int64(443)},
+			},
+			resTarget: "TCP:443",
+		},
+		{
+			desc: "HTTPS and TCP listeners",
+			portsToOpen: PortPairs{
+				{PortELB: 80, PortInstance: 80},
+			},
+			listeners: []ELBListener{
+				{Protocol: "HTTPS", LoadBalancerPort: 443, InstancePort: 6443, SSLCertificateID: certificateARN},
+				{Protocol: "TCP", LoadBalancerPort: 2379, InstancePort: 2379},
+			},
+			res: []*elb.Listener{
+				{Protocol: aws.String("HTTPS"), LoadBalancerPort: aws.Int64(443), InstancePort: aws.Int64(6443), SSLCertificateId: aws.String(certificateARN)},
+				{Protocol: aws.String("TCP"), LoadBalancerPort: aws.Int64(2379), InstancePort: aws.Int64(2379)},
+			},
+			resTarget: "TCP:6443",
+		},
+		{
+			desc: "HTTPS listener without certificate",
+			listeners: []ELBListener{
+				{Protocol: "HTTPS", LoadBalancerPort: 443, InstancePort: 6443},
+			},
+			errorMatcher: IsInvalidListener,
+		},
+		{
+			desc: "TCP listener with certificate",
+			listeners: []ELBListener{
+				{Protocol: "TCP", LoadBalancerPort: 443, InstancePort: 6443, SSLCertificateID: certificateARN},
+			},
+			errorMatcher: IsInvalidListener,
+		},
+		{
+			desc: "unknown protocol",
+			listeners: []ELBListener{
+				{Protocol: "UDP", LoadBalancerPort: 53, InstancePort: 53},
+			},
+			errorMatcher: IsInvalidListener,
+		},
+		{
+			desc:         "no listeners",
+			errorMatcher: IsAttributeEmpty,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+				{
+					CanonicalHostedZoneNameID: aws.String("Z1"),
+					DNSName:                   aws.String("foo-api.elb.amazonaws.com"),
+				},
+			}
+			return nil
+		})
+
+		lb := &ELB{
+			Name:            "foo-api",
+			SecurityGroupID: "sg-masters",
+			SubnetID:        "subnet-public",
+			PortsToOpen:     tc.portsToOpen,
+			Listeners:       tc.listeners,
+			Client:          clients.ELB,
+		}
+
+		err := lb.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Nil(t, fake.operations(), fmt.Sprintf("[%s] No ELB must be created", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		params := fake.paramsOf("CreateLoadBalancer")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single CreateLoadBalancer call", tc.desc))
+		assert.Equal(t, tc.res, params[0].(*elb.CreateLoadBalancerInput).Listeners, fmt.Sprintf("[%s] The input values didn't produce the expected listeners", tc.desc))
+
+		healthChecks := fake.paramsOf("ConfigureHealthCheck")
+		assert.Len(t, healthChecks, 1, fmt.Sprintf("[%s] Expected a single ConfigureHealthCheck call", tc.desc))
+		assert.Equal(t, tc.resTarget, *healthChecks[0].(*elb.ConfigureHealthCheckInput).HealthCheck.Target, fmt.Sprintf("[%s] The health check doesn't probe the primary listener", tc.desc))
+	}
+}
+
+func TestHealthCheckTimings(t *testing.T) {
+	listeners := PortPairs{{PortELB: 443, PortInstance: 443}}
+
+	tests := []struct {
+		desc         string
+		healthCheck  HealthCheck
+		res          *elb.HealthCheck
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:        "defaults",
+			healthCheck: HealthCheck{},
+			res: &elb.HealthCheck{
+				HealthyThreshold:   aws.Int64(10),
+				Interval:           aws.Int64(5),
+				Target:             aws.String("TCP:443"),
+				Timeout:            aws.Int64(3),
+				UnhealthyThreshold: aws.Int64(2),
+			},
+		},
+		{
+			desc: "configured timings",
+			healthCheck: HealthCheck{
+				Protocol:           "HTTPS",
+				Path:               "/healthz",
+				Interval:           30,
+				Timeout:            10,
+				HealthyThreshold:   3,
+				UnhealthyThreshold: 5,
+			},
+			res: &elb.HealthCheck{
+				HealthyThreshold:   aws.Int64(3),
+				Interval:           aws.Int64(30),
+				Target:             aws.String("HTTPS:443/healthz"),
+				Timeout:            aws.Int64(10),
+				UnhealthyThreshold: aws.Int64(5),
+			},
+		},
+		{
+			desc:         "interval too short",
+			healthCheck:  HealthCheck{Interval: 4, Timeout: 2},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "timeout not lower than the interval",
+			healthCheck:  HealthCheck{Interval: 10, Timeout: 10},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+		{
+			desc:         "threshold too high",
+			healthCheck:  HealthCheck{UnhealthyThreshold: 11},
+			errorMatcher: IsInvalidHealthCheck,
+		},
+	}
+
+	for _, tc := range tests {
+		healthCheck, err := tc.healthCheck.healthCheck(listeners)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, healthCheck, fmt.Sprintf("[%s] The input values didn't produce the expected health check", tc.desc))
+	}
+}
+
+func TestELBCreateIfNotExists(t *testing.T) {
+	existing := func(params, output interface{}) error {
+		output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+			{
+				CanonicalHostedZoneNameID: aws.String("Z1"),
+				DNSName:                   aws.String("foo-api.elb.amazonaws.com"),
+				ListenerDescriptions: []*elb.ListenerDescription{
+					{
+						Listener: &elb.Listener{
+							InstancePort:     aws.Int64(443),
+							InstanceProtocol: aws.String("TCP"),
+							LoadBalancerPort: aws.Int64(443),
+							Protocol:         aws.String("TCP"),
+						},
+					},
+				},
+				LoadBalancerName: aws.String("foo-api"),
+			},
+		}
+		return nil
+	}
+	missing := func(params, output interface{}) error {
+		return awserr.New(elb.ErrCodeAccessPointNotFoundException, "There is no ACTIVE Load Balancer named 'foo-api'", nil)
+	}
+
+	tests := []struct {
+		desc          string
+		responses     []fakeResponse
+		resCreated    bool
+		resOperations []string
+	}{
+		{
+			desc:          "existing ELB is reused and tagged",
+			responses:     []fakeResponse{existing},
+			resCreated:    false,
+			resOperations: []string{"DescribeLoadBalancers", "AddTags"},
+		},
+		{
+			desc:          "missing ELB is created",
+			responses:     []fakeResponse{missing, existing},
+			resCreated:    true,
+			resOperations: []string{"DescribeLoadBalancers", "CreateLoadBalancer", "AddTags", "ConfigureHealthCheck", "DescribeLoadBalancers"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", tc.responses...)
+
+		lb := &ELB{
+			Name:            "foo-api",
+			SecurityGroupID: "sg-masters",
+			SubnetID:        "subnet-public",
+			PortsToOpen: PortPairs{
+				{PortELB: 443, PortInstance: 443},
+			},
+			HealthCheck: HealthCheck{Interval: 30, Timeout: 10},
+			Client:      clients.ELB,
+		}
+
+		created, err := lb.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Unexpected created flag", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		assert.Equal(t, "foo-api.elb.amazonaws.com", lb.DNSName(), fmt.Sprintf("[%s] Unexpected DNS name", tc.desc))
+		assert.Equal(t, "Z1", lb.HostedZoneID(), fmt.Sprintf("[%s] Unexpected hosted zone ID", tc.desc))
+	}
+}
+
+func TestELBReconcileListeners(t *testing.T) {
+	listener := func(protocol string, lbPort, instancePort int64) *elb.ListenerDescription {
+		return &elb.ListenerDescription{
+			Listener: &elb.Listener{
+				InstancePort:     aws.Int64(instancePort),
+				InstanceProtocol: aws.String(protocol),
+				LoadBalancerPort: aws.Int64(lbPort),
+				Protocol:         aws.String(protocol),
+			},
+		}
+	}
+
+	tests := []struct {
+		desc         string
+		listeners    []*elb.ListenerDescription
+		resDeleted   []int64
+		resCreated   []int64
+		resCreations int
+	}{
+		{
+			desc:      "listeners in sync",
+			listeners: []*elb.ListenerDescription{listener("TCP", 443, 443), listener("TCP", 2379, 2379)},
+		},
+		{
+			desc:       "missing listener is recreated",
+			listeners:  []*elb.ListenerDescription{listener("TCP", 2379, 2379)},
+			resCreated: []int64{443},
+		},
+		{
+			desc:       "extra listener is removed",
+			listeners:  []*elb.ListenerDescription{listener("TCP", 443, 443), listener("TCP", 80, 8080), listener("TCP", 2379, 2379)},
+			resDeleted: []int64{80},
+		},
+		{
+			desc:       "changed listener is replaced",
+			listeners:  []*elb.ListenerDescription{listener("HTTP", 443, 8443), listener("TCP", 2379, 2379)},
+			resDeleted: []int64{443},
+			resCreated: []int64{443},
+		},
+		{
+			desc:       "all listeners removed",
+			listeners:  nil,
+			resCreated: []int64{443, 2379},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+				{
+					ListenerDescriptions: tc.listeners,
+					LoadBalancerName:     aws.String("foo-api"),
+				},
+			}
+			return nil
+		})
+
+		lb := ELB{
+			Name: "foo-api",
+			PortsToOpen: PortPairs{
+				{PortELB: 443, PortInstance: 443},
+				{PortELB: 2379, PortInstance: 2379},
+			},
+			Client: clients.ELB,
+		}
+
+		err := lb.ReconcileListeners()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		var deleted []int64
+		for _, params := range fake.paramsOf("DeleteLoadBalancerListeners") {
+			deleted = append(deleted, aws.Int64ValueSlice(params.(*elb.DeleteLoadBalancerListenersInput).LoadBalancerPorts)...)
+		}
+		assert.Equal(t, tc.resDeleted, deleted, fmt.Sprintf("[%s] Wrong deleted listeners", tc.desc))
+
+		var created []int64
+		for _, params := range fake.paramsOf("CreateLoadBalancerListeners") {
+			for _, listener := range params.(*elb.CreateLoadBalancerListenersInput).Listeners {
+				assert.Equal(t, "TCP", aws.StringValue(listener.Protocol), fmt.Sprintf("[%s] Wrong protocol", tc.desc))
+				assert.Equal(t, aws.Int64Value(listener.LoadBalancerPort), aws.Int64Value(listener.InstancePort), fmt.Sprintf("[%s] Wrong instance port", tc.desc))
+				created = append(created, aws.Int64Value(listener.LoadBalancerPort))
+			}
+		}
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong created listeners", tc.desc))
+
+		if len(tc.resDeleted) > 0 && len(tc.resCreated) > 0 {
+			operations := fake.operations()
+			assert.Equal(t, "DeleteLoadBalancerListeners", operations[1], fmt.Sprintf("[%s] Listeners must be deleted before being recreated", tc.desc))
+		}
+	}
+}
+
+func TestELBDelete(t *testing.T) {
+	clients, fake := newFakeClients()
+
+	lb := ELB{
+		Name:        "foo-api",
+		HealthCheck: HealthCheck{Protocol: "HTTPS", Path: "/healthz"},
+		Client:      clients.ELB,
+	}
+
+	err := lb.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"DeleteLoadBalancer"}, fake.operations(), "Deleting an ELB must only delete it")
+}
+
+func TestELBInstancesNotInService(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeInstanceHealth", func(params, output interface{}) error {
+		output.(*elb.DescribeInstanceHealthOutput).InstanceStates = []*elb.InstanceState{
+			{InstanceId: aws.String("i-1"), State: aws.String("InService")},
+			{InstanceId: aws.String("i-2"), State: aws.String("OutOfService")},
+			{InstanceId: aws.String("i-3"), State: aws.String("Unknown")},
+		}
+		return nil
+	})
+
+	lb := ELB{
+		Name:   "foo-api",
+		Client: clients.ELB,
+	}
+
+	notInService, err := lb.InstancesNotInService([]string{"i-1", "i-2", "i-3", "i-4"})
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"i-2", "i-3", "i-4"}, notInService, "Only InService instances are ready")
+}
+
+func TestELBCreateOrFailAttributes(t *testing.T) {
+	tests := []struct {
+		desc               string
+		az                 string
+		azs                []string
+		subnetID           string
+		subnets            []string
+		crossZone          bool
+		connectionDraining int
+		resAZs             []*string
+		resSubnets         []*string
+		resAttributes      *elb.LoadBalancerAttributes
+		errorMatcher       func(error) bool
+	}{
+		{
+			desc:       "ELB in a subnet without attributes",
+			az:         "eu-central-1a",
+			subnetID:   "subnet-public",
+			resSubnets: []*string{aws.String("subnet-public")},
+		},
+		{
+			desc:       "subnets win over the subnet and the AZs",
+			az:         "eu-central-1a",
+			azs:        []string{"eu-central-1a", "eu-central-1b"},
+			subnetID:   "subnet-public",
+			subnets:    []string{"subnet-public-a", "subnet-public-b"},
+			resSubnets: []*string{aws.String("subnet-public-a"), aws.String("subnet-public-b")},
+		},
+		{
+			desc:   "single AZ",
+			az:     "eu-central-1a",
+			resAZs: []*string{aws.String("eu-central-1a")},
+		},
+		{
+			desc:      "AZs win over the AZ, with cross-zone load balancing",
+			az:        "eu-central-1a",
+			azs:       []string{"eu-central-1a", "eu-central-1b"},
+			crossZone: true,
+			resAZs:    []*string{aws.String("eu-central-1a"), aws.String("eu-central-1b")},
+			resAttributes: &elb.LoadBalancerAttributes{
+				ConnectionDraining:     &elb.ConnectionDraining{Enabled: aws.Bool(false)},
+				CrossZoneLoadBalancing: &elb.CrossZoneLoadBalancing{Enabled: aws.Bool(true)},
+			},
+		},
+		{
+			desc:               "connection draining",
+			subnetID:           "subnet-public",
+			connectionDraining: 300,
+			resSubnets:         []*string{aws.String("subnet-public")},
+			resAttributes: &elb.LoadBalancerAttributes{
+				ConnectionDraining:     &elb.ConnectionDraining{Enabled: aws.Bool(true), Timeout: aws.Int64(300)},
+				CrossZoneLoadBalancing: &elb.CrossZoneLoadBalancing{Enabled: aws.Bool(false)},
+			},
+		},
+		{
+			desc:               "connection draining timeout too long",
+			subnetID:           "subnet-public",
+			connectionDraining: 3601,
+			errorMatcher:       IsInvalidConnectionDraining,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+				{
+					CanonicalHostedZoneNameID: aws.String("Z1"),
+					DNSName:                   aws.String("foo-api.elb.amazonaws.com"),
+				},
+			}
+			return nil
+		})
+
+		lb := &ELB{
+			Name:               "foo-api",
+			AZ:                 tc.az,
+			AZs:                tc.azs,
+			CrossZone:          tc.crossZone,
+			ConnectionDraining: tc.connectionDraining,
+			SubnetID:           tc.subnetID,
+			Subnets:            tc.subnets,
+			PortsToOpen: PortPairs{
+				{PortELB: 443, PortInstance: 443},
+			},
+			Client: clients.ELB,
+		}
+
+		err := lb.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Nil(t, fake.operations(), fmt.Sprintf("[%s] No ELB must be created", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		params := fake.paramsOf("CreateLoadBalancer")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single CreateLoadBalancer call", tc.desc))
+		assert.Equal(t, tc.resAZs, params[0].(*elb.CreateLoadBalancerInput).AvailabilityZones, fmt.Sprintf("[%s] Wrong availability zones", tc.desc))
+		assert.Equal(t, tc.resSubnets, params[0].(*elb.CreateLoadBalancerInput).Subnets, fmt.Sprintf("[%s] Wrong subnets", tc.desc))
+
+		attributes := fake.paramsOf("ModifyLoadBalancerAttributes")
+		if tc.resAttributes == nil {
+			assert.Nil(t, attributes, fmt.Sprintf("[%s] The attributes must not be modified", tc.desc))
+			continue
+		}
+		assert.Len(t, attributes, 1, fmt.Sprintf("[%s] Expected a single ModifyLoadBalancerAttributes call", tc.desc))
+		assert.Equal(t, tc.resAttributes, attributes[0].(*elb.ModifyLoadBalancerAttributesInput).LoadBalancerAttributes, fmt.Sprintf("[%s] Wrong attributes", tc.desc))
+	}
+}
+
+func TestELBGet(t *testing.T) {
+	tests := []struct {
+		desc            string
+		response        fakeResponse
+		resDNSName      string
+		resHostedZoneID string
+		errorMatcher    func(error) bool
+	}{
+		{
+			desc: "existing ELB",
+			response: func(params, output interface{}) error {
+				output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+					{
+						CanonicalHostedZoneNameID: aws.String("Z215JYRZR1TBD5"),
+						DNSName:                   aws.String("foo-api-123.eu-central-1.elb.amazonaws.com"),
+						LoadBalancerName:          aws.String("foo-api"),
+					},
+				}
+				return nil
+			},
+			resDNSName:      "foo-api-123.eu-central-1.elb.amazonaws.com",
+			resHostedZoneID: "Z215JYRZR1TBD5",
+		},
+		{
+			desc: "missing ELB",
+			response: func(params, output interface{}) error {
+				return awserr.New(elb.ErrCodeAccessPointNotFoundException, "There is no ACTIVE Load Balancer named 'foo-api'", nil)
+			},
+			errorMatcher: IsNotFound,
+		},
+		{
+			desc: "empty response",
+			response: func(params, output interface{}) error {
+				return nil
+			},
+			errorMatcher: IsNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeLoadBalancers", tc.response)
+
+		lb := &ELB{
+			Name:   "foo-api",
+			Client: clients.ELB,
+		}
+
+		err := lb.Get()
+		params := fake.paramsOf("DescribeLoadBalancers")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single DescribeLoadBalancers call", tc.desc))
+		assert.Equal(t, []*string{aws.String("foo-api")}, params[0].(*elb.DescribeLoadBalancersInput).LoadBalancerNames, fmt.Sprintf("[%s] The wrong ELB was described", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resDNSName, lb.DNSName(), fmt.Sprintf("[%s] Unexpected DNS name", tc.desc))
+		assert.Equal(t, tc.resHostedZoneID, lb.HostedZoneID(), fmt.Sprintf("[%s] Unexpected hosted zone ID", tc.desc))
+	}
+}
+
+func TestELBDeregisterInstances(t *testing.T) {
+	tests := []struct {
+		desc          string
+		registered    []string
+		instanceIDs   []string
+		resInstances  []*elb.Instance
+		resOperations []string
+	}{
+		{
+			desc:        "registered instances are deregistered",
+			registered:  []string{"i-1", "i-2", "i-3"},
+			instanceIDs: []string{"i-1", "i-3"},
+			resInstances: []*elb.Instance{
+				{InstanceId: aws.String("i-1")},
+				{InstanceId: aws.String("i-3")},
+			},
+			resOperations: []string{"DescribeLoadBalancers", "DeregisterInstancesFromLoadBalancer"},
+		},
+		{
+			desc:        "unregistered instances are skipped",
+			registered:  []string{"i-1"},
+			instanceIDs: []string{"i-1", "i-4"},
+			resInstances: []*elb.Instance{
+				{InstanceId: aws.String("i-1")},
+			},
+			resOperations: []string{"DescribeLoadBalancers", "DeregisterInstancesFromLoadBalancer"},
+		},
+		{
+			desc:          "nothing to deregister",
+			registered:    []string{"i-1"},
+			instanceIDs:   []string{"i-4"},
+			resOperations: []string{"DescribeLoadBalancers"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		registered := tc.registered
+		fake.on("DescribeLoadBalancers", func(params, output interface{}) error {
+			desc := &elb.LoadBalancerDescription{
+				LoadBalancerName: aws.String("foo-api"),
+			}
+			for _, id := range registered {
+				desc.Instances = append(desc.Instances, &elb.Instance{InstanceId: aws.String(id)})
+			}
+			output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{desc}
+			return nil
+		})
+
+		lb := ELB{
+			Name:   "foo-api",
+			Client: clients.ELB,
+		}
+
+		err := lb.DeregisterInstances(tc.instanceIDs)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		params := fake.paramsOf("DeregisterInstancesFromLoadBalancer")
+		if tc.resInstances == nil {
+			assert.Nil(t, params, fmt.Sprintf("[%s] No instances must be deregistered", tc.desc))
+			continue
+		}
+		input := params[0].(*elb.DeregisterInstancesFromLoadBalancerInput)
+		assert.Equal(t, "foo-api", aws.StringValue(input.LoadBalancerName), fmt.Sprintf("[%s] Wrong ELB", tc.desc))
+		assert.Equal(t, tc.resInstances, input.Instances, fmt.Sprintf("[%s] Wrong deregistered instances", tc.desc))
+	}
+}
@@ -1,6 +1,12 @@
 package aws
 
 import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/juju/errgo"
 )
 
@@ -14,15 +20,26 @@ const (
 type resourceType string
 
 const (
-	ELBType           resourceType = "elb"
-	HostedZoneType    resourceType = "hosted zone"
-	GatewayType       resourceType = "gateway"
-	InstanceType      resourceType = "instance"
-	RouteTableType    resourceType = "route table"
-	RouteType         resourceType = "route"
-	SecurityGroupType resourceType = "security group"
-	SubnetType        resourceType = "subnet"
-	VPCType           resourceType = "vpc"
+	ELBType                 resourceType = "elb"
+	HostedZoneType          resourceType = "hosted zone"
+	ImageType               resourceType = "image"
+	InstanceProfileType     resourceType = "instance profile"
+	EgressOnlyGatewayType   resourceType = "egress-only gateway"
+	GatewayType             resourceType = "gateway"
+	NATGatewayType          resourceType = "nat gateway"
+	HostType                resourceType = "dedicated host"
+	InstanceType            resourceType = "instance"
+	KMSKeyType              resourceType = "kms key"
+	NetworkInterfaceType    resourceType = "network interface"
+	RecordSetType           resourceType = "record set"
+	RouteTableType          resourceType = "route table"
+	RouteType               resourceType = "route"
+	SecurityGroupType       resourceType = "security group"
+	SpotInstanceRequestType resourceType = "spot instance request"
+	SubnetType              resourceType = "subnet"
+	VPCType                 resourceType = "vpc"
+	VPCEndpointType         resourceType = "vpc endpoint"
+	VPCPeeringType          resourceType = "vpc peering connection"
 )
 
 // NotFound errors.
@@ -34,6 +51,51 @@ func IsNotFound(err error) bool {
 	return errgo.Cause(err) == notFoundError
 }
 
+// Codes of AWS errors which the SDK has no constants for.
+const (
+	addressNotFoundErrorCode     = "InvalidAllocationID.NotFound"
+	dependencyViolationErrorCode = "DependencyViolation"
+	dhcpOptionsNotFoundErrorCode = "InvalidDhcpOptionID.NotFound"
+	kmsErrCodeAccessDenied       = "AccessDeniedException"
+	natGatewayNotFoundErrorCode  = "NatGatewayNotFound"
+	vpcNotFoundErrorCode         = "InvalidVpcID.NotFound"
+	vpcPeeringNotFoundErrorCode  = "InvalidVpcPeeringConnectionID.NotFound"
+)
+
+// notFoundErrorCodes are the codes of the errors AWS returns about resources
+// which don't exist.
+var notFoundErrorCodes = map[string]bool{
+	"InvalidGroup.NotFound":                 true,
+	"InvalidInstanceID.NotFound":            true,
+	"InvalidInternetGatewayID.NotFound":     true,
+	"InvalidKeyPair.NotFound":               true,
+	"InvalidRouteTableID.NotFound":          true,
+	"InvalidSubnetID.NotFound":              true,
+	"InvalidVpcEndpointId.NotFound":         true,
+	addressNotFoundErrorCode:                true,
+	dhcpOptionsNotFoundErrorCode:            true,
+	natGatewayNotFoundErrorCode:             true,
+	vpcNotFoundErrorCode:                    true,
+	vpcPeeringNotFoundErrorCode:             true,
+	elb.ErrCodeAccessPointNotFoundException: true,
+	iam.ErrCodeNoSuchEntityException:        true,
+	kms.ErrCodeNotFoundException:            true,
+	route53.ErrCodeNoSuchHostedZone:         true,
+	s3.ErrCodeNoSuchBucket:                  true,
+	s3.ErrCodeNoSuchKey:                     true,
+}
+
+// IsAlreadyDeleted asserts errors about deleting resources which don't exist,
+// either notFoundError or the not found errors of AWS.
+func IsAlreadyDeleted(err error) bool {
+	if IsNotFound(err) {
+		return true
+	}
+
+	awsErr, ok := errgo.Cause(err).(awserr.Error)
+	return ok && notFoundErrorCodes[awsErr.Code()]
+}
+
 // Delete errors.
 
 var resourceDeleteError = errgo.New("couldn't delete resource, it lacks the necessary data (ID)")
@@ -80,9 +142,163 @@ func IsKMSKeyAliasEmpty(err error) bool {
 	return errgo.Cause(err) == kmsKeyAliasEmptyError
 }
 
+var kmsKeyNotCreatedError = errgo.New("the KMS key has not been created or found yet")
+
+// IsKMSKeyNotCreated asserts kmsKeyNotCreatedError.
+func IsKMSKeyNotCreated(err error) bool {
+	return errgo.Cause(err) == kmsKeyNotCreatedError
+}
+
+var invalidKMSKeySpecError = errgo.New("invalid KMS key spec")
+
+// IsInvalidKMSKeySpec asserts invalidKMSKeySpecError.
+func IsInvalidKMSKeySpec(err error) bool {
+	return errgo.Cause(err) == invalidKMSKeySpecError
+}
+
+var bucketRegionMismatchError = errgo.New("bucket region mismatch")
+
+// IsBucketRegionMismatch asserts bucketRegionMismatchError.
+func IsBucketRegionMismatch(err error) bool {
+	return errgo.Cause(err) == bucketRegionMismatchError
+}
+
+var zoneNotDelegatedError = errgo.New("zone not delegated")
+
+// IsZoneNotDelegated asserts zoneNotDelegatedError.
+func IsZoneNotDelegated(err error) bool {
+	return errgo.Cause(err) == zoneNotDelegatedError
+}
+
+var architectureMismatchError = errgo.New("image architecture doesn't match instance type")
+
+// IsArchitectureMismatch asserts architectureMismatchError.
+func IsArchitectureMismatch(err error) bool {
+	return errgo.Cause(err) == architectureMismatchError
+}
+
+var invalidHealthCheckError = errgo.New("invalid health check")
+
+// IsInvalidHealthCheck asserts invalidHealthCheckError.
+func IsInvalidHealthCheck(err error) bool {
+	return errgo.Cause(err) == invalidHealthCheckError
+}
+
+var invalidListenerError = errgo.New("invalid listener")
+
+// IsInvalidListener asserts invalidListenerError.
+func IsInvalidListener(err error) bool {
+	return errgo.Cause(err) == invalidListenerError
+}
+
+var invalidConnectionDrainingError = errgo.New("invalid connection draining")
+
+// IsInvalidConnectionDraining asserts invalidConnectionDrainingError.
+func IsInvalidConnectionDraining(err error) bool {
+	return errgo.Cause(err) == invalidConnectionDrainingError
+}
+
+var unmanagedInstanceError = errgo.New("instance is not managed by the operator")
+
+// IsUnmanagedInstance asserts unmanagedInstanceError.
+func IsUnmanagedInstance(err error) bool {
+	return errgo.Cause(err) == unmanagedInstanceError
+}
+
+var invalidELBSchemeError = errgo.New("invalid ELB scheme")
+
+// IsInvalidELBScheme asserts invalidELBSchemeError.
+func IsInvalidELBScheme(err error) bool {
+	return errgo.Cause(err) == invalidELBSchemeError
+}
+
+var invalidSecurityGroupRuleError = errgo.New("invalid security group rule")
+
+// IsInvalidSecurityGroupRule asserts invalidSecurityGroupRuleError.
+func IsInvalidSecurityGroupRule(err error) bool {
+	return errgo.Cause(err) == invalidSecurityGroupRuleError
+}
+
+var invalidEBSOptimizedError = errgo.New("invalid EBS optimization")
+
+// IsInvalidEBSOptimized asserts invalidEBSOptimizedError.
+func IsInvalidEBSOptimized(err error) bool {
+	return errgo.Cause(err) == invalidEBSOptimizedError
+}
+
+var invalidBlockDeviceError = errgo.New("invalid block device")
+
+// IsInvalidBlockDevice asserts invalidBlockDeviceError.
+func IsInvalidBlockDevice(err error) bool {
+	return errgo.Cause(err) == invalidBlockDeviceError
+}
+
+var invalidSpotPriceError = errgo.New("invalid spot price")
+
+// IsInvalidSpotPrice asserts invalidSpotPriceError.
+func IsInvalidSpotPrice(err error) bool {
+	return errgo.Cause(err) == invalidSpotPriceError
+}
+
+var spotRequestFailedError = errgo.New("spot request failed")
+
+// IsSpotRequestFailed asserts spotRequestFailedError.
+func IsSpotRequestFailed(err error) bool {
+	return errgo.Cause(err) == spotRequestFailedError
+}
+
+var instanceProfileNotReadyError = errgo.New("instance profile not ready")
+
+// IsInstanceProfileNotReady asserts instanceProfileNotReadyError.
+func IsInstanceProfileNotReady(err error) bool {
+	return errgo.Cause(err) == instanceProfileNotReadyError
+}
+
+var invalidPrivateIPAddressError = errgo.New("invalid private IP address")
+
+// IsInvalidPrivateIPAddress asserts invalidPrivateIPAddressError.
+func IsInvalidPrivateIPAddress(err error) bool {
+	return errgo.Cause(err) == invalidPrivateIPAddressError
+}
+
+var invalidHostError = errgo.New("invalid dedicated host")
+
+// IsInvalidHost asserts invalidHostError.
+func IsInvalidHost(err error) bool {
+	return errgo.Cause(err) == invalidHostError
+}
+
 var attributeEmptyError = errgo.New("attribute cannot be empty")
 
 // IsPortsToOpenEmpty asserts portsToOpenEmptyError.
 func IsAttributeEmpty(err error) bool {
 	return errgo.Cause(err) == attributeEmptyError
 }
+
+var gatewayAttachedError = errgo.New("gateway attached")
+
+// IsGatewayAttached asserts gatewayAttachedError.
+func IsGatewayAttached(err error) bool {
+	return errgo.Cause(err) == gatewayAttachedError
+}
+
+var vpcHasDependenciesError = errgo.New("VPC has dependencies")
+
+// IsVPCHasDependencies asserts vpcHasDependenciesError.
+func IsVPCHasDependencies(err error) bool {
+	return errgo.Cause(err) == vpcHasDependenciesError
+}
+
+var flowLogsNotCreatedError = errgo.New("flow logs not created")
+
+// IsFlowLogsNotCreated asserts flowLogsNotCreatedError.
+func IsFlowLogsNotCreated(err error) bool {
+	return errgo.Cause(err) == flowLogsNotCreatedError
+}
+
+var existingVPCError = errgo.New("existing VPC")
+
+// IsExistingVPC asserts existingVPCError.
+func IsExistingVPC(err error) bool {
+	return errgo.Cause(err) == existingVPCError
+}
@@ -19,16 +19,36 @@ type Gateway struct {
 	AWSEntity
 }
 
+// findExisting returns the gateway named Name. A gateway created recently is
+// waited for, since it might not be visible yet.
 func (g Gateway) findExisting() (*ec2.InternetGateway, error) {
+	var gateway *ec2.InternetGateway
+	err := findCreated(g.creationKey(), func() error {
+		var err error
+		gateway, err = g.describe()
+		return err
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return gateway, nil
+}
+
+func (g Gateway) creationKey() string {
+	return creationKey(GatewayType, g.OperatorID, g.Name)
+}
+
+func (g Gateway) describe() (*ec2.InternetGateway, error) {
 	gateways, err := g.Clients.EC2.DescribeInternetGateways(&ec2.DescribeInternetGatewaysInput{
-		Filters: []*ec2.Filter{
+		Filters: append([]*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
 				Values: []*string{
 					aws.String(g.Name),
 				},
 			},
-		},
+		}, operatorFilters(g.OperatorID)...),
 	})
 	if err != nil {
 		return nil, microerror.MaskAny(err)
@@ -87,39 +107,52 @@ func (g *Gateway) CreateOrFail() error {
 		Resources: []*string{
 			aws.String(gatewayID),
 		},
-		Tags: []*ec2.Tag{
+		Tags: append([]*ec2.Tag{
 			{
 				Key:   aws.String(tagKeyName),
 				Value: aws.String(g.Name),
 			},
-		},
+		}, resourceTags(g.OperatorID, g.Tags)...),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
 
 	g.id = gatewayID
+	recentCreations.add(g.creationKey())
 
 	return nil
 }
 
+// Delete detaches the gateway from the VPCs it is attached to, and deletes it.
+// VpcID is not needed, so the gateway is deleted even when an earlier deletion
+// detached it already, or its VPC is gone.
 func (g *Gateway) Delete() error {
 	gateway, err := g.findExisting()
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
 
-	detachOperation := func() error {
-		if _, err := g.Clients.EC2.DetachInternetGateway(&ec2.DetachInternetGatewayInput{
-			InternetGatewayId: gateway.InternetGatewayId,
-			VpcId:             aws.String(g.VpcID),
-		}); err != nil {
+	for _, attachment := range gateway.Attachments {
+		vpcID := attachment.VpcId
+		detachOperation := func() error {
+			if _, err := g.Clients.EC2.DetachInternetGateway(&ec2.DetachInternetGatewayInput{
+				InternetGatewayId: gateway.InternetGatewayId,
+				VpcId:             vpcID,
+			}); err != nil {
+				return microerror.MaskAny(err)
+			}
+			return nil
+		}
+		detachNotify := NewNotify(g.Logger, "detaching gateway")
+		if err := backoff.RetryNotify(detachOperation, NewCustomExponentialBackoff(), detachNotify); err != nil {
 			return microerror.MaskAny(err)
 		}
-		return nil
 	}
-	detachNotify := NewNotify(g.Logger, "detaching gateway")
-	if err := backoff.RetryNotify(detachOperation, NewCustomExponentialBackoff(), detachNotify); err != nil {
-		return microerror.MaskAny(err)
+
+	if len(gateway.Attachments) > 0 {
+		if err := g.waitUntilDetached(gateway.InternetGatewayId); err != nil {
+			return microerror.MaskAny(err)
+		}
 	}
 
 	deleteOperation := func() error {
@@ -134,6 +167,38 @@ func (g *Gateway) Delete() error {
 	if err := backoff.RetryNotify(deleteOperation, NewCustomExponentialBackoff(), deleteNotify); err != nil {
 		return microerror.MaskAny(err)
 	}
+	recentCreations.remove(g.creationKey())
+
+	return nil
+}
+
+// waitUntilDetached waits until the gateway with the given ID is detached from
+// all VPCs, since it can't be deleted before.
+func (g Gateway) waitUntilDetached(gatewayID *string) error {
+	operation := func() error {
+		resp, err := g.Clients.EC2.DescribeInternetGateways(&ec2.DescribeInternetGatewaysInput{
+			InternetGatewayIds: []*string{
+				gatewayID,
+			},
+		})
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		for _, gateway := range resp.InternetGateways {
+			for _, attachment := range gateway.Attachments {
+				if aws.StringValue(attachment.State) != ec2.AttachmentStatusDetached {
+					return microerror.MaskAnyf(gatewayAttachedError, "attachment of gateway '%s' to VPC '%s' is %s", aws.StringValue(gatewayID), aws.StringValue(attachment.VpcId), aws.StringValue(attachment.State))
+				}
+			}
+		}
+
+		return nil
+	}
+	notify := NewNotify(g.Logger, "waiting for gateway to be detached")
+	if err := backoff.RetryNotify(operation, NewCustomExponentialBackoff(), notify); err != nil {
+		return microerror.MaskAny(err)
+	}
 
 	return nil
 }
@@ -0,0 +1,170 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatewayDelete(t *testing.T) {
+	tests := []struct {
+		desc        string
+		attachments []*ec2.InternetGatewayAttachment
+		// detaching are the attachments seen while waiting for the gateway to be
+		// detached, one lookup after the other.
+		detaching     [][]*ec2.InternetGatewayAttachment
+		resOperations []string
+	}{
+		{
+			desc: "attached gateway is detached",
+			attachments: []*ec2.InternetGatewayAttachment{
+				{VpcId: aws.String("vpc-123"), State: aws.String("available")},
+			},
+			resOperations: []string{"DescribeInternetGateways", "DetachInternetGateway", "DescribeInternetGateways", "DeleteInternetGateway"},
+		},
+		{
+			desc: "gateway is deleted once detached",
+			attachments: []*ec2.InternetGatewayAttachment{
+				{VpcId: aws.String("vpc-123"), State: aws.String("available")},
+			},
+			detaching: [][]*ec2.InternetGatewayAttachment{
+				{{VpcId: aws.String("vpc-123"), State: aws.String("detaching")}},
+				{{VpcId: aws.String("vpc-123"), State: aws.String("detached")}},
+			},
+			resOperations: []string{"DescribeInternetGateways", "DetachInternetGateway", "DescribeInternetGateways", "DescribeInternetGateways", "DeleteInternetGateway"},
+		},
+		{
+			desc:          "detached gateway",
+			attachments:   nil,
+			resOperations: []string{"DescribeInternetGateways", "DeleteInternetGateway"},
+		},
+	}
+
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		attachments := tc.attachments
+		detaching := tc.detaching
+		fake.on("DescribeInternetGateways", func(params, output interface{}) error {
+			gateway := &ec2.InternetGateway{
+				InternetGatewayId: aws.String("igw-123"),
+				Attachments:       attachments,
+			}
+			// Waiting for the gateway to be detached looks it up by ID.
+			if len(params.(*ec2.DescribeInternetGatewaysInput).InternetGatewayIds) > 0 {
+				gateway.Attachments = nil
+				if len(detaching) > 0 {
+					gateway.Attachments = detaching[0]
+					detaching = detaching[1:]
+				}
+			}
+			output.(*ec2.DescribeInternetGatewaysOutput).InternetGateways = []*ec2.InternetGateway{gateway}
+			return nil
+		})
+
+		gateway := &Gateway{
+			Name:      "foo",
+			Logger:    logger,
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := gateway.Delete()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("DetachInternetGateway") {
+			input := params.(*ec2.DetachInternetGatewayInput)
+			assert.Equal(t, "igw-123", aws.StringValue(input.InternetGatewayId), fmt.Sprintf("[%s] Wrong gateway detached", tc.desc))
+			assert.Equal(t, "vpc-123", aws.StringValue(input.VpcId), fmt.Sprintf("[%s] Detached from the wrong VPC", tc.desc))
+		}
+	}
+}
+
+func TestGatewayCreateIfNotExists(t *testing.T) {
+	tests := []struct {
+		desc          string
+		gateways      []*ec2.InternetGateway
+		describeError error
+		errorMatcher  func(error) bool
+		resCreated    bool
+		resOperations []string
+	}{
+		{
+			desc:       "missing gateway is created",
+			resCreated: true,
+			resOperations: []string{
+				"DescribeInternetGateways",
+				"CreateInternetGateway",
+				"AttachInternetGateway",
+				"CreateTags",
+			},
+		},
+		{
+			desc:          "existing gateway is reused",
+			gateways:      []*ec2.InternetGateway{{InternetGatewayId: aws.String("igw-123")}},
+			resCreated:    false,
+			resOperations: []string{"DescribeInternetGateways"},
+		},
+		{
+			desc:          "failing lookup is not taken for a missing gateway",
+			describeError: awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil),
+			errorMatcher:  func(err error) bool { return err != nil && !IsNotFound(err) },
+			resOperations: []string{"DescribeInternetGateways"},
+		},
+	}
+
+	for i, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInternetGateways", func(params, output interface{}) error {
+			if tc.describeError != nil {
+				return tc.describeError
+			}
+			output.(*ec2.DescribeInternetGatewaysOutput).InternetGateways = tc.gateways
+			return nil
+		})
+		fake.on("CreateInternetGateway", func(params, output interface{}) error {
+			output.(*ec2.CreateInternetGatewayOutput).InternetGateway = &ec2.InternetGateway{InternetGatewayId: aws.String("igw-new")}
+			return nil
+		})
+
+		gateway := &Gateway{
+			Name:      fmt.Sprintf("gateway-%d", i),
+			VpcID:     "vpc-123",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		created, err := gateway.CreateIfNotExists()
+		recentCreations.remove(gateway.creationKey())
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		} else {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		}
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong creation", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+	}
+}
+
+func TestGatewayFindExisting(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeInternetGateways", func(params, output interface{}) error {
+		return nil
+	})
+
+	gateway := &Gateway{
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	_, err := gateway.findExisting()
+	assert.True(t, IsNotFound(err), fmt.Sprintf("Unexpected error: %v", err))
+	_, err = gateway.GetID()
+	assert.True(t, IsNotFound(err), fmt.Sprintf("Unexpected error: %v", err))
+}
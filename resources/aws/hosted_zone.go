@@ -2,11 +2,12 @@ package aws
 
 import (
 	"strings"
-	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/route53"
 	microerror "github.com/giantswarm/microkit/error"
+	"github.com/pborman/uuid"
 )
 
 type HostedZone struct {
@@ -15,13 +16,19 @@ type HostedZone struct {
 	Private bool
 	Comment string
 	Client  *route53.Route53
+	// callerReference identifies a single creation request of the hosted zone.
+	// It is kept across retries, so a retried request which already went through
+	// does not create a second hosted zone.
+	callerReference string
 }
 
 func (hz *HostedZone) CreateOrFail() error {
-	callerReference := time.Now().UTC().String()
+	if hz.callerReference == "" {
+		hz.callerReference = uuid.New()
+	}
 
 	resp, err := hz.Client.CreateHostedZone(&route53.CreateHostedZoneInput{
-		CallerReference: aws.String(callerReference),
+		CallerReference: aws.String(hz.callerReference),
 		Name:            aws.String(hz.Name),
 		HostedZoneConfig: &route53.HostedZoneConfig{
 			Comment:     aws.String(hz.Comment),
@@ -29,7 +36,18 @@ func (hz *HostedZone) CreateOrFail() error {
 		},
 	})
 
-	if err != nil {
+	if awserr, ok := err.(awserr.Error); ok && awserr.Code() == route53.ErrCodeHostedZoneAlreadyExists {
+		// An earlier attempt with the same caller reference created the hosted
+		// zone already, so we reuse it.
+		existingHz, err := hz.findExisting()
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		hz.id = *existingHz.Id
+
+		return nil
+	} else if err != nil {
 		return microerror.MaskAny(err)
 	}
 
@@ -89,6 +107,97 @@ func NewHostedZoneFromExisting(name string, client *route53.Route53) (*HostedZon
 	return &hz, nil
 }
 
+// CheckDelegation makes sure the parent zone delegates to the hosted zone, i.e.
+// its NS records for the zone are the zone's name servers. Otherwise the
+// records of the zone don't resolve outside of the VPC. The parent zone must
+// be hosted in the same account.
+func (hz HostedZone) CheckDelegation() error {
+	nameServers, err := hz.nameServers()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	labels := strings.SplitN(hz.Name, ".", 2)
+	if len(labels) != 2 {
+		return microerror.MaskAnyf(zoneNotDelegatedError, "hosted zone '%s' has no parent zone", hz.Name)
+	}
+	parent := HostedZone{
+		Name:   labels[1],
+		Client: hz.Client,
+	}
+	parentZone, err := parent.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	resp, err := hz.Client.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    parentZone.Id,
+		StartRecordName: aws.String(hz.Name),
+		StartRecordType: aws.String(route53.RRTypeNs),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	var delegatedNameServers []string
+	for _, recordSet := range resp.ResourceRecordSets {
+		if normalizeDNSName(aws.StringValue(recordSet.Name)) != normalizeDNSName(hz.Name) || aws.StringValue(recordSet.Type) != route53.RRTypeNs {
+			continue
+		}
+		for _, record := range recordSet.ResourceRecords {
+			delegatedNameServers = append(delegatedNameServers, aws.StringValue(record.Value))
+		}
+	}
+
+	if !sameDNSNames(nameServers, delegatedNameServers) {
+		return microerror.MaskAnyf(zoneNotDelegatedError, "zone '%s' delegates '%s' to %v instead of %v", parent.Name, hz.Name, delegatedNameServers, nameServers)
+	}
+
+	return nil
+}
+
+func (hz HostedZone) nameServers() ([]string, error) {
+	resp, err := hz.Client.GetHostedZone(&route53.GetHostedZoneInput{
+		Id: aws.String(hz.id),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+	if resp.DelegationSet == nil {
+		return nil, nil
+	}
+
+	return aws.StringValueSlice(resp.DelegationSet.NameServers), nil
+}
+
+// normalizeDNSName lower cases the name and removes its trailing dot, which
+// AWS returns for some names but not for others.
+func normalizeDNSName(name string) string {
+	return strings.ToLower(strings.TrimRight(name, "."))
+}
+
+// sameDNSNames checks both lists contain the same names, in any order.
+func sameDNSNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	names := make(map[string]int, len(a))
+	for _, name := range a {
+		names[normalizeDNSName(name)]++
+	}
+	for _, name := range b {
+		name = normalizeDNSName(name)
+		if names[name] == 0 {
+			return false
+		}
+		names[name]--
+	}
+
+	return true
+}
+
 func (hz HostedZone) GetID() string {
 	return hz.id
 }
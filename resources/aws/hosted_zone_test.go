@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostedZoneCreateOrFailRetry(t *testing.T) {
+	tests := []struct {
+		desc      string
+		responses []fakeResponse
+		res       string
+	}{
+		{
+			desc: "retry after a transient failure",
+			responses: []fakeResponse{
+				func(params, output interface{}) error {
+					return awserr.New("Throttling", "rate exceeded", nil)
+				},
+				func(params, output interface{}) error {
+					output.(*route53.CreateHostedZoneOutput).HostedZone = &route53.HostedZone{
+						Id: aws.String("/hostedzone/new"),
+					}
+					return nil
+				},
+			},
+			res: "/hostedzone/new",
+		},
+		{
+			desc: "retry of a request which already created the zone",
+			responses: []fakeResponse{
+				func(params, output interface{}) error {
+					return awserr.New("RequestTimeout", "timed out", nil)
+				},
+				func(params, output interface{}) error {
+					return awserr.New(route53.ErrCodeHostedZoneAlreadyExists, "already exists", nil)
+				},
+			},
+			res: "/hostedzone/existing",
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("CreateHostedZone", tc.responses...)
+		fake.on("ListHostedZonesByName", func(params, output interface{}) error {
+			output.(*route53.ListHostedZonesByNameOutput).HostedZones = []*route53.HostedZone{
+				{
+					Id:   aws.String("/hostedzone/existing"),
+					Name: aws.String("foo.example.com."),
+				},
+			}
+			return nil
+		})
+
+		hz := &HostedZone{
+			Name:   "foo.example.com",
+			Client: clients.Route53,
+		}
+
+		err := hz.CreateOrFail()
+		assert.NotNil(t, err, fmt.Sprintf("[%s] Expected the first attempt to fail", tc.desc))
+
+		err = hz.CreateOrFail()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, hz.GetID(), fmt.Sprintf("[%s] The input values didn't produce the expected hosted zone", tc.desc))
+
+		var references []string
+		for _, params := range fake.paramsOf("CreateHostedZone") {
+			references = append(references, *params.(*route53.CreateHostedZoneInput).CallerReference)
+		}
+		assert.Len(t, references, 2, fmt.Sprintf("[%s] Expected two creation attempts", tc.desc))
+		assert.NotEmpty(t, references[0], fmt.Sprintf("[%s] Empty caller reference", tc.desc))
+		assert.Equal(t, references[0], references[1], fmt.Sprintf("[%s] Retries must reuse the caller reference", tc.desc))
+	}
+}
+
+func TestHostedZoneCallerReferenceUnique(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("CreateHostedZone", func(params, output interface{}) error {
+		output.(*route53.CreateHostedZoneOutput).HostedZone = &route53.HostedZone{
+			Id: aws.String("/hostedzone/new"),
+		}
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		hz := &HostedZone{
+			Name:   "foo.example.com",
+			Client: clients.Route53,
+		}
+		err := hz.CreateOrFail()
+		assert.Nil(t, err, "Unexpected error")
+	}
+
+	params := fake.paramsOf("CreateHostedZone")
+	first := *params[0].(*route53.CreateHostedZoneInput).CallerReference
+	second := *params[1].(*route53.CreateHostedZoneInput).CallerReference
+	assert.NotEqual(t, first, second, "Different hosted zones must use different caller references")
+}
+
+func TestHostedZoneCheckDelegation(t *testing.T) {
+	zoneNameServers := []string{"ns-1.awsdns-01.org", "ns-2.awsdns-02.com"}
+	nsRecordSet := func(name string, nameServers ...string) *route53.ResourceRecordSet {
+		recordSet := &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(route53.RRTypeNs),
+		}
+		for _, nameServer := range nameServers {
+			recordSet.ResourceRecords = append(recordSet.ResourceRecords, &route53.ResourceRecord{Value: aws.String(nameServer)})
+		}
+		return recordSet
+	}
+
+	tests := []struct {
+		desc         string
+		recordSets   []*route53.ResourceRecordSet
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "delegated zone",
+			recordSets: []*route53.ResourceRecordSet{
+				nsRecordSet("foo.example.com.", "ns-2.awsdns-02.com.", "ns-1.awsdns-01.org."),
+			},
+		},
+		{
+			desc:         "missing delegation",
+			recordSets:   []*route53.ResourceRecordSet{nsRecordSet("other.example.com.", "ns-1.awsdns-01.org.", "ns-2.awsdns-02.com.")},
+			errorMatcher: IsZoneNotDelegated,
+		},
+		{
+			desc: "delegation to other name servers",
+			recordSets: []*route53.ResourceRecordSet{
+				nsRecordSet("foo.example.com.", "ns-3.awsdns-03.net.", "ns-4.awsdns-04.co.uk."),
+			},
+			errorMatcher: IsZoneNotDelegated,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("GetHostedZone", func(params, output interface{}) error {
+			output.(*route53.GetHostedZoneOutput).DelegationSet = &route53.DelegationSet{
+				NameServers: aws.StringSlice(zoneNameServers),
+			}
+			return nil
+		})
+		fake.on("ListHostedZonesByName", func(params, output interface{}) error {
+			output.(*route53.ListHostedZonesByNameOutput).HostedZones = []*route53.HostedZone{
+				{
+					Id:   aws.String("/hostedzone/parent"),
+					Name: aws.String("example.com."),
+				},
+			}
+			return nil
+		})
+		fake.on("ListResourceRecordSets", func(params, output interface{}) error {
+			output.(*route53.ListResourceRecordSetsOutput).ResourceRecordSets = tc.recordSets
+			return nil
+		})
+
+		hz := HostedZone{
+			Name:   "foo.example.com",
+			id:     "/hostedzone/foo",
+			Client: clients.Route53,
+		}
+
+		err := hz.CheckDelegation()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+
+		params := fake.paramsOf("ListResourceRecordSets")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single ListResourceRecordSets call", tc.desc))
+		assert.Equal(t, "/hostedzone/parent", *params[0].(*route53.ListResourceRecordSetsInput).HostedZoneId, fmt.Sprintf("[%s] The records of the parent zone weren't listed", tc.desc))
+	}
+}
+
+func TestHostedZoneCheckDelegationParentNotHosted(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("ListHostedZonesByName", func(params, output interface{}) error {
+		output.(*route53.ListHostedZonesByNameOutput).HostedZones = []*route53.HostedZone{
+			{
+				Id:   aws.String("/hostedzone/other"),
+				Name: aws.String("other.org."),
+			},
+		}
+		return nil
+	})
+
+	hz := HostedZone{
+		Name:   "foo.example.com",
+		id:     "/hostedzone/foo",
+		Client: clients.Route53,
+	}
+
+	err := hz.CheckDelegation()
+	assert.True(t, IsNotFound(err), fmt.Sprintf("Expected the parent zone not to be found, got: %v", err))
+}
@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsclient "github.com/giantswarm/aws-operator/client/aws"
+)
+
+// describeImagesOutput is the output of DescribeImages, holding the deprecation
+// times of the images. The vendored SDK predates them, so ec2.Image lacks them.
+type describeImagesOutput struct {
+	_ struct{} `type:"structure"`
+
+	Images []*deprecatableImage `locationName:"imagesSet" locationNameList:"item" type:"list"`
+}
+
+type deprecatableImage struct {
+	_ struct{} `type:"structure"`
+
+	DeprecationTime *string `locationName:"deprecationTime" type:"string"`
+	ImageId         *string `locationName:"imageId" type:"string"`
+}
+
+// ImageDeprecationTime returns the time the image with the given ID is, or
+// was, deprecated at. It is zero when the image has no deprecation time.
+func ImageDeprecationTime(clients awsclient.Clients, imageID string) (time.Time, error) {
+	output := &describeImagesOutput{}
+	req := clients.EC2.NewRequest(&request.Operation{
+		Name:       "DescribeImages",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &ec2.DescribeImagesInput{
+		ImageIds: []*string{
+			aws.String(imageID),
+		},
+	}, output)
+	if err := req.Send(); err != nil {
+		return time.Time{}, microerror.MaskAny(err)
+	}
+	if len(output.Images) == 0 {
+		return time.Time{}, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, ImageType, imageID)
+	}
+
+	deprecationTime := aws.StringValue(output.Images[0].DeprecationTime)
+	if deprecationTime == "" {
+		return time.Time{}, nil
+	}
+	deprecatedAt, err := time.Parse(time.RFC3339, deprecationTime)
+	if err != nil {
+		return time.Time{}, microerror.MaskAny(err)
+	}
+
+	return deprecatedAt, nil
+}
@@ -0,0 +1,102 @@
+package aws
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/private/protocol/ec2query"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageDeprecationTime(t *testing.T) {
+	tests := []struct {
+		desc           string
+		describeImages fakeResponse
+		errorMatcher   func(error) bool
+		res            time.Time
+	}{
+		{
+			desc: "deprecated image",
+			describeImages: func(params, output interface{}) error {
+				output.(*describeImagesOutput).Images = []*deprecatableImage{
+					{ImageId: aws.String("ami-1"), DeprecationTime: aws.String("2021-07-06T00:00:00.000Z")},
+				}
+				return nil
+			},
+			res: time.Date(2021, 7, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			desc: "image without deprecation time",
+			describeImages: func(params, output interface{}) error {
+				output.(*describeImagesOutput).Images = []*deprecatableImage{
+					{ImageId: aws.String("ami-1")},
+				}
+				return nil
+			},
+			res: time.Time{},
+		},
+		{
+			desc: "missing image",
+			describeImages: func(params, output interface{}) error {
+				return nil
+			},
+			errorMatcher: IsNotFound,
+		},
+		{
+			desc: "failing call",
+			describeImages: func(params, output interface{}) error {
+				return awserr.New("InvalidAMIID.Malformed", "malformed", nil)
+			},
+			errorMatcher: func(err error) bool { return err != nil },
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", tc.describeImages)
+
+		res, err := ImageDeprecationTime(clients, "ami-1")
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.True(t, tc.res.Equal(res), fmt.Sprintf("[%s] Wrong deprecation time: %s", tc.desc, res))
+
+		params := fake.paramsOf("DescribeImages")
+		assert.Equal(t, []*string{aws.String("ami-1")}, params[0].(*ec2.DescribeImagesInput).ImageIds, fmt.Sprintf("[%s] Wrong image described", tc.desc))
+	}
+}
+
+func TestDescribeImagesOutputUnmarshal(t *testing.T) {
+	body := `<DescribeImagesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+  <imagesSet>
+    <item>
+      <imageId>ami-1</imageId>
+      <deprecationTime>2021-07-06T00:00:00.000Z</deprecationTime>
+    </item>
+  </imagesSet>
+</DescribeImagesResponse>`
+
+	clients, _ := newFakeClients()
+	output := &describeImagesOutput{}
+	req := clients.EC2.NewRequest(&request.Operation{Name: "DescribeImages"}, &ec2.DescribeImagesInput{}, output)
+	req.HTTPResponse = &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+	}
+
+	ec2query.Unmarshal(req)
+	assert.Nil(t, req.Error, "Unexpected error")
+	assert.Len(t, output.Images, 1, "Wrong number of images")
+	assert.Equal(t, "2021-07-06T00:00:00.000Z", aws.StringValue(output.Images[0].DeprecationTime), "Deprecation time not unmarshalled")
+}
@@ -1,10 +1,20 @@
 package aws
 
 import (
+	"encoding/base64"
 	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/cenkalti/backoff"
 	microerror "github.com/giantswarm/microkit/error"
 	micrologger "github.com/giantswarm/microkit/logger"
@@ -24,6 +34,99 @@ const (
 	EC2StoppedState      EC2StateCode = 80
 )
 
+// spotRequestAttempts is the number of checks of a spot request, waiting for
+// it to be fulfilled.
+const spotRequestAttempts = 10
+
+// spotRequestPollInterval is the delay between the checks of a spot request.
+var spotRequestPollInterval = 5 * time.Second
+
+// runInstancesRetries is the number of times launching an instance is retried
+// while its instance profile propagates to EC2.
+const runInstancesRetries = 10
+
+// newRunInstancesBackOff returns the backoff between the retries of launching
+// an instance.
+var newRunInstancesBackOff = func() backoff.BackOff {
+	return NewCustomExponentialBackoff()
+}
+
+// instanceRunningPollInterval is the delay between the checks of the state of
+// an instance, while waiting for it to run.
+const instanceRunningPollInterval = 15 * time.Second
+
+const (
+	// Architectures of AMIs, as reported by DescribeImages.
+	architectureARM64  = "arm64"
+	architectureX86_64 = "x86_64"
+)
+
+// armInstanceFamily matches the families of instance types running on AWS
+// Graviton processors, e.g. m6g, c6gn, t4g or im4gn.
+var armInstanceFamily = regexp.MustCompile(`^(a1|[a-z]+[0-9]+g[a-z]*)$`)
+
+// optionalEBSOptimizedTypes are the instance types which are EBS-optimized on
+// request only. See
+// https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/ebs-optimized.html
+var optionalEBSOptimizedTypes = map[string]bool{
+	"c1.xlarge":  true,
+	"c3.xlarge":  true,
+	"c3.2xlarge": true,
+	"c3.4xlarge": true,
+	"g2.2xlarge": true,
+	"i2.xlarge":  true,
+	"i2.2xlarge": true,
+	"i2.4xlarge": true,
+	"m1.large":   true,
+	"m1.xlarge":  true,
+	"m2.2xlarge": true,
+	"m2.4xlarge": true,
+	"m3.xlarge":  true,
+	"m3.2xlarge": true,
+	"r3.xlarge":  true,
+	"r3.2xlarge": true,
+	"r3.4xlarge": true,
+}
+
+// previousGenerationFamilies are the families of instance types which predate
+// EBS optimization by default. Their types which can't be EBS-optimized on
+// request can't be EBS-optimized at all. All the later families are always
+// EBS-optimized.
+var previousGenerationFamilies = map[string]bool{
+	"c1":  true,
+	"c3":  true,
+	"cc2": true,
+	"cg1": true,
+	"cr1": true,
+	"g2":  true,
+	"hi1": true,
+	"hs1": true,
+	"i2":  true,
+	"m1":  true,
+	"m2":  true,
+	"m3":  true,
+	"r3":  true,
+	"t1":  true,
+	"t2":  true,
+}
+
+// BlockDevice is an EBS volume attached to an instance at launch.
+type BlockDevice struct {
+	// DeviceName is the device of the volume, e.g. /dev/xvdb.
+	DeviceName string
+	// VolumeSize is the size of the volume in GiB. It is the size of the
+	// image's snapshot when zero.
+	VolumeSize int
+	// VolumeType is the EBS volume type, e.g. gp2 or io1. It is left to AWS
+	// when empty.
+	VolumeType string
+	// DeleteOnTermination deletes the volume along with the instance. It is
+	// left to AWS when nil, which deletes the root device and keeps the others.
+	DeleteOnTermination *bool
+	// Encrypted encrypts the volume with the default EBS key of the account.
+	Encrypted bool
+}
+
 type Instance struct {
 	Name                   string
 	ClusterName            string
@@ -35,26 +138,59 @@ type Instance struct {
 	UserData               string
 	SmallCloudconfig       string
 	IamInstanceProfileName string
-	PlacementAZ            string
-	SecurityGroupID        string
-	SubnetID               string
-	id                     string
+	// BlockDeviceMappings are the volumes attached to the instance at launch,
+	// besides the ones of its image. A mapping of the root device of the image
+	// resizes it.
+	BlockDeviceMappings []BlockDevice
+	// Spot launches the instance as a spot instance, bidding at most
+	// SpotMaxPrice, instead of an on-demand instance.
+	Spot bool
+	// SpotMaxPrice is the maximum price per hour paid for a spot instance, in
+	// US dollars, e.g. 0.05. It must be set for spot instances.
+	SpotMaxPrice string
+	// EBSOptimized requests dedicated bandwidth to EBS. It is left to the
+	// instance type when nil. It is ignored for instance types which are
+	// always EBS-optimized.
+	EBSOptimized *bool
+	// DisableAPITermination protects the instance from being terminated
+	// through the API, e.g. by accident. Delete lifts the protection first.
+	// Spot instances can't be protected.
+	DisableAPITermination bool
+	// HostID is the dedicated host the instance is launched on, e.g. for
+	// licenses bound to physical servers. The host must be available and have
+	// capacity for the instance type. Spot instances can't be launched on
+	// dedicated hosts.
+	HostID string
+	// FixedPrivateIPAddress is the private IP address the instance is launched
+	// with, e.g. to give etcd peers stable addresses. It must be within the
+	// CIDR of the subnet SubnetID. AWS assigns an address when it is empty.
+	// Spot instances can't have a fixed address.
+	FixedPrivateIPAddress string
+	PlacementAZ           string
+	SecurityGroupID       string
+	SubnetID              string
+	id                    string
+	privateDNSName        string
+	privateIPAddress      string
+	publicIPAddress       string
+	state                 EC2StateCode
 	// Dependencies.
 	Logger micrologger.Logger
 	AWSEntity
 }
 
-func statePendingOrRunning(instance *ec2.Instance) bool {
-	stateCode := *instance.State.Code
-	switch stateCode {
-	case int64(EC2PendingState), int64(EC2RunningState):
-		return true
+// findExisting returns the pending or running instance.
+func (i Instance) findExisting() (*ec2.Instance, error) {
+	instance, err := i.findExistingIn(EC2PendingState, EC2RunningState)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
 	}
 
-	return false
+	return instance, nil
 }
 
-func (i Instance) findExisting() (*ec2.Instance, error) {
+// findExistingIn returns the instance if it is in one of the given states.
+func (i Instance) findExistingIn(states ...EC2StateCode) (*ec2.Instance, error) {
 	filters := []*ec2.Filter{}
 	if i.ClusterName != "" {
 		filters = append(filters, &ec2.Filter{
@@ -80,6 +216,7 @@ func (i Instance) findExisting() (*ec2.Instance, error) {
 			},
 		})
 	}
+	filters = append(filters, operatorFilters(i.OperatorID)...)
 
 	reservations, err := i.Clients.EC2.DescribeInstances(&ec2.DescribeInstancesInput{
 		Filters: filters,
@@ -90,7 +227,7 @@ func (i Instance) findExisting() (*ec2.Instance, error) {
 
 	for _, reservation := range reservations.Reservations {
 		for _, instance := range reservation.Instances {
-			if statePendingOrRunning(instance) {
+			if stateIn(instance, states) {
 				return instance, nil
 			}
 		}
@@ -130,67 +267,483 @@ func (i *Instance) CreateIfNotExists() (bool, error) {
 }
 
 func (i *Instance) CreateOrFail() error {
+	if err := i.checkArchitecture(); err != nil {
+		return microerror.MaskAny(err)
+	}
+	if err := i.checkFixedPrivateIPAddress(); err != nil {
+		return microerror.MaskAny(err)
+	}
+	if err := i.checkHost(); err != nil {
+		return microerror.MaskAny(err)
+	}
+	ebsOptimized, err := i.ebsOptimized()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	blockDeviceMappings, err := i.blockDeviceMappings()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if !i.Spot {
+		reservation, err := i.runInstance(blockDeviceMappings, ebsOptimized)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		for _, rawInstance := range reservation.Instances {
+			i.id = *rawInstance.InstanceId
+		}
+
+		return nil
+	}
+
+	// Spot requests can't tag the instances they launch, so spot instances are
+	// tagged once they exist.
+	instanceID, err := i.requestSpotInstance(blockDeviceMappings, ebsOptimized)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	i.id = instanceID
+
+	if _, err := i.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{aws.String(instanceID)},
+		Tags:      i.tags(),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// tags returns the tags of the instance. The instance is found by its Name and
+// Cluster tags.
+func (i Instance) tags() []*ec2.Tag {
+	return append([]*ec2.Tag{
+		{
+			Key:   aws.String(tagKeyName),
+			Value: aws.String(i.Name),
+		},
+		{
+			Key:   aws.String(tagKeyCluster),
+			Value: aws.String(i.ClusterName),
+		},
+	}, resourceTags(i.OperatorID, i.Tags)...)
+}
+
+// runInstance launches the instance on demand. The instance is tagged on
+// launch, so it can't exist without the tags it is found by, e.g. when the
+// operator crashes right after launching it. A new instance profile takes a
+// while to propagate to EC2, so launching is retried while the profile is not
+// found. Other errors fail right away.
+func (i Instance) runInstance(blockDeviceMappings []*ec2.BlockDeviceMapping, ebsOptimized *bool) (*ec2.Reservation, error) {
+	var privateIPAddress *string
+	if i.FixedPrivateIPAddress != "" {
+		privateIPAddress = aws.String(i.FixedPrivateIPAddress)
+	}
+	placement := &ec2.Placement{
+		AvailabilityZone: aws.String(i.PlacementAZ),
+	}
+	if i.HostID != "" {
+		placement.HostId = aws.String(i.HostID)
+		placement.Tenancy = aws.String(ec2.TenancyHost)
+	}
+
 	var reservation *ec2.Reservation
 	reserveOperation := func() error {
 		var err error
 		reservation, err = i.Clients.EC2.RunInstances(&ec2.RunInstancesInput{
-			ImageId:      aws.String(i.ImageID),
-			InstanceType: aws.String(i.InstanceType),
-			KeyName:      aws.String(i.KeyName),
-			MinCount:     aws.Int64(int64(1)),
-			MaxCount:     aws.Int64(int64(1)),
-			UserData:     aws.String(i.SmallCloudconfig),
+			BlockDeviceMappings:   blockDeviceMappings,
+			DisableApiTermination: aws.Bool(i.DisableAPITermination),
+			EbsOptimized:          ebsOptimized,
+			ImageId:               aws.String(i.ImageID),
+			InstanceType:          aws.String(i.InstanceType),
+			KeyName:               aws.String(i.KeyName),
+			MinCount:              aws.Int64(int64(1)),
+			MaxCount:              aws.Int64(int64(1)),
+			UserData:              aws.String(i.SmallCloudconfig),
 			IamInstanceProfile: &ec2.IamInstanceProfileSpecification{
 				Name: aws.String(i.IamInstanceProfileName),
 			},
-			Placement: &ec2.Placement{
+			Placement:        placement,
+			PrivateIpAddress: privateIPAddress,
+			SecurityGroupIds: []*string{
+				aws.String(i.SecurityGroupID),
+			},
+			SubnetId: aws.String(i.SubnetID),
+			TagSpecifications: []*ec2.TagSpecification{
+				{
+					ResourceType: aws.String(ec2.ResourceTypeInstance),
+					Tags:         i.tags(),
+				},
+			},
+		})
+		if isInstanceProfileNotFound(err) {
+			return microerror.MaskAny(err)
+		} else if err != nil {
+			return backoff.Permanent(microerror.MaskAny(err))
+		}
+		return nil
+	}
+	reserveNotify := NewNotify(i.Logger, "creating instance")
+	reserveBackOff := NewMaxRetriesBackOff(newRunInstancesBackOff(), runInstancesRetries)
+	if err := backoff.RetryNotify(reserveOperation, reserveBackOff, reserveNotify); err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return reservation, nil
+}
+
+// isInstanceProfileNotFound tells whether EC2 rejected an instance because
+// its instance profile can't be found, e.g. since it hasn't propagated yet.
+func isInstanceProfileNotFound(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok || awsErr.Code() != awsutil.InvalidParameterValue {
+		return false
+	}
+
+	return strings.Contains(strings.ToLower(awsErr.Message()), "instance profile")
+}
+
+// requestSpotInstance requests the instance as a spot instance and returns its
+// ID, once the request is fulfilled. Requests still pending after
+// spotRequestAttempts checks are cancelled, so they don't launch an instance
+// nobody waits for anymore.
+func (i Instance) requestSpotInstance(blockDeviceMappings []*ec2.BlockDeviceMapping, ebsOptimized *bool) (string, error) {
+	if i.SpotMaxPrice == "" {
+		return "", microerror.MaskAnyf(invalidSpotPriceError, "maximum price of spot instance '%s' must not be empty", i.Name)
+	}
+
+	resp, err := i.Clients.EC2.RequestSpotInstances(&ec2.RequestSpotInstancesInput{
+		InstanceCount: aws.Int64(1),
+		LaunchSpecification: &ec2.RequestSpotLaunchSpecification{
+			BlockDeviceMappings: blockDeviceMappings,
+			EbsOptimized:        ebsOptimized,
+			ImageId:             aws.String(i.ImageID),
+			InstanceType:        aws.String(i.InstanceType),
+			KeyName:             aws.String(i.KeyName),
+			UserData:            aws.String(i.SmallCloudconfig),
+			IamInstanceProfile: &ec2.IamInstanceProfileSpecification{
+				Name: aws.String(i.IamInstanceProfileName),
+			},
+			Placement: &ec2.SpotPlacement{
 				AvailabilityZone: aws.String(i.PlacementAZ),
 			},
 			SecurityGroupIds: []*string{
 				aws.String(i.SecurityGroupID),
 			},
 			SubnetId: aws.String(i.SubnetID),
+		},
+		SpotPrice: aws.String(i.SpotMaxPrice),
+		Type:      aws.String(ec2.SpotInstanceTypeOneTime),
+	})
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+	if len(resp.SpotInstanceRequests) == 0 {
+		return "", microerror.MaskAnyf(notFoundError, notFoundErrorFormat, SpotInstanceRequestType, i.Name)
+	}
+	requestID := resp.SpotInstanceRequests[0].SpotInstanceRequestId
+
+	for attempt := 0; attempt < spotRequestAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(spotRequestPollInterval)
+		}
+
+		resp, err := i.Clients.EC2.DescribeSpotInstanceRequests(&ec2.DescribeSpotInstanceRequestsInput{
+			SpotInstanceRequestIds: []*string{requestID},
 		})
 		if err != nil {
+			return "", microerror.MaskAny(err)
+		}
+		if len(resp.SpotInstanceRequests) == 0 {
+			// The request might not be visible yet.
+			continue
+		}
 
-			return microerror.MaskAny(err)
+		request := resp.SpotInstanceRequests[0]
+		if instanceID := aws.StringValue(request.InstanceId); instanceID != "" {
+			return instanceID, nil
+		}
+		if state := aws.StringValue(request.State); state != ec2.SpotInstanceStateOpen {
+			var reason string
+			if request.Status != nil {
+				reason = aws.StringValue(request.Status.Message)
+			}
+			return "", microerror.MaskAnyf(spotRequestFailedError, "spot request of instance '%s' is %s: %s", i.Name, state, reason)
 		}
+	}
+
+	if _, err := i.Clients.EC2.CancelSpotInstanceRequests(&ec2.CancelSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: []*string{requestID},
+	}); err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return "", microerror.MaskAnyf(spotRequestFailedError, "spot request of instance '%s' is still pending after %d checks, cancelled it", i.Name, spotRequestAttempts)
+}
+
+// checkArchitecture makes sure the instance's image can run on its instance
+// type, since EC2 fails with a cryptic error when they don't match.
+func (i *Instance) checkArchitecture() error {
+	resp, err := i.Clients.EC2.DescribeImages(&ec2.DescribeImagesInput{
+		ImageIds: []*string{
+			aws.String(i.ImageID),
+		},
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(resp.Images) == 0 {
+		return microerror.MaskAnyf(notFoundError, notFoundErrorFormat, ImageType, i.ImageID)
+	}
+
+	imageArchitecture := aws.StringValue(resp.Images[0].Architecture)
+	typeArchitecture := instanceTypeArchitecture(i.InstanceType)
+	if imageArchitecture != typeArchitecture {
+		return microerror.MaskAnyf(architectureMismatchError, "image '%s' is built for %s, but instance type '%s' requires %s", i.ImageID, imageArchitecture, i.InstanceType, typeArchitecture)
+	}
+
+	return nil
+}
+
+// checkFixedPrivateIPAddress checks that the fixed private IP address of the
+// instance, if any, is within the CIDR of its subnet, so the instance lands in
+// the subnet the address belongs to.
+func (i *Instance) checkFixedPrivateIPAddress() error {
+	if i.FixedPrivateIPAddress == "" {
 		return nil
 	}
-	reserveNotify := NewNotify(i.Logger, "creating instance")
-	if err := backoff.RetryNotify(reserveOperation, NewCustomExponentialBackoff(), reserveNotify); err != nil {
+	if i.Spot {
+		return microerror.MaskAnyf(invalidPrivateIPAddressError, "spot instance '%s' can't have a fixed private IP address", i.Name)
+	}
+	if i.SubnetID == "" {
+		return microerror.MaskAnyf(invalidPrivateIPAddressError, "instance '%s' needs a subnet for its fixed private IP address", i.Name)
+	}
+	ip := net.ParseIP(i.FixedPrivateIPAddress)
+	if ip == nil {
+		return microerror.MaskAnyf(invalidPrivateIPAddressError, "'%s' is not an IP address", i.FixedPrivateIPAddress)
+	}
+
+	resp, err := i.Clients.EC2.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		SubnetIds: []*string{
+			aws.String(i.SubnetID),
+		},
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(resp.Subnets) == 0 {
+		return microerror.MaskAnyf(notFoundError, notFoundErrorFormat, SubnetType, i.SubnetID)
+	}
+
+	_, cidr, err := net.ParseCIDR(aws.StringValue(resp.Subnets[0].CidrBlock))
+	if err != nil {
 		return microerror.MaskAny(err)
 	}
+	if !cidr.Contains(ip) {
+		return microerror.MaskAnyf(invalidPrivateIPAddressError, "'%s' is not within the CIDR %s of subnet '%s'", i.FixedPrivateIPAddress, cidr, i.SubnetID)
+	}
 
-	for _, rawInstance := range reservation.Instances {
-		i.id = *rawInstance.InstanceId
+	return nil
+}
 
-		if _, err := i.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
-			Resources: []*string{rawInstance.InstanceId},
-			Tags: []*ec2.Tag{
-				{
-					Key:   aws.String(tagKeyName),
-					Value: aws.String(i.Name),
-				},
-				{
-					Key:   aws.String(tagKeyCluster),
-					Value: aws.String(i.ClusterName),
-				},
-			},
-		}); err != nil {
-			return microerror.MaskAny(err)
+// checkHost checks that the dedicated host of the instance, if any, is
+// available and has capacity for another instance of the instance type, so
+// the launch doesn't fail halfway through the creation of a cluster.
+func (i *Instance) checkHost() error {
+	if i.HostID == "" {
+		return nil
+	}
+	if i.Spot {
+		return microerror.MaskAnyf(invalidHostError, "spot instance '%s' can't be launched on a dedicated host", i.Name)
+	}
+
+	resp, err := i.Clients.EC2.DescribeHosts(&ec2.DescribeHostsInput{
+		HostIds: []*string{
+			aws.String(i.HostID),
+		},
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(resp.Hosts) == 0 {
+		return microerror.MaskAnyf(notFoundError, notFoundErrorFormat, HostType, i.HostID)
+	}
+	host := resp.Hosts[0]
+
+	if state := aws.StringValue(host.State); state != ec2.AllocationStateAvailable {
+		return microerror.MaskAnyf(invalidHostError, "host '%s' is %s", i.HostID, state)
+	}
+	if zone := aws.StringValue(host.AvailabilityZone); i.PlacementAZ != "" && zone != i.PlacementAZ {
+		return microerror.MaskAnyf(invalidHostError, "host '%s' is in availability zone '%s', not '%s'", i.HostID, zone, i.PlacementAZ)
+	}
+	if host.AvailableCapacity != nil {
+		for _, capacity := range host.AvailableCapacity.AvailableInstanceCapacity {
+			if aws.StringValue(capacity.InstanceType) == i.InstanceType && aws.Int64Value(capacity.AvailableCapacity) > 0 {
+				return nil
+			}
+		}
+	}
+
+	return microerror.MaskAnyf(invalidHostError, "host '%s' has no capacity for instance type '%s'", i.HostID, i.InstanceType)
+}
+
+// instanceTypeArchitecture returns the architecture of the processors of the
+// given instance type, e.g. arm64 for m6g.large and x86_64 for m4.large.
+func instanceTypeArchitecture(instanceType string) string {
+	family := strings.SplitN(instanceType, ".", 2)[0]
+	if armInstanceFamily.MatchString(family) {
+		return architectureARM64
+	}
+
+	return architectureX86_64
+}
+
+// ebsOptimized returns the EBS optimization to request for the instance. It is
+// only requested for the instance types where it is optional, and fails for the
+// ones which can't be EBS-optimized.
+func (i Instance) ebsOptimized() (*bool, error) {
+	if i.EBSOptimized == nil {
+		return nil, nil
+	}
+
+	switch {
+	case optionalEBSOptimizedTypes[i.InstanceType]:
+		return i.EBSOptimized, nil
+	case !EBSOptimizable(i.InstanceType):
+		if *i.EBSOptimized {
+			return nil, microerror.MaskAnyf(invalidEBSOptimizedError, "instance type '%s' can't be EBS-optimized", i.InstanceType)
 		}
+		return nil, nil
+	default:
+		return nil, nil
+	}
+}
+
+// blockDeviceMappings returns the block device mappings of the instance for
+// RunInstances.
+func (i Instance) blockDeviceMappings() ([]*ec2.BlockDeviceMapping, error) {
+	var mappings []*ec2.BlockDeviceMapping
+
+	for _, device := range i.BlockDeviceMappings {
+		if device.DeviceName == "" {
+			return nil, microerror.MaskAnyf(invalidBlockDeviceError, "device name must not be empty")
+		}
+		if device.VolumeSize < 0 {
+			return nil, microerror.MaskAnyf(invalidBlockDeviceError, "size of device '%s' must not be negative", device.DeviceName)
+		}
+
+		ebs := &ec2.EbsBlockDevice{
+			DeleteOnTermination: device.DeleteOnTermination,
+		}
+		if device.VolumeSize > 0 {
+			ebs.VolumeSize = aws.Int64(int64(device.VolumeSize))
+		}
+		if device.VolumeType != "" {
+			ebs.VolumeType = aws.String(device.VolumeType)
+		}
+		if device.Encrypted {
+			ebs.Encrypted = aws.Bool(true)
+		}
+
+		mappings = append(mappings, &ec2.BlockDeviceMapping{
+			DeviceName: aws.String(device.DeviceName),
+			Ebs:        ebs,
+		})
+	}
+
+	return mappings, nil
+}
+
+// EBSOptimizable returns whether the given instance type can be EBS-optimized,
+// on request or by default.
+func EBSOptimizable(instanceType string) bool {
+	if optionalEBSOptimizedTypes[instanceType] {
+		return true
+	}
+
+	family := strings.SplitN(instanceType, ".", 2)[0]
+	return !previousGenerationFamilies[family]
+}
+
+// WaitUntilRunning blocks until the instance is running, for at most the given
+// timeout. Pending instances can't be registered with load balancers yet.
+func (i Instance) WaitUntilRunning(timeout time.Duration) error {
+	maxAttempts := int(timeout/instanceRunningPollInterval) + 1
+
+	if err := i.Clients.EC2.WaitUntilInstanceRunningWithContext(
+		aws.BackgroundContext(),
+		&ec2.DescribeInstancesInput{
+			InstanceIds: []*string{
+				aws.String(i.id),
+			},
+		},
+		request.WithWaiterDelay(request.ConstantWaiterDelay(instanceRunningPollInterval)),
+		request.WithWaiterMaxAttempts(maxAttempts),
+	); err != nil {
+		return microerror.MaskAnyf(err, "instance '%s' is not running after %s", i.Name, timeout)
 	}
 
 	return nil
 }
 
+// InstanceBootDiagnostics describes the state of an instance which didn't
+// boot in time.
+type InstanceBootDiagnostics struct {
+	State string
+	// StateReason explains the last state change, e.g. why the launch of the
+	// instance failed.
+	StateReason string
+	// ConsoleOutput is empty until the instance has written to its console.
+	ConsoleOutput string
+}
+
+// BootDiagnostics collects the state and the console output of the instance,
+// e.g. once it didn't run in time.
+func (i Instance) BootDiagnostics() (InstanceBootDiagnostics, error) {
+	resp, err := i.Clients.EC2.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{
+			aws.String(i.id),
+		},
+	})
+	if err != nil {
+		return InstanceBootDiagnostics{}, microerror.MaskAny(err)
+	}
+	if len(resp.Reservations) == 0 || len(resp.Reservations[0].Instances) == 0 {
+		return InstanceBootDiagnostics{}, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, InstanceType, i.Name)
+	}
+	instance := resp.Reservations[0].Instances[0]
+
+	var diagnostics InstanceBootDiagnostics
+	if instance.State != nil {
+		diagnostics.State = aws.StringValue(instance.State.Name)
+	}
+	if instance.StateReason != nil {
+		diagnostics.StateReason = aws.StringValue(instance.StateReason.Message)
+	} else {
+		diagnostics.StateReason = aws.StringValue(instance.StateTransitionReason)
+	}
+
+	diagnostics.ConsoleOutput, err = InstanceConsoleOutput(i.Clients, i.id)
+	if err != nil {
+		return InstanceBootDiagnostics{}, microerror.MaskAny(err)
+	}
+
+	return diagnostics, nil
+}
+
+// Delete terminates the instance, stopped ones included, and waits until it is
+// terminated.
 func (i *Instance) Delete() error {
-	instance, err := i.findExisting()
+	instance, err := i.findExistingIn(EC2PendingState, EC2RunningState, EC2StoppingState, EC2StoppedState)
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
 
+	if err := allowTermination(i.Clients, instance.InstanceId); err != nil {
+		return microerror.MaskAny(err)
+	}
+
 	if _, err := i.Clients.EC2.TerminateInstances(&ec2.TerminateInstancesInput{
 		InstanceIds: []*string{
 			instance.InstanceId,
@@ -210,46 +763,338 @@ func (i *Instance) Delete() error {
 	return nil
 }
 
+// ReplaceInstanceProfile associates the instance with the instance profile of
+// the given name, replacing the profile it is associated with, without
+// recreating the instance. The profile must exist and have a role. It returns
+// false when the instance is associated with the profile already.
+func (i *Instance) ReplaceInstanceProfile(profileName string) (bool, error) {
+	resp, err := i.Clients.IAM.GetInstanceProfile(&iam.GetInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeNoSuchEntityException {
+		return false, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, InstanceProfileType, profileName)
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+	profile := resp.InstanceProfile
+	if len(profile.Roles) == 0 {
+		return false, microerror.MaskAnyf(instanceProfileNotReadyError, "instance profile '%s' has no role", profileName)
+	}
+
+	associations, err := i.Clients.EC2.DescribeIamInstanceProfileAssociations(&ec2.DescribeIamInstanceProfileAssociationsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("instance-id"),
+				Values: []*string{aws.String(i.id)},
+			},
+			{
+				Name:   aws.String("state"),
+				Values: []*string{aws.String(ec2.IamInstanceProfileAssociationStateAssociated)},
+			},
+		},
+	})
+	if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	specification := &ec2.IamInstanceProfileSpecification{
+		Arn: profile.Arn,
+	}
+
+	if len(associations.IamInstanceProfileAssociations) == 0 {
+		if _, err := i.Clients.EC2.AssociateIamInstanceProfile(&ec2.AssociateIamInstanceProfileInput{
+			IamInstanceProfile: specification,
+			InstanceId:         aws.String(i.id),
+		}); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+
+		return true, nil
+	}
+
+	// Profiles are compared by ID, since a profile recreated under the same
+	// name is a different profile.
+	association := associations.IamInstanceProfileAssociations[0]
+	if association.IamInstanceProfile != nil && aws.StringValue(association.IamInstanceProfile.Id) == aws.StringValue(profile.InstanceProfileId) {
+		return false, nil
+	}
+
+	if _, err := i.Clients.EC2.ReplaceIamInstanceProfileAssociation(&ec2.ReplaceIamInstanceProfileAssociationInput{
+		AssociationId:      association.AssociationId,
+		IamInstanceProfile: specification,
+	}); err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return true, nil
+}
+
+// Get fetches the pending or running instance named Name, within the cluster
+// ClusterName when it is set, and populates the fields returned by ID,
+// PrivateDNSName, PrivateIPAddress, PublicIPAddress and State. It returns a
+// notFoundError when the instance doesn't exist.
+func (i *Instance) Get() error {
+	instance, err := i.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	i.id = aws.StringValue(instance.InstanceId)
+	i.privateDNSName = aws.StringValue(instance.PrivateDnsName)
+	i.privateIPAddress = aws.StringValue(instance.PrivateIpAddress)
+	i.publicIPAddress = aws.StringValue(instance.PublicIpAddress)
+	i.state = EC2StateCode(aws.Int64Value(instance.State.Code) & 0xff)
+
+	return nil
+}
+
 func (i Instance) ID() string {
 	return i.id
 }
 
+// PrivateDNSName returns the private DNS name of the instance.
+func (i Instance) PrivateDNSName() string {
+	return i.privateDNSName
+}
+
+// PrivateIPAddress returns the private IP address of the instance, which is
+// also the name of the Kubernetes node running on it.
+func (i Instance) PrivateIPAddress() string {
+	return i.privateIPAddress
+}
+
+// PublicIPAddress returns the public IP address of the instance. It is empty
+// for instances without one.
+func (i Instance) PublicIPAddress() string {
+	return i.publicIPAddress
+}
+
+// State returns the state of the instance, as of the last Get.
+func (i Instance) State() EC2StateCode {
+	return i.state
+}
+
 type FindInstancesInput struct {
 	Clients awsutil.Clients
 	Logger  micrologger.Logger
-	Pattern string
+	// OperatorID restricts the search to the instances of the given operator.
+	OperatorID string
+	Pattern    string
+	// States are the states of the instances found. They default to pending
+	// and running.
+	States []EC2StateCode
 }
 
+// FindInstances returns the instances whose name starts with the given pattern
+// and whose state is one of the given states, across all result pages.
 func FindInstances(input FindInstancesInput) ([]*Instance, error) {
-	reservations, err := input.Clients.EC2.DescribeInstances(&ec2.DescribeInstancesInput{
-		Filters: []*ec2.Filter{
+	states := input.States
+	if len(states) == 0 {
+		states = []EC2StateCode{EC2PendingState, EC2RunningState}
+	}
+	var stateCodes []*string
+	for _, state := range states {
+		stateCodes = append(stateCodes, aws.String(strconv.Itoa(int(state))))
+	}
+
+	var instances []*Instance
+	err := input.Clients.EC2.DescribeInstancesPages(&ec2.DescribeInstancesInput{
+		Filters: append([]*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
 				Values: []*string{
 					aws.String(fmt.Sprintf("%s*", input.Pattern)),
 				},
 			},
-		},
+			&ec2.Filter{
+				Name:   aws.String("instance-state-code"),
+				Values: stateCodes,
+			},
+		}, operatorFilters(input.OperatorID)...),
+	}, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, rawInstance := range reservation.Instances {
+				if !stateIn(rawInstance, states) {
+					continue
+				}
+				var name string
+				for _, tag := range rawInstance.Tags {
+					if aws.StringValue(tag.Key) == tagKeyName {
+						name = aws.StringValue(tag.Value)
+					}
+				}
+				instances = append(instances, &Instance{
+					Name:             name,
+					id:               *rawInstance.InstanceId,
+					privateDNSName:   aws.StringValue(rawInstance.PrivateDnsName),
+					privateIPAddress: aws.StringValue(rawInstance.PrivateIpAddress),
+					// Dependencies.
+					Logger:    input.Logger,
+					AWSEntity: AWSEntity{Clients: input.Clients, OperatorID: input.OperatorID},
+				})
+			}
+		}
+		return true
 	})
 	if err != nil {
 		return nil, microerror.MaskAny(err)
 	}
 
-	instances := make([]*Instance, 0, len(reservations.Reservations))
+	return instances, nil
+}
 
-	for _, reservation := range reservations.Reservations {
-		for _, rawInstance := range reservation.Instances {
-			if !statePendingOrRunning(rawInstance) {
-				continue
+// stateIn returns whether the instance is in one of the given states. The high
+// byte of state codes is reserved for internal use by AWS and is ignored.
+func stateIn(instance *ec2.Instance, states []EC2StateCode) bool {
+	if instance.State == nil {
+		return false
+	}
+	stateCode := EC2StateCode(aws.Int64Value(instance.State.Code) & 0xff)
+	for _, state := range states {
+		if stateCode == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+type TerminateInstancesInput struct {
+	Clients awsutil.Clients
+	IDs     []string
+	// OperatorID is the ID the instances must be tagged with, when it is set.
+	OperatorID string
+}
+
+// TerminateInstances terminates the given instances, after deregistering them
+// from the ELBs of their clusters, so no more requests are routed to them. Only
+// instances tagged with their cluster, and with the operator ID when it is set,
+// are managed by the operator. When any of the given instances isn't, none of
+// them is touched.
+func TerminateInstances(input TerminateInstancesInput) error {
+	if len(input.IDs) == 0 {
+		return microerror.MaskAnyf(attributeEmptyError, attributeEmptyErrorFormat, "IDs")
+	}
+
+	clusterInstances, err := managedInstancesByCluster(input.Clients, input.IDs, input.OperatorID)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	clusterNames := make([]string, 0, len(clusterInstances))
+	for clusterName := range clusterInstances {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+
+	for _, clusterName := range clusterNames {
+		lbs, err := FindClusterELBs(input.Clients.ELB, clusterName, input.OperatorID)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		for _, lb := range lbs {
+			if err := lb.DeregisterInstances(clusterInstances[clusterName]); err != nil {
+				return microerror.MaskAnyf(err, "deregistering instances from ELB '%s'", lb.Name)
 			}
-			instances = append(instances, &Instance{
-				id: *rawInstance.InstanceId,
-				// Dependencies.
-				Logger:    input.Logger,
-				AWSEntity: AWSEntity{Clients: input.Clients},
-			})
 		}
 	}
 
-	return instances, nil
+	instanceIDs := aws.StringSlice(input.IDs)
+
+	for _, instanceID := range instanceIDs {
+		if err := allowTermination(input.Clients, instanceID); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	if _, err := input.Clients.EC2.TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: instanceIDs,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if err := input.Clients.EC2.WaitUntilInstanceTerminated(&ec2.DescribeInstancesInput{
+		InstanceIds: instanceIDs,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// allowTermination lifts the termination protection of the instance with the
+// given ID, e.g. of masters, which doesn't apply to deliberate terminations.
+func allowTermination(clients awsutil.Clients, instanceID *string) error {
+	if _, err := clients.EC2.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+		DisableApiTermination: &ec2.AttributeBooleanValue{
+			Value: aws.Bool(false),
+		},
+		InstanceId: instanceID,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// managedInstancesByCluster returns the IDs of the given instances grouped by
+// the name of their cluster. It fails when any of them is missing, or isn't
+// tagged as managed by the operator.
+func managedInstancesByCluster(clients awsutil.Clients, ids []string, operatorID string) (map[string][]string, error) {
+	resp, err := clients.EC2.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: aws.StringSlice(ids),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	tags := make(map[string]map[string]string)
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			values := make(map[string]string)
+			for _, tag := range instance.Tags {
+				values[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+			tags[aws.StringValue(instance.InstanceId)] = values
+		}
+	}
+
+	clusterInstances := make(map[string][]string)
+	for _, id := range ids {
+		values, ok := tags[id]
+		if !ok {
+			return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, InstanceType, id)
+		}
+
+		clusterName := values[tagKeyCluster]
+		if clusterName == "" {
+			return nil, microerror.MaskAnyf(unmanagedInstanceError, "instance '%s' has no %s tag", id, tagKeyCluster)
+		}
+		if operatorID != "" && values[tagKeyOperator] != operatorID {
+			return nil, microerror.MaskAnyf(unmanagedInstanceError, "instance '%s' is not tagged with %s '%s'", id, tagKeyOperator, operatorID)
+		}
+
+		clusterInstances[clusterName] = append(clusterInstances[clusterName], id)
+	}
+
+	return clusterInstances, nil
+}
+
+// InstanceConsoleOutput returns the decoded console output of the given
+// instance. It is empty until the instance has written to its console.
+func InstanceConsoleOutput(clients awsutil.Clients, instanceID string) (string, error) {
+	resp, err := clients.EC2.GetConsoleOutput(&ec2.GetConsoleOutputInput{
+		InstanceId: aws.String(instanceID),
+	})
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	output, err := base64.StdEncoding.DecodeString(aws.StringValue(resp.Output))
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return string(output), nil
 }
@@ -0,0 +1,1490 @@
+package aws
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/cenkalti/backoff"
+	"github.com/giantswarm/aws-operator/resources"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/juju/errgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceTypeArchitecture(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		res          string
+	}{
+		{instanceType: "m3.large", res: architectureX86_64},
+		{instanceType: "m5d.xlarge", res: architectureX86_64},
+		{instanceType: "g4dn.xlarge", res: architectureX86_64},
+		{instanceType: "a1.medium", res: architectureARM64},
+		{instanceType: "m6g.large", res: architectureARM64},
+		{instanceType: "c6gn.large", res: architectureARM64},
+		{instanceType: "t4g.micro", res: architectureARM64},
+		{instanceType: "im4gn.large", res: architectureARM64},
+		{instanceType: "g5g.xlarge", res: architectureARM64},
+	}
+
+	for _, tc := range tests {
+		res := instanceTypeArchitecture(tc.instanceType)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.instanceType))
+	}
+}
+
+func TestInstanceCheckArchitecture(t *testing.T) {
+	tests := []struct {
+		desc         string
+		instanceType string
+		images       []*ec2.Image
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:         "x86_64 image on x86_64 instance type",
+			instanceType: "m3.large",
+			images:       []*ec2.Image{{Architecture: aws.String("x86_64")}},
+		},
+		{
+			desc:         "arm64 image on Graviton instance type",
+			instanceType: "m6g.large",
+			images:       []*ec2.Image{{Architecture: aws.String("arm64")}},
+		},
+		{
+			desc:         "arm64 image on x86_64 instance type",
+			instanceType: "m3.large",
+			images:       []*ec2.Image{{Architecture: aws.String("arm64")}},
+			errorMatcher: IsArchitectureMismatch,
+		},
+		{
+			desc:         "x86_64 image on Graviton instance type",
+			instanceType: "t4g.micro",
+			images:       []*ec2.Image{{Architecture: aws.String("x86_64")}},
+			errorMatcher: IsArchitectureMismatch,
+		},
+		{
+			desc:         "image doesn't exist",
+			instanceType: "m3.large",
+			images:       nil,
+			errorMatcher: IsNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		images := tc.images
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = images
+			return nil
+		})
+
+		i := &Instance{
+			ImageID:      "ami-d60ad6b9",
+			InstanceType: tc.instanceType,
+			AWSEntity:    AWSEntity{Clients: clients},
+		}
+
+		err := i.checkArchitecture()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+	}
+}
+
+func TestInstanceConsoleOutput(t *testing.T) {
+	tests := []struct {
+		desc     string
+		output   *string
+		res      string
+		hasError bool
+	}{
+		{
+			desc:   "output is decoded",
+			output: aws.String(base64.StdEncoding.EncodeToString([]byte("Booting CoreOS...\nlogin:"))),
+			res:    "Booting CoreOS...\nlogin:",
+		},
+		{
+			desc:   "no output yet",
+			output: nil,
+			res:    "",
+		},
+		{
+			desc:     "malformed output",
+			output:   aws.String("not base64!"),
+			hasError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("GetConsoleOutput", func(params, output interface{}) error {
+			output.(*ec2.GetConsoleOutputOutput).Output = tc.output
+			return nil
+		})
+
+		res, err := InstanceConsoleOutput(clients, "i-123")
+		if tc.hasError {
+			assert.NotNil(t, err, fmt.Sprintf("[%s] Expected an error", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+		params := fake.paramsOf("GetConsoleOutput")
+		assert.Equal(t, "i-123", *params[0].(*ec2.GetConsoleOutputInput).InstanceId, fmt.Sprintf("[%s] Wrong instance", tc.desc))
+	}
+}
+
+func TestTerminateInstances(t *testing.T) {
+	describeInstances := func(tags map[string][]*ec2.Tag) fakeResponse {
+		return func(params, output interface{}) error {
+			var instances []*ec2.Instance
+			for _, id := range params.(*ec2.DescribeInstancesInput).InstanceIds {
+				if instanceTags, ok := tags[*id]; ok {
+					instances = append(instances, &ec2.Instance{
+						InstanceId: id,
+						Tags:       instanceTags,
+					})
+				}
+			}
+			output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{{Instances: instances}}
+			return nil
+		}
+	}
+	terminated := func(params, output interface{}) error {
+		var instances []*ec2.Instance
+		for _, id := range params.(*ec2.DescribeInstancesInput).InstanceIds {
+			instances = append(instances, &ec2.Instance{
+				InstanceId: id,
+				State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+			})
+		}
+		output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{{Instances: instances}}
+		return nil
+	}
+	managedTags := []*ec2.Tag{
+		{Key: aws.String(tagKeyName), Value: aws.String("foo-worker-0")},
+		{Key: aws.String(tagKeyCluster), Value: aws.String("foo")},
+		{Key: aws.String(tagKeyOperator), Value: aws.String("operator-a")},
+	}
+
+	tests := []struct {
+		desc          string
+		ids           []string
+		tags          map[string][]*ec2.Tag
+		protected     map[string]bool
+		resOperations []string
+		errorMatcher  func(error) bool
+	}{
+		{
+			desc: "managed instances are deregistered before being terminated",
+			ids:  []string{"i-1", "i-2"},
+			tags: map[string][]*ec2.Tag{
+				"i-1": managedTags,
+				"i-2": managedTags,
+			},
+			resOperations: []string{
+				"DescribeInstances",
+				"DescribeLoadBalancers",
+				"DescribeTags",
+				"DescribeLoadBalancers",
+				"DescribeLoadBalancers",
+				"DeregisterInstancesFromLoadBalancer",
+				"ModifyInstanceAttribute",
+				"ModifyInstanceAttribute",
+				"TerminateInstances",
+				"DescribeInstances",
+			},
+		},
+		{
+			desc: "protected instances are terminated",
+			ids:  []string{"i-1", "i-2"},
+			tags: map[string][]*ec2.Tag{
+				"i-1": managedTags,
+				"i-2": managedTags,
+			},
+			protected: map[string]bool{"i-1": true},
+			resOperations: []string{
+				"DescribeInstances",
+				"DescribeLoadBalancers",
+				"DescribeTags",
+				"DescribeLoadBalancers",
+				"DescribeLoadBalancers",
+				"DeregisterInstancesFromLoadBalancer",
+				"ModifyInstanceAttribute",
+				"ModifyInstanceAttribute",
+				"TerminateInstances",
+				"DescribeInstances",
+			},
+		},
+		{
+			desc: "instance without cluster tag",
+			ids:  []string{"i-1", "i-2"},
+			tags: map[string][]*ec2.Tag{
+				"i-1": managedTags,
+				"i-2": {{Key: aws.String(tagKeyName), Value: aws.String("bastion")}},
+			},
+			resOperations: []string{"DescribeInstances"},
+			errorMatcher:  IsUnmanagedInstance,
+		},
+		{
+			desc: "instance of another operator",
+			ids:  []string{"i-1"},
+			tags: map[string][]*ec2.Tag{
+				"i-1": {
+					{Key: aws.String(tagKeyCluster), Value: aws.String("foo")},
+					{Key: aws.String(tagKeyOperator), Value: aws.String("operator-b")},
+				},
+			},
+			resOperations: []string{"DescribeInstances"},
+			errorMatcher:  IsUnmanagedInstance,
+		},
+		{
+			desc:          "missing instance",
+			ids:           []string{"i-1"},
+			tags:          map[string][]*ec2.Tag{},
+			resOperations: []string{"DescribeInstances"},
+			errorMatcher:  IsNotFound,
+		},
+		{
+			desc:         "no instances",
+			ids:          nil,
+			errorMatcher: IsAttributeEmpty,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInstances", describeInstances(tc.tags), terminated)
+		fake.on("DescribeLoadBalancers",
+			func(params, output interface{}) error {
+				output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+					{LoadBalancerName: aws.String("foo-api")},
+					{LoadBalancerName: aws.String("foo-ingress")},
+				}
+				return nil
+			},
+			func(params, output interface{}) error {
+				output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+					{
+						LoadBalancerName: aws.String("foo-api"),
+						Instances:        []*elb.Instance{{InstanceId: aws.String("i-0")}},
+					},
+				}
+				return nil
+			},
+			func(params, output interface{}) error {
+				output.(*elb.DescribeLoadBalancersOutput).LoadBalancerDescriptions = []*elb.LoadBalancerDescription{
+					{
+						LoadBalancerName: aws.String("foo-ingress"),
+						Instances:        []*elb.Instance{{InstanceId: aws.String("i-0")}, {InstanceId: aws.String("i-1")}, {InstanceId: aws.String("i-2")}},
+					},
+				}
+				return nil
+			},
+		)
+		fake.on("DescribeTags", func(params, output interface{}) error {
+			output.(*elb.DescribeTagsOutput).TagDescriptions = []*elb.TagDescription{
+				{
+					LoadBalancerName: aws.String("foo-api"),
+					Tags:             []*elb.Tag{{Key: aws.String(tagKeyCluster), Value: aws.String("foo")}, {Key: aws.String(tagKeyOperator), Value: aws.String("operator-a")}},
+				},
+				{
+					LoadBalancerName: aws.String("foo-ingress"),
+					Tags:             []*elb.Tag{{Key: aws.String(tagKeyCluster), Value: aws.String("foo")}, {Key: aws.String(tagKeyOperator), Value: aws.String("operator-a")}},
+				},
+			}
+			return nil
+		})
+		protected := map[string]bool{}
+		for id, p := range tc.protected {
+			protected[id] = p
+		}
+		fake.on("ModifyInstanceAttribute", func(params, output interface{}) error {
+			input := params.(*ec2.ModifyInstanceAttributeInput)
+			if input.DisableApiTermination != nil {
+				protected[*input.InstanceId] = aws.BoolValue(input.DisableApiTermination.Value)
+			}
+			return nil
+		})
+		fake.on("TerminateInstances", func(params, output interface{}) error {
+			for _, id := range params.(*ec2.TerminateInstancesInput).InstanceIds {
+				if protected[*id] {
+					return awserr.New("OperationNotPermitted", fmt.Sprintf("The instance '%s' may not be terminated.", *id), nil)
+				}
+			}
+			return nil
+		})
+
+		err := TerminateInstances(TerminateInstancesInput{
+			Clients:    clients,
+			IDs:        tc.ids,
+			OperatorID: "operator-a",
+		})
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		deregistered := fake.paramsOf("DeregisterInstancesFromLoadBalancer")
+		assert.Len(t, deregistered, 1, fmt.Sprintf("[%s] Only the ELB with registered instances must be called", tc.desc))
+		input := deregistered[0].(*elb.DeregisterInstancesFromLoadBalancerInput)
+		assert.Equal(t, "foo-ingress", aws.StringValue(input.LoadBalancerName), fmt.Sprintf("[%s] Wrong ELB", tc.desc))
+		assert.Equal(t, []*elb.Instance{{InstanceId: aws.String("i-1")}, {InstanceId: aws.String("i-2")}}, input.Instances, fmt.Sprintf("[%s] Wrong deregistered instances", tc.desc))
+	}
+}
+
+func TestInstanceCreateOrFailEBSOptimized(t *testing.T) {
+	tests := []struct {
+		desc            string
+		instanceType    string
+		ebsOptimized    *bool
+		resEBSOptimized *bool
+		errorMatcher    func(error) bool
+	}{
+		{
+			desc:            "left to the instance type when not set",
+			instanceType:    "m3.xlarge",
+			ebsOptimized:    nil,
+			resEBSOptimized: nil,
+		},
+		{
+			desc:            "requested for types where it is optional",
+			instanceType:    "m3.xlarge",
+			ebsOptimized:    aws.Bool(true),
+			resEBSOptimized: aws.Bool(true),
+		},
+		{
+			desc:            "turned off for types where it is optional",
+			instanceType:    "r3.2xlarge",
+			ebsOptimized:    aws.Bool(false),
+			resEBSOptimized: aws.Bool(false),
+		},
+		{
+			desc:            "ignored for types which are always EBS-optimized",
+			instanceType:    "m5.large",
+			ebsOptimized:    aws.Bool(true),
+			resEBSOptimized: nil,
+		},
+		{
+			desc:         "rejected for types which can't be EBS-optimized",
+			instanceType: "t2.medium",
+			ebsOptimized: aws.Bool(true),
+			errorMatcher: IsInvalidEBSOptimized,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("RunInstances", func(params, output interface{}) error {
+			output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+			return nil
+		})
+
+		i := &Instance{
+			ImageID:      "ami-d60ad6b9",
+			InstanceType: tc.instanceType,
+			EBSOptimized: tc.ebsOptimized,
+			AWSEntity:    AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Empty(t, fake.paramsOf("RunInstances"), fmt.Sprintf("[%s] Unexpected instance", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		params := fake.paramsOf("RunInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one instance", tc.desc))
+		assert.Equal(t, tc.resEBSOptimized, params[0].(*ec2.RunInstancesInput).EbsOptimized, fmt.Sprintf("[%s] Wrong EBS optimization", tc.desc))
+	}
+}
+
+func TestInstanceCreateOrFailTags(t *testing.T) {
+	tests := []struct {
+		desc       string
+		operatorID string
+		tags       map[string]string
+		res        []string
+	}{
+		{
+			desc: "name and cluster tags",
+			res:  []string{"Name=foo-master-0", "Cluster=foo"},
+		},
+		{
+			desc:       "operator and extra tags",
+			operatorID: "prod",
+			tags: map[string]string{
+				"owner":       "team-a",
+				tagKeyCluster: "bar",
+			},
+			res: []string{"Name=foo-master-0", "Cluster=foo", "OperatorID=prod", "owner=team-a"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("RunInstances", func(params, output interface{}) error {
+			output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+			return nil
+		})
+
+		i := &Instance{
+			Name:         "foo-master-0",
+			ClusterName:  "foo",
+			ImageID:      "ami-d60ad6b9",
+			InstanceType: "m4.large",
+			AWSEntity: AWSEntity{
+				Clients:    clients,
+				OperatorID: tc.operatorID,
+				Tags:       tc.tags,
+			},
+		}
+
+		err := i.CreateOrFail()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, "i-123", i.ID(), fmt.Sprintf("[%s] Wrong instance ID", tc.desc))
+
+		// The instance is tagged on launch, not afterwards.
+		assert.Empty(t, fake.paramsOf("CreateTags"), fmt.Sprintf("[%s] Unexpected tagging after launch", tc.desc))
+
+		params := fake.paramsOf("RunInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one instance", tc.desc))
+		specs := params[0].(*ec2.RunInstancesInput).TagSpecifications
+		assert.Len(t, specs, 1, fmt.Sprintf("[%s] Expected one tag specification", tc.desc))
+		assert.Equal(t, ec2.ResourceTypeInstance, *specs[0].ResourceType, fmt.Sprintf("[%s] Wrong tagged resource type", tc.desc))
+
+		var tags []string
+		for _, tag := range specs[0].Tags {
+			tags = append(tags, fmt.Sprintf("%s=%s", *tag.Key, *tag.Value))
+		}
+		assert.Equal(t, tc.res, tags, fmt.Sprintf("[%s] Wrong tags", tc.desc))
+	}
+}
+
+func TestInstanceCreateOrFailTerminationProtection(t *testing.T) {
+	tests := []struct {
+		desc                  string
+		disableAPITermination bool
+	}{
+		{
+			desc:                  "protected instance",
+			disableAPITermination: true,
+		},
+		{
+			desc:                  "unprotected instance",
+			disableAPITermination: false,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("RunInstances", func(params, output interface{}) error {
+			output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+			return nil
+		})
+
+		i := &Instance{
+			ImageID:               "ami-d60ad6b9",
+			InstanceType:          "m4.large",
+			DisableAPITermination: tc.disableAPITermination,
+			AWSEntity:             AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		params := fake.paramsOf("RunInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one instance", tc.desc))
+		assert.Equal(t, tc.disableAPITermination, aws.BoolValue(params[0].(*ec2.RunInstancesInput).DisableApiTermination), fmt.Sprintf("[%s] Wrong termination protection", tc.desc))
+	}
+}
+
+func TestInstanceCreateOrFailFixedPrivateIPAddress(t *testing.T) {
+	tests := []struct {
+		desc                  string
+		fixedPrivateIPAddress string
+		subnetID              string
+		spot                  bool
+		resPrivateIPAddress   *string
+		errorMatcher          func(error) bool
+	}{
+		{
+			desc:                "address assigned by AWS",
+			subnetID:            "subnet-123",
+			resPrivateIPAddress: nil,
+		},
+		{
+			desc:                  "address within the subnet",
+			fixedPrivateIPAddress: "10.1.2.10",
+			subnetID:              "subnet-123",
+			resPrivateIPAddress:   aws.String("10.1.2.10"),
+		},
+		{
+			desc:                  "address outside of the subnet",
+			fixedPrivateIPAddress: "10.1.3.10",
+			subnetID:              "subnet-123",
+			errorMatcher:          IsInvalidPrivateIPAddress,
+		},
+		{
+			desc:                  "address without subnet",
+			fixedPrivateIPAddress: "10.1.2.10",
+			errorMatcher:          IsInvalidPrivateIPAddress,
+		},
+		{
+			desc:                  "malformed address",
+			fixedPrivateIPAddress: "10.1.2",
+			subnetID:              "subnet-123",
+			errorMatcher:          IsInvalidPrivateIPAddress,
+		},
+		{
+			desc:                  "spot instance",
+			fixedPrivateIPAddress: "10.1.2.10",
+			subnetID:              "subnet-123",
+			spot:                  true,
+			errorMatcher:          IsInvalidPrivateIPAddress,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("DescribeSubnets", func(params, output interface{}) error {
+			output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{{CidrBlock: aws.String("10.1.2.0/24")}}
+			return nil
+		})
+		fake.on("RunInstances", func(params, output interface{}) error {
+			output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+			return nil
+		})
+
+		i := &Instance{
+			ImageID:               "ami-d60ad6b9",
+			InstanceType:          "m4.large",
+			FixedPrivateIPAddress: tc.fixedPrivateIPAddress,
+			Spot:                  tc.spot,
+			SpotMaxPrice:          "0.05",
+			SubnetID:              tc.subnetID,
+			AWSEntity:             AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Empty(t, fake.paramsOf("RunInstances"), fmt.Sprintf("[%s] No instance must be launched", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		params := fake.paramsOf("RunInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one instance", tc.desc))
+		input := params[0].(*ec2.RunInstancesInput)
+		assert.Equal(t, tc.resPrivateIPAddress, input.PrivateIpAddress, fmt.Sprintf("[%s] Wrong private IP address", tc.desc))
+		assert.Equal(t, tc.subnetID, aws.StringValue(input.SubnetId), fmt.Sprintf("[%s] Wrong subnet", tc.desc))
+	}
+}
+
+func TestInstanceCreateOrFailHost(t *testing.T) {
+	host := func(state, zone string, capacity int64) *ec2.Host {
+		return &ec2.Host{
+			HostId:           aws.String("h-123"),
+			State:            aws.String(state),
+			AvailabilityZone: aws.String(zone),
+			AvailableCapacity: &ec2.AvailableCapacity{
+				AvailableInstanceCapacity: []*ec2.InstanceCapacity{
+					{
+						InstanceType:      aws.String("m4.large"),
+						AvailableCapacity: aws.Int64(capacity),
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		desc         string
+		hostID       string
+		hosts        []*ec2.Host
+		spot         bool
+		resPlacement *ec2.Placement
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:   "shared tenancy",
+			hostID: "",
+			resPlacement: &ec2.Placement{
+				AvailabilityZone: aws.String("eu-central-1a"),
+			},
+		},
+		{
+			desc:   "host with capacity",
+			hostID: "h-123",
+			hosts:  []*ec2.Host{host("available", "eu-central-1a", 1)},
+			resPlacement: &ec2.Placement{
+				AvailabilityZone: aws.String("eu-central-1a"),
+				HostId:           aws.String("h-123"),
+				Tenancy:          aws.String("host"),
+			},
+		},
+		{
+			desc:         "host without capacity",
+			hostID:       "h-123",
+			hosts:        []*ec2.Host{host("available", "eu-central-1a", 0)},
+			errorMatcher: IsInvalidHost,
+		},
+		{
+			desc:         "released host",
+			hostID:       "h-123",
+			hosts:        []*ec2.Host{host("released", "eu-central-1a", 1)},
+			errorMatcher: IsInvalidHost,
+		},
+		{
+			desc:         "host in another availability zone",
+			hostID:       "h-123",
+			hosts:        []*ec2.Host{host("available", "eu-central-1b", 1)},
+			errorMatcher: IsInvalidHost,
+		},
+		{
+			desc:         "missing host",
+			hostID:       "h-123",
+			hosts:        nil,
+			errorMatcher: IsNotFound,
+		},
+		{
+			desc:         "spot instance",
+			hostID:       "h-123",
+			hosts:        []*ec2.Host{host("available", "eu-central-1a", 1)},
+			spot:         true,
+			errorMatcher: IsInvalidHost,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("DescribeHosts", func(params, output interface{}) error {
+			output.(*ec2.DescribeHostsOutput).Hosts = tc.hosts
+			return nil
+		})
+		fake.on("RunInstances", func(params, output interface{}) error {
+			output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+			return nil
+		})
+
+		i := &Instance{
+			ImageID:      "ami-d60ad6b9",
+			InstanceType: "m4.large",
+			HostID:       tc.hostID,
+			PlacementAZ:  "eu-central-1a",
+			Spot:         tc.spot,
+			SpotMaxPrice: "0.05",
+			AWSEntity:    AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Empty(t, fake.paramsOf("RunInstances"), fmt.Sprintf("[%s] No instance must be launched", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		params := fake.paramsOf("RunInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one instance", tc.desc))
+		input := params[0].(*ec2.RunInstancesInput)
+		assert.Equal(t, tc.resPlacement, input.Placement, fmt.Sprintf("[%s] Wrong placement", tc.desc))
+	}
+}
+
+func TestInstanceDelete(t *testing.T) {
+	inState := func(state EC2StateCode) fakeResponse {
+		return func(params, output interface{}) error {
+			output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+				{
+					Instances: []*ec2.Instance{
+						{
+							InstanceId: aws.String("i-123"),
+							State:      &ec2.InstanceState{Code: aws.Int64(int64(state))},
+						},
+					},
+				},
+			}
+			return nil
+		}
+	}
+	terminated := func(params, output interface{}) error {
+		output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+			{
+				Instances: []*ec2.Instance{
+					{
+						InstanceId: aws.String("i-123"),
+						State:      &ec2.InstanceState{Code: aws.Int64(int64(EC2TerminatedState)), Name: aws.String(ec2.InstanceStateNameTerminated)},
+					},
+				},
+			},
+		}
+		return nil
+	}
+
+	tests := []struct {
+		desc          string
+		state         EC2StateCode
+		modifyErr     error
+		resOperations []string
+		resErr        bool
+	}{
+		{
+			desc:  "protection is lifted before terminating",
+			state: EC2RunningState,
+			resOperations: []string{
+				"DescribeInstances",
+				"ModifyInstanceAttribute",
+				"TerminateInstances",
+				"DescribeInstances",
+			},
+		},
+		{
+			desc:  "stopped instance is terminated",
+			state: EC2StoppedState,
+			resOperations: []string{
+				"DescribeInstances",
+				"ModifyInstanceAttribute",
+				"TerminateInstances",
+				"DescribeInstances",
+			},
+		},
+		{
+			desc:      "instance is kept when the protection can't be lifted",
+			state:     EC2RunningState,
+			modifyErr: fmt.Errorf("unauthorized"),
+			resOperations: []string{
+				"DescribeInstances",
+				"ModifyInstanceAttribute",
+			},
+			resErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInstances", inState(tc.state), terminated)
+		fake.on("ModifyInstanceAttribute", func(params, output interface{}) error {
+			return tc.modifyErr
+		})
+
+		i := &Instance{
+			Name:        "foo-master-0",
+			ClusterName: "foo",
+			AWSEntity:   AWSEntity{Clients: clients},
+		}
+
+		err := i.Delete()
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		if tc.resErr {
+			assert.NotNil(t, err, fmt.Sprintf("[%s] Expected an error", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		params := fake.paramsOf("ModifyInstanceAttribute")[0].(*ec2.ModifyInstanceAttributeInput)
+		assert.Equal(t, "i-123", aws.StringValue(params.InstanceId), fmt.Sprintf("[%s] Wrong instance", tc.desc))
+		assert.False(t, aws.BoolValue(params.DisableApiTermination.Value), fmt.Sprintf("[%s] Protection not lifted", tc.desc))
+	}
+}
+
+func TestEBSOptimizable(t *testing.T) {
+	tests := []struct {
+		instanceType string
+		res          bool
+	}{
+		{instanceType: "m3.xlarge", res: true},
+		{instanceType: "m3.medium", res: false},
+		{instanceType: "t2.large", res: false},
+		{instanceType: "m4.large", res: true},
+		{instanceType: "t3.medium", res: true},
+		{instanceType: "m6g.large", res: true},
+	}
+
+	for _, tc := range tests {
+		res := EBSOptimizable(tc.instanceType)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.instanceType))
+	}
+}
+
+func TestInstanceWaitUntilRunning(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeInstances", func(params, output interface{}) error {
+		output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+			{
+				Instances: []*ec2.Instance{
+					{
+						InstanceId: aws.String("i-123"),
+						State:      &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+					},
+				},
+			},
+		}
+		return nil
+	})
+
+	i := Instance{
+		Name:      "foo-master-0",
+		id:        "i-123",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err := i.WaitUntilRunning(time.Minute)
+	assert.Nil(t, err, "Unexpected error")
+	params := fake.paramsOf("DescribeInstances")
+	assert.Len(t, params, 1, "Expected a single check of a running instance")
+	assert.Equal(t, []*string{aws.String("i-123")}, params[0].(*ec2.DescribeInstancesInput).InstanceIds, "Wrong instance waited for")
+}
+
+func TestInstanceCreateOrFailBlockDeviceMappings(t *testing.T) {
+	tests := []struct {
+		desc         string
+		devices      []BlockDevice
+		resMappings  []*ec2.BlockDeviceMapping
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:        "image volumes only",
+			devices:     nil,
+			resMappings: nil,
+		},
+		{
+			desc: "resized root device",
+			devices: []BlockDevice{
+				{DeviceName: "/dev/xvda", VolumeSize: 50},
+			},
+			resMappings: []*ec2.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvda"),
+					Ebs:        &ec2.EbsBlockDevice{VolumeSize: aws.Int64(50)},
+				},
+			},
+		},
+		{
+			desc: "encrypted etcd volume",
+			devices: []BlockDevice{
+				{DeviceName: "/dev/xvdb", VolumeSize: 20, VolumeType: "io1", DeleteOnTermination: aws.Bool(true), Encrypted: true},
+			},
+			resMappings: []*ec2.BlockDeviceMapping{
+				{
+					DeviceName: aws.String("/dev/xvdb"),
+					Ebs: &ec2.EbsBlockDevice{
+						DeleteOnTermination: aws.Bool(true),
+						Encrypted:           aws.Bool(true),
+						VolumeSize:          aws.Int64(20),
+						VolumeType:          aws.String("io1"),
+					},
+				},
+			},
+		},
+		{
+			desc: "device without name",
+			devices: []BlockDevice{
+				{VolumeSize: 20},
+			},
+			errorMatcher: IsInvalidBlockDevice,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("RunInstances", func(params, output interface{}) error {
+			output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+			return nil
+		})
+
+		i := &Instance{
+			ImageID:             "ami-d60ad6b9",
+			InstanceType:        "m4.large",
+			BlockDeviceMappings: tc.devices,
+			AWSEntity:           AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Empty(t, fake.paramsOf("RunInstances"), fmt.Sprintf("[%s] Unexpected instance", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		params := fake.paramsOf("RunInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one instance", tc.desc))
+		assert.Equal(t, tc.resMappings, params[0].(*ec2.RunInstancesInput).BlockDeviceMappings, fmt.Sprintf("[%s] Wrong block device mappings", tc.desc))
+	}
+}
+
+func TestInstanceCreateOrFailSpot(t *testing.T) {
+	spotRequestPollInterval = 0
+
+	open := func(params, output interface{}) error {
+		output.(*ec2.DescribeSpotInstanceRequestsOutput).SpotInstanceRequests = []*ec2.SpotInstanceRequest{
+			{
+				SpotInstanceRequestId: aws.String("sir-123"),
+				State:                 aws.String(ec2.SpotInstanceStateOpen),
+			},
+		}
+		return nil
+	}
+	fulfilled := func(params, output interface{}) error {
+		output.(*ec2.DescribeSpotInstanceRequestsOutput).SpotInstanceRequests = []*ec2.SpotInstanceRequest{
+			{
+				InstanceId:            aws.String("i-spot"),
+				SpotInstanceRequestId: aws.String("sir-123"),
+				State:                 aws.String(ec2.SpotInstanceStateActive),
+			},
+		}
+		return nil
+	}
+	failed := func(params, output interface{}) error {
+		output.(*ec2.DescribeSpotInstanceRequestsOutput).SpotInstanceRequests = []*ec2.SpotInstanceRequest{
+			{
+				SpotInstanceRequestId: aws.String("sir-123"),
+				State:                 aws.String(ec2.SpotInstanceStateFailed),
+				Status:                &ec2.SpotInstanceStatus{Message: aws.String("price too low")},
+			},
+		}
+		return nil
+	}
+
+	tests := []struct {
+		desc          string
+		spotMaxPrice  string
+		responses     []fakeResponse
+		resCancelled  bool
+		resInstanceID string
+		errorMatcher  func(error) bool
+	}{
+		{
+			desc:          "request fulfilled after pending",
+			spotMaxPrice:  "0.05",
+			responses:     []fakeResponse{open, open, fulfilled},
+			resInstanceID: "i-spot",
+		},
+		{
+			desc:         "request pending for too long is cancelled",
+			spotMaxPrice: "0.05",
+			responses:    []fakeResponse{open},
+			resCancelled: true,
+			errorMatcher: IsSpotRequestFailed,
+		},
+		{
+			desc:         "failed request",
+			spotMaxPrice: "0.05",
+			responses:    []fakeResponse{failed},
+			errorMatcher: IsSpotRequestFailed,
+		},
+		{
+			desc:         "missing maximum price",
+			spotMaxPrice: "",
+			responses:    []fakeResponse{fulfilled},
+			errorMatcher: IsInvalidSpotPrice,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("RequestSpotInstances", func(params, output interface{}) error {
+			output.(*ec2.RequestSpotInstancesOutput).SpotInstanceRequests = []*ec2.SpotInstanceRequest{
+				{SpotInstanceRequestId: aws.String("sir-123")},
+			}
+			return nil
+		})
+		fake.on("DescribeSpotInstanceRequests", tc.responses...)
+
+		i := &Instance{
+			Name:         "foo-worker-0",
+			ImageID:      "ami-d60ad6b9",
+			InstanceType: "m4.large",
+			Spot:         true,
+			SpotMaxPrice: tc.spotMaxPrice,
+			AWSEntity:    AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		assert.Empty(t, fake.paramsOf("RunInstances"), fmt.Sprintf("[%s] Unexpected on-demand instance", tc.desc))
+		assert.Equal(t, tc.resCancelled, len(fake.paramsOf("CancelSpotInstanceRequests")) > 0, fmt.Sprintf("[%s] Unexpected cancellation", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Empty(t, fake.paramsOf("CreateTags"), fmt.Sprintf("[%s] Unexpected tags", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resInstanceID, i.ID(), fmt.Sprintf("[%s] Wrong instance ID", tc.desc))
+		params := fake.paramsOf("RequestSpotInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one spot request", tc.desc))
+		assert.Equal(t, tc.spotMaxPrice, *params[0].(*ec2.RequestSpotInstancesInput).SpotPrice, fmt.Sprintf("[%s] Wrong spot price", tc.desc))
+		tags := fake.paramsOf("CreateTags")
+		assert.Len(t, tags, 1, fmt.Sprintf("[%s] Expected the instance to be tagged", tc.desc))
+		assert.Equal(t, []*string{aws.String("i-spot")}, tags[0].(*ec2.CreateTagsInput).Resources, fmt.Sprintf("[%s] Wrong instance tagged", tc.desc))
+	}
+}
+
+func TestInstanceReplaceInstanceProfile(t *testing.T) {
+	profile := func(roles ...string) fakeResponse {
+		return func(params, output interface{}) error {
+			instanceProfile := &iam.InstanceProfile{
+				Arn:               aws.String("arn:aws:iam::123456789012:instance-profile/abc12-EC2-K8S-Role"),
+				InstanceProfileId: aws.String("AIPA-new"),
+			}
+			for _, role := range roles {
+				instanceProfile.Roles = append(instanceProfile.Roles, &iam.Role{RoleName: aws.String(role)})
+			}
+			output.(*iam.GetInstanceProfileOutput).InstanceProfile = instanceProfile
+			return nil
+		}
+	}
+	associatedWith := func(profileIDs ...string) fakeResponse {
+		return func(params, output interface{}) error {
+			for _, id := range profileIDs {
+				output.(*ec2.DescribeIamInstanceProfileAssociationsOutput).IamInstanceProfileAssociations = append(
+					output.(*ec2.DescribeIamInstanceProfileAssociationsOutput).IamInstanceProfileAssociations,
+					&ec2.IamInstanceProfileAssociation{
+						AssociationId:      aws.String("iip-assoc-1"),
+						IamInstanceProfile: &ec2.IamInstanceProfile{Id: aws.String(id)},
+						InstanceId:         aws.String("i-123"),
+					},
+				)
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		desc                 string
+		getProfileResponse   fakeResponse
+		associationsResponse fakeResponse
+		res                  bool
+		resOperations        []string
+		errorMatcher         func(error) bool
+	}{
+		{
+			desc:                 "association is replaced on profile change",
+			getProfileResponse:   profile("abc12-EC2-K8S-Role"),
+			associationsResponse: associatedWith("AIPA-old"),
+			res:                  true,
+			resOperations:        []string{"GetInstanceProfile", "DescribeIamInstanceProfileAssociations", "ReplaceIamInstanceProfileAssociation"},
+		},
+		{
+			desc:                 "instance without profile is associated",
+			getProfileResponse:   profile("abc12-EC2-K8S-Role"),
+			associationsResponse: associatedWith(),
+			res:                  true,
+			resOperations:        []string{"GetInstanceProfile", "DescribeIamInstanceProfileAssociations", "AssociateIamInstanceProfile"},
+		},
+		{
+			desc:                 "association with the profile is kept",
+			getProfileResponse:   profile("abc12-EC2-K8S-Role"),
+			associationsResponse: associatedWith("AIPA-new"),
+			res:                  false,
+			resOperations:        []string{"GetInstanceProfile", "DescribeIamInstanceProfileAssociations"},
+		},
+		{
+			desc:                 "profile without role",
+			getProfileResponse:   profile(),
+			associationsResponse: associatedWith("AIPA-old"),
+			resOperations:        []string{"GetInstanceProfile"},
+			errorMatcher:         IsInstanceProfileNotReady,
+		},
+		{
+			desc: "missing profile",
+			getProfileResponse: func(params, output interface{}) error {
+				return awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+			},
+			associationsResponse: associatedWith("AIPA-old"),
+			resOperations:        []string{"GetInstanceProfile"},
+			errorMatcher:         IsNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("GetInstanceProfile", tc.getProfileResponse)
+		fake.on("DescribeIamInstanceProfileAssociations", tc.associationsResponse)
+
+		i := &Instance{
+			Name:      "foo-master-0",
+			id:        "i-123",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		replaced, err := i.ReplaceInstanceProfile("abc12-EC2-K8S-Role")
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, replaced, fmt.Sprintf("[%s] Wrong replacement", tc.desc))
+
+		for _, params := range fake.paramsOf("ReplaceIamInstanceProfileAssociation") {
+			input := params.(*ec2.ReplaceIamInstanceProfileAssociationInput)
+			assert.Equal(t, "iip-assoc-1", aws.StringValue(input.AssociationId), fmt.Sprintf("[%s] Wrong association replaced", tc.desc))
+			assert.Equal(t, "arn:aws:iam::123456789012:instance-profile/abc12-EC2-K8S-Role", aws.StringValue(input.IamInstanceProfile.Arn), fmt.Sprintf("[%s] Wrong profile", tc.desc))
+		}
+		for _, params := range fake.paramsOf("AssociateIamInstanceProfile") {
+			input := params.(*ec2.AssociateIamInstanceProfileInput)
+			assert.Equal(t, "i-123", aws.StringValue(input.InstanceId), fmt.Sprintf("[%s] Wrong instance associated", tc.desc))
+		}
+	}
+}
+
+func TestInstanceCreateOrFailRetries(t *testing.T) {
+	newRunInstancesBackOff = func() backoff.BackOff {
+		return &backoff.ZeroBackOff{}
+	}
+
+	profileNotFound := func(params, output interface{}) error {
+		return awserr.New("InvalidParameterValue", "Value (foo-EC2-K8S-Role) for parameter iamInstanceProfile.name is invalid. Invalid IAM Instance Profile name", nil)
+	}
+	invalidAMI := func(params, output interface{}) error {
+		return awserr.New("InvalidAMIID.NotFound", "The image id '[ami-d60ad6b9]' does not exist", nil)
+	}
+	launched := func(params, output interface{}) error {
+		output.(*ec2.Reservation).Instances = []*ec2.Instance{{InstanceId: aws.String("i-123")}}
+		return nil
+	}
+
+	tests := []struct {
+		desc         string
+		responses    []fakeResponse
+		resCalls     int
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:      "launched once the instance profile propagated",
+			responses: []fakeResponse{profileNotFound, profileNotFound, launched},
+			resCalls:  3,
+		},
+		{
+			desc:         "other errors fail right away",
+			responses:    []fakeResponse{invalidAMI, launched},
+			resCalls:     1,
+			errorMatcher: func(err error) bool { return err != nil && strings.Contains(err.Error(), "InvalidAMIID.NotFound") },
+		},
+		{
+			desc:         "instance profile never propagates",
+			responses:    []fakeResponse{profileNotFound},
+			resCalls:     runInstancesRetries + 1,
+			errorMatcher: isInstanceProfileNotFound,
+		},
+	}
+
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeImages", func(params, output interface{}) error {
+			output.(*ec2.DescribeImagesOutput).Images = []*ec2.Image{{Architecture: aws.String("x86_64")}}
+			return nil
+		})
+		fake.on("RunInstances", tc.responses...)
+
+		i := &Instance{
+			ImageID:                "ami-d60ad6b9",
+			InstanceType:           "m4.large",
+			IamInstanceProfileName: "foo-EC2-K8S-Role",
+			Logger:                 logger,
+			AWSEntity:              AWSEntity{Clients: clients},
+		}
+
+		err := i.CreateOrFail()
+		assert.Len(t, fake.paramsOf("RunInstances"), tc.resCalls, fmt.Sprintf("[%s] Wrong number of launches", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(errgo.Cause(err)), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, "i-123", i.ID(), fmt.Sprintf("[%s] Wrong instance ID", tc.desc))
+	}
+}
+
+func TestFindInstances(t *testing.T) {
+	instance := func(id string, state EC2StateCode) *ec2.Instance {
+		return &ec2.Instance{
+			InstanceId: aws.String(id),
+			State:      &ec2.InstanceState{Code: aws.Int64(int64(state))},
+			Tags:       []*ec2.Tag{{Key: aws.String(tagKeyName), Value: aws.String("foo-worker-" + id)}},
+		}
+	}
+	page := func(nextToken string, instances ...*ec2.Instance) fakeResponse {
+		return func(params, output interface{}) error {
+			output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{{Instances: instances}}
+			if nextToken != "" {
+				output.(*ec2.DescribeInstancesOutput).NextToken = aws.String(nextToken)
+			}
+			return nil
+		}
+	}
+
+	tests := []struct {
+		desc      string
+		states    []EC2StateCode
+		resIDs    []string
+		resStates []string
+	}{
+		{
+			desc:      "pending and running instances by default",
+			resIDs:    []string{"i-1", "i-3"},
+			resStates: []string{"instance-state-code=0", "instance-state-code=16"},
+		},
+		{
+			desc:      "running and stopped instances",
+			states:    []EC2StateCode{EC2RunningState, EC2StoppedState},
+			resIDs:    []string{"i-1", "i-2", "i-4"},
+			resStates: []string{"instance-state-code=16", "instance-state-code=80"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInstances",
+			page("page-2", instance("i-1", EC2RunningState), instance("i-2", EC2StoppedState)),
+			page("page-3", instance("i-3", EC2PendingState), instance("i-5", EC2TerminatedState)),
+			page("", instance("i-4", EC2StoppedState)),
+		)
+
+		instances, err := FindInstances(FindInstancesInput{Clients: clients, Pattern: "foo-worker", States: tc.states})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		var ids []string
+		for _, instance := range instances {
+			ids = append(ids, instance.ID())
+		}
+		assert.Equal(t, tc.resIDs, ids, fmt.Sprintf("[%s] Wrong instances found", tc.desc))
+
+		params := fake.paramsOf("DescribeInstances")
+		assert.Len(t, params, 3, fmt.Sprintf("[%s] Expected all pages to be read", tc.desc))
+		assert.Nil(t, params[0].(*ec2.DescribeInstancesInput).NextToken, fmt.Sprintf("[%s] Unexpected token of the first page", tc.desc))
+		assert.Equal(t, "page-3", aws.StringValue(params[2].(*ec2.DescribeInstancesInput).NextToken), fmt.Sprintf("[%s] Wrong token of the last page", tc.desc))
+
+		var stateFilters []string
+		for _, filter := range filterStrings(params[0].(*ec2.DescribeInstancesInput).Filters) {
+			if strings.HasPrefix(filter, "instance-state-code=") {
+				stateFilters = append(stateFilters, filter)
+			}
+		}
+		assert.Equal(t, tc.resStates, stateFilters, fmt.Sprintf("[%s] Wrong state filters", tc.desc))
+	}
+}
+
+func TestInstanceGet(t *testing.T) {
+	// Instance is fetched by the DNS and ELB code through this interface.
+	var _ resources.FetchableResource = &Instance{}
+
+	tests := []struct {
+		desc         string
+		response     fakeResponse
+		resID        string
+		resPrivateIP string
+		resPublicIP  string
+		resState     EC2StateCode
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "running instance",
+			response: func(params, output interface{}) error {
+				output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+					{
+						Instances: []*ec2.Instance{
+							{
+								InstanceId:       aws.String("i-old"),
+								State:            &ec2.InstanceState{Code: aws.Int64(int64(EC2TerminatedState))},
+								PrivateIpAddress: aws.String("10.0.0.4"),
+							},
+							{
+								InstanceId:       aws.String("i-123"),
+								State:            &ec2.InstanceState{Code: aws.Int64(int64(EC2RunningState))},
+								PrivateDnsName:   aws.String("ip-10-0-0-5.eu-central-1.compute.internal"),
+								PrivateIpAddress: aws.String("10.0.0.5"),
+								PublicIpAddress:  aws.String("52.1.2.3"),
+							},
+						},
+					},
+				}
+				return nil
+			},
+			resID:        "i-123",
+			resPrivateIP: "10.0.0.5",
+			resPublicIP:  "52.1.2.3",
+			resState:     EC2RunningState,
+		},
+		{
+			desc: "pending instance without public IP",
+			response: func(params, output interface{}) error {
+				output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+					{
+						Instances: []*ec2.Instance{
+							{
+								InstanceId:       aws.String("i-123"),
+								State:            &ec2.InstanceState{Code: aws.Int64(int64(EC2PendingState))},
+								PrivateIpAddress: aws.String("10.0.0.5"),
+							},
+						},
+					},
+				}
+				return nil
+			},
+			resID:        "i-123",
+			resPrivateIP: "10.0.0.5",
+			resState:     EC2PendingState,
+		},
+		{
+			desc: "only terminated instances",
+			response: func(params, output interface{}) error {
+				output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+					{
+						Instances: []*ec2.Instance{
+							{
+								InstanceId: aws.String("i-old"),
+								State:      &ec2.InstanceState{Code: aws.Int64(int64(EC2TerminatedState))},
+							},
+						},
+					},
+				}
+				return nil
+			},
+			errorMatcher: IsNotFound,
+		},
+		{
+			desc: "no instance",
+			response: func(params, output interface{}) error {
+				return nil
+			},
+			errorMatcher: IsNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInstances", tc.response)
+
+		i := &Instance{
+			Name:        "foo-worker-0",
+			ClusterName: "foo",
+			AWSEntity:   AWSEntity{Clients: clients},
+		}
+
+		err := i.Get()
+		params := fake.paramsOf("DescribeInstances")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single DescribeInstances call", tc.desc))
+		assert.Contains(t, filterStrings(params[0].(*ec2.DescribeInstancesInput).Filters), "tag:Name=foo-worker-0", fmt.Sprintf("[%s] The instance wasn't looked up by name", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resID, i.ID(), fmt.Sprintf("[%s] Wrong instance ID", tc.desc))
+		assert.Equal(t, tc.resPrivateIP, i.PrivateIPAddress(), fmt.Sprintf("[%s] Wrong private IP address", tc.desc))
+		assert.Equal(t, tc.resPublicIP, i.PublicIPAddress(), fmt.Sprintf("[%s] Wrong public IP address", tc.desc))
+		assert.Equal(t, tc.resState, i.State(), fmt.Sprintf("[%s] Wrong state", tc.desc))
+	}
+}
+
+func TestInstanceBootDiagnostics(t *testing.T) {
+	tests := []struct {
+		desc     string
+		instance *ec2.Instance
+		res      InstanceBootDiagnostics
+	}{
+		{
+			desc: "state reason of a failed launch",
+			instance: &ec2.Instance{
+				State:                 &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+				StateReason:           &ec2.StateReason{Message: aws.String("Server.InternalError: Internal error on launch")},
+				StateTransitionReason: aws.String("Server.InternalError"),
+			},
+			res: InstanceBootDiagnostics{
+				State:         ec2.InstanceStateNameTerminated,
+				StateReason:   "Server.InternalError: Internal error on launch",
+				ConsoleOutput: "Booting CoreOS...",
+			},
+		},
+		{
+			desc: "state transition reason without state reason",
+			instance: &ec2.Instance{
+				State:                 &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNamePending)},
+				StateTransitionReason: aws.String("User initiated"),
+			},
+			res: InstanceBootDiagnostics{
+				State:         ec2.InstanceStateNamePending,
+				StateReason:   "User initiated",
+				ConsoleOutput: "Booting CoreOS...",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		instance := tc.instance
+		fake.on("DescribeInstances", func(params, output interface{}) error {
+			output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{
+				{Instances: []*ec2.Instance{instance}},
+			}
+			return nil
+		})
+		fake.on("GetConsoleOutput", func(params, output interface{}) error {
+			output.(*ec2.GetConsoleOutputOutput).Output = aws.String(base64.StdEncoding.EncodeToString([]byte("Booting CoreOS...")))
+			return nil
+		})
+
+		i := Instance{
+			Name:      "foo-master-0",
+			id:        "i-123",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		res, err := i.BootDiagnostics()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] Wrong diagnostics", tc.desc))
+		input := fake.paramsOf("DescribeInstances")[0].(*ec2.DescribeInstancesInput)
+		assert.Equal(t, []*string{aws.String("i-123")}, input.InstanceIds, fmt.Sprintf("[%s] Wrong instance described", tc.desc))
+	}
+}
@@ -9,9 +9,23 @@ import (
 	microerror "github.com/giantswarm/microkit/error"
 )
 
+const (
+	// KMSKeySpecSymmetricDefault is the spec of symmetric AES-256 keys.
+	KMSKeySpecSymmetricDefault = "SYMMETRIC_DEFAULT"
+	// KMSKeyUsageEncryptDecrypt is the usage of keys encrypting and decrypting
+	// data.
+	KMSKeyUsageEncryptDecrypt = kms.KeyUsageTypeEncryptDecrypt
+)
+
 type KMSKey struct {
 	Name string
-	arn  string
+	// KeySpec is the spec of the key. It defaults to KMSKeySpecSymmetricDefault.
+	// Only symmetric keys are supported, since the TLS assets are encrypted and
+	// decrypted directly with the key by the operator and the instances.
+	KeySpec string
+	// KeyUsage is the usage of the key. It defaults to KMSKeyUsageEncryptDecrypt.
+	KeyUsage string
+	arn      string
 	AWSEntity
 }
 
@@ -19,30 +33,56 @@ func (kk *KMSKey) CreateIfNotExists() (bool, error) {
 	if kk.Name == "" {
 		return false, microerror.MaskAny(kmsKeyAliasEmptyError)
 	}
+	if err := kk.validateSpec(); err != nil {
+		return false, microerror.MaskAny(err)
+	}
 
 	existingKey, err := kk.findExisting()
 	if err != nil {
 		return false, microerror.MaskAny(err)
 	}
 
-	if existingKey != nil {
+	if existingKey != nil && aws.StringValue(existingKey.KeyState) == kms.KeyStateEnabled {
 		kk.arn = *existingKey.Arn
 
 		return false, nil
 	}
 
+	// The alias outlives its key when the key is disabled or deleted, e.g. once
+	// the pending window of a former cluster's key elapsed. Such a dangling
+	// alias is pointed at a new key, the TLS assets get encrypted with it.
+	dangling := existingKey != nil
+	if !dangling {
+		dangling, err = kk.aliasExists()
+		if err != nil {
+			return false, microerror.MaskAny(err)
+		}
+	}
+
+	if dangling {
+		if err := kk.repointAlias(); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+
+		return true, nil
+	}
+
 	if err := kk.CreateOrFail(); err != nil {
 		return false, microerror.MaskAny(err)
 	}
 
 	return true, nil
 }
+
 func (kk *KMSKey) CreateOrFail() error {
 	if kk.Name == "" {
 		return microerror.MaskAny(kmsKeyAliasEmptyError)
 	}
+	if err := kk.validateSpec(); err != nil {
+		return microerror.MaskAny(err)
+	}
 
-	key, err := kk.Clients.KMS.CreateKey(&kms.CreateKeyInput{})
+	keyArn, err := kk.createKey()
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
@@ -50,16 +90,68 @@ func (kk *KMSKey) CreateOrFail() error {
 	if _, err := kk.Clients.KMS.CreateAlias(&kms.CreateAliasInput{
 		// Alias names need to start from "alias/" prefix.
 		AliasName:   aws.String(kk.fullAlias()),
-		TargetKeyId: key.KeyMetadata.Arn,
+		TargetKeyId: aws.String(keyArn),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
 
-	kk.arn = *key.KeyMetadata.Arn
+	kk.arn = keyArn
 
 	return nil
 }
 
+// createKey creates a key without alias and returns its ARN.
+func (kk *KMSKey) createKey() (string, error) {
+	// The symmetric default is the only supported spec and AWS's default, so it
+	// is not passed explicitly.
+	key, err := kk.Clients.KMS.CreateKey(&kms.CreateKeyInput{
+		KeyUsage: aws.String(kk.keyUsage()),
+	})
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return *key.KeyMetadata.Arn, nil
+}
+
+// repointAlias creates a new key and points the existing alias at it.
+func (kk *KMSKey) repointAlias() error {
+	keyArn, err := kk.createKey()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := kk.Clients.KMS.UpdateAlias(&kms.UpdateAliasInput{
+		AliasName:   aws.String(kk.fullAlias()),
+		TargetKeyId: aws.String(keyArn),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	kk.arn = keyArn
+
+	return nil
+}
+
+// aliasExists tells whether the alias exists, regardless of its target key.
+func (kk KMSKey) aliasExists() (bool, error) {
+	var exists bool
+	err := kk.Clients.KMS.ListAliasesPages(&kms.ListAliasesInput{}, func(page *kms.ListAliasesOutput, lastPage bool) bool {
+		for _, alias := range page.Aliases {
+			if aws.StringValue(alias.AliasName) == kk.fullAlias() {
+				exists = true
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return exists, nil
+}
+
 func (kk *KMSKey) Delete() error {
 	key, err := kk.Clients.KMS.DescribeKey(&kms.DescribeKeyInput{
 		KeyId: aws.String(kk.fullAlias()),
@@ -68,16 +160,107 @@ func (kk *KMSKey) Delete() error {
 		return microerror.MaskAny(err)
 	}
 
+	// The key is scheduled for deletion before its alias is deleted, so a
+	// failed deletion can be retried. The key can't be found anymore once the
+	// alias is gone.
+	if aws.StringValue(key.KeyMetadata.KeyState) != kms.KeyStatePendingDeletion {
+		// Grants, e.g. the one of the cluster's instance role, would keep working
+		// until the key is actually deleted, so they are retired first.
+		if err := kk.retireGrants(aws.StringValue(key.KeyMetadata.KeyId)); err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		// AWS API doesn't allow to delete the KMS key immediately, but we can schedule its deletion
+		if _, err := kk.Clients.KMS.ScheduleKeyDeletion(&kms.ScheduleKeyDeletionInput{
+			KeyId:               key.KeyMetadata.KeyId,
+			PendingWindowInDays: aws.Int64(7),
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
 	if _, err := kk.Clients.KMS.DeleteAlias(&kms.DeleteAliasInput{
 		AliasName: aws.String(kk.fullAlias()),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
 
-	// AWS API doesn't allow to delete the KMS key immediately, but we can schedule its deletion
-	if _, err := kk.Clients.KMS.ScheduleKeyDeletion(&kms.ScheduleKeyDeletionInput{
-		KeyId:               key.KeyMetadata.KeyId,
-		PendingWindowInDays: aws.Int64(7),
+	return nil
+}
+
+// retireGrants removes all the grants of the key with the given ID. Grants are
+// revoked, which key administrators may do for any grant. When revoking is
+// denied, e.g. because the operator runs under an assumed role which isn't a
+// key administrator but the retiring principal of the grant, the grant is
+// retired instead. Grants which are gone already are skipped.
+func (kk KMSKey) retireGrants(keyID string) error {
+	var grantIDs []string
+	err := kk.Clients.KMS.ListGrantsPages(&kms.ListGrantsInput{
+		KeyId: aws.String(keyID),
+	}, func(page *kms.ListGrantsResponse, lastPage bool) bool {
+		for _, grant := range page.Grants {
+			grantIDs = append(grantIDs, aws.StringValue(grant.GrantId))
+		}
+		return true
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	for _, grantID := range grantIDs {
+		_, err := kk.Clients.KMS.RevokeGrant(&kms.RevokeGrantInput{
+			GrantId: aws.String(grantID),
+			KeyId:   aws.String(keyID),
+		})
+		if isAccessDenied(err) {
+			_, err = kk.Clients.KMS.RetireGrant(&kms.RetireGrantInput{
+				GrantId: aws.String(grantID),
+				KeyId:   aws.String(keyID),
+			})
+		}
+		if isGrantGone(err) {
+			continue
+		} else if err != nil {
+			return microerror.MaskAnyf(err, "could not retire grant '%s'", grantID)
+		}
+	}
+
+	return nil
+}
+
+// GrantDecrypt makes sure the given principal, e.g. the cluster's instance
+// role, is allowed to decrypt with the key. A grant is only created when the
+// principal does not have one already.
+func (kk *KMSKey) GrantDecrypt(granteePrincipal string) error {
+	if kk.arn == "" {
+		return microerror.MaskAnyf(kmsKeyNotCreatedError, "key '%s'", kk.Name)
+	}
+
+	var granted bool
+	err := kk.Clients.KMS.ListGrantsPages(&kms.ListGrantsInput{
+		KeyId: aws.String(kk.arn),
+	}, func(page *kms.ListGrantsResponse, lastPage bool) bool {
+		for _, grant := range page.Grants {
+			if aws.StringValue(grant.GranteePrincipal) == granteePrincipal && allowsDecrypt(grant) {
+				granted = true
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if granted {
+		return nil
+	}
+
+	if _, err := kk.Clients.KMS.CreateGrant(&kms.CreateGrantInput{
+		GranteePrincipal: aws.String(granteePrincipal),
+		KeyId:            aws.String(kk.arn),
+		Name:             aws.String(fmt.Sprintf("%s-decrypt", kk.Name)),
+		Operations:       []*string{aws.String(kms.GrantOperationDecrypt)},
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
@@ -85,6 +268,29 @@ func (kk *KMSKey) Delete() error {
 	return nil
 }
 
+// NewKMSKeyFromExisting initializes a KMS key from the existing, enabled key
+// with the given alias name. It does not create a new key on AWS, so it fails
+// with notFoundError when the key is missing or not enabled, e.g. when data
+// encrypted with the key can't be decrypted anymore.
+func NewKMSKeyFromExisting(name string, entity AWSEntity) (*KMSKey, error) {
+	kk := KMSKey{
+		Name:      name,
+		AWSEntity: entity,
+	}
+
+	existingKey, err := kk.findExisting()
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+	if existingKey == nil || aws.StringValue(existingKey.KeyState) != kms.KeyStateEnabled {
+		return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, KMSKeyType, kk.fullAlias())
+	}
+
+	kk.arn = *existingKey.Arn
+
+	return &kk, nil
+}
+
 func (kk KMSKey) Arn() string {
 	return kk.arn
 }
@@ -104,10 +310,56 @@ func (kk KMSKey) findExisting() (*kms.KeyMetadata, error) {
 	return resp.KeyMetadata, nil
 }
 
+// validateSpec checks the key spec and usage can be used for encrypting the
+// TLS assets. Asymmetric keys cannot, their encryption is limited to a few
+// hundred bytes and they are not supported by the instances decrypting them.
+func (kk KMSKey) validateSpec() error {
+	if kk.KeySpec != "" && kk.KeySpec != KMSKeySpecSymmetricDefault {
+		return microerror.MaskAnyf(invalidKMSKeySpecError, "key spec '%s' is not supported, only '%s' keys can encrypt the TLS assets", kk.KeySpec, KMSKeySpecSymmetricDefault)
+	}
+	if kk.keyUsage() != KMSKeyUsageEncryptDecrypt {
+		return microerror.MaskAnyf(invalidKMSKeySpecError, "key usage '%s' is not supported, the key must be usable for '%s'", kk.KeyUsage, KMSKeyUsageEncryptDecrypt)
+	}
+
+	return nil
+}
+
+func (kk KMSKey) keyUsage() string {
+	if kk.KeyUsage == "" {
+		return KMSKeyUsageEncryptDecrypt
+	}
+
+	return kk.KeyUsage
+}
+
 func (kk KMSKey) fullAlias() string {
 	return fmt.Sprintf("alias/%s", kk.Name)
 }
 
+func allowsDecrypt(grant *kms.GrantListEntry) bool {
+	for _, operation := range grant.Operations {
+		if aws.StringValue(operation) == kms.GrantOperationDecrypt {
+			return true
+		}
+	}
+
+	return false
+}
+
 func isNotFoundError(code string) bool {
 	return code == kms.ErrCodeNotFoundException
 }
+
+// isAccessDenied checks whether the caller isn't allowed to perform a KMS
+// operation.
+func isAccessDenied(err error) bool {
+	awserr, ok := err.(awserr.Error)
+	return ok && awserr.Code() == kmsErrCodeAccessDenied
+}
+
+// isGrantGone checks whether a grant doesn't exist anymore, e.g. because it
+// was retired concurrently.
+func isGrantGone(err error) bool {
+	awserr, ok := err.(awserr.Error)
+	return ok && (isNotFoundError(awserr.Code()) || awserr.Code() == kms.ErrCodeInvalidGrantIdException)
+}
@@ -0,0 +1,457 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKMSKeyGrantDecrypt(t *testing.T) {
+	roleArn := "arn:aws:iam::123456789012:role/foo-EC2-K8S-Role"
+	otherRoleArn := "arn:aws:iam::123456789012:role/bar-EC2-K8S-Role"
+
+	tests := []struct {
+		desc       string
+		grants     []*kms.GrantListEntry
+		operations []string
+	}{
+		{
+			desc:       "grant is created when the key has none",
+			grants:     nil,
+			operations: []string{"ListGrants", "CreateGrant"},
+		},
+		{
+			desc: "grant is created when only other principals have one",
+			grants: []*kms.GrantListEntry{
+				{
+					GranteePrincipal: aws.String(otherRoleArn),
+					Operations:       []*string{aws.String(kms.GrantOperationDecrypt)},
+				},
+			},
+			operations: []string{"ListGrants", "CreateGrant"},
+		},
+		{
+			desc: "grant is created when the role cannot decrypt",
+			grants: []*kms.GrantListEntry{
+				{
+					GranteePrincipal: aws.String(roleArn),
+					Operations:       []*string{aws.String(kms.GrantOperationEncrypt)},
+				},
+			},
+			operations: []string{"ListGrants", "CreateGrant"},
+		},
+		{
+			desc: "existing grant is reused",
+			grants: []*kms.GrantListEntry{
+				{
+					GranteePrincipal: aws.String(roleArn),
+					Operations:       []*string{aws.String(kms.GrantOperationDecrypt)},
+				},
+			},
+			operations: []string{"ListGrants"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		grants := tc.grants
+		fake.on("ListGrants", func(params, output interface{}) error {
+			output.(*kms.ListGrantsResponse).Grants = grants
+			return nil
+		})
+
+		kk := &KMSKey{
+			Name:      "foo",
+			arn:       "arn:aws:kms:eu-central-1:123456789012:key/abc",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := kk.GrantDecrypt(roleArn)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.operations, fake.operations(), fmt.Sprintf("[%s] The input values didn't produce the expected API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("CreateGrant") {
+			input := params.(*kms.CreateGrantInput)
+			assert.Equal(t, roleArn, *input.GranteePrincipal, fmt.Sprintf("[%s] The grant doesn't reference the role ARN", tc.desc))
+			assert.Equal(t, kk.arn, *input.KeyId, fmt.Sprintf("[%s] The grant doesn't reference the key", tc.desc))
+			assert.Equal(t, []*string{aws.String(kms.GrantOperationDecrypt)}, input.Operations, fmt.Sprintf("[%s] The grant doesn't allow decrypting", tc.desc))
+		}
+	}
+}
+
+func TestKMSKeyGrantDecryptWithoutKey(t *testing.T) {
+	clients, fake := newFakeClients()
+
+	kk := &KMSKey{
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err := kk.GrantDecrypt("arn:aws:iam::123456789012:role/foo-EC2-K8S-Role")
+	assert.True(t, IsKMSKeyNotCreated(err), "Expected a KMS key not created error")
+	assert.Empty(t, fake.operations(), "No API calls expected without a key")
+}
+
+func TestKMSKeyCreateOrFailSpec(t *testing.T) {
+	tests := []struct {
+		desc         string
+		keySpec      string
+		keyUsage     string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "defaults to a symmetric encryption key",
+		},
+		{
+			desc:     "symmetric encryption key",
+			keySpec:  KMSKeySpecSymmetricDefault,
+			keyUsage: KMSKeyUsageEncryptDecrypt,
+		},
+		{
+			desc:         "asymmetric key is rejected",
+			keySpec:      "RSA_2048",
+			errorMatcher: IsInvalidKMSKeySpec,
+		},
+		{
+			desc:         "signing key is rejected",
+			keySpec:      KMSKeySpecSymmetricDefault,
+			keyUsage:     "SIGN_VERIFY",
+			errorMatcher: IsInvalidKMSKeySpec,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("CreateKey", func(params, output interface{}) error {
+			output.(*kms.CreateKeyOutput).KeyMetadata = &kms.KeyMetadata{
+				Arn: aws.String("arn:aws:kms:eu-central-1:123456789012:key/foo"),
+			}
+			return nil
+		})
+
+		kmsKey := &KMSKey{
+			Name:      "foo",
+			KeySpec:   tc.keySpec,
+			KeyUsage:  tc.keyUsage,
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := kmsKey.CreateOrFail()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Nil(t, fake.operations(), fmt.Sprintf("[%s] No key must be created", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		params := fake.paramsOf("CreateKey")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected a single CreateKey call", tc.desc))
+		assert.Equal(t, KMSKeyUsageEncryptDecrypt, aws.StringValue(params[0].(*kms.CreateKeyInput).KeyUsage), fmt.Sprintf("[%s] Wrong key usage", tc.desc))
+	}
+}
+
+func TestKMSKeyCreateIfNotExists(t *testing.T) {
+	oldKeyArn := "arn:aws:kms:eu-central-1:123456789012:key/old"
+	newKeyArn := "arn:aws:kms:eu-central-1:123456789012:key/new"
+
+	tests := []struct {
+		desc          string
+		describeKey   fakeResponse
+		aliases       []*kms.AliasListEntry
+		resCreated    bool
+		resArn        string
+		resOperations []string
+	}{
+		{
+			desc: "enabled key is reused",
+			describeKey: func(params, output interface{}) error {
+				output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{
+					Arn:      aws.String(oldKeyArn),
+					KeyState: aws.String(kms.KeyStateEnabled),
+				}
+				return nil
+			},
+			resCreated:    false,
+			resArn:        oldKeyArn,
+			resOperations: []string{"DescribeKey"},
+		},
+		{
+			desc: "alias of a key pending deletion is repointed",
+			describeKey: func(params, output interface{}) error {
+				output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{
+					Arn:      aws.String(oldKeyArn),
+					KeyState: aws.String(kms.KeyStatePendingDeletion),
+				}
+				return nil
+			},
+			resCreated:    true,
+			resArn:        newKeyArn,
+			resOperations: []string{"DescribeKey", "CreateKey", "UpdateAlias"},
+		},
+		{
+			desc: "alias of a deleted key is repointed",
+			describeKey: func(params, output interface{}) error {
+				return awserr.New(kms.ErrCodeNotFoundException, "alias/foo is not found", nil)
+			},
+			aliases: []*kms.AliasListEntry{
+				{AliasName: aws.String("alias/bar")},
+				{AliasName: aws.String("alias/foo")},
+			},
+			resCreated:    true,
+			resArn:        newKeyArn,
+			resOperations: []string{"DescribeKey", "ListAliases", "CreateKey", "UpdateAlias"},
+		},
+		{
+			desc: "key and alias are created",
+			describeKey: func(params, output interface{}) error {
+				return awserr.New(kms.ErrCodeNotFoundException, "alias/foo is not found", nil)
+			},
+			aliases: []*kms.AliasListEntry{
+				{AliasName: aws.String("alias/bar")},
+			},
+			resCreated:    true,
+			resArn:        newKeyArn,
+			resOperations: []string{"DescribeKey", "ListAliases", "CreateKey", "CreateAlias"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeKey", tc.describeKey)
+		aliases := tc.aliases
+		fake.on("ListAliases", func(params, output interface{}) error {
+			output.(*kms.ListAliasesOutput).Aliases = aliases
+			return nil
+		})
+		fake.on("CreateKey", func(params, output interface{}) error {
+			output.(*kms.CreateKeyOutput).KeyMetadata = &kms.KeyMetadata{
+				Arn: aws.String(newKeyArn),
+			}
+			return nil
+		})
+
+		kmsKey := &KMSKey{
+			Name:      "foo",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		created, err := kmsKey.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Unexpected created flag", tc.desc))
+		assert.Equal(t, tc.resArn, kmsKey.Arn(), fmt.Sprintf("[%s] Unexpected key ARN", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("UpdateAlias") {
+			input := params.(*kms.UpdateAliasInput)
+			assert.Equal(t, "alias/foo", aws.StringValue(input.AliasName), fmt.Sprintf("[%s] The wrong alias was updated", tc.desc))
+			assert.Equal(t, newKeyArn, aws.StringValue(input.TargetKeyId), fmt.Sprintf("[%s] The alias must point at the new key", tc.desc))
+		}
+	}
+}
+
+func TestNewKMSKeyFromExisting(t *testing.T) {
+	keyArn := "arn:aws:kms:eu-central-1:123456789012:key/old"
+
+	tests := []struct {
+		desc         string
+		describeKey  fakeResponse
+		errorMatcher func(error) bool
+		resArn       string
+	}{
+		{
+			desc: "enabled key is found",
+			describeKey: func(params, output interface{}) error {
+				output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{
+					Arn:      aws.String(keyArn),
+					KeyState: aws.String(kms.KeyStateEnabled),
+				}
+				return nil
+			},
+			resArn: keyArn,
+		},
+		{
+			desc: "key pending deletion is not found",
+			describeKey: func(params, output interface{}) error {
+				output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{
+					Arn:      aws.String(keyArn),
+					KeyState: aws.String(kms.KeyStatePendingDeletion),
+				}
+				return nil
+			},
+			errorMatcher: IsNotFound,
+		},
+		{
+			desc: "deleted key is not found",
+			describeKey: func(params, output interface{}) error {
+				return awserr.New(kms.ErrCodeNotFoundException, "alias/foo is not found", nil)
+			},
+			errorMatcher: IsNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeKey", tc.describeKey)
+
+		kmsKey, err := NewKMSKeyFromExisting("foo", AWSEntity{Clients: clients})
+		assert.Equal(t, []string{"DescribeKey"}, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resArn, kmsKey.Arn(), fmt.Sprintf("[%s] Unexpected key ARN", tc.desc))
+	}
+}
+
+func TestKMSKeyDeleteRetiresGrants(t *testing.T) {
+	accessDenied := func(params, output interface{}) error {
+		return awserr.New("AccessDeniedException", "not a key administrator", nil)
+	}
+	granted := func(params, output interface{}) error {
+		return nil
+	}
+
+	tests := []struct {
+		desc        string
+		grants      []*kms.GrantListEntry
+		revokeGrant []fakeResponse
+		retireGrant []fakeResponse
+		operations  []string
+		resRetired  []string
+	}{
+		{
+			desc:       "key without grants",
+			grants:     nil,
+			operations: []string{"DescribeKey", "ListGrants", "ScheduleKeyDeletion", "DeleteAlias"},
+		},
+		{
+			desc: "grants are revoked",
+			grants: []*kms.GrantListEntry{
+				{GrantId: aws.String("grant-1")},
+				{GrantId: aws.String("grant-2")},
+			},
+			revokeGrant: []fakeResponse{granted},
+			operations:  []string{"DescribeKey", "ListGrants", "RevokeGrant", "RevokeGrant", "ScheduleKeyDeletion", "DeleteAlias"},
+			resRetired:  []string{"grant-1", "grant-2"},
+		},
+		{
+			desc: "grants of an assumed role are retired when revoking is denied",
+			grants: []*kms.GrantListEntry{
+				{
+					GrantId:           aws.String("grant-1"),
+					GranteePrincipal:  aws.String("arn:aws:sts::123456789012:assumed-role/foo-EC2-K8S-Role/i-abc"),
+					RetiringPrincipal: aws.String("arn:aws:sts::123456789012:assumed-role/aws-operator/session"),
+				},
+			},
+			revokeGrant: []fakeResponse{accessDenied},
+			retireGrant: []fakeResponse{granted},
+			operations:  []string{"DescribeKey", "ListGrants", "RevokeGrant", "RetireGrant", "ScheduleKeyDeletion", "DeleteAlias"},
+			resRetired:  []string{"grant-1"},
+		},
+		{
+			desc: "grants retired concurrently are skipped",
+			grants: []*kms.GrantListEntry{
+				{GrantId: aws.String("grant-1")},
+				{GrantId: aws.String("grant-2")},
+			},
+			revokeGrant: []fakeResponse{
+				func(params, output interface{}) error {
+					return awserr.New(kms.ErrCodeInvalidGrantIdException, "grant-1 is invalid", nil)
+				},
+				granted,
+			},
+			operations: []string{"DescribeKey", "ListGrants", "RevokeGrant", "RevokeGrant", "ScheduleKeyDeletion", "DeleteAlias"},
+			resRetired: []string{"grant-1", "grant-2"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeKey", func(params, output interface{}) error {
+			output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{KeyId: aws.String("abc")}
+			return nil
+		})
+		grants := tc.grants
+		fake.on("ListGrants", func(params, output interface{}) error {
+			output.(*kms.ListGrantsResponse).Grants = grants
+			return nil
+		})
+		fake.on("RevokeGrant", tc.revokeGrant...)
+		fake.on("RetireGrant", tc.retireGrant...)
+
+		kk := &KMSKey{
+			Name:      "foo",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := kk.Delete()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.operations, fake.operations(), fmt.Sprintf("[%s] The grants weren't retired before scheduling the key deletion", tc.desc))
+
+		var revoked []string
+		for _, params := range fake.paramsOf("RevokeGrant") {
+			input := params.(*kms.RevokeGrantInput)
+			assert.Equal(t, "abc", *input.KeyId, fmt.Sprintf("[%s] The revoked grant doesn't reference the key", tc.desc))
+			revoked = append(revoked, *input.GrantId)
+		}
+		for _, params := range fake.paramsOf("RetireGrant") {
+			input := params.(*kms.RetireGrantInput)
+			assert.Equal(t, "abc", *input.KeyId, fmt.Sprintf("[%s] The retired grant doesn't reference the key", tc.desc))
+			assert.Contains(t, revoked, *input.GrantId, fmt.Sprintf("[%s] The grant was retired without being revoked first", tc.desc))
+		}
+		assert.Equal(t, tc.resRetired, revoked, fmt.Sprintf("[%s] Wrong grants retired", tc.desc))
+	}
+}
+
+func TestKMSKeyDeleteFailsOnGrantError(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeKey", func(params, output interface{}) error {
+		output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{KeyId: aws.String("abc")}
+		return nil
+	})
+	fake.on("ListGrants", func(params, output interface{}) error {
+		output.(*kms.ListGrantsResponse).Grants = []*kms.GrantListEntry{{GrantId: aws.String("grant-1")}}
+		return nil
+	})
+	denied := func(params, output interface{}) error {
+		return awserr.New("AccessDeniedException", "not allowed", nil)
+	}
+	fake.on("RevokeGrant", denied)
+	fake.on("RetireGrant", denied)
+
+	kk := &KMSKey{
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err := kk.Delete()
+	assert.NotNil(t, err, "Expected an error when grants cannot be retired")
+	assert.Equal(t, []string{"DescribeKey", "ListGrants", "RevokeGrant", "RetireGrant"}, fake.operations(), "The key deletion was scheduled despite a remaining grant")
+}
+
+func TestKMSKeyDeletePendingDeletion(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeKey", func(params, output interface{}) error {
+		output.(*kms.DescribeKeyOutput).KeyMetadata = &kms.KeyMetadata{
+			KeyId:    aws.String("abc"),
+			KeyState: aws.String(kms.KeyStatePendingDeletion),
+		}
+		return nil
+	})
+
+	kk := &KMSKey{
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	// An earlier deletion scheduled the key deletion, but failed to delete the
+	// alias.
+	err := kk.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"DescribeKey", "DeleteAlias"}, fake.operations(), "The deletion of the key was scheduled again")
+}
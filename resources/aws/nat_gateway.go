@@ -0,0 +1,252 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/cenkalti/backoff"
+	microerror "github.com/giantswarm/microkit/error"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/pborman/uuid"
+)
+
+// NATGateway is a NAT gateway with an Elastic IP, which lets the instances of
+// private subnets reach the internet, e.g. to pull images.
+type NATGateway struct {
+	Name string
+	// SubnetID is the ID of the public subnet the NAT gateway is created in.
+	SubnetID string
+	id       string
+	// Dependencies.
+	Logger micrologger.Logger
+	AWSEntity
+}
+
+// findExisting returns the NAT gateway named Name, ignoring the ones which are
+// gone or failed. A NAT gateway created recently is waited for, since it might
+// not be visible yet.
+func (n NATGateway) findExisting() (*ec2.NatGateway, error) {
+	var natGateway *ec2.NatGateway
+	err := findCreated(n.creationKey(), func() error {
+		var err error
+		natGateway, err = n.describe()
+		return err
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return natGateway, nil
+}
+
+func (n NATGateway) creationKey() string {
+	return creationKey(NATGatewayType, n.OperatorID, n.Name)
+}
+
+func (n NATGateway) describe() (*ec2.NatGateway, error) {
+	natGateways, err := n.Clients.EC2.DescribeNatGateways(&ec2.DescribeNatGatewaysInput{
+		Filter: append([]*ec2.Filter{
+			&ec2.Filter{
+				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
+				Values: []*string{
+					aws.String(n.Name),
+				},
+			},
+		}, operatorFilters(n.OperatorID)...),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	for _, natGateway := range natGateways.NatGateways {
+		switch aws.StringValue(natGateway.State) {
+		case ec2.NatGatewayStateDeleted, ec2.NatGatewayStateDeleting, ec2.NatGatewayStateFailed:
+			continue
+		}
+		return natGateway, nil
+	}
+
+	return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, NATGatewayType, n.Name)
+}
+
+func (n *NATGateway) checkIfExists() (bool, error) {
+	_, err := n.findExisting()
+	if IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return true, nil
+}
+
+func (n *NATGateway) CreateIfNotExists() (bool, error) {
+	exists, err := n.checkIfExists()
+	if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	if exists {
+		return false, nil
+	}
+
+	if err := n.CreateOrFail(); err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return true, nil
+}
+
+// CreateOrFail allocates an Elastic IP, creates the NAT gateway with it in the
+// subnet SubnetID, and waits until the NAT gateway is available. The NAT
+// gateway and the Elastic IP are cleaned up when the creation fails.
+func (n *NATGateway) CreateOrFail() error {
+	address, err := n.Clients.EC2.AllocateAddress(&ec2.AllocateAddressInput{
+		Domain: aws.String(ec2.DomainTypeVpc),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if err := n.createTags(*address.AllocationId); err != nil {
+		n.releaseAddress(address.AllocationId)
+		return microerror.MaskAny(err)
+	}
+
+	natGateway, err := n.Clients.EC2.CreateNatGateway(&ec2.CreateNatGatewayInput{
+		AllocationId: address.AllocationId,
+		ClientToken:  aws.String(uuid.New()),
+		SubnetId:     aws.String(n.SubnetID),
+	})
+	if err != nil {
+		n.releaseAddress(address.AllocationId)
+		return microerror.MaskAny(err)
+	}
+	natGatewayID := *natGateway.NatGateway.NatGatewayId
+
+	// Untagged NAT gateways are never found again, and failed ones are
+	// ignored, so they are deleted right away instead of being leaked.
+	if err := n.createTags(natGatewayID); err != nil {
+		n.deleteUncreated(natGatewayID, address.AllocationId)
+		return microerror.MaskAny(err)
+	}
+
+	n.id = natGatewayID
+	recentCreations.add(n.creationKey())
+
+	if err := n.Clients.EC2.WaitUntilNatGatewayAvailable(&ec2.DescribeNatGatewaysInput{
+		NatGatewayIds: []*string{
+			aws.String(natGatewayID),
+		},
+	}); err != nil {
+		n.id = ""
+		recentCreations.remove(n.creationKey())
+		n.deleteUncreated(natGatewayID, address.AllocationId)
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+func (n NATGateway) createTags(resourceID string) error {
+	if _, err := n.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{
+			aws.String(resourceID),
+		},
+		Tags: append([]*ec2.Tag{
+			{
+				Key:   aws.String(tagKeyName),
+				Value: aws.String(n.Name),
+			},
+		}, resourceTags(n.OperatorID, n.Tags)...),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// releaseAddress releases the Elastic IP of a NAT gateway which couldn't be
+// created, logging failures since the creation error is the one returned.
+func (n NATGateway) releaseAddress(allocationID *string) {
+	if _, err := n.Clients.EC2.ReleaseAddress(&ec2.ReleaseAddressInput{
+		AllocationId: allocationID,
+	}); err != nil {
+		n.Logger.Log("error", fmt.Sprintf("could not release Elastic IP '%s' of NAT gateway '%s': %s", aws.StringValue(allocationID), n.Name, err.Error()))
+	}
+}
+
+// deleteUncreated deletes a NAT gateway which couldn't be created properly and
+// releases its Elastic IP, logging failures since the creation error is the one
+// returned.
+func (n NATGateway) deleteUncreated(natGatewayID string, allocationID *string) {
+	if _, err := n.Clients.EC2.DeleteNatGateway(&ec2.DeleteNatGatewayInput{
+		NatGatewayId: aws.String(natGatewayID),
+	}); err != nil {
+		n.Logger.Log("error", fmt.Sprintf("could not delete NAT gateway '%s' of '%s': %s", natGatewayID, n.Name, err.Error()))
+		return
+	}
+
+	if err := n.releaseAddressOfDeleted(allocationID); err != nil {
+		n.Logger.Log("error", fmt.Sprintf("could not release Elastic IP '%s' of NAT gateway '%s': %s", aws.StringValue(allocationID), n.Name, err.Error()))
+	}
+}
+
+// releaseAddressOfDeleted releases an Elastic IP of a deleted NAT gateway. It
+// can only be released once the NAT gateway is gone, which takes a while, so
+// releasing it is retried.
+func (n NATGateway) releaseAddressOfDeleted(allocationID *string) error {
+	releaseOperation := func() error {
+		if _, err := n.Clients.EC2.ReleaseAddress(&ec2.ReleaseAddressInput{
+			AllocationId: allocationID,
+		}); err != nil && !IsAlreadyDeleted(err) {
+			return microerror.MaskAny(err)
+		}
+		return nil
+	}
+	releaseNotify := NewNotify(n.Logger, "releasing Elastic IP of NAT gateway")
+	if err := backoff.RetryNotify(releaseOperation, NewCustomExponentialBackoff(), releaseNotify); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// Delete deletes the NAT gateway and releases its Elastic IPs.
+func (n *NATGateway) Delete() error {
+	natGateway, err := n.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := n.Clients.EC2.DeleteNatGateway(&ec2.DeleteNatGatewayInput{
+		NatGatewayId: natGateway.NatGatewayId,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+	recentCreations.remove(n.creationKey())
+
+	for _, address := range natGateway.NatGatewayAddresses {
+		if err := n.releaseAddressOfDeleted(address.AllocationId); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// GetID returns the ID of the NAT gateway, e.g. for the routes of the route
+// tables of private subnets.
+func (n NATGateway) GetID() (string, error) {
+	if n.id != "" {
+		return n.id, nil
+	}
+
+	natGateway, err := n.findExisting()
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return *natGateway.NatGatewayId, nil
+}
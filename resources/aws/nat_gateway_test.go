@@ -0,0 +1,221 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNATGatewayCreateIfNotExists(t *testing.T) {
+	tests := []struct {
+		desc          string
+		existing      []*ec2.NatGateway
+		createError   error
+		tagError      error
+		waitState     string
+		errorMatcher  func(error) bool
+		resCreated    bool
+		resID         string
+		resOperations []string
+	}{
+		{
+			desc:       "NAT gateway is created",
+			resCreated: true,
+			resID:      "nat-new",
+			resOperations: []string{
+				"DescribeNatGateways",
+				"AllocateAddress",
+				"CreateTags",
+				"CreateNatGateway",
+				"CreateTags",
+				"DescribeNatGateways",
+			},
+		},
+		{
+			desc: "deleted NAT gateway is replaced",
+			existing: []*ec2.NatGateway{
+				{NatGatewayId: aws.String("nat-old"), State: aws.String(ec2.NatGatewayStateDeleted)},
+			},
+			resCreated: true,
+			resID:      "nat-new",
+			resOperations: []string{
+				"DescribeNatGateways",
+				"AllocateAddress",
+				"CreateTags",
+				"CreateNatGateway",
+				"CreateTags",
+				"DescribeNatGateways",
+			},
+		},
+		{
+			desc: "available NAT gateway is reused",
+			existing: []*ec2.NatGateway{
+				{NatGatewayId: aws.String("nat-existing"), State: aws.String(ec2.NatGatewayStateAvailable)},
+			},
+			resCreated:    false,
+			resID:         "nat-existing",
+			resOperations: []string{"DescribeNatGateways"},
+		},
+		{
+			desc:         "Elastic IP is released when the NAT gateway can't be created",
+			createError:  awserr.New("InvalidSubnetID.NotFound", "The subnet ID 'subnet-public' does not exist", nil),
+			errorMatcher: IsAlreadyDeleted,
+			resOperations: []string{
+				"DescribeNatGateways",
+				"AllocateAddress",
+				"CreateTags",
+				"CreateNatGateway",
+				"ReleaseAddress",
+			},
+		},
+		{
+			desc:         "NAT gateway is deleted when it can't be tagged",
+			tagError:     awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil),
+			errorMatcher: func(err error) bool { return err != nil },
+			resOperations: []string{
+				"DescribeNatGateways",
+				"AllocateAddress",
+				"CreateTags",
+				"CreateNatGateway",
+				"CreateTags",
+				"DeleteNatGateway",
+				"ReleaseAddress",
+			},
+		},
+		{
+			desc:         "NAT gateway is deleted when it fails",
+			waitState:    ec2.NatGatewayStateFailed,
+			errorMatcher: func(err error) bool { return err != nil },
+			resOperations: []string{
+				"DescribeNatGateways",
+				"AllocateAddress",
+				"CreateTags",
+				"CreateNatGateway",
+				"CreateTags",
+				"DescribeNatGateways",
+				"DeleteNatGateway",
+				"ReleaseAddress",
+			},
+		},
+	}
+
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	for i, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeNatGateways", func(params, output interface{}) error {
+			// The waiter of the creation looks the NAT gateway up by ID.
+			if len(params.(*ec2.DescribeNatGatewaysInput).NatGatewayIds) > 0 {
+				state := ec2.NatGatewayStateAvailable
+				if tc.waitState != "" {
+					state = tc.waitState
+				}
+				output.(*ec2.DescribeNatGatewaysOutput).NatGateways = []*ec2.NatGateway{
+					{NatGatewayId: aws.String("nat-new"), State: aws.String(state)},
+				}
+				return nil
+			}
+			output.(*ec2.DescribeNatGatewaysOutput).NatGateways = tc.existing
+			return nil
+		})
+		fake.on("AllocateAddress", func(params, output interface{}) error {
+			output.(*ec2.AllocateAddressOutput).AllocationId = aws.String("eipalloc-new")
+			return nil
+		})
+		fake.on("CreateTags", func(params, output interface{}) error {
+			if aws.StringValue(params.(*ec2.CreateTagsInput).Resources[0]) == "nat-new" {
+				return tc.tagError
+			}
+			return nil
+		})
+		fake.on("CreateNatGateway", func(params, output interface{}) error {
+			if tc.createError != nil {
+				return tc.createError
+			}
+			output.(*ec2.CreateNatGatewayOutput).NatGateway = &ec2.NatGateway{NatGatewayId: aws.String("nat-new")}
+			return nil
+		})
+
+		natGateway := &NATGateway{
+			Name:      fmt.Sprintf("nat-gateway-%d", i),
+			SubnetID:  "subnet-public",
+			Logger:    logger,
+			AWSEntity: AWSEntity{Clients: clients, OperatorID: "prod"},
+		}
+
+		created, err := natGateway.CreateIfNotExists()
+		recentCreations.remove(natGateway.creationKey())
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			release := fake.paramsOf("ReleaseAddress")[0].(*ec2.ReleaseAddressInput)
+			assert.Equal(t, "eipalloc-new", aws.StringValue(release.AllocationId), fmt.Sprintf("[%s] Wrong Elastic IP released", tc.desc))
+			for _, params := range fake.paramsOf("DeleteNatGateway") {
+				assert.Equal(t, "nat-new", aws.StringValue(params.(*ec2.DeleteNatGatewayInput).NatGatewayId), fmt.Sprintf("[%s] Wrong NAT gateway deleted", tc.desc))
+			}
+			_, err := natGateway.GetID()
+			assert.True(t, IsNotFound(err), fmt.Sprintf("[%s] Deleted NAT gateway is still known", tc.desc))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong creation", tc.desc))
+
+		id, err := natGateway.GetID()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resID, id, fmt.Sprintf("[%s] Wrong NAT gateway ID", tc.desc))
+
+		if tc.resCreated {
+			input := fake.paramsOf("CreateNatGateway")[0].(*ec2.CreateNatGatewayInput)
+			assert.Equal(t, "eipalloc-new", aws.StringValue(input.AllocationId), fmt.Sprintf("[%s] Wrong Elastic IP", tc.desc))
+			assert.Equal(t, "subnet-public", aws.StringValue(input.SubnetId), fmt.Sprintf("[%s] Wrong subnet", tc.desc))
+
+			var tagged []*string
+			for _, params := range fake.paramsOf("CreateTags") {
+				tags := params.(*ec2.CreateTagsInput)
+				tagged = append(tagged, tags.Resources...)
+				assert.Contains(t, tags.Tags, &ec2.Tag{Key: aws.String(tagKeyName), Value: aws.String(natGateway.Name)}, fmt.Sprintf("[%s] Missing name tag", tc.desc))
+			}
+			assert.Equal(t, []*string{aws.String("eipalloc-new"), aws.String("nat-new")}, tagged, fmt.Sprintf("[%s] Wrong tagged resources", tc.desc))
+		}
+	}
+}
+
+func TestNATGatewayDelete(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	clients, fake := newFakeClients()
+	fake.on("DescribeNatGateways", func(params, output interface{}) error {
+		output.(*ec2.DescribeNatGatewaysOutput).NatGateways = []*ec2.NatGateway{
+			{
+				NatGatewayId: aws.String("nat-123"),
+				NatGatewayAddresses: []*ec2.NatGatewayAddress{
+					{AllocationId: aws.String("eipalloc-123")},
+				},
+				State: aws.String(ec2.NatGatewayStateAvailable),
+			},
+		}
+		return nil
+	})
+
+	natGateway := &NATGateway{
+		Name:      "foo",
+		Logger:    logger,
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err = natGateway.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"DescribeNatGateways", "DeleteNatGateway", "ReleaseAddress"}, fake.operations(), "Unexpected AWS API calls")
+
+	input := fake.paramsOf("DeleteNatGateway")[0].(*ec2.DeleteNatGatewayInput)
+	assert.Equal(t, "nat-123", aws.StringValue(input.NatGatewayId), "Wrong NAT gateway deleted")
+	release := fake.paramsOf("ReleaseAddress")[0].(*ec2.ReleaseAddressInput)
+	assert.Equal(t, "eipalloc-123", aws.StringValue(release.AllocationId), "Wrong Elastic IP released")
+}
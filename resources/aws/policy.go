@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/iam"
 	microerror "github.com/giantswarm/microkit/error"
 )
@@ -57,90 +58,148 @@ const (
 type Policy struct {
 	ClusterID string
 	KMSKeyArn string
-	S3Bucket  string
-	name      string
+	// NamePrefix is prepended to the names of the role, its policy and the
+	// instance profile.
+	NamePrefix string
+	S3Bucket   string
 	AWSEntity
 }
 
 func (p *Policy) clusterPolicyName() string {
-	return fmt.Sprintf("%s-%s", p.ClusterID, PolicyNameTemplate)
+	return fmt.Sprintf("%s%s-%s", p.NamePrefix, p.ClusterID, PolicyNameTemplate)
 }
 
 func (p *Policy) clusterProfileName() string {
-	return fmt.Sprintf("%s-%s", p.ClusterID, ProfileNameTemplate)
+	return fmt.Sprintf("%s%s-%s", p.NamePrefix, p.ClusterID, ProfileNameTemplate)
 }
 
 func (p *Policy) clusterRoleName() string {
-	return fmt.Sprintf("%s-%s", p.ClusterID, RoleNameTemplate)
+	return fmt.Sprintf("%s%s-%s", p.NamePrefix, p.ClusterID, RoleNameTemplate)
 }
 
+// CreateIfNotExists creates the role, its policy and the instance profile,
+// reusing the ones left behind by an earlier, failed attempt. It returns true
+// when the role or the instance profile had to be created.
 func (p *Policy) CreateIfNotExists() (bool, error) {
-	return false, fmt.Errorf("instance profiles cannot be reused")
+	roleCreated, err := p.createRole()
+	if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	profileCreated, err := p.createInstanceProfile()
+	if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	return roleCreated || profileCreated, nil
 }
 
-func (p *Policy) createRole() error {
+// createRole creates the role unless it exists already, and puts its policy.
+// Putting a role policy overwrites an existing one, so the policy always refers
+// to the current KMS key and bucket.
+func (p *Policy) createRole() (bool, error) {
 	// TODO switch to using a file and Go templates
 	policyDocument := fmt.Sprintf(PolicyDocumentTempl, p.KMSKeyArn, p.S3Bucket, p.S3Bucket, p.ClusterID)
 
-	clusterRoleName := fmt.Sprintf("%s-%s", p.ClusterID, RoleNameTemplate)
+	clusterRoleName := p.clusterRoleName()
 
+	created := true
 	if _, err := p.Clients.IAM.CreateRole(&iam.CreateRoleInput{
 		RoleName:                 aws.String(clusterRoleName),
 		AssumeRolePolicyDocument: aws.String(AssumeRolePolicyDocument),
-	}); err != nil {
-		return microerror.MaskAny(err)
+	}); isEntityAlreadyExists(err) {
+		created = false
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
 	}
 
-	clusterPolicyName := fmt.Sprintf("%s-%s", p.ClusterID, PolicyNameTemplate)
+	clusterPolicyName := p.clusterPolicyName()
 
 	if _, err := p.Clients.IAM.PutRolePolicy(&iam.PutRolePolicyInput{
 		PolicyName:     aws.String(clusterPolicyName),
 		RoleName:       aws.String(clusterRoleName),
 		PolicyDocument: aws.String(policyDocument),
 	}); err != nil {
-		return microerror.MaskAny(err)
+		return false, microerror.MaskAny(err)
 	}
 
-	return nil
+	return created, nil
 }
 
-func (p *Policy) createInstanceProfile() error {
+// createInstanceProfile creates the instance profile unless it exists already,
+// and adds the role to it unless it has been added already.
+func (p *Policy) createInstanceProfile() (bool, error) {
+	created := true
 	if _, err := p.Clients.IAM.CreateInstanceProfile(&iam.CreateInstanceProfileInput{
 		InstanceProfileName: aws.String(p.clusterProfileName()),
-	}); err != nil {
-		return microerror.MaskAny(err)
-	} else {
+	}); isEntityAlreadyExists(err) {
+		created = false
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	hasRole := false
+	if !created {
+		var err error
+		hasRole, err = p.instanceProfileHasRole()
+		if err != nil {
+			return false, microerror.MaskAny(err)
+		}
+	}
+
+	if !hasRole {
 		if _, err := p.Clients.IAM.AddRoleToInstanceProfile(&iam.AddRoleToInstanceProfileInput{
 			InstanceProfileName: aws.String(p.clusterProfileName()),
 			RoleName:            aws.String(p.clusterRoleName()),
 		}); err != nil {
-			return microerror.MaskAny(err)
+			return false, microerror.MaskAny(err)
 		}
 	}
 
 	if err := p.Clients.IAM.WaitUntilInstanceProfileExists(&iam.GetInstanceProfileInput{
 		InstanceProfileName: aws.String(p.clusterProfileName()),
 	}); err != nil {
-		return microerror.MaskAny(err)
+		return false, microerror.MaskAny(err)
 	}
 
-	return nil
+	return created, nil
 }
 
-func (p *Policy) CreateOrFail() error {
-	if err := p.createRole(); err != nil {
-		return microerror.MaskAny(err)
+// instanceProfileHasRole tells whether the role has been added to the instance
+// profile already.
+func (p *Policy) instanceProfileHasRole() (bool, error) {
+	resp, err := p.Clients.IAM.GetInstanceProfile(&iam.GetInstanceProfileInput{
+		InstanceProfileName: aws.String(p.clusterProfileName()),
+	})
+	if err != nil {
+		return false, microerror.MaskAny(err)
 	}
 
-	if err := p.createInstanceProfile(); err != nil {
-		return microerror.MaskAny(err)
+	for _, role := range resp.InstanceProfile.Roles {
+		if aws.StringValue(role.RoleName) == p.clusterRoleName() {
+			return true, nil
+		}
 	}
 
-	p.name = p.clusterProfileName()
+	return false, nil
+}
+
+// CreateOrFail creates the role, its policy and the instance profile. It is
+// safe to run again after a failure, since existing entities are reused.
+func (p *Policy) CreateOrFail() error {
+	if _, err := p.CreateIfNotExists(); err != nil {
+		return microerror.MaskAny(err)
+	}
 
 	return nil
 }
 
+// isEntityAlreadyExists tells whether the IAM entity to create exists already.
+func isEntityAlreadyExists(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == iam.ErrCodeEntityAlreadyExistsException
+}
+
 func (p *Policy) removeRoleFromInstanceProfile() error {
 	if _, err := p.Clients.IAM.RemoveRoleFromInstanceProfile(&iam.RemoveRoleFromInstanceProfileInput{
 		InstanceProfileName: aws.String(p.clusterProfileName()),
@@ -183,26 +242,37 @@ func (p *Policy) deleteRole() error {
 	return nil
 }
 
+// Delete deletes the instance profile, policy and role of the cluster. The ones
+// which don't exist are skipped, e.g. when the cluster was partially created
+// or an earlier deletion failed halfway.
 func (p *Policy) Delete() error {
-	if err := p.removeRoleFromInstanceProfile(); err != nil {
-		return microerror.MaskAny(err)
-	}
-
-	if err := p.deleteInstanceProfile(); err != nil {
-		return microerror.MaskAny(err)
+	for _, deletion := range []func() error{
+		p.removeRoleFromInstanceProfile,
+		p.deleteInstanceProfile,
+		p.deletePolicy,
+		p.deleteRole,
+	} {
+		if err := deletion(); err != nil && !IsAlreadyDeleted(err) {
+			return microerror.MaskAny(err)
+		}
 	}
 
-	if err := p.deletePolicy(); err != nil {
-		return microerror.MaskAny(err)
-	}
+	return nil
+}
 
-	if err := p.deleteRole(); err != nil {
-		return microerror.MaskAny(err)
+// RoleArn returns the ARN of the cluster's instance role.
+func (p *Policy) RoleArn() (string, error) {
+	resp, err := p.Clients.IAM.GetRole(&iam.GetRoleInput{
+		RoleName: aws.String(p.clusterRoleName()),
+	})
+	if err != nil {
+		return "", microerror.MaskAny(err)
 	}
 
-	return nil
+	return *resp.Role.Arn, nil
 }
 
+// GetName returns the name of the instance profile of the cluster.
 func (p Policy) GetName() string {
-	return p.name
+	return p.clusterProfileName()
 }
@@ -0,0 +1,228 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCreateIfNotExists(t *testing.T) {
+	alreadyExists := func(params, output interface{}) error {
+		return awserr.New(iam.ErrCodeEntityAlreadyExistsException, "already exists", nil)
+	}
+	profileWithRoles := func(roleNames ...string) fakeResponse {
+		return func(params, output interface{}) error {
+			profile := &iam.InstanceProfile{}
+			for _, roleName := range roleNames {
+				profile.Roles = append(profile.Roles, &iam.Role{RoleName: aws.String(roleName)})
+			}
+			output.(*iam.GetInstanceProfileOutput).InstanceProfile = profile
+			return nil
+		}
+	}
+
+	tests := []struct {
+		desc                  string
+		createRoleResponse    fakeResponse
+		createProfileResponse fakeResponse
+		getProfileResponse    fakeResponse
+		res                   bool
+		operations            []string
+	}{
+		{
+			desc: "everything is created",
+			res:  true,
+			operations: []string{
+				"CreateRole",
+				"PutRolePolicy",
+				"CreateInstanceProfile",
+				"AddRoleToInstanceProfile",
+				"GetInstanceProfile",
+			},
+		},
+		{
+			desc:                  "role and instance profile are reused",
+			createRoleResponse:    alreadyExists,
+			createProfileResponse: alreadyExists,
+			getProfileResponse:    profileWithRoles("foo-EC2-K8S-Role"),
+			res:                   false,
+			operations: []string{
+				"CreateRole",
+				"PutRolePolicy",
+				"CreateInstanceProfile",
+				"GetInstanceProfile",
+				"GetInstanceProfile",
+			},
+		},
+		{
+			desc:                  "role is added to an orphaned instance profile",
+			createRoleResponse:    alreadyExists,
+			createProfileResponse: alreadyExists,
+			getProfileResponse:    profileWithRoles(),
+			res:                   false,
+			operations: []string{
+				"CreateRole",
+				"PutRolePolicy",
+				"CreateInstanceProfile",
+				"GetInstanceProfile",
+				"AddRoleToInstanceProfile",
+				"GetInstanceProfile",
+			},
+		},
+		{
+			desc:               "instance profile is created for an orphaned role",
+			createRoleResponse: alreadyExists,
+			res:                true,
+			operations: []string{
+				"CreateRole",
+				"PutRolePolicy",
+				"CreateInstanceProfile",
+				"AddRoleToInstanceProfile",
+				"GetInstanceProfile",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		if tc.createRoleResponse != nil {
+			fake.on("CreateRole", tc.createRoleResponse)
+		}
+		if tc.createProfileResponse != nil {
+			fake.on("CreateInstanceProfile", tc.createProfileResponse)
+		}
+		if tc.getProfileResponse != nil {
+			fake.on("GetInstanceProfile", tc.getProfileResponse)
+		}
+
+		policy := &Policy{
+			ClusterID: "foo",
+			KMSKeyArn: "arn:aws:kms:eu-central-1:123456789012:key/foo",
+			S3Bucket:  "foo-bucket",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		created, err := policy.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, created, fmt.Sprintf("[%s] The input values didn't produce the expected result", tc.desc))
+		assert.Equal(t, tc.operations, fake.operations(), fmt.Sprintf("[%s] The input values didn't produce the expected AWS calls", tc.desc))
+		assert.Equal(t, "foo-EC2-K8S-Role", policy.GetName(), fmt.Sprintf("[%s] Unexpected instance profile name", tc.desc))
+	}
+}
+
+func TestPolicyCreateOrFailOtherErrors(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("CreateRole", func(params, output interface{}) error {
+		return awserr.New(iam.ErrCodeLimitExceededException, "too many roles", nil)
+	})
+
+	policy := &Policy{
+		ClusterID: "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err := policy.CreateOrFail()
+	assert.NotNil(t, err, "Expected errors other than EntityAlreadyExists to be returned")
+	assert.Equal(t, []string{"CreateRole"}, fake.operations(), "Nothing must be created after a failure")
+}
+
+func TestPolicyDelete(t *testing.T) {
+	noSuchEntity := func(params, output interface{}) error {
+		return awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)
+	}
+
+	tests := []struct {
+		desc          string
+		responses     map[string]fakeResponse
+		errorMatcher  func(error) bool
+		resOperations []string
+	}{
+		{
+			desc: "all the resources are deleted",
+			resOperations: []string{
+				"RemoveRoleFromInstanceProfile",
+				"DeleteInstanceProfile",
+				"DeleteRolePolicy",
+				"DeleteRole",
+			},
+		},
+		{
+			desc: "missing resources are skipped",
+			responses: map[string]fakeResponse{
+				"RemoveRoleFromInstanceProfile": noSuchEntity,
+				"DeleteInstanceProfile":         noSuchEntity,
+			},
+			resOperations: []string{
+				"RemoveRoleFromInstanceProfile",
+				"DeleteInstanceProfile",
+				"DeleteRolePolicy",
+				"DeleteRole",
+			},
+		},
+		{
+			desc: "other errors stop the deletion",
+			responses: map[string]fakeResponse{
+				"DeleteRolePolicy": func(params, output interface{}) error {
+					return awserr.New("AccessDenied", "not allowed", nil)
+				},
+			},
+			errorMatcher: func(err error) bool { return err != nil },
+			resOperations: []string{
+				"RemoveRoleFromInstanceProfile",
+				"DeleteInstanceProfile",
+				"DeleteRolePolicy",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		for operation, response := range tc.responses {
+			fake.on(operation, response)
+		}
+
+		policy := &Policy{
+			ClusterID: "foo",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := policy.Delete()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+	}
+}
+
+func TestPolicyNamePrefix(t *testing.T) {
+	clients, fake := newFakeClients()
+
+	policy := &Policy{
+		ClusterID:  "foo",
+		KMSKeyArn:  "arn:aws:kms:eu-central-1:123456789012:key/foo",
+		NamePrefix: "acme-",
+		S3Bucket:   "foo-bucket",
+		AWSEntity:  AWSEntity{Clients: clients},
+	}
+
+	_, err := policy.CreateIfNotExists()
+	assert.Nil(t, err, "Unexpected error")
+
+	createRole := fake.paramsOf("CreateRole")[0].(*iam.CreateRoleInput)
+	assert.Equal(t, "acme-foo-EC2-K8S-Role", aws.StringValue(createRole.RoleName), "Wrong role name")
+
+	putRolePolicy := fake.paramsOf("PutRolePolicy")[0].(*iam.PutRolePolicyInput)
+	assert.Equal(t, "acme-foo-EC2-K8S-Policy", aws.StringValue(putRolePolicy.PolicyName), "Wrong policy name")
+	// The cloudconfigs are still stored under the cluster ID.
+	assert.Contains(t, aws.StringValue(putRolePolicy.PolicyDocument), "arn:aws:s3:::foo-bucket/foo/*", "Wrong policy document")
+
+	createProfile := fake.paramsOf("CreateInstanceProfile")[0].(*iam.CreateInstanceProfileInput)
+	assert.Equal(t, "acme-foo-EC2-K8S-Role", aws.StringValue(createProfile.InstanceProfileName), "Wrong instance profile name")
+	assert.Equal(t, "acme-foo-EC2-K8S-Role", policy.GetName(), "Wrong instance profile name")
+}
@@ -31,14 +31,57 @@ func (record RecordSet) CreateOrFail() error {
 	return nil
 }
 
+// Delete deletes the A record of the domain as it exists in the hosted zone.
+// Resource is not needed, so the record is deleted even when the resource it
+// points to is gone already.
 func (record RecordSet) Delete() error {
-	if err := record.perform(route53.ChangeActionDelete); err != nil {
+	if record.Client == nil {
+		return clientNotInitializedError
+	}
+
+	recordSet, err := record.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := record.Client.ChangeResourceRecordSets(&route53.ChangeResourceRecordSetsInput{
+		ChangeBatch: &route53.ChangeBatch{
+			Changes: []*route53.Change{
+				{
+					Action:            aws.String(route53.ChangeActionDelete),
+					ResourceRecordSet: recordSet,
+				},
+			},
+		},
+		HostedZoneId: aws.String(record.HostedZoneID),
+	}); err != nil {
 		return microerror.MaskAny(err)
 	}
 
 	return nil
 }
 
+// findExisting returns the A record of the domain in the hosted zone.
+func (record RecordSet) findExisting() (*route53.ResourceRecordSet, error) {
+	resp, err := record.Client.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(record.HostedZoneID),
+		StartRecordName: aws.String(record.Domain),
+		StartRecordType: aws.String(route53.RRTypeA),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	for _, recordSet := range resp.ResourceRecordSets {
+		if normalizeDNSName(aws.StringValue(recordSet.Name)) == normalizeDNSName(record.Domain) && aws.StringValue(recordSet.Type) == route53.RRTypeA {
+			return recordSet, nil
+		}
+	}
+
+	return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, RecordSetType, record.Domain)
+}
+
 func (record RecordSet) perform(action string) error {
 	if record.Client == nil {
 		return clientNotInitializedError
@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordSetDelete(t *testing.T) {
+	aliasRecordSet := func(name string) *route53.ResourceRecordSet {
+		return &route53.ResourceRecordSet{
+			Name: aws.String(name),
+			Type: aws.String(route53.RRTypeA),
+			AliasTarget: &route53.AliasTarget{
+				DNSName:              aws.String("foo-api-123.eu-central-1.elb.amazonaws.com."),
+				HostedZoneId:         aws.String("Z215JYRZR1TBD5"),
+				EvaluateTargetHealth: aws.Bool(false),
+			},
+		}
+	}
+
+	tests := []struct {
+		desc          string
+		recordSets    []*route53.ResourceRecordSet
+		errorMatcher  func(error) bool
+		resOperations []string
+	}{
+		{
+			desc:          "existing record set is deleted",
+			recordSets:    []*route53.ResourceRecordSet{aliasRecordSet("api.foo.example.com.")},
+			resOperations: []string{"ListResourceRecordSets", "ChangeResourceRecordSets"},
+		},
+		{
+			desc:          "missing record set",
+			recordSets:    []*route53.ResourceRecordSet{aliasRecordSet("etcd.foo.example.com.")},
+			errorMatcher:  IsNotFound,
+			resOperations: []string{"ListResourceRecordSets"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		recordSets := tc.recordSets
+		fake.on("ListResourceRecordSets", func(params, output interface{}) error {
+			output.(*route53.ListResourceRecordSetsOutput).ResourceRecordSets = recordSets
+			return nil
+		})
+
+		// The record set is deleted without its load balancer, which might be
+		// gone already.
+		recordSet := RecordSet{
+			Client:       clients.Route53,
+			Domain:       "api.foo.example.com",
+			HostedZoneID: "Z123",
+		}
+
+		err := recordSet.Delete()
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("ChangeResourceRecordSets") {
+			change := params.(*route53.ChangeResourceRecordSetsInput).ChangeBatch.Changes[0]
+			assert.Equal(t, route53.ChangeActionDelete, aws.StringValue(change.Action), fmt.Sprintf("[%s] Wrong change action", tc.desc))
+			assert.Equal(t, tc.recordSets[0], change.ResourceRecordSet, fmt.Sprintf("[%s] The existing record set wasn't deleted", tc.desc))
+		}
+	}
+}
@@ -8,23 +8,34 @@ import (
 	microerror "github.com/giantswarm/microkit/error"
 )
 
+// defaultRouteCIDR is the destination of the default route.
+const defaultRouteCIDR = "0.0.0.0/0"
+
 type RouteTable struct {
 	Name   string
 	VpcID  string
 	id     string
 	Client *ec2.EC2
+	// OperatorID identifies the operator managing the route table. See
+	// AWSEntity.
+	OperatorID string
+	// Tags are added to the route table on creation. See AWSEntity.
+	Tags map[string]string
+	// ClusterID scopes the route table to the cluster with the given ID. See
+	// AWSEntity.
+	ClusterID string
 }
 
 func (r RouteTable) findExisting() (*ec2.RouteTable, error) {
 	routeTables, err := r.Client.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
-		Filters: []*ec2.Filter{
+		Filters: append([]*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
 				Values: []*string{
 					aws.String(r.Name),
 				},
 			},
-		},
+		}, append(operatorFilters(r.OperatorID), clusterIDFilters(r.ClusterID)...)...),
 	})
 	if err != nil {
 		return nil, microerror.MaskAny(err)
@@ -77,12 +88,12 @@ func (r *RouteTable) CreateOrFail() error {
 
 	if _, err := r.Client.CreateTags(&ec2.CreateTagsInput{
 		Resources: []*string{routeTable.RouteTable.RouteTableId},
-		Tags: []*ec2.Tag{
+		Tags: append([]*ec2.Tag{
 			{
 				Key:   aws.String(tagKeyName),
 				Value: aws.String(r.Name),
 			},
-		},
+		}, append(resourceTags(r.OperatorID, r.Tags), clusterIDTags(r.ClusterID)...)...),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
@@ -99,6 +110,10 @@ func (r *RouteTable) Delete() error {
 	}
 
 	for _, association := range routeTable.Associations {
+		// The main association of a VPC can't be removed, only replaced.
+		if aws.BoolValue(association.Main) {
+			continue
+		}
 		if _, err := r.Client.DisassociateRouteTable(&ec2.DisassociateRouteTableInput{
 			AssociationId: association.RouteTableAssociationId,
 		}); err != nil {
@@ -115,6 +130,35 @@ func (r *RouteTable) Delete() error {
 	return nil
 }
 
+// Drift returns how the existing route table differs from a public one, whose
+// default route goes to the Internet Gateway of the VPC. It fails with a not
+// found error when the route table doesn't exist.
+func (r RouteTable) Drift() ([]string, error) {
+	routeTable, err := r.findExisting()
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	gatewayID, err := r.getInternetGateway()
+	if IsNotFound(err) {
+		return []string{"the VPC has no internet gateway for the default route"}, nil
+	} else if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	route := defaultRoute(routeTable)
+	switch {
+	case route == nil:
+		return []string{"default route is missing"}, nil
+	case aws.StringValue(route.GatewayId) != gatewayID:
+		return []string{fmt.Sprintf("default route goes to '%s', want '%s'", aws.StringValue(route.GatewayId), gatewayID)}, nil
+	case aws.StringValue(route.State) == ec2.RouteStateBlackhole:
+		return []string{"default route is a blackhole"}, nil
+	}
+
+	return nil, nil
+}
+
 func (r RouteTable) GetID() (string, error) {
 	if r.id != "" {
 		return r.id, nil
@@ -128,22 +172,65 @@ func (r RouteTable) GetID() (string, error) {
 	return *routeTable.RouteTableId, nil
 }
 
-// MakePublic creates a route that allows traffic from outside the VPC.
-// To do that, it needs to add a route on the Internet Gateway of the VPC.
+// MakePublic makes sure the route table has a default route to the Internet
+// Gateway of the VPC, which allows traffic from outside the VPC. A reused VPC
+// might lack the route or have it point elsewhere, e.g. to a deleted gateway,
+// in which case it is created or replaced.
 func (r RouteTable) MakePublic() error {
 	gatewayID, err := r.getInternetGateway()
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
 
-	if _, err := r.Client.CreateRoute(&ec2.CreateRouteInput{
-		RouteTableId:         aws.String(r.id),
-		DestinationCidrBlock: aws.String("0.0.0.0/0"),
-		GatewayId:            aws.String(gatewayID),
-	}); err != nil {
+	routeTableID, err := r.GetID()
+	if err != nil {
 		return microerror.MaskAny(err)
 	}
 
+	resp, err := r.Client.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		RouteTableIds: []*string{
+			aws.String(routeTableID),
+		},
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(resp.RouteTables) == 0 {
+		return microerror.MaskAnyf(notFoundError, notFoundErrorFormat, RouteTableType, r.Name)
+	}
+
+	route := defaultRoute(resp.RouteTables[0])
+	switch {
+	case route == nil:
+		if _, err := r.Client.CreateRoute(&ec2.CreateRouteInput{
+			RouteTableId:         aws.String(routeTableID),
+			DestinationCidrBlock: aws.String(defaultRouteCIDR),
+			GatewayId:            aws.String(gatewayID),
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	case aws.StringValue(route.GatewayId) != gatewayID || aws.StringValue(route.State) == ec2.RouteStateBlackhole:
+		if _, err := r.Client.ReplaceRoute(&ec2.ReplaceRouteInput{
+			RouteTableId:         aws.String(routeTableID),
+			DestinationCidrBlock: aws.String(defaultRouteCIDR),
+			GatewayId:            aws.String(gatewayID),
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// defaultRoute returns the route of the route table matching all the
+// destinations, if any.
+func defaultRoute(routeTable *ec2.RouteTable) *ec2.Route {
+	for _, route := range routeTable.Routes {
+		if aws.StringValue(route.DestinationCidrBlock) == defaultRouteCIDR {
+			return route
+		}
+	}
+
 	return nil
 }
 
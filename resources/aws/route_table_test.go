@@ -0,0 +1,201 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTableMakePublic(t *testing.T) {
+	tests := []struct {
+		desc          string
+		routes        []*ec2.Route
+		resOperations []string
+	}{
+		{
+			desc: "missing default route of a reused VPC is created",
+			routes: []*ec2.Route{
+				{
+					DestinationCidrBlock: aws.String("10.0.0.0/16"),
+					GatewayId:            aws.String("local"),
+					State:                aws.String(ec2.RouteStateActive),
+				},
+			},
+			resOperations: []string{"DescribeInternetGateways", "DescribeRouteTables", "CreateRoute"},
+		},
+		{
+			desc: "default route to another gateway is replaced",
+			routes: []*ec2.Route{
+				{
+					DestinationCidrBlock: aws.String("0.0.0.0/0"),
+					GatewayId:            aws.String("igw-old"),
+					State:                aws.String(ec2.RouteStateBlackhole),
+				},
+			},
+			resOperations: []string{"DescribeInternetGateways", "DescribeRouteTables", "ReplaceRoute"},
+		},
+		{
+			desc: "existing default route is kept",
+			routes: []*ec2.Route{
+				{
+					DestinationCidrBlock: aws.String("0.0.0.0/0"),
+					GatewayId:            aws.String("igw-123"),
+					State:                aws.String(ec2.RouteStateActive),
+				},
+			},
+			resOperations: []string{"DescribeInternetGateways", "DescribeRouteTables"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInternetGateways", func(params, output interface{}) error {
+			output.(*ec2.DescribeInternetGatewaysOutput).InternetGateways = []*ec2.InternetGateway{
+				{InternetGatewayId: aws.String("igw-123")},
+			}
+			return nil
+		})
+		routes := tc.routes
+		fake.on("DescribeRouteTables", func(params, output interface{}) error {
+			output.(*ec2.DescribeRouteTablesOutput).RouteTables = []*ec2.RouteTable{
+				{
+					RouteTableId: aws.String("rtb-123"),
+					Routes:       routes,
+				},
+			}
+			return nil
+		})
+
+		routeTable := RouteTable{
+			Name:   "foo",
+			VpcID:  "vpc-123",
+			id:     "rtb-123",
+			Client: clients.EC2,
+		}
+
+		err := routeTable.MakePublic()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("CreateRoute") {
+			input := params.(*ec2.CreateRouteInput)
+			assert.Equal(t, "rtb-123", aws.StringValue(input.RouteTableId), fmt.Sprintf("[%s] Wrong route table", tc.desc))
+			assert.Equal(t, "0.0.0.0/0", aws.StringValue(input.DestinationCidrBlock), fmt.Sprintf("[%s] Wrong destination", tc.desc))
+			assert.Equal(t, "igw-123", aws.StringValue(input.GatewayId), fmt.Sprintf("[%s] Wrong gateway", tc.desc))
+		}
+		for _, params := range fake.paramsOf("ReplaceRoute") {
+			input := params.(*ec2.ReplaceRouteInput)
+			assert.Equal(t, "igw-123", aws.StringValue(input.GatewayId), fmt.Sprintf("[%s] Wrong gateway", tc.desc))
+		}
+	}
+}
+
+func TestRouteTableDelete(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeRouteTables", func(params, output interface{}) error {
+		output.(*ec2.DescribeRouteTablesOutput).RouteTables = []*ec2.RouteTable{
+			{
+				RouteTableId: aws.String("rtb-123"),
+				Associations: []*ec2.RouteTableAssociation{
+					{
+						Main:                    aws.Bool(true),
+						RouteTableAssociationId: aws.String("rtbassoc-main"),
+					},
+					{
+						Main:                    aws.Bool(false),
+						RouteTableAssociationId: aws.String("rtbassoc-123"),
+						SubnetId:                aws.String("subnet-123"),
+					},
+				},
+			},
+		}
+		return nil
+	})
+
+	routeTable := RouteTable{
+		Name:   "foo",
+		VpcID:  "vpc-123",
+		Client: clients.EC2,
+	}
+
+	err := routeTable.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"DescribeRouteTables", "DisassociateRouteTable", "DeleteRouteTable"}, fake.operations(), "Unexpected AWS API calls")
+
+	for _, params := range fake.paramsOf("DisassociateRouteTable") {
+		assert.Equal(t, "rtbassoc-123", aws.StringValue(params.(*ec2.DisassociateRouteTableInput).AssociationId), "Wrong association removed")
+	}
+}
+
+func TestRouteTableDrift(t *testing.T) {
+	tests := []struct {
+		desc     string
+		routes   []*ec2.Route
+		resDrift []string
+	}{
+		{
+			desc: "default route to the gateway is in sync",
+			routes: []*ec2.Route{
+				{
+					DestinationCidrBlock: aws.String("0.0.0.0/0"),
+					GatewayId:            aws.String("igw-123"),
+					State:                aws.String(ec2.RouteStateActive),
+				},
+			},
+			resDrift: nil,
+		},
+		{
+			desc:     "missing default route drifts",
+			routes:   nil,
+			resDrift: []string{"default route is missing"},
+		},
+		{
+			desc: "default route to another gateway drifts",
+			routes: []*ec2.Route{
+				{
+					DestinationCidrBlock: aws.String("0.0.0.0/0"),
+					GatewayId:            aws.String("igw-old"),
+					State:                aws.String(ec2.RouteStateBlackhole),
+				},
+			},
+			resDrift: []string{"default route goes to 'igw-old', want 'igw-123'"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeInternetGateways", func(params, output interface{}) error {
+			output.(*ec2.DescribeInternetGatewaysOutput).InternetGateways = []*ec2.InternetGateway{
+				{InternetGatewayId: aws.String("igw-123")},
+			}
+			return nil
+		})
+		routes := tc.routes
+		fake.on("DescribeRouteTables", func(params, output interface{}) error {
+			output.(*ec2.DescribeRouteTablesOutput).RouteTables = []*ec2.RouteTable{
+				{
+					RouteTableId: aws.String("rtb-123"),
+					Routes:       routes,
+				},
+			}
+			return nil
+		})
+
+		routeTable := RouteTable{
+			Name:   "foo",
+			VpcID:  "vpc-123",
+			Client: clients.EC2,
+		}
+
+		drift, err := routeTable.Drift()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resDrift, drift, fmt.Sprintf("[%s] Unexpected drift", tc.desc))
+	}
+
+	clients, _ := newFakeClients()
+	_, err := RouteTable{Name: "foo", Client: clients.EC2}.Drift()
+	assert.True(t, IsNotFound(err), fmt.Sprintf("Missing route table not reported: %v", err))
+}
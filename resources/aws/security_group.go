@@ -9,6 +9,9 @@ import (
 	microerror "github.com/giantswarm/microkit/error"
 )
 
+// defaultSecurityGroupRuleProtocol is the protocol of rules not defining one.
+const defaultSecurityGroupRuleProtocol = "tcp"
+
 type SecurityGroup struct {
 	Description string
 	GroupName   string
@@ -20,7 +23,14 @@ type SecurityGroup struct {
 
 // SecurityGroupRule is an AWS security group rule.
 type SecurityGroupRule struct {
+	// Protocol is the IP protocol of the rule, e.g. tcp, udp or -1 for all
+	// protocols. It is tcp when empty.
+	Protocol string
+	// Port is the first port of the rule.
 	Port int
+	// ToPort is the last port of the rule, when it opens a port range. Only Port
+	// is opened when it is zero.
+	ToPort int
 	// SourceCIDR is the CIDR of the source.
 	SourceCIDR string
 	// SecurityGroupID is the ID of the source Security Group.
@@ -29,7 +39,7 @@ type SecurityGroupRule struct {
 
 func (s SecurityGroup) findExisting() (*ec2.SecurityGroup, error) {
 	securityGroups, err := s.Clients.EC2.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
-		Filters: []*ec2.Filter{
+		Filters: append([]*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String(subnetDescription),
 				Values: []*string{
@@ -42,7 +52,7 @@ func (s SecurityGroup) findExisting() (*ec2.SecurityGroup, error) {
 					aws.String(s.GroupName),
 				},
 			},
-		},
+		}, append(operatorFilters(s.OperatorID), clusterIDFilters(s.ClusterID)...)...),
 	})
 	if err != nil {
 		return nil, microerror.MaskAny(err)
@@ -73,9 +83,29 @@ func (s *SecurityGroup) CreateIfNotExists() (bool, error) {
 	return true, nil
 }
 
-// createRule creates a security group rule.
-// SourceCIDR always takes precedence over SecurityGroupID.
-func (s *SecurityGroup) createRule(rule SecurityGroupRule) error {
+func (r SecurityGroupRule) protocol() string {
+	if r.Protocol == "" {
+		return defaultSecurityGroupRuleProtocol
+	}
+
+	return r.Protocol
+}
+
+func (r SecurityGroupRule) toPort() int {
+	if r.ToPort == 0 {
+		return r.Port
+	}
+
+	return r.ToPort
+}
+
+// AddRule authorizes the ingress traffic of a rule, unless it is already
+// authorized. SourceCIDR always takes precedence over SecurityGroupID.
+func (s SecurityGroup) AddRule(rule SecurityGroupRule) error {
+	if rule.toPort() < rule.Port {
+		return microerror.MaskAnyf(invalidSecurityGroupRuleError, "port range %d-%d ends before it starts", rule.Port, rule.ToPort)
+	}
+
 	groupID, err := s.GetID()
 	if err != nil {
 		return microerror.MaskAny(err)
@@ -86,9 +116,9 @@ func (s *SecurityGroup) createRule(rule SecurityGroupRule) error {
 		params = &ec2.AuthorizeSecurityGroupIngressInput{
 			CidrIp:     aws.String(rule.SourceCIDR),
 			GroupId:    aws.String(groupID),
-			IpProtocol: aws.String("tcp"),
+			IpProtocol: aws.String(rule.protocol()),
 			FromPort:   aws.Int64(int64(rule.Port)),
-			ToPort:     aws.Int64(int64(rule.Port)),
+			ToPort:     aws.Int64(int64(rule.toPort())),
 		}
 	} else {
 		params = &ec2.AuthorizeSecurityGroupIngressInput{
@@ -96,8 +126,8 @@ func (s *SecurityGroup) createRule(rule SecurityGroupRule) error {
 			IpPermissions: []*ec2.IpPermission{
 				{
 					FromPort:   aws.Int64(int64(rule.Port)),
-					ToPort:     aws.Int64(int64(rule.Port)),
-					IpProtocol: aws.String("tcp"),
+					ToPort:     aws.Int64(int64(rule.toPort())),
+					IpProtocol: aws.String(rule.protocol()),
 					UserIdGroupPairs: []*ec2.UserIdGroupPair{
 						{
 							GroupId: aws.String(rule.SecurityGroupID),
@@ -109,6 +139,9 @@ func (s *SecurityGroup) createRule(rule SecurityGroupRule) error {
 	}
 
 	if _, err := s.Clients.EC2.AuthorizeSecurityGroupIngress(params); err != nil {
+		if strings.Contains(err.Error(), awsclient.SecurityGroupRuleDuplicate) {
+			return nil
+		}
 		return microerror.MaskAny(err)
 	}
 
@@ -127,14 +160,25 @@ func (s *SecurityGroup) CreateOrFail() error {
 
 	s.id = *securityGroup.GroupId
 
-	s.ApplyRules(s.Rules)
+	if tags := append(resourceTags(s.OperatorID, s.Tags), clusterIDTags(s.ClusterID)...); len(tags) > 0 {
+		if _, err := s.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
+			Resources: []*string{securityGroup.GroupId},
+			Tags:      tags,
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	if err := s.ApplyRules(s.Rules); err != nil {
+		return microerror.MaskAny(err)
+	}
 
 	return nil
 }
 
 func (s SecurityGroup) ApplyRules(rules []SecurityGroupRule) error {
 	for _, rule := range rules {
-		if err := s.createRule(rule); err != nil {
+		if err := s.AddRule(rule); err != nil {
 			return microerror.MaskAny(err)
 		}
 	}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityGroupApplyRules(t *testing.T) {
+	tests := []struct {
+		desc     string
+		response fakeResponse
+		res      bool
+	}{
+		{
+			desc: "rules are created",
+			response: func(params, output interface{}) error {
+				return nil
+			},
+		},
+		{
+			desc: "existing rules are kept",
+			response: func(params, output interface{}) error {
+				return awserr.New("InvalidPermission.Duplicate", "the specified rule already exists", nil)
+			},
+		},
+		{
+			desc: "other failures are returned",
+			response: func(params, output interface{}) error {
+				return awserr.New("InvalidGroup.NotFound", "the security group does not exist", nil)
+			},
+			res: true,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("AuthorizeSecurityGroupIngress", tc.response)
+
+		sg := SecurityGroup{
+			id:        "sg-workers",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := sg.ApplyRules([]SecurityGroupRule{
+			{Port: 443, SourceCIDR: "0.0.0.0/0"},
+			{Port: 30010, SecurityGroupID: "sg-ingress"},
+		})
+		assert.Equal(t, tc.res, err != nil, fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		if tc.res {
+			continue
+		}
+
+		params := fake.paramsOf("AuthorizeSecurityGroupIngress")
+		assert.Len(t, params, 2, fmt.Sprintf("[%s] Expected one call per rule", tc.desc))
+		assert.Equal(t, "0.0.0.0/0", *params[0].(*ec2.AuthorizeSecurityGroupIngressInput).CidrIp, fmt.Sprintf("[%s] Wrong source CIDR", tc.desc))
+		pair := params[1].(*ec2.AuthorizeSecurityGroupIngressInput).IpPermissions[0].UserIdGroupPairs[0]
+		assert.Equal(t, "sg-ingress", *pair.GroupId, fmt.Sprintf("[%s] Wrong source security group", tc.desc))
+	}
+}
+
+func TestSecurityGroupAddRule(t *testing.T) {
+	tests := []struct {
+		desc         string
+		rule         SecurityGroupRule
+		resProtocol  string
+		resFromPort  int64
+		resToPort    int64
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:        "single TCP port by default",
+			rule:        SecurityGroupRule{Port: 443, SourceCIDR: "0.0.0.0/0"},
+			resProtocol: "tcp",
+			resFromPort: 443,
+			resToPort:   443,
+		},
+		{
+			desc:        "UDP port range",
+			rule:        SecurityGroupRule{Protocol: "udp", Port: 30000, ToPort: 32767, SourceCIDR: "10.0.0.0/16"},
+			resProtocol: "udp",
+			resFromPort: 30000,
+			resToPort:   32767,
+		},
+		{
+			desc:         "port range ending before it starts",
+			rule:         SecurityGroupRule{Port: 443, ToPort: 80, SourceCIDR: "0.0.0.0/0"},
+			errorMatcher: IsInvalidSecurityGroupRule,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+
+		sg := SecurityGroup{
+			id:        "sg-workers",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := sg.AddRule(tc.rule)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			assert.Empty(t, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		params := fake.paramsOf("AuthorizeSecurityGroupIngress")
+		assert.Len(t, params, 1, fmt.Sprintf("[%s] Expected one call", tc.desc))
+		input := params[0].(*ec2.AuthorizeSecurityGroupIngressInput)
+		assert.Equal(t, tc.resProtocol, *input.IpProtocol, fmt.Sprintf("[%s] Wrong protocol", tc.desc))
+		assert.Equal(t, tc.resFromPort, *input.FromPort, fmt.Sprintf("[%s] Wrong first port", tc.desc))
+		assert.Equal(t, tc.resToPort, *input.ToPort, fmt.Sprintf("[%s] Wrong last port", tc.desc))
+	}
+}
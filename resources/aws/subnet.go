@@ -2,6 +2,7 @@ package aws
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -26,14 +27,14 @@ type Subnet struct {
 
 func (s Subnet) findExisting() (*ec2.Subnet, error) {
 	subnets, err := s.Clients.EC2.DescribeSubnets(&ec2.DescribeSubnetsInput{
-		Filters: []*ec2.Filter{
+		Filters: append([]*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
 				Values: []*string{
 					aws.String(s.Name),
 				},
 			},
-		},
+		}, append(operatorFilters(s.OperatorID), clusterIDFilters(s.ClusterID)...)...),
 	})
 	if err != nil {
 		return nil, microerror.MaskAny(err)
@@ -47,13 +48,15 @@ func (s Subnet) findExisting() (*ec2.Subnet, error) {
 }
 
 func (s *Subnet) checkIfExists() (bool, error) {
-	_, err := s.findExisting()
+	subnet, err := s.findExisting()
 	if IsNotFound(err) {
 		return false, nil
 	} else if err != nil {
 		return false, microerror.MaskAny(err)
 	}
 
+	s.id = *subnet.SubnetId
+
 	return true, nil
 }
 
@@ -84,14 +87,21 @@ func (s *Subnet) CreateOrFail() error {
 		return microerror.MaskAny(err)
 	}
 
+	// Instances can only be launched into available subnets.
+	if err := s.Clients.EC2.WaitUntilSubnetAvailable(&ec2.DescribeSubnetsInput{
+		SubnetIds: []*string{subnet.Subnet.SubnetId},
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
 	if _, err := s.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
 		Resources: []*string{subnet.Subnet.SubnetId},
-		Tags: []*ec2.Tag{
+		Tags: append([]*ec2.Tag{
 			{
 				Key:   aws.String(tagKeyName),
 				Value: aws.String(s.Name),
 			},
-		},
+		}, append(resourceTags(s.OperatorID, s.Tags), clusterIDTags(s.ClusterID)...)...),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
@@ -123,6 +133,25 @@ func (s *Subnet) Delete() error {
 	return nil
 }
 
+// Drift returns how the existing subnet differs from its definition. It fails
+// with a not found error when the subnet doesn't exist.
+func (s Subnet) Drift() ([]string, error) {
+	subnet, err := s.findExisting()
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var drift []string
+	if cidrBlock := aws.StringValue(subnet.CidrBlock); cidrBlock != s.CidrBlock {
+		drift = append(drift, fmt.Sprintf("CIDR block is '%s', want '%s'", cidrBlock, s.CidrBlock))
+	}
+	if az := aws.StringValue(subnet.AvailabilityZone); az != s.AvailabilityZone {
+		drift = append(drift, fmt.Sprintf("availability zone is '%s', want '%s'", az, s.AvailabilityZone))
+	}
+
+	return drift, nil
+}
+
 func (s Subnet) GetID() (string, error) {
 	if s.id != "" {
 		return s.id, nil
@@ -167,3 +196,58 @@ func (s *Subnet) MakePublic(routeTable *RouteTable) error {
 
 	return nil
 }
+
+// OverlappingSubnets returns the subnets of the VPC with the given ID whose CIDR
+// block overlaps the given one, as "<subnet ID> (<CIDR block>)". The subnets
+// scoped to the cluster with the given ID are left out, since they are the
+// cluster's own ones. See AWSEntity.ClusterID.
+func OverlappingSubnets(clients awsclient.Clients, vpcID, clusterID, cidrBlock string) ([]string, error) {
+	_, network, err := net.ParseCIDR(cidrBlock)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	resp, err := clients.EC2.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name: aws.String(subnetVpcID),
+				Values: []*string{
+					aws.String(vpcID),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var overlapping []string
+	for _, subnet := range resp.Subnets {
+		if clusterID != "" && subnetClusterID(subnet) == clusterID {
+			continue
+		}
+
+		_, other, err := net.ParseCIDR(aws.StringValue(subnet.CidrBlock))
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+		// CIDR blocks either contain one another or are disjoint.
+		if network.Contains(other.IP) || other.Contains(network.IP) {
+			overlapping = append(overlapping, fmt.Sprintf("%s (%s)", aws.StringValue(subnet.SubnetId), aws.StringValue(subnet.CidrBlock)))
+		}
+	}
+
+	return overlapping, nil
+}
+
+// subnetClusterID returns the ID of the cluster the given subnet is scoped to,
+// if any.
+func subnetClusterID(subnet *ec2.Subnet) string {
+	for _, tag := range subnet.Tags {
+		if aws.StringValue(tag.Key) == tagKeyClusterID {
+			return aws.StringValue(tag.Value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,194 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubnetCreateIfNotExists(t *testing.T) {
+	available := func(params, output interface{}) error {
+		output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{
+			{
+				SubnetId: aws.String("subnet-123"),
+				State:    aws.String(ec2.SubnetStateAvailable),
+			},
+		}
+		return nil
+	}
+	missing := func(params, output interface{}) error {
+		return nil
+	}
+
+	tests := []struct {
+		desc          string
+		responses     []fakeResponse
+		resCreated    bool
+		resOperations []string
+	}{
+		{
+			desc:          "missing subnet is created, tagged once available",
+			responses:     []fakeResponse{missing, available},
+			resCreated:    true,
+			resOperations: []string{"DescribeSubnets", "CreateSubnet", "DescribeSubnets", "CreateTags"},
+		},
+		{
+			desc:          "existing subnet is reused",
+			responses:     []fakeResponse{available},
+			resCreated:    false,
+			resOperations: []string{"DescribeSubnets"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeSubnets", tc.responses...)
+		fake.on("CreateSubnet", func(params, output interface{}) error {
+			output.(*ec2.CreateSubnetOutput).Subnet = &ec2.Subnet{
+				SubnetId: aws.String("subnet-123"),
+			}
+			return nil
+		})
+
+		subnet := &Subnet{
+			AvailabilityZone: "eu-central-1a",
+			CidrBlock:        "10.0.1.0/24",
+			Name:             "foo-public",
+			VpcID:            "vpc-123",
+			AWSEntity:        AWSEntity{Clients: clients},
+		}
+
+		created, err := subnet.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Unexpected created flag", tc.desc))
+
+		id, err := subnet.GetID()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, "subnet-123", id, fmt.Sprintf("[%s] Unexpected subnet ID", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("CreateTags") {
+			assert.Equal(t, []*ec2.Tag{
+				{Key: aws.String("Name"), Value: aws.String("foo-public")},
+			}, params.(*ec2.CreateTagsInput).Tags, fmt.Sprintf("[%s] The subnet wasn't tagged with its name", tc.desc))
+		}
+	}
+}
+
+func TestSubnetDrift(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeSubnets", func(params, output interface{}) error {
+		output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{
+			{
+				SubnetId:         aws.String("subnet-123"),
+				AvailabilityZone: aws.String("eu-central-1b"),
+				CidrBlock:        aws.String("10.0.1.0/24"),
+			},
+		}
+		return nil
+	})
+
+	subnet := Subnet{
+		AvailabilityZone: "eu-central-1a",
+		CidrBlock:        "10.0.1.0/24",
+		Name:             "foo-public",
+		AWSEntity:        AWSEntity{Clients: clients},
+	}
+
+	drift, err := subnet.Drift()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"availability zone is 'eu-central-1b', want 'eu-central-1a'"}, drift, "Unexpected drift")
+}
+
+func TestSubnetDeleteScopedToCluster(t *testing.T) {
+	clients, fake := newFakeClients()
+	// The shared VPC only has a subnet of another cluster with the same name.
+	fake.on("DescribeSubnets", func(params, output interface{}) error {
+		for _, filter := range params.(*ec2.DescribeSubnetsInput).Filters {
+			if *filter.Name == "tag:ClusterID" && *filter.Values[0] != "other" {
+				return nil
+			}
+		}
+		output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{
+			{SubnetId: aws.String("subnet-other")},
+		}
+		return nil
+	})
+
+	subnet := &Subnet{
+		Name:      "foo-public",
+		AWSEntity: AWSEntity{Clients: clients, ClusterID: "abc12"},
+	}
+
+	err := subnet.Delete()
+	assert.True(t, IsNotFound(err), fmt.Sprintf("Unexpected error: %v", err))
+	assert.Equal(t, []string{"DescribeSubnets"}, fake.operations(), "The subnet of another cluster was deleted")
+}
+
+func TestOverlappingSubnets(t *testing.T) {
+	subnets := []*ec2.Subnet{
+		{
+			SubnetId:  aws.String("subnet-own"),
+			CidrBlock: aws.String("10.0.1.0/24"),
+			Tags:      []*ec2.Tag{{Key: aws.String("ClusterID"), Value: aws.String("abc12")}},
+		},
+		{
+			SubnetId:  aws.String("subnet-other"),
+			CidrBlock: aws.String("10.0.2.0/24"),
+			Tags:      []*ec2.Tag{{Key: aws.String("ClusterID"), Value: aws.String("def34")}},
+		},
+		{
+			SubnetId:  aws.String("subnet-unmanaged"),
+			CidrBlock: aws.String("10.0.128.0/17"),
+		},
+	}
+
+	tests := []struct {
+		desc      string
+		cidrBlock string
+		res       []string
+	}{
+		{
+			desc:      "own subnet doesn't overlap",
+			cidrBlock: "10.0.1.0/24",
+			res:       nil,
+		},
+		{
+			desc:      "free CIDR block",
+			cidrBlock: "10.0.3.0/24",
+			res:       nil,
+		},
+		{
+			desc:      "subnet of another cluster",
+			cidrBlock: "10.0.2.0/24",
+			res:       []string{"subnet-other (10.0.2.0/24)"},
+		},
+		{
+			desc:      "CIDR block within an unmanaged subnet",
+			cidrBlock: "10.0.200.0/24",
+			res:       []string{"subnet-unmanaged (10.0.128.0/17)"},
+		},
+		{
+			desc:      "CIDR block containing several subnets",
+			cidrBlock: "10.0.0.0/16",
+			res:       []string{"subnet-other (10.0.2.0/24)", "subnet-unmanaged (10.0.128.0/17)"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeSubnets", func(params, output interface{}) error {
+			output.(*ec2.DescribeSubnetsOutput).Subnets = subnets
+			return nil
+		})
+
+		res, err := OverlappingSubnets(clients, "vpc-shared", "abc12", tc.cidrBlock)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] Wrong overlapping subnets", tc.desc))
+		filters := filterStrings(fake.paramsOf("DescribeSubnets")[0].(*ec2.DescribeSubnetsInput).Filters)
+		assert.Equal(t, []string{"vpc-id=vpc-shared"}, filters, fmt.Sprintf("[%s] Subnets not listed within the VPC", tc.desc))
+	}
+}
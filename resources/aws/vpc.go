@@ -4,27 +4,106 @@ import (
 	"fmt"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	microerror "github.com/giantswarm/microkit/error"
+	"github.com/pborman/uuid"
 )
 
+const (
+	// flowLogsDestinationFormat is the format of the ARN of the folder of the S3
+	// bucket the flow logs of VPCs are delivered to.
+	flowLogsDestinationFormat = "arn:aws:s3:::%s/flow-logs/"
+	flowLogsDestinationTypeS3 = "s3"
+)
+
+// createFlowLogsInput is the input of CreateFlowLogs, with the S3 destination
+// of the flow logs. The vendored SDK predates S3 destinations, so
+// ec2.CreateFlowLogsInput only supports CloudWatch log groups.
+type createFlowLogsInput struct {
+	_ struct{} `type:"structure"`
+
+	ClientToken        *string   `type:"string"`
+	LogDestination     *string   `type:"string"`
+	LogDestinationType *string   `type:"string"`
+	ResourceIds        []*string `locationName:"ResourceId" locationNameList:"item" type:"list"`
+	ResourceType       *string   `type:"string"`
+	TrafficType        *string   `type:"string"`
+}
+
+// DHCPOptions are the options of a custom DHCP options set of a VPC.
+type DHCPOptions struct {
+	// DomainName is the search domain of the instances, e.g. the name of a
+	// private hosted zone.
+	DomainName string
+	// DomainNameServers are the DNS servers of the instances. They default to
+	// the DNS server of the VPC.
+	DomainNameServers []string
+}
+
 type VPC struct {
 	CidrBlock string
-	Name      string
-	id        string
+	// DHCPOptions makes the VPC use a custom DHCP options set. Existing VPCs are
+	// left alone.
+	DHCPOptions *DHCPOptions
+	// ExistingID references a VPC managed outside of the operator, e.g. one
+	// provisioned centrally or shared by several clusters. Such a VPC is only
+	// looked up by ID, it is never created or deleted.
+	ExistingID string
+	// FlowLogs makes the VPC log its IP traffic to the S3 bucket
+	// FlowLogsS3Bucket. Existing VPCs are left alone.
+	FlowLogs         bool
+	FlowLogsS3Bucket string
+	// Force makes Delete delete the internet gateways and subnets the operator
+	// created for the VPC first, e.g. when earlier deletions of the cluster
+	// didn't go through.
+	Force bool
+	Name  string
+	id    string
 	AWSEntity
 }
 
+// findExisting returns the VPC with the ID ExistingID, or else the VPC named
+// Name. A VPC created recently is waited for, since it might not be visible
+// yet.
 func (v VPC) findExisting() (*ec2.Vpc, error) {
+	if v.ExistingID != "" {
+		vpc, err := v.describeExisting()
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+
+		return vpc, nil
+	}
+
+	var vpc *ec2.Vpc
+	err := findCreated(v.creationKey(), func() error {
+		var err error
+		vpc, err = v.describe()
+		return err
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return vpc, nil
+}
+
+func (v VPC) creationKey() string {
+	return creationKey(VPCType, v.OperatorID, v.Name)
+}
+
+func (v VPC) describe() (*ec2.Vpc, error) {
 	vpcs, err := v.Clients.EC2.DescribeVpcs(&ec2.DescribeVpcsInput{
-		Filters: []*ec2.Filter{
+		Filters: append([]*ec2.Filter{
 			&ec2.Filter{
 				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
 				Values: []*string{
 					aws.String(v.Name),
 				},
 			},
-		},
+		}, operatorFilters(v.OperatorID)...),
 	})
 	if err != nil {
 		return nil, microerror.MaskAny(err)
@@ -37,6 +116,26 @@ func (v VPC) findExisting() (*ec2.Vpc, error) {
 	return vpcs.Vpcs[0], nil
 }
 
+// describeExisting returns the VPC with the ID ExistingID, whatever its tags.
+func (v VPC) describeExisting() (*ec2.Vpc, error) {
+	vpcs, err := v.Clients.EC2.DescribeVpcs(&ec2.DescribeVpcsInput{
+		VpcIds: []*string{
+			aws.String(v.ExistingID),
+		},
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == vpcNotFoundErrorCode {
+		return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, VPCType, v.ExistingID)
+	} else if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	if len(vpcs.Vpcs) < 1 {
+		return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, VPCType, v.ExistingID)
+	}
+
+	return vpcs.Vpcs[0], nil
+}
+
 func (v *VPC) checkIfExists() (bool, error) {
 	_, err := v.findExisting()
 	if IsNotFound(err) {
@@ -55,8 +154,18 @@ func (v *VPC) CreateIfNotExists() (bool, error) {
 	}
 
 	if exists {
+		if err := v.ensureFlowLogs(); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+		if err := v.ensureDHCPOptions(); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+
 		return false, nil
 	}
+	if v.ExistingID != "" {
+		return false, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, VPCType, v.ExistingID)
+	}
 
 	if err := v.CreateOrFail(); err != nil {
 		return false, microerror.MaskAny(err)
@@ -66,6 +175,10 @@ func (v *VPC) CreateIfNotExists() (bool, error) {
 }
 
 func (v *VPC) CreateOrFail() error {
+	if v.ExistingID != "" {
+		return microerror.MaskAnyf(existingVPCError, "VPC '%s' is managed outside of the operator and cannot be created", v.ExistingID)
+	}
+
 	vpc, err := v.Clients.EC2.CreateVpc(&ec2.CreateVpcInput{
 		CidrBlock: aws.String(v.CidrBlock),
 	})
@@ -86,12 +199,12 @@ func (v *VPC) CreateOrFail() error {
 		Resources: []*string{
 			aws.String(vpcID),
 		},
-		Tags: []*ec2.Tag{
+		Tags: append([]*ec2.Tag{
 			{
 				Key:   aws.String(tagKeyName),
 				Value: aws.String(v.Name),
 			},
-		},
+		}, resourceTags(v.OperatorID, v.Tags)...),
 	}); err != nil {
 		return microerror.MaskAny(err)
 	}
@@ -116,25 +229,177 @@ func (v *VPC) CreateOrFail() error {
 	}
 
 	v.id = vpcID
+	recentCreations.add(v.creationKey())
+
+	if v.FlowLogs {
+		if err := v.createFlowLogs(vpcID); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	if v.DHCPOptions != nil {
+		if err := v.createDHCPOptions(vpcID); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
 
 	return nil
 }
 
+// ensureFlowLogs creates the flow logs of the VPC when they are enabled and
+// missing, e.g. for VPCs created before they were enabled.
+func (v *VPC) ensureFlowLogs() error {
+	if !v.FlowLogs || v.ExistingID != "" {
+		return nil
+	}
+
+	vpcID, err := v.GetID()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	flowLogIDs, err := v.flowLogIDs(vpcID)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(flowLogIDs) > 0 {
+		return nil
+	}
+
+	if err := v.createFlowLogs(vpcID); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// createFlowLogs makes the VPC with the given ID log all its IP traffic to the
+// S3 bucket FlowLogsS3Bucket.
+func (v VPC) createFlowLogs(vpcID string) error {
+	if v.FlowLogsS3Bucket == "" {
+		return microerror.MaskAnyf(attributeEmptyError, attributeEmptyErrorFormat, "FlowLogsS3Bucket")
+	}
+
+	output := &ec2.CreateFlowLogsOutput{}
+	req := v.Clients.EC2.NewRequest(&request.Operation{
+		Name:       "CreateFlowLogs",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &createFlowLogsInput{
+		ClientToken:        aws.String(uuid.New()),
+		LogDestination:     aws.String(fmt.Sprintf(flowLogsDestinationFormat, v.FlowLogsS3Bucket)),
+		LogDestinationType: aws.String(flowLogsDestinationTypeS3),
+		ResourceIds: []*string{
+			aws.String(vpcID),
+		},
+		ResourceType: aws.String(ec2.FlowLogsResourceTypeVpc),
+		TrafficType:  aws.String(ec2.TrafficTypeAll),
+	}, output)
+	if err := req.Send(); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	for _, item := range output.Unsuccessful {
+		if item.Error != nil {
+			return microerror.MaskAnyf(flowLogsNotCreatedError, "flow logs of VPC '%s': %s", vpcID, aws.StringValue(item.Error.Message))
+		}
+	}
+
+	return nil
+}
+
+// flowLogIDs returns the IDs of the flow logs of the VPC with the given ID.
+func (v VPC) flowLogIDs(vpcID string) ([]*string, error) {
+	resp, err := v.Clients.EC2.DescribeFlowLogs(&ec2.DescribeFlowLogsInput{
+		Filter: []*ec2.Filter{
+			{
+				Name: aws.String("resource-id"),
+				Values: []*string{
+					aws.String(vpcID),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []*string
+	for _, flowLog := range resp.FlowLogs {
+		ids = append(ids, flowLog.FlowLogId)
+	}
+
+	return ids, nil
+}
+
 func (v *VPC) Delete() error {
+	if v.ExistingID != "" {
+		return microerror.MaskAnyf(existingVPCError, "VPC '%s' is managed outside of the operator and cannot be deleted", v.ExistingID)
+	}
+
 	vpc, err := v.findExisting()
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
 
-	if _, err := v.Clients.EC2.DeleteVpc(&ec2.DeleteVpcInput{
+	if v.FlowLogs {
+		flowLogIDs, err := v.flowLogIDs(*vpc.VpcId)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		if len(flowLogIDs) > 0 {
+			if _, err := v.Clients.EC2.DeleteFlowLogs(&ec2.DeleteFlowLogsInput{
+				FlowLogIds: flowLogIDs,
+			}); err != nil {
+				return microerror.MaskAny(err)
+			}
+		}
+	}
+
+	if v.DHCPOptions != nil {
+		if err := v.deleteDHCPOptions(*vpc.VpcId); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	if v.Force {
+		if err := v.deleteCreatedDependencies(*vpc.VpcId); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	// AWS only tells the VPC has dependencies, so they are looked up to tell
+	// which ones.
+	_, err = v.Clients.EC2.DeleteVpc(&ec2.DeleteVpcInput{
 		VpcId: vpc.VpcId,
-	}); err != nil {
+	})
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dependencyViolationErrorCode {
+		return microerror.MaskAny(v.dependenciesError(*vpc.VpcId))
+	} else if err != nil {
 		return microerror.MaskAny(err)
 	}
+	recentCreations.remove(v.creationKey())
 
 	return nil
 }
 
+// Drift returns how the existing VPC differs from its definition. It fails with
+// a not found error when the VPC doesn't exist.
+func (v VPC) Drift() ([]string, error) {
+	vpc, err := v.findExisting()
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var drift []string
+	if cidrBlock := aws.StringValue(vpc.CidrBlock); v.CidrBlock != "" && cidrBlock != v.CidrBlock {
+		drift = append(drift, fmt.Sprintf("CIDR block is '%s', want '%s'", cidrBlock, v.CidrBlock))
+	}
+
+	return drift, nil
+}
+
+// GetID returns the ID of the VPC. An existing VPC is looked up, so its ID is
+// only returned when it exists.
 func (v VPC) GetID() (string, error) {
 	if v.id != "" {
 		return v.id, nil
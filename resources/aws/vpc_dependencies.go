@@ -0,0 +1,231 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// vpcDependency lists the IDs of the resources of a type within a VPC.
+type vpcDependency struct {
+	resourceType resourceType
+	list         func(vpcID string) ([]string, error)
+}
+
+// dependencies returns the resources within the VPC with the given ID, which
+// keep it from being deleted. The default security group and main route table
+// are left out, since they go away with the VPC.
+func (v VPC) dependencies(vpcID string) ([]string, error) {
+	var dependencies []string
+	for _, dependency := range []vpcDependency{
+		{resourceType: SubnetType, list: v.subnetIDs},
+		{resourceType: GatewayType, list: v.gatewayIDs},
+		{resourceType: NetworkInterfaceType, list: v.networkInterfaceIDs},
+		{resourceType: SecurityGroupType, list: v.securityGroupIDs},
+		{resourceType: RouteTableType, list: v.routeTableIDs},
+		{resourceType: VPCEndpointType, list: v.vpcEndpointIDs},
+	} {
+		ids, err := dependency.list(vpcID)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+		if len(ids) > 0 {
+			dependencies = append(dependencies, fmt.Sprintf("%s %s", dependency.resourceType, strings.Join(ids, ", ")))
+		}
+	}
+
+	return dependencies, nil
+}
+
+// dependenciesError returns a vpcHasDependenciesError listing the resources
+// keeping the VPC with the given ID from being deleted.
+func (v VPC) dependenciesError(vpcID string) error {
+	dependencies, err := v.dependencies(vpcID)
+	if err != nil {
+		return microerror.MaskAnyf(vpcHasDependenciesError, "VPC '%s' has dependencies which could not be listed: %s", vpcID, err.Error())
+	}
+
+	return microerror.MaskAnyf(vpcHasDependenciesError, "VPC '%s' still has %s", vpcID, strings.Join(dependencies, "; "))
+}
+
+// deleteCreatedDependencies deletes the internet gateways and subnets created
+// by the operator for the VPC with the given ID, i.e. named like the VPC, so
+// the VPC can be deleted.
+func (v VPC) deleteCreatedDependencies(vpcID string) error {
+	gateways, err := v.Clients.EC2.DescribeInternetGateways(&ec2.DescribeInternetGatewaysInput{
+		Filters: append(v.createdFilters(), vpcFilter("attachment.vpc-id", vpcID)),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	for _, gateway := range gateways.InternetGateways {
+		if _, err := v.Clients.EC2.DetachInternetGateway(&ec2.DetachInternetGatewayInput{
+			InternetGatewayId: gateway.InternetGatewayId,
+			VpcId:             aws.String(vpcID),
+		}); err != nil && !IsAlreadyDeleted(err) {
+			return microerror.MaskAny(err)
+		}
+		if _, err := v.Clients.EC2.DeleteInternetGateway(&ec2.DeleteInternetGatewayInput{
+			InternetGatewayId: gateway.InternetGatewayId,
+		}); err != nil && !IsAlreadyDeleted(err) {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	subnets, err := v.Clients.EC2.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: append(v.createdFilters(), vpcFilter("vpc-id", vpcID)),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	for _, subnet := range subnets.Subnets {
+		if _, err := v.Clients.EC2.DeleteSubnet(&ec2.DeleteSubnetInput{
+			SubnetId: subnet.SubnetId,
+		}); err != nil && !IsAlreadyDeleted(err) {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// createdFilters returns the filters matching the resources the operator
+// created for the VPC.
+func (v VPC) createdFilters() []*ec2.Filter {
+	return append([]*ec2.Filter{
+		{
+			Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
+			Values: []*string{
+				aws.String(v.Name),
+			},
+		},
+	}, operatorFilters(v.OperatorID)...)
+}
+
+func vpcFilter(name, vpcID string) *ec2.Filter {
+	return &ec2.Filter{
+		Name: aws.String(name),
+		Values: []*string{
+			aws.String(vpcID),
+		},
+	}
+}
+
+func (v VPC) subnetIDs(vpcID string) ([]string, error) {
+	resp, err := v.Clients.EC2.DescribeSubnets(&ec2.DescribeSubnetsInput{
+		Filters: []*ec2.Filter{vpcFilter("vpc-id", vpcID)},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []string
+	for _, subnet := range resp.Subnets {
+		ids = append(ids, aws.StringValue(subnet.SubnetId))
+	}
+
+	return ids, nil
+}
+
+func (v VPC) gatewayIDs(vpcID string) ([]string, error) {
+	resp, err := v.Clients.EC2.DescribeInternetGateways(&ec2.DescribeInternetGatewaysInput{
+		Filters: []*ec2.Filter{vpcFilter("attachment.vpc-id", vpcID)},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []string
+	for _, gateway := range resp.InternetGateways {
+		ids = append(ids, aws.StringValue(gateway.InternetGatewayId))
+	}
+
+	return ids, nil
+}
+
+func (v VPC) networkInterfaceIDs(vpcID string) ([]string, error) {
+	resp, err := v.Clients.EC2.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{vpcFilter("vpc-id", vpcID)},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []string
+	for _, networkInterface := range resp.NetworkInterfaces {
+		ids = append(ids, aws.StringValue(networkInterface.NetworkInterfaceId))
+	}
+
+	return ids, nil
+}
+
+func (v VPC) securityGroupIDs(vpcID string) ([]string, error) {
+	resp, err := v.Clients.EC2.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{vpcFilter("vpc-id", vpcID)},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []string
+	for _, group := range resp.SecurityGroups {
+		if aws.StringValue(group.GroupName) == "default" {
+			continue
+		}
+		ids = append(ids, aws.StringValue(group.GroupId))
+	}
+
+	return ids, nil
+}
+
+func (v VPC) routeTableIDs(vpcID string) ([]string, error) {
+	resp, err := v.Clients.EC2.DescribeRouteTables(&ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{vpcFilter("vpc-id", vpcID)},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []string
+	for _, routeTable := range resp.RouteTables {
+		if isMainRouteTable(routeTable) {
+			continue
+		}
+		ids = append(ids, aws.StringValue(routeTable.RouteTableId))
+	}
+
+	return ids, nil
+}
+
+func isMainRouteTable(routeTable *ec2.RouteTable) bool {
+	for _, association := range routeTable.Associations {
+		if aws.BoolValue(association.Main) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v VPC) vpcEndpointIDs(vpcID string) ([]string, error) {
+	resp, err := v.Clients.EC2.DescribeVpcEndpoints(&ec2.DescribeVpcEndpointsInput{
+		Filters: []*ec2.Filter{vpcFilter("vpc-id", vpcID)},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []string
+	for _, endpoint := range resp.VpcEndpoints {
+		switch aws.StringValue(endpoint.State) {
+		case vpcEndpointStateDeleting, vpcEndpointStateDeleted:
+			continue
+		}
+		ids = append(ids, aws.StringValue(endpoint.VpcEndpointId))
+	}
+
+	return ids, nil
+}
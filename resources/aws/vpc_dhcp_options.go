@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+const (
+	// defaultDHCPOptionsID associates a VPC with the default DHCP options set,
+	// when disassociating its custom one.
+	defaultDHCPOptionsID = "default"
+	// amazonProvidedDNS is the DNS server of the VPC, which resolves the records
+	// of its private hosted zones.
+	amazonProvidedDNS = "AmazonProvidedDNS"
+)
+
+// ensureDHCPOptions creates and associates the DHCP options set of the VPC
+// when it is enabled and missing, e.g. for VPCs created before it was enabled.
+func (v *VPC) ensureDHCPOptions() error {
+	if v.DHCPOptions == nil || v.ExistingID != "" {
+		return nil
+	}
+
+	vpcID, err := v.GetID()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	dhcpOptionsIDs, err := v.dhcpOptionsIDs()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(dhcpOptionsIDs) > 0 {
+		return nil
+	}
+
+	if err := v.createDHCPOptions(vpcID); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// createDHCPOptions creates the DHCP options set DHCPOptions, and associates it
+// with the VPC with the given ID.
+func (v VPC) createDHCPOptions(vpcID string) error {
+	if v.DHCPOptions.DomainName == "" {
+		return microerror.MaskAnyf(attributeEmptyError, attributeEmptyErrorFormat, "DHCPOptions.DomainName")
+	}
+
+	domainNameServers := v.DHCPOptions.DomainNameServers
+	if len(domainNameServers) == 0 {
+		domainNameServers = []string{amazonProvidedDNS}
+	}
+
+	resp, err := v.Clients.EC2.CreateDhcpOptions(&ec2.CreateDhcpOptionsInput{
+		DhcpConfigurations: []*ec2.NewDhcpConfiguration{
+			{
+				Key: aws.String("domain-name"),
+				Values: []*string{
+					aws.String(v.DHCPOptions.DomainName),
+				},
+			},
+			{
+				Key:    aws.String("domain-name-servers"),
+				Values: aws.StringSlice(domainNameServers),
+			},
+		},
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	dhcpOptionsID := resp.DhcpOptions.DhcpOptionsId
+
+	if _, err := v.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{
+			dhcpOptionsID,
+		},
+		Tags: append([]*ec2.Tag{
+			{
+				Key:   aws.String(tagKeyName),
+				Value: aws.String(v.Name),
+			},
+		}, resourceTags(v.OperatorID, v.Tags)...),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := v.Clients.EC2.AssociateDhcpOptions(&ec2.AssociateDhcpOptionsInput{
+		DhcpOptionsId: dhcpOptionsID,
+		VpcId:         aws.String(vpcID),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// deleteDHCPOptions reverts the VPC with the given ID to the default DHCP
+// options set, and deletes its custom one.
+func (v VPC) deleteDHCPOptions(vpcID string) error {
+	dhcpOptionsIDs, err := v.dhcpOptionsIDs()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(dhcpOptionsIDs) == 0 {
+		return nil
+	}
+
+	if _, err := v.Clients.EC2.AssociateDhcpOptions(&ec2.AssociateDhcpOptionsInput{
+		DhcpOptionsId: aws.String(defaultDHCPOptionsID),
+		VpcId:         aws.String(vpcID),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	for _, id := range dhcpOptionsIDs {
+		if _, err := v.Clients.EC2.DeleteDhcpOptions(&ec2.DeleteDhcpOptionsInput{
+			DhcpOptionsId: id,
+		}); err != nil && !IsAlreadyDeleted(err) {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// dhcpOptionsIDs returns the IDs of the DHCP options sets created for the VPC.
+func (v VPC) dhcpOptionsIDs() ([]*string, error) {
+	resp, err := v.Clients.EC2.DescribeDhcpOptions(&ec2.DescribeDhcpOptionsInput{
+		Filters: append([]*ec2.Filter{
+			{
+				Name: aws.String(fmt.Sprintf("tag:%s", tagKeyName)),
+				Values: []*string{
+					aws.String(v.Name),
+				},
+			},
+		}, operatorFilters(v.OperatorID)...),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	var ids []*string
+	for _, dhcpOptions := range resp.DhcpOptions {
+		ids = append(ids, dhcpOptions.DhcpOptionsId)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	microerror "github.com/giantswarm/microkit/error"
+	"github.com/pborman/uuid"
+)
+
+const (
+	// s3EndpointServiceNameFormat is the format of the service name of the S3
+	// gateway endpoint of a region.
+	s3EndpointServiceNameFormat = "com.amazonaws.%s.s3"
+	// States of VPC endpoints which are gone or going away.
+	vpcEndpointStateDeleting = "deleting"
+	vpcEndpointStateDeleted  = "deleted"
+)
+
+// VPCEndpoint is a gateway endpoint, which routes the traffic of the VPC to an
+// AWS service through the given route tables, without leaving the AWS network.
+type VPCEndpoint struct {
+	// ServiceName is the name of the AWS service, e.g. com.amazonaws.eu-central-1.s3.
+	ServiceName string
+	VpcID       string
+	// RouteTableIDs are the IDs of the route tables routing to the endpoint.
+	RouteTableIDs []string
+	id            string
+	// clientToken identifies a single creation request of the endpoint. It is
+	// kept across retries, so a retried request which already went through does
+	// not create a second endpoint.
+	clientToken string
+	AWSEntity
+}
+
+// S3EndpointServiceName returns the service name of the S3 gateway endpoint of
+// the given region.
+func S3EndpointServiceName(region string) string {
+	return fmt.Sprintf(s3EndpointServiceNameFormat, region)
+}
+
+func (e VPCEndpoint) findExisting() (*ec2.VpcEndpoint, error) {
+	resp, err := e.Clients.EC2.DescribeVpcEndpoints(&ec2.DescribeVpcEndpointsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name: aws.String("vpc-id"),
+				Values: []*string{
+					aws.String(e.VpcID),
+				},
+			},
+			{
+				Name: aws.String("service-name"),
+				Values: []*string{
+					aws.String(e.ServiceName),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	for _, endpoint := range resp.VpcEndpoints {
+		switch aws.StringValue(endpoint.State) {
+		case vpcEndpointStateDeleting, vpcEndpointStateDeleted:
+			continue
+		}
+		return endpoint, nil
+	}
+
+	return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, VPCEndpointType, e.ServiceName)
+}
+
+// CreateIfNotExists creates the endpoint, or makes sure an existing one is
+// associated with all the route tables.
+func (e *VPCEndpoint) CreateIfNotExists() (bool, error) {
+	endpoint, err := e.findExisting()
+	if IsNotFound(err) {
+		if err := e.CreateOrFail(); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+
+		return true, nil
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	e.id = *endpoint.VpcEndpointId
+
+	if missing := missingRouteTableIDs(endpoint.RouteTableIds, e.RouteTableIDs); len(missing) > 0 {
+		if _, err := e.Clients.EC2.ModifyVpcEndpoint(&ec2.ModifyVpcEndpointInput{
+			VpcEndpointId:    endpoint.VpcEndpointId,
+			AddRouteTableIds: aws.StringSlice(missing),
+		}); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+	}
+
+	return false, nil
+}
+
+func (e *VPCEndpoint) CreateOrFail() error {
+	if e.clientToken == "" {
+		e.clientToken = uuid.New()
+	}
+
+	resp, err := e.Clients.EC2.CreateVpcEndpoint(&ec2.CreateVpcEndpointInput{
+		ClientToken:   aws.String(e.clientToken),
+		RouteTableIds: aws.StringSlice(e.RouteTableIDs),
+		ServiceName:   aws.String(e.ServiceName),
+		VpcId:         aws.String(e.VpcID),
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	e.id = *resp.VpcEndpoint.VpcEndpointId
+
+	return nil
+}
+
+func (e *VPCEndpoint) Delete() error {
+	endpoint, err := e.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := e.Clients.EC2.DeleteVpcEndpoints(&ec2.DeleteVpcEndpointsInput{
+		VpcEndpointIds: []*string{
+			endpoint.VpcEndpointId,
+		},
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+func (e VPCEndpoint) GetID() (string, error) {
+	if e.id != "" {
+		return e.id, nil
+	}
+
+	endpoint, err := e.findExisting()
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return *endpoint.VpcEndpointId, nil
+}
+
+// missingRouteTableIDs returns the wanted route table IDs which are not in the
+// associated ones.
+func missingRouteTableIDs(associated []*string, wanted []string) []string {
+	associatedSet := make(map[string]bool, len(associated))
+	for _, id := range associated {
+		associatedSet[aws.StringValue(id)] = true
+	}
+
+	var missing []string
+	for _, id := range wanted {
+		if !associatedSet[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
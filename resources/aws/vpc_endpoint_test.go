@@ -0,0 +1,128 @@
+package aws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVPCEndpointCreateIfNotExists(t *testing.T) {
+	tests := []struct {
+		desc           string
+		existing       []*ec2.VpcEndpoint
+		res            bool
+		operations     []string
+		addRouteTables []string
+	}{
+		{
+			desc:       "S3 gateway endpoint is created with the route table",
+			res:        true,
+			operations: []string{"DescribeVpcEndpoints", "CreateVpcEndpoint"},
+		},
+		{
+			desc: "deleted endpoints are replaced",
+			existing: []*ec2.VpcEndpoint{
+				{
+					VpcEndpointId: aws.String("vpce-old"),
+					State:         aws.String("deleted"),
+				},
+			},
+			res:        true,
+			operations: []string{"DescribeVpcEndpoints", "CreateVpcEndpoint"},
+		},
+		{
+			desc: "existing endpoint is reused",
+			existing: []*ec2.VpcEndpoint{
+				{
+					VpcEndpointId: aws.String("vpce-existing"),
+					State:         aws.String("available"),
+					RouteTableIds: aws.StringSlice([]string{"rtb-123"}),
+				},
+			},
+			res:        false,
+			operations: []string{"DescribeVpcEndpoints"},
+		},
+		{
+			desc: "existing endpoint is associated with the route table",
+			existing: []*ec2.VpcEndpoint{
+				{
+					VpcEndpointId: aws.String("vpce-existing"),
+					State:         aws.String("available"),
+				},
+			},
+			res:            false,
+			operations:     []string{"DescribeVpcEndpoints", "ModifyVpcEndpoint"},
+			addRouteTables: []string{"rtb-123"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeVpcEndpoints", func(params, output interface{}) error {
+			output.(*ec2.DescribeVpcEndpointsOutput).VpcEndpoints = tc.existing
+			return nil
+		})
+		fake.on("CreateVpcEndpoint", func(params, output interface{}) error {
+			output.(*ec2.CreateVpcEndpointOutput).VpcEndpoint = &ec2.VpcEndpoint{
+				VpcEndpointId: aws.String("vpce-new"),
+			}
+			return nil
+		})
+
+		endpoint := &VPCEndpoint{
+			ServiceName:   S3EndpointServiceName("eu-central-1"),
+			VpcID:         "vpc-123",
+			RouteTableIDs: []string{"rtb-123"},
+			AWSEntity:     AWSEntity{Clients: clients},
+		}
+
+		created, err := endpoint.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, created, fmt.Sprintf("[%s] The input values didn't produce the expected result", tc.desc))
+		assert.Equal(t, tc.operations, fake.operations(), fmt.Sprintf("[%s] The input values didn't produce the expected AWS calls", tc.desc))
+
+		if created {
+			params := fake.paramsOf("CreateVpcEndpoint")[0].(*ec2.CreateVpcEndpointInput)
+			assert.Equal(t, "com.amazonaws.eu-central-1.s3", *params.ServiceName, fmt.Sprintf("[%s] Wrong service", tc.desc))
+			assert.Equal(t, "vpc-123", *params.VpcId, fmt.Sprintf("[%s] Wrong VPC", tc.desc))
+			assert.Equal(t, []string{"rtb-123"}, aws.StringValueSlice(params.RouteTableIds), fmt.Sprintf("[%s] Wrong route tables", tc.desc))
+			assert.NotEmpty(t, *params.ClientToken, fmt.Sprintf("[%s] Empty client token", tc.desc))
+		}
+		if len(tc.addRouteTables) > 0 {
+			params := fake.paramsOf("ModifyVpcEndpoint")[0].(*ec2.ModifyVpcEndpointInput)
+			assert.Equal(t, tc.addRouteTables, aws.StringValueSlice(params.AddRouteTableIds), fmt.Sprintf("[%s] Wrong associated route tables", tc.desc))
+		}
+
+		id, err := endpoint.GetID()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.NotEmpty(t, id, fmt.Sprintf("[%s] Empty endpoint ID", tc.desc))
+	}
+}
+
+func TestVPCEndpointDelete(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeVpcEndpoints", func(params, output interface{}) error {
+		output.(*ec2.DescribeVpcEndpointsOutput).VpcEndpoints = []*ec2.VpcEndpoint{
+			{
+				VpcEndpointId: aws.String("vpce-existing"),
+				State:         aws.String("available"),
+			},
+		}
+		return nil
+	})
+
+	endpoint := &VPCEndpoint{
+		ServiceName: S3EndpointServiceName("eu-central-1"),
+		VpcID:       "vpc-123",
+		AWSEntity:   AWSEntity{Clients: clients},
+	}
+
+	err := endpoint.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	params := fake.paramsOf("DeleteVpcEndpoints")
+	assert.Len(t, params, 1, "Expected the endpoint to be deleted")
+	assert.Equal(t, []string{"vpce-existing"}, aws.StringValueSlice(params[0].(*ec2.DeleteVpcEndpointsInput).VpcEndpointIds), "Wrong endpoint deleted")
+}
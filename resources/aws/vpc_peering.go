@@ -0,0 +1,224 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// createVpcPeeringConnectionInput is the input of CreateVpcPeeringConnection,
+// with the region of the peer VPC. The vendored SDK predates inter-region
+// peering, so ec2.CreateVpcPeeringConnectionInput lacks it.
+type createVpcPeeringConnectionInput struct {
+	_ struct{} `type:"structure"`
+
+	PeerOwnerId *string `locationName:"peerOwnerId" type:"string"`
+	PeerRegion  *string `locationName:"peerRegion" type:"string"`
+	PeerVpcId   *string `locationName:"peerVpcId" type:"string"`
+	VpcId       *string `locationName:"vpcId" type:"string"`
+}
+
+// VPCPeering is a peering connection between the VPC of a cluster and a peer
+// VPC, e.g. one of shared services.
+type VPCPeering struct {
+	// Name is the name of the cluster, which the connection is tagged with.
+	Name string
+	// VPCID is the ID of the VPC requesting the connection.
+	VPCID string
+	// PeerVPCID is the ID of the VPC accepting the connection.
+	PeerVPCID string
+	// PeerRegion is the region of the peer VPC. It is empty when the peer VPC is
+	// in the region of the clients.
+	PeerRegion string
+	// PeerOwnerID is the account of the peer VPC. It is empty when the peer VPC
+	// is in the account of the clients, in which case the connection is
+	// accepted right away. Otherwise it has to be accepted by the owner of the
+	// peer VPC.
+	PeerOwnerID string
+	id          string
+	AWSEntity
+}
+
+// findExisting returns the connection between the VPCs which is active or on
+// its way to be, ignoring the ones which are gone or failed.
+func (p VPCPeering) findExisting() (*ec2.VpcPeeringConnection, error) {
+	resp, err := p.Clients.EC2.DescribeVpcPeeringConnections(&ec2.DescribeVpcPeeringConnectionsInput{
+		Filters: append([]*ec2.Filter{
+			{
+				Name: aws.String("requester-vpc-info.vpc-id"),
+				Values: []*string{
+					aws.String(p.VPCID),
+				},
+			},
+			{
+				Name: aws.String("accepter-vpc-info.vpc-id"),
+				Values: []*string{
+					aws.String(p.PeerVPCID),
+				},
+			},
+		}, operatorFilters(p.OperatorID)...),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	for _, connection := range resp.VpcPeeringConnections {
+		if connection.Status == nil {
+			continue
+		}
+		switch aws.StringValue(connection.Status.Code) {
+		case ec2.VpcPeeringConnectionStateReasonCodeDeleted,
+			ec2.VpcPeeringConnectionStateReasonCodeDeleting,
+			ec2.VpcPeeringConnectionStateReasonCodeExpired,
+			ec2.VpcPeeringConnectionStateReasonCodeFailed,
+			ec2.VpcPeeringConnectionStateReasonCodeRejected:
+			continue
+		}
+		return connection, nil
+	}
+
+	return nil, microerror.MaskAnyf(notFoundError, notFoundErrorFormat, VPCPeeringType, p.Name)
+}
+
+// CreateIfNotExists creates the connection, or reuses an existing one, which
+// is accepted when it is still pending.
+func (p *VPCPeering) CreateIfNotExists() (bool, error) {
+	connection, err := p.findExisting()
+	if IsNotFound(err) {
+		if err := p.CreateOrFail(); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+
+		return true, nil
+	} else if err != nil {
+		return false, microerror.MaskAny(err)
+	}
+
+	p.id = *connection.VpcPeeringConnectionId
+
+	if aws.StringValue(connection.Status.Code) == ec2.VpcPeeringConnectionStateReasonCodePendingAcceptance {
+		if err := p.accept(); err != nil {
+			return false, microerror.MaskAny(err)
+		}
+	}
+
+	return false, nil
+}
+
+func (p *VPCPeering) CreateOrFail() error {
+	input := &createVpcPeeringConnectionInput{
+		PeerVpcId: aws.String(p.PeerVPCID),
+		VpcId:     aws.String(p.VPCID),
+	}
+	if p.PeerOwnerID != "" {
+		input.PeerOwnerId = aws.String(p.PeerOwnerID)
+	}
+	if p.PeerRegion != "" {
+		input.PeerRegion = aws.String(p.PeerRegion)
+	}
+
+	output := &ec2.CreateVpcPeeringConnectionOutput{}
+	req := p.Clients.EC2.NewRequest(&request.Operation{
+		Name:       "CreateVpcPeeringConnection",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+	if err := req.Send(); err != nil {
+		return microerror.MaskAny(err)
+	}
+	p.id = *output.VpcPeeringConnection.VpcPeeringConnectionId
+
+	if _, err := p.Clients.EC2.CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{
+			aws.String(p.id),
+		},
+		Tags: append([]*ec2.Tag{
+			{
+				Key:   aws.String(tagKeyName),
+				Value: aws.String(p.Name),
+			},
+		}, resourceTags(p.OperatorID, p.Tags)...),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if err := p.accept(); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// accept accepts the connection on behalf of the peer VPC, when it is in the
+// same account. Connections to other accounts are left for their owners to
+// accept.
+func (p VPCPeering) accept() error {
+	if p.PeerOwnerID != "" {
+		return nil
+	}
+
+	// Connections to other regions only show up in the peer region after a
+	// while.
+	peerEC2 := p.peerEC2()
+	if err := peerEC2.WaitUntilVpcPeeringConnectionExists(&ec2.DescribeVpcPeeringConnectionsInput{
+		VpcPeeringConnectionIds: []*string{
+			aws.String(p.id),
+		},
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := peerEC2.AcceptVpcPeeringConnection(&ec2.AcceptVpcPeeringConnectionInput{
+		VpcPeeringConnectionId: aws.String(p.id),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// peerEC2 returns an EC2 client of the region of the peer VPC. It shares the
+// handlers of the EC2 client of the clients, e.g. their rate limiting.
+func (p VPCPeering) peerEC2() *ec2.EC2 {
+	if p.PeerRegion == "" || p.PeerRegion == aws.StringValue(p.Clients.EC2.Config.Region) {
+		return p.Clients.EC2
+	}
+
+	client := ec2.New(session.New(p.Clients.EC2.Config.Copy(&aws.Config{
+		Region: aws.String(p.PeerRegion),
+	})))
+	client.Handlers = p.Clients.EC2.Handlers.Copy()
+
+	return client
+}
+
+func (p *VPCPeering) Delete() error {
+	connection, err := p.findExisting()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if _, err := p.Clients.EC2.DeleteVpcPeeringConnection(&ec2.DeleteVpcPeeringConnectionInput{
+		VpcPeeringConnectionId: connection.VpcPeeringConnectionId,
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// GetID returns the ID of the connection.
+func (p VPCPeering) GetID() (string, error) {
+	if p.id != "" {
+		return p.id, nil
+	}
+
+	connection, err := p.findExisting()
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return *connection.VpcPeeringConnectionId, nil
+}
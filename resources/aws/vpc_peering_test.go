@@ -0,0 +1,257 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/private/protocol/ec2query"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVPCPeeringCreateIfNotExists(t *testing.T) {
+	tests := []struct {
+		desc          string
+		peerRegion    string
+		peerOwnerID   string
+		existing      []*ec2.VpcPeeringConnection
+		resCreated    bool
+		resID         string
+		resOperations []string
+		resRegion     string
+	}{
+		{
+			desc:       "connection within the account is created and accepted",
+			resCreated: true,
+			resID:      "pcx-new",
+			resOperations: []string{
+				"DescribeVpcPeeringConnections",
+				"CreateVpcPeeringConnection",
+				"CreateTags",
+				"DescribeVpcPeeringConnections",
+				"AcceptVpcPeeringConnection",
+			},
+			resRegion: "eu-central-1",
+		},
+		{
+			desc:       "connection to another region is accepted in the peer region",
+			peerRegion: "us-east-1",
+			resCreated: true,
+			resID:      "pcx-new",
+			resOperations: []string{
+				"DescribeVpcPeeringConnections",
+				"CreateVpcPeeringConnection",
+				"CreateTags",
+				"DescribeVpcPeeringConnections",
+				"AcceptVpcPeeringConnection",
+			},
+			resRegion: "us-east-1",
+		},
+		{
+			desc:        "connection to another account is left to accept",
+			peerOwnerID: "123456789012",
+			resCreated:  true,
+			resID:       "pcx-new",
+			resOperations: []string{
+				"DescribeVpcPeeringConnections",
+				"CreateVpcPeeringConnection",
+				"CreateTags",
+			},
+		},
+		{
+			desc: "deleted connection is replaced",
+			existing: []*ec2.VpcPeeringConnection{
+				{
+					VpcPeeringConnectionId: aws.String("pcx-old"),
+					Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String("deleted")},
+				},
+			},
+			resCreated: true,
+			resID:      "pcx-new",
+			resOperations: []string{
+				"DescribeVpcPeeringConnections",
+				"CreateVpcPeeringConnection",
+				"CreateTags",
+				"DescribeVpcPeeringConnections",
+				"AcceptVpcPeeringConnection",
+			},
+			resRegion: "eu-central-1",
+		},
+		{
+			desc: "active connection is reused",
+			existing: []*ec2.VpcPeeringConnection{
+				{
+					VpcPeeringConnectionId: aws.String("pcx-existing"),
+					Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String("active")},
+				},
+			},
+			resCreated:    false,
+			resID:         "pcx-existing",
+			resOperations: []string{"DescribeVpcPeeringConnections"},
+		},
+		{
+			desc: "pending connection is accepted",
+			existing: []*ec2.VpcPeeringConnection{
+				{
+					VpcPeeringConnectionId: aws.String("pcx-existing"),
+					Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String("pending-acceptance")},
+				},
+			},
+			resCreated: false,
+			resID:      "pcx-existing",
+			resOperations: []string{
+				"DescribeVpcPeeringConnections",
+				"DescribeVpcPeeringConnections",
+				"AcceptVpcPeeringConnection",
+			},
+			resRegion: "eu-central-1",
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeVpcPeeringConnections", func(params, output interface{}) error {
+			// The waiter of the acceptance looks the connection up by ID.
+			if len(params.(*ec2.DescribeVpcPeeringConnectionsInput).VpcPeeringConnectionIds) > 0 {
+				return nil
+			}
+			output.(*ec2.DescribeVpcPeeringConnectionsOutput).VpcPeeringConnections = tc.existing
+			return nil
+		})
+		fake.on("CreateVpcPeeringConnection", func(params, output interface{}) error {
+			output.(*ec2.CreateVpcPeeringConnectionOutput).VpcPeeringConnection = &ec2.VpcPeeringConnection{
+				VpcPeeringConnectionId: aws.String("pcx-new"),
+			}
+			return nil
+		})
+
+		peering := &VPCPeering{
+			Name:        "foo",
+			VPCID:       "vpc-cluster",
+			PeerVPCID:   "vpc-services",
+			PeerRegion:  tc.peerRegion,
+			PeerOwnerID: tc.peerOwnerID,
+			AWSEntity:   AWSEntity{Clients: clients, OperatorID: "prod"},
+		}
+
+		created, err := peering.CreateIfNotExists()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong creation", tc.desc))
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		id, err := peering.GetID()
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resID, id, fmt.Sprintf("[%s] Wrong connection ID", tc.desc))
+
+		if tc.resCreated {
+			input := fake.paramsOf("CreateVpcPeeringConnection")[0].(*createVpcPeeringConnectionInput)
+			assert.Equal(t, "vpc-cluster", aws.StringValue(input.VpcId), fmt.Sprintf("[%s] Wrong requester VPC", tc.desc))
+			assert.Equal(t, "vpc-services", aws.StringValue(input.PeerVpcId), fmt.Sprintf("[%s] Wrong accepter VPC", tc.desc))
+			assert.Equal(t, tc.peerRegion, aws.StringValue(input.PeerRegion), fmt.Sprintf("[%s] Wrong peer region", tc.desc))
+			assert.Equal(t, tc.peerOwnerID, aws.StringValue(input.PeerOwnerId), fmt.Sprintf("[%s] Wrong peer owner", tc.desc))
+
+			tags := fake.paramsOf("CreateTags")[0].(*ec2.CreateTagsInput)
+			assert.Equal(t, []*string{aws.String("pcx-new")}, tags.Resources, fmt.Sprintf("[%s] Wrong tagged resource", tc.desc))
+			assert.Contains(t, tags.Tags, &ec2.Tag{Key: aws.String(tagKeyName), Value: aws.String("foo")}, fmt.Sprintf("[%s] Missing name tag", tc.desc))
+		}
+
+		accepts := fake.paramsOf("AcceptVpcPeeringConnection")
+		if tc.resRegion == "" {
+			assert.Empty(t, accepts, fmt.Sprintf("[%s] Unexpected acceptance", tc.desc))
+			continue
+		}
+		assert.Equal(t, tc.resID, aws.StringValue(accepts[0].(*ec2.AcceptVpcPeeringConnectionInput).VpcPeeringConnectionId), fmt.Sprintf("[%s] Wrong accepted connection", tc.desc))
+		assert.Equal(t, []string{tc.resRegion}, fake.regionsOf("AcceptVpcPeeringConnection"), fmt.Sprintf("[%s] Accepted in the wrong region", tc.desc))
+	}
+}
+
+func TestVPCPeeringDelete(t *testing.T) {
+	tests := []struct {
+		desc          string
+		existing      []*ec2.VpcPeeringConnection
+		errorMatcher  func(error) bool
+		resOperations []string
+	}{
+		{
+			desc: "active connection is deleted",
+			existing: []*ec2.VpcPeeringConnection{
+				{
+					VpcPeeringConnectionId: aws.String("pcx-existing"),
+					Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String("active")},
+				},
+			},
+			resOperations: []string{"DescribeVpcPeeringConnections", "DeleteVpcPeeringConnection"},
+		},
+		{
+			desc: "deleted connection is already deleted",
+			existing: []*ec2.VpcPeeringConnection{
+				{
+					VpcPeeringConnectionId: aws.String("pcx-existing"),
+					Status:                 &ec2.VpcPeeringConnectionStateReason{Code: aws.String("deleted")},
+				},
+			},
+			errorMatcher:  IsAlreadyDeleted,
+			resOperations: []string{"DescribeVpcPeeringConnections"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeVpcPeeringConnections", func(params, output interface{}) error {
+			output.(*ec2.DescribeVpcPeeringConnectionsOutput).VpcPeeringConnections = tc.existing
+			return nil
+		})
+
+		peering := &VPCPeering{
+			Name:      "foo",
+			VPCID:     "vpc-cluster",
+			PeerVPCID: "vpc-services",
+			AWSEntity: AWSEntity{Clients: clients},
+		}
+
+		err := peering.Delete()
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		} else {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		}
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+
+		for _, params := range fake.paramsOf("DeleteVpcPeeringConnection") {
+			input := params.(*ec2.DeleteVpcPeeringConnectionInput)
+			assert.Equal(t, "pcx-existing", aws.StringValue(input.VpcPeeringConnectionId), fmt.Sprintf("[%s] Wrong deleted connection", tc.desc))
+		}
+	}
+}
+
+func TestCreateVpcPeeringConnectionInputBuild(t *testing.T) {
+	clients, _ := newFakeClients()
+	req := clients.EC2.NewRequest(&request.Operation{
+		Name:       "CreateVpcPeeringConnection",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &createVpcPeeringConnectionInput{
+		PeerOwnerId: aws.String("123456789012"),
+		PeerRegion:  aws.String("us-east-1"),
+		PeerVpcId:   aws.String("vpc-services"),
+		VpcId:       aws.String("vpc-cluster"),
+	}, &ec2.CreateVpcPeeringConnectionOutput{})
+
+	ec2query.Build(req)
+	assert.Nil(t, req.Error, "Unexpected error")
+
+	body, err := ioutil.ReadAll(req.GetBody())
+	assert.Nil(t, err, "Unexpected error reading the body")
+	values, err := url.ParseQuery(string(body))
+	assert.Nil(t, err, "Unexpected error parsing the body")
+
+	assert.Equal(t, "CreateVpcPeeringConnection", values.Get("Action"), "Wrong action")
+	assert.Equal(t, "123456789012", values.Get("PeerOwnerId"), "Peer owner not built")
+	assert.Equal(t, "us-east-1", values.Get("PeerRegion"), "Peer region not built")
+	assert.Equal(t, "vpc-services", values.Get("PeerVpcId"), "Peer VPC not built")
+	assert.Equal(t, "vpc-cluster", values.Get("VpcId"), "VPC not built")
+}
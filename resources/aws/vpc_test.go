@@ -0,0 +1,498 @@
+package aws
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/private/protocol/ec2query"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVPCExisting(t *testing.T) {
+	tests := []struct {
+		desc          string
+		describeVpcs  fakeResponse
+		errorMatcher  func(error) bool
+		resID         string
+		resOperations []string
+	}{
+		{
+			desc: "existing VPC is adopted",
+			describeVpcs: func(params, output interface{}) error {
+				output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{
+					{VpcId: aws.String("vpc-existing")},
+				}
+				return nil
+			},
+			resID:         "vpc-existing",
+			resOperations: []string{"DescribeVpcs", "DescribeVpcs"},
+		},
+		{
+			desc: "missing existing VPC isn't created",
+			describeVpcs: func(params, output interface{}) error {
+				return awserr.New("InvalidVpcID.NotFound", "The vpc ID 'vpc-existing' does not exist", nil)
+			},
+			errorMatcher:  IsNotFound,
+			resOperations: []string{"DescribeVpcs", "DescribeVpcs"},
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeVpcs", tc.describeVpcs)
+
+		vpc := &VPC{
+			CidrBlock:  "10.0.0.0/16",
+			ExistingID: "vpc-existing",
+			Name:       "foo",
+			AWSEntity:  AWSEntity{Clients: clients, OperatorID: "prod"},
+		}
+
+		created, err := vpc.CreateIfNotExists()
+		assert.False(t, created, fmt.Sprintf("[%s] Existing VPC created", tc.desc))
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		} else {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		}
+
+		id, err := vpc.GetID()
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		} else {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		}
+		assert.Equal(t, tc.resID, id, fmt.Sprintf("[%s] Wrong VPC ID", tc.desc))
+
+		assert.Equal(t, tc.resOperations, fake.operations(), fmt.Sprintf("[%s] Unexpected AWS API calls", tc.desc))
+		for _, params := range fake.paramsOf("DescribeVpcs") {
+			input := params.(*ec2.DescribeVpcsInput)
+			assert.Equal(t, []*string{aws.String("vpc-existing")}, input.VpcIds, fmt.Sprintf("[%s] Existing VPC not looked up by ID", tc.desc))
+			assert.Empty(t, input.Filters, fmt.Sprintf("[%s] Existing VPC looked up by tags", tc.desc))
+		}
+	}
+}
+
+func TestVPCExistingDelete(t *testing.T) {
+	clients, fake := newFakeClients()
+
+	vpc := &VPC{
+		ExistingID: "vpc-existing",
+		Name:       "foo",
+		AWSEntity:  AWSEntity{Clients: clients},
+	}
+
+	err := vpc.Delete()
+	assert.True(t, IsExistingVPC(err), fmt.Sprintf("Unexpected error: %v", err))
+	assert.Empty(t, fake.operations(), "The existing VPC was touched")
+}
+
+func TestVPCCreateIfNotExists(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeVpcs", func(params, output interface{}) error {
+		// The waiter of the creation looks the VPC up by ID.
+		if len(params.(*ec2.DescribeVpcsInput).VpcIds) > 0 {
+			output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-new"), State: aws.String(ec2.VpcStateAvailable)}}
+		}
+		return nil
+	})
+	fake.on("CreateVpc", func(params, output interface{}) error {
+		output.(*ec2.CreateVpcOutput).Vpc = &ec2.Vpc{VpcId: aws.String("vpc-new")}
+		return nil
+	})
+
+	vpc := &VPC{
+		CidrBlock: "10.0.0.0/16",
+		Name:      "vpc-create-test",
+		AWSEntity: AWSEntity{Clients: clients, OperatorID: "prod"},
+	}
+	defer recentCreations.remove(vpc.creationKey())
+
+	created, err := vpc.CreateIfNotExists()
+	assert.Nil(t, err, "Unexpected error")
+	assert.True(t, created, "The VPC wasn't created")
+
+	expected := []string{"DescribeVpcs", "CreateVpc", "DescribeVpcs", "CreateTags", "ModifyVpcAttribute", "ModifyVpcAttribute"}
+	assert.Equal(t, expected, fake.operations(), "Unexpected AWS API calls")
+
+	lookup := fake.paramsOf("DescribeVpcs")[0].(*ec2.DescribeVpcsInput)
+	assert.Empty(t, lookup.VpcIds, "New VPC looked up by ID")
+	assert.Equal(t, "10.0.0.0/16", *fake.paramsOf("CreateVpc")[0].(*ec2.CreateVpcInput).CidrBlock, "Wrong CIDR block")
+
+	// The ID of the created VPC is known without looking it up.
+	id, err := vpc.GetID()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, "vpc-new", id, "Wrong VPC ID")
+	assert.Len(t, fake.operations(), len(expected), "The created VPC was looked up")
+}
+
+func TestVPCFlowLogs(t *testing.T) {
+	tests := []struct {
+		desc          string
+		existingID    string
+		vpcs          []*ec2.Vpc
+		flowLogs      []*ec2.FlowLog
+		unsuccessful  []*ec2.UnsuccessfulItem
+		errorMatcher  func(error) bool
+		resFlowLogsOf string
+	}{
+		{
+			desc:          "flow logs of a new VPC are created",
+			resFlowLogsOf: "vpc-new",
+		},
+		{
+			desc:          "missing flow logs of a VPC are created",
+			vpcs:          []*ec2.Vpc{{VpcId: aws.String("vpc-123")}},
+			resFlowLogsOf: "vpc-123",
+		},
+		{
+			desc:     "flow logs of a VPC are kept",
+			vpcs:     []*ec2.Vpc{{VpcId: aws.String("vpc-123")}},
+			flowLogs: []*ec2.FlowLog{{FlowLogId: aws.String("fl-123")}},
+		},
+		{
+			desc:       "existing VPC is left alone",
+			existingID: "vpc-existing",
+			vpcs:       []*ec2.Vpc{{VpcId: aws.String("vpc-existing")}},
+		},
+		{
+			desc: "flow logs failing to be created",
+			unsuccessful: []*ec2.UnsuccessfulItem{
+				{
+					ResourceId: aws.String("vpc-new"),
+					Error:      &ec2.UnsuccessfulItemError{Code: aws.String("400"), Message: aws.String("Access Denied for LogDestination")},
+				},
+			},
+			errorMatcher:  IsFlowLogsNotCreated,
+			resFlowLogsOf: "vpc-new",
+		},
+	}
+
+	for i, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeVpcs", func(params, output interface{}) error {
+			// The waiter of the creation looks the VPC up by ID.
+			if len(params.(*ec2.DescribeVpcsInput).VpcIds) > 0 && tc.existingID == "" {
+				output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-new"), State: aws.String(ec2.VpcStateAvailable)}}
+				return nil
+			}
+			output.(*ec2.DescribeVpcsOutput).Vpcs = tc.vpcs
+			return nil
+		})
+		fake.on("CreateVpc", func(params, output interface{}) error {
+			output.(*ec2.CreateVpcOutput).Vpc = &ec2.Vpc{VpcId: aws.String("vpc-new")}
+			return nil
+		})
+		fake.on("DescribeFlowLogs", func(params, output interface{}) error {
+			output.(*ec2.DescribeFlowLogsOutput).FlowLogs = tc.flowLogs
+			return nil
+		})
+		fake.on("CreateFlowLogs", func(params, output interface{}) error {
+			output.(*ec2.CreateFlowLogsOutput).Unsuccessful = tc.unsuccessful
+			return nil
+		})
+
+		vpc := &VPC{
+			CidrBlock:        "10.0.0.0/16",
+			ExistingID:       tc.existingID,
+			FlowLogs:         true,
+			FlowLogsS3Bucket: "bucket-foo",
+			Name:             fmt.Sprintf("vpc-flow-logs-%d", i),
+			AWSEntity:        AWSEntity{Clients: clients},
+		}
+		_, err := vpc.CreateIfNotExists()
+		recentCreations.remove(vpc.creationKey())
+
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		} else {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		}
+
+		created := fake.paramsOf("CreateFlowLogs")
+		if tc.resFlowLogsOf == "" {
+			assert.Empty(t, created, fmt.Sprintf("[%s] Unexpected flow logs", tc.desc))
+			continue
+		}
+		if assert.Len(t, created, 1, fmt.Sprintf("[%s] Expected flow logs", tc.desc)) {
+			input := created[0].(*createFlowLogsInput)
+			assert.Equal(t, []*string{aws.String(tc.resFlowLogsOf)}, input.ResourceIds, fmt.Sprintf("[%s] Wrong VPC", tc.desc))
+			assert.Equal(t, "VPC", aws.StringValue(input.ResourceType), fmt.Sprintf("[%s] Wrong resource type", tc.desc))
+			assert.Equal(t, "ALL", aws.StringValue(input.TrafficType), fmt.Sprintf("[%s] Wrong traffic type", tc.desc))
+			assert.Equal(t, "s3", aws.StringValue(input.LogDestinationType), fmt.Sprintf("[%s] Wrong destination type", tc.desc))
+			assert.Equal(t, "arn:aws:s3:::bucket-foo/flow-logs/", aws.StringValue(input.LogDestination), fmt.Sprintf("[%s] Wrong destination", tc.desc))
+		}
+	}
+}
+
+func TestVPCDeleteFlowLogs(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeVpcs", func(params, output interface{}) error {
+		output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-123")}}
+		return nil
+	})
+	fake.on("DescribeFlowLogs", func(params, output interface{}) error {
+		output.(*ec2.DescribeFlowLogsOutput).FlowLogs = []*ec2.FlowLog{{FlowLogId: aws.String("fl-123")}}
+		return nil
+	})
+
+	vpc := &VPC{
+		FlowLogs:  true,
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err := vpc.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"DescribeVpcs", "DescribeFlowLogs", "DeleteFlowLogs", "DeleteVpc"}, fake.operations(), "Unexpected AWS API calls")
+
+	filter := fake.paramsOf("DescribeFlowLogs")[0].(*ec2.DescribeFlowLogsInput).Filter[0]
+	assert.Equal(t, []*string{aws.String("vpc-123")}, filter.Values, "Flow logs of the wrong VPC")
+	input := fake.paramsOf("DeleteFlowLogs")[0].(*ec2.DeleteFlowLogsInput)
+	assert.Equal(t, []*string{aws.String("fl-123")}, input.FlowLogIds, "Wrong flow logs deleted")
+}
+
+func TestVPCDeleteDependencies(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeVpcs", func(params, output interface{}) error {
+		output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-123")}}
+		return nil
+	})
+	fake.on("DeleteVpc", func(params, output interface{}) error {
+		return awserr.New(dependencyViolationErrorCode, "The vpc 'vpc-123' has dependencies and cannot be deleted.", nil)
+	})
+	fake.on("DescribeSubnets", func(params, output interface{}) error {
+		output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{{SubnetId: aws.String("subnet-1")}, {SubnetId: aws.String("subnet-2")}}
+		return nil
+	})
+	fake.on("DescribeNetworkInterfaces", func(params, output interface{}) error {
+		output.(*ec2.DescribeNetworkInterfacesOutput).NetworkInterfaces = []*ec2.NetworkInterface{{NetworkInterfaceId: aws.String("eni-1")}}
+		return nil
+	})
+	fake.on("DescribeSecurityGroups", func(params, output interface{}) error {
+		output.(*ec2.DescribeSecurityGroupsOutput).SecurityGroups = []*ec2.SecurityGroup{{GroupId: aws.String("sg-default"), GroupName: aws.String("default")}}
+		return nil
+	})
+	fake.on("DescribeRouteTables", func(params, output interface{}) error {
+		output.(*ec2.DescribeRouteTablesOutput).RouteTables = []*ec2.RouteTable{
+			{RouteTableId: aws.String("rtb-main"), Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}}},
+			{RouteTableId: aws.String("rtb-1")},
+		}
+		return nil
+	})
+
+	vpc := &VPC{
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients},
+	}
+
+	err := vpc.Delete()
+	assert.True(t, IsVPCHasDependencies(err), fmt.Sprintf("Unexpected error: %v", err))
+	assert.Contains(t, err.Error(), "subnet subnet-1, subnet-2", "Subnets not listed")
+	assert.Contains(t, err.Error(), "network interface eni-1", "Network interface not listed")
+	assert.Contains(t, err.Error(), "route table rtb-1", "Route table not listed")
+	assert.NotContains(t, err.Error(), "gateway", "Unexpected gateway listed")
+	assert.NotContains(t, err.Error(), "sg-default", "Default security group listed")
+	assert.NotContains(t, err.Error(), "rtb-main", "Main route table listed")
+}
+
+func TestVPCDeleteForce(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeVpcs", func(params, output interface{}) error {
+		output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-123")}}
+		return nil
+	})
+	fake.on("DescribeInternetGateways", func(params, output interface{}) error {
+		output.(*ec2.DescribeInternetGatewaysOutput).InternetGateways = []*ec2.InternetGateway{{InternetGatewayId: aws.String("igw-1")}}
+		return nil
+	})
+	fake.on("DescribeSubnets", func(params, output interface{}) error {
+		output.(*ec2.DescribeSubnetsOutput).Subnets = []*ec2.Subnet{{SubnetId: aws.String("subnet-1")}}
+		return nil
+	})
+	fake.on("DeleteSubnet", func(params, output interface{}) error {
+		return awserr.New("InvalidSubnetID.NotFound", "The subnet ID 'subnet-1' does not exist", nil)
+	})
+
+	vpc := &VPC{
+		Force:     true,
+		Name:      "foo",
+		AWSEntity: AWSEntity{Clients: clients, OperatorID: "prod"},
+	}
+
+	err := vpc.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{
+		"DescribeVpcs",
+		"DescribeInternetGateways",
+		"DetachInternetGateway",
+		"DeleteInternetGateway",
+		"DescribeSubnets",
+		"DeleteSubnet",
+		"DeleteVpc",
+	}, fake.operations(), "Unexpected AWS API calls")
+
+	// Only the resources the operator created for the VPC are deleted.
+	filters := fake.paramsOf("DescribeSubnets")[0].(*ec2.DescribeSubnetsInput).Filters
+	assert.Contains(t, filters, &ec2.Filter{Name: aws.String("tag:" + tagKeyName), Values: []*string{aws.String("foo")}}, "Subnets not filtered by name")
+	assert.Contains(t, filters, vpcFilter("vpc-id", "vpc-123"), "Subnets not filtered by VPC")
+	detach := fake.paramsOf("DetachInternetGateway")[0].(*ec2.DetachInternetGatewayInput)
+	assert.Equal(t, "igw-1", aws.StringValue(detach.InternetGatewayId), "Wrong gateway detached")
+	assert.Equal(t, "vpc-123", aws.StringValue(detach.VpcId), "Gateway detached from the wrong VPC")
+}
+
+func TestVPCDHCPOptions(t *testing.T) {
+	tests := []struct {
+		desc              string
+		existingID        string
+		vpcs              []*ec2.Vpc
+		dhcpOptions       []*ec2.DhcpOptions
+		domainNameServers []string
+		resAssociatedWith string
+		resNameServers    []*string
+	}{
+		{
+			desc:              "DHCP options of a new VPC are created",
+			resAssociatedWith: "vpc-new",
+			resNameServers:    []*string{aws.String("AmazonProvidedDNS")},
+		},
+		{
+			desc:              "missing DHCP options of a VPC are created",
+			vpcs:              []*ec2.Vpc{{VpcId: aws.String("vpc-123")}},
+			domainNameServers: []string{"10.0.0.2", "10.0.0.3"},
+			resAssociatedWith: "vpc-123",
+			resNameServers:    []*string{aws.String("10.0.0.2"), aws.String("10.0.0.3")},
+		},
+		{
+			desc:        "DHCP options of a VPC are kept",
+			vpcs:        []*ec2.Vpc{{VpcId: aws.String("vpc-123")}},
+			dhcpOptions: []*ec2.DhcpOptions{{DhcpOptionsId: aws.String("dopt-123")}},
+		},
+		{
+			desc:       "existing VPC is left alone",
+			existingID: "vpc-existing",
+			vpcs:       []*ec2.Vpc{{VpcId: aws.String("vpc-existing")}},
+		},
+	}
+
+	for i, tc := range tests {
+		clients, fake := newFakeClients()
+		fake.on("DescribeVpcs", func(params, output interface{}) error {
+			// The waiter of the creation looks the VPC up by ID.
+			if len(params.(*ec2.DescribeVpcsInput).VpcIds) > 0 && tc.existingID == "" {
+				output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-new"), State: aws.String(ec2.VpcStateAvailable)}}
+				return nil
+			}
+			output.(*ec2.DescribeVpcsOutput).Vpcs = tc.vpcs
+			return nil
+		})
+		fake.on("CreateVpc", func(params, output interface{}) error {
+			output.(*ec2.CreateVpcOutput).Vpc = &ec2.Vpc{VpcId: aws.String("vpc-new")}
+			return nil
+		})
+		fake.on("DescribeDhcpOptions", func(params, output interface{}) error {
+			output.(*ec2.DescribeDhcpOptionsOutput).DhcpOptions = tc.dhcpOptions
+			return nil
+		})
+		fake.on("CreateDhcpOptions", func(params, output interface{}) error {
+			output.(*ec2.CreateDhcpOptionsOutput).DhcpOptions = &ec2.DhcpOptions{DhcpOptionsId: aws.String("dopt-new")}
+			return nil
+		})
+
+		vpc := &VPC{
+			CidrBlock: "10.0.0.0/16",
+			DHCPOptions: &DHCPOptions{
+				DomainName:        "foo.aws.giantswarm.io",
+				DomainNameServers: tc.domainNameServers,
+			},
+			ExistingID: tc.existingID,
+			Name:       fmt.Sprintf("vpc-dhcp-options-%d", i),
+			AWSEntity:  AWSEntity{Clients: clients},
+		}
+		_, err := vpc.CreateIfNotExists()
+		recentCreations.remove(vpc.creationKey())
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		associations := fake.paramsOf("AssociateDhcpOptions")
+		if tc.resAssociatedWith == "" {
+			assert.Empty(t, fake.paramsOf("CreateDhcpOptions"), fmt.Sprintf("[%s] Unexpected DHCP options", tc.desc))
+			assert.Empty(t, associations, fmt.Sprintf("[%s] Unexpected association", tc.desc))
+			continue
+		}
+
+		input := fake.paramsOf("CreateDhcpOptions")[0].(*ec2.CreateDhcpOptionsInput)
+		assert.Equal(t, []*ec2.NewDhcpConfiguration{
+			{Key: aws.String("domain-name"), Values: []*string{aws.String("foo.aws.giantswarm.io")}},
+			{Key: aws.String("domain-name-servers"), Values: tc.resNameServers},
+		}, input.DhcpConfigurations, fmt.Sprintf("[%s] Wrong DHCP configurations", tc.desc))
+		if assert.Len(t, associations, 1, fmt.Sprintf("[%s] Expected an association", tc.desc)) {
+			association := associations[0].(*ec2.AssociateDhcpOptionsInput)
+			assert.Equal(t, "dopt-new", aws.StringValue(association.DhcpOptionsId), fmt.Sprintf("[%s] Wrong DHCP options associated", tc.desc))
+			assert.Equal(t, tc.resAssociatedWith, aws.StringValue(association.VpcId), fmt.Sprintf("[%s] Wrong VPC", tc.desc))
+		}
+	}
+}
+
+func TestVPCDeleteDHCPOptions(t *testing.T) {
+	clients, fake := newFakeClients()
+	fake.on("DescribeVpcs", func(params, output interface{}) error {
+		output.(*ec2.DescribeVpcsOutput).Vpcs = []*ec2.Vpc{{VpcId: aws.String("vpc-123")}}
+		return nil
+	})
+	fake.on("DescribeDhcpOptions", func(params, output interface{}) error {
+		output.(*ec2.DescribeDhcpOptionsOutput).DhcpOptions = []*ec2.DhcpOptions{{DhcpOptionsId: aws.String("dopt-123")}}
+		return nil
+	})
+
+	vpc := &VPC{
+		DHCPOptions: &DHCPOptions{DomainName: "foo.aws.giantswarm.io"},
+		Name:        "foo",
+		AWSEntity:   AWSEntity{Clients: clients},
+	}
+
+	err := vpc.Delete()
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"DescribeVpcs", "DescribeDhcpOptions", "AssociateDhcpOptions", "DeleteDhcpOptions", "DeleteVpc"}, fake.operations(), "Unexpected AWS API calls")
+
+	association := fake.paramsOf("AssociateDhcpOptions")[0].(*ec2.AssociateDhcpOptionsInput)
+	assert.Equal(t, "default", aws.StringValue(association.DhcpOptionsId), "VPC not reverted to the default DHCP options")
+	assert.Equal(t, "vpc-123", aws.StringValue(association.VpcId), "Wrong VPC reverted")
+	input := fake.paramsOf("DeleteDhcpOptions")[0].(*ec2.DeleteDhcpOptionsInput)
+	assert.Equal(t, "dopt-123", aws.StringValue(input.DhcpOptionsId), "Wrong DHCP options deleted")
+}
+
+func TestCreateFlowLogsInputBuild(t *testing.T) {
+	clients, _ := newFakeClients()
+	req := clients.EC2.NewRequest(&request.Operation{
+		Name:       "CreateFlowLogs",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, &createFlowLogsInput{
+		LogDestination:     aws.String("arn:aws:s3:::bucket-foo/flow-logs/"),
+		LogDestinationType: aws.String("s3"),
+		ResourceIds:        []*string{aws.String("vpc-123")},
+		ResourceType:       aws.String("VPC"),
+		TrafficType:        aws.String("ALL"),
+	}, &ec2.CreateFlowLogsOutput{})
+
+	ec2query.Build(req)
+	assert.Nil(t, req.Error, "Unexpected error")
+
+	body, err := ioutil.ReadAll(req.GetBody())
+	assert.Nil(t, err, "Unexpected error reading the body")
+	values, err := url.ParseQuery(string(body))
+	assert.Nil(t, err, "Unexpected error parsing the body")
+
+	assert.Equal(t, "CreateFlowLogs", values.Get("Action"), "Wrong action")
+	assert.Equal(t, "arn:aws:s3:::bucket-foo/flow-logs/", values.Get("LogDestination"), "Destination not built")
+	assert.Equal(t, "s3", values.Get("LogDestinationType"), "Destination type not built")
+	assert.Equal(t, "vpc-123", values.Get("ResourceId.1"), "VPC not built")
+	assert.Equal(t, "VPC", values.Get("ResourceType"), "Resource type not built")
+	assert.Equal(t, "ALL", values.Get("TrafficType"), "Traffic type not built")
+}
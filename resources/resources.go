@@ -32,3 +32,15 @@ type DNSNamedResource interface {
 	HostedZoneID() string
 	Resource
 }
+
+type DriftingResource interface {
+	// Drift returns how the existing resource differs from its definition. It
+	// is empty when the resource is in sync.
+	Drift() ([]string, error)
+}
+
+type FetchableResource interface {
+	// Get reads the resource back from the provider, populating the fields
+	// which are only known once it exists.
+	Get() error
+}
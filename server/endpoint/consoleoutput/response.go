@@ -0,0 +1,15 @@
+package consoleoutput
+
+// Response is the return value of the service action.
+type Response struct {
+	InstanceID string `json:"instance_id"`
+	Output     string `json:"output"`
+}
+
+// DefaultResponse provides a default response object by best effort.
+func DefaultResponse() *Response {
+	return &Response{
+		InstanceID: "",
+		Output:     "",
+	}
+}
@@ -4,6 +4,9 @@ import (
 	microerror "github.com/giantswarm/microkit/error"
 	micrologger "github.com/giantswarm/microkit/logger"
 
+	"github.com/giantswarm/aws-operator/server/endpoint/consoleoutput"
+	"github.com/giantswarm/aws-operator/server/endpoint/plan"
+	"github.com/giantswarm/aws-operator/server/endpoint/terminate"
 	"github.com/giantswarm/aws-operator/server/endpoint/version"
 	"github.com/giantswarm/aws-operator/server/middleware"
 	"github.com/giantswarm/aws-operator/service"
@@ -32,6 +35,42 @@ func DefaultConfig() Config {
 func New(config Config) (*Endpoint, error) {
 	var err error
 
+	var consoleOutputEndpoint *consoleoutput.Endpoint
+	{
+		consoleOutputConfig := consoleoutput.DefaultConfig()
+		consoleOutputConfig.Logger = config.Logger
+		consoleOutputConfig.Middleware = config.Middleware
+		consoleOutputConfig.Service = config.Service
+		consoleOutputEndpoint, err = consoleoutput.New(consoleOutputConfig)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+	}
+
+	var planEndpoint *plan.Endpoint
+	{
+		planConfig := plan.DefaultConfig()
+		planConfig.Logger = config.Logger
+		planConfig.Middleware = config.Middleware
+		planConfig.Service = config.Service
+		planEndpoint, err = plan.New(planConfig)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+	}
+
+	var terminateEndpoint *terminate.Endpoint
+	{
+		terminateConfig := terminate.DefaultConfig()
+		terminateConfig.Logger = config.Logger
+		terminateConfig.Middleware = config.Middleware
+		terminateConfig.Service = config.Service
+		terminateEndpoint, err = terminate.New(terminateConfig)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+	}
+
 	var versionEndpoint *version.Endpoint
 	{
 		versionConfig := version.DefaultConfig()
@@ -45,7 +84,10 @@ func New(config Config) (*Endpoint, error) {
 	}
 
 	newEndpoint := &Endpoint{
-		Version: versionEndpoint,
+		ConsoleOutput: consoleOutputEndpoint,
+		Plan:          planEndpoint,
+		Terminate:     terminateEndpoint,
+		Version:       versionEndpoint,
 	}
 
 	return newEndpoint, nil
@@ -53,5 +95,8 @@ func New(config Config) (*Endpoint, error) {
 
 // Endpoint is the endpoint collection.
 type Endpoint struct {
-	Version *version.Endpoint
+	ConsoleOutput *consoleoutput.Endpoint
+	Plan          *plan.Endpoint
+	Terminate     *terminate.Endpoint
+	Version       *version.Endpoint
 }
@@ -0,0 +1,19 @@
+package plan
+
+import (
+	"github.com/giantswarm/aws-operator/service/create"
+)
+
+// Response is the return value of the service action.
+type Response struct {
+	Cluster string                 `json:"cluster"`
+	Actions []create.PlannedAction `json:"actions"`
+}
+
+// DefaultResponse provides a default response object by best effort.
+func DefaultResponse() *Response {
+	return &Response{
+		Cluster: "",
+		Actions: []create.PlannedAction{},
+	}
+}
@@ -0,0 +1,15 @@
+package terminate
+
+// Request is the body of the requests of this endpoint.
+type Request struct {
+	ClusterID   string   `json:"-"`
+	InstanceIDs []string `json:"instance_ids"`
+}
+
+// DefaultRequest provides a default request object by best effort.
+func DefaultRequest() Request {
+	return Request{
+		ClusterID:   "",
+		InstanceIDs: []string{},
+	}
+}
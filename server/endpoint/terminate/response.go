@@ -0,0 +1,15 @@
+package terminate
+
+// Response is the return value of the service action.
+type Response struct {
+	Cluster     string   `json:"cluster"`
+	InstanceIDs []string `json:"instance_ids"`
+}
+
+// DefaultResponse provides a default response object by best effort.
+func DefaultResponse() *Response {
+	return &Response{
+		Cluster:     "",
+		InstanceIDs: []string{},
+	}
+}
@@ -106,11 +106,15 @@ func New(config Config) (microserver.Server, error) {
 		// Dependencies.
 		logger:               config.Logger,
 		router:               config.Router,
+		service:              config.Service,
 		transactionResponder: config.TransactionResponder,
 
 		// Internals
 		bootOnce: sync.Once{},
 		endpoints: []microserver.Endpoint{
+			endpointCollection.ConsoleOutput,
+			endpointCollection.Plan,
+			endpointCollection.Terminate,
 			endpointCollection.Version,
 		},
 		shutdownOnce: sync.Once{},
@@ -126,6 +130,7 @@ type server struct {
 	// Dependencies.
 	logger               micrologger.Logger
 	router               *mux.Router
+	service              *service.Service
 	transactionResponder microtransaction.Responder
 
 	// Internals.
@@ -199,8 +204,9 @@ func (s *server) ServiceName() string {
 
 func (s *server) Shutdown() {
 	s.shutdownOnce.Do(func() {
-		// Here goes your custom shutdown logic for your server/endpoint/middleware,
-		// if any.
+		// Active reconciles are given the chance to finish before the operator
+		// exits.
+		s.service.Shutdown()
 	})
 }
 
@@ -21,3 +21,69 @@ func (s *Service) encodeTLSAssets(assets certificatetpr.AssetsBundle, svc *kms.K
 
 	return compTLS, nil
 }
+
+// nodeTLSAssets are the encoded TLS assets of the machines of a cluster.
+// Machines with certificates of their own, e.g. etcd peer certificates, have
+// assets of their own, all the others share the assets of the cluster.
+type nodeTLSAssets struct {
+	shared *certificatetpr.CompactTLSAssets
+	// nodes are the assets of the machines with certificates of their own, by
+	// machine ID.
+	nodes map[string]*certificatetpr.CompactTLSAssets
+}
+
+// forMachine returns the assets of the machine with the given ID.
+func (a nodeTLSAssets) forMachine(id string) *certificatetpr.CompactTLSAssets {
+	if assets, ok := a.nodes[id]; ok {
+		return assets
+	}
+
+	return a.shared
+}
+
+// encodeNodeTLSAssets encodes the assets of the cluster, and the assets of
+// every machine with certificates of their own. The certificates of a machine
+// take precedence over the ones of the cluster, the ones it lacks are taken
+// from the cluster.
+func encodeNodeTLSAssets(certs certificatetpr.AssetsBundle, nodeCerts map[string]certificatetpr.AssetsBundle, encode func(certificatetpr.AssetsBundle) (*certificatetpr.CompactTLSAssets, error)) (nodeTLSAssets, error) {
+	shared, err := encode(certs)
+	if err != nil {
+		return nodeTLSAssets{}, microerror.MaskAny(err)
+	}
+
+	assets := nodeTLSAssets{
+		shared: shared,
+		nodes:  make(map[string]*certificatetpr.CompactTLSAssets),
+	}
+
+	for id, machineCerts := range nodeCerts {
+		merged := make(certificatetpr.AssetsBundle)
+		for key, asset := range certs {
+			merged[key] = asset
+		}
+		for key, asset := range machineCerts {
+			merged[key] = asset
+		}
+
+		encoded, err := encode(merged)
+		if err != nil {
+			return nodeTLSAssets{}, microerror.MaskAnyf(err, "could not encode the TLS assets of machine '%s'", id)
+		}
+		assets.nodes[id] = encoded
+	}
+
+	return assets, nil
+}
+
+// encodeClusterTLSAssets encrypts the TLS assets of the machines of a cluster
+// with the given KMS key.
+func (s *Service) encodeClusterTLSAssets(certs certificatetpr.AssetsBundle, nodeCerts map[string]certificatetpr.AssetsBundle, svc *kms.KMS, kmsKeyArn string) (nodeTLSAssets, error) {
+	assets, err := encodeNodeTLSAssets(certs, nodeCerts, func(bundle certificatetpr.AssetsBundle) (*certificatetpr.CompactTLSAssets, error) {
+		return s.encodeTLSAssets(bundle, svc, kmsKeyArn)
+	})
+	if err != nil {
+		return nodeTLSAssets{}, microerror.MaskAny(err)
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,92 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/certificatetpr"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEncode "encodes" the etcd and API certificates of a bundle by prefixing
+// them, and counts its calls.
+func fakeEncode(calls *int) func(certificatetpr.AssetsBundle) (*certificatetpr.CompactTLSAssets, error) {
+	return func(bundle certificatetpr.AssetsBundle) (*certificatetpr.CompactTLSAssets, error) {
+		*calls++
+		return &certificatetpr.CompactTLSAssets{
+			APIServerCrt:  "enc:" + string(bundle[certificatetpr.AssetsBundleKey{Component: certificatetpr.APIComponent, Type: certificatetpr.Crt}]),
+			EtcdServerCrt: "enc:" + string(bundle[certificatetpr.AssetsBundleKey{Component: certificatetpr.EtcdComponent, Type: certificatetpr.Crt}]),
+		}, nil
+	}
+}
+
+func TestEncodeNodeTLSAssets(t *testing.T) {
+	etcdCrt := certificatetpr.AssetsBundleKey{Component: certificatetpr.EtcdComponent, Type: certificatetpr.Crt}
+	apiCrt := certificatetpr.AssetsBundleKey{Component: certificatetpr.APIComponent, Type: certificatetpr.Crt}
+
+	certs := certificatetpr.AssetsBundle{
+		etcdCrt: []byte("cluster-etcd"),
+		apiCrt:  []byte("cluster-api"),
+	}
+
+	tests := []struct {
+		desc      string
+		nodeCerts map[string]certificatetpr.AssetsBundle
+		// res are the expected etcd and API certificates by machine ID.
+		res      map[string][2]string
+		resCalls int
+	}{
+		{
+			desc:      "machines share the cluster assets",
+			nodeCerts: nil,
+			res: map[string][2]string{
+				machineID(prefixMaster, 0): {"enc:cluster-etcd", "enc:cluster-api"},
+				machineID(prefixMaster, 1): {"enc:cluster-etcd", "enc:cluster-api"},
+				machineID(prefixWorker, 0): {"enc:cluster-etcd", "enc:cluster-api"},
+			},
+			resCalls: 1,
+		},
+		{
+			desc: "masters have etcd peer certificates of their own",
+			nodeCerts: map[string]certificatetpr.AssetsBundle{
+				machineID(prefixMaster, 0): {etcdCrt: []byte("master-0-etcd")},
+				machineID(prefixMaster, 1): {etcdCrt: []byte("master-1-etcd")},
+			},
+			res: map[string][2]string{
+				machineID(prefixMaster, 0): {"enc:master-0-etcd", "enc:cluster-api"},
+				machineID(prefixMaster, 1): {"enc:master-1-etcd", "enc:cluster-api"},
+				machineID(prefixWorker, 0): {"enc:cluster-etcd", "enc:cluster-api"},
+			},
+			resCalls: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		var calls int
+		assets, err := encodeNodeTLSAssets(certs, tc.nodeCerts, fakeEncode(&calls))
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCalls, calls, fmt.Sprintf("[%s] Wrong number of encodings", tc.desc))
+
+		for id, res := range tc.res {
+			machineAssets := assets.forMachine(id)
+			assert.Equal(t, res[0], machineAssets.EtcdServerCrt, fmt.Sprintf("[%s] Wrong etcd certificate of machine '%s'", tc.desc, id))
+			assert.Equal(t, res[1], machineAssets.APIServerCrt, fmt.Sprintf("[%s] Wrong API certificate of machine '%s'", tc.desc, id))
+		}
+	}
+
+	// The certificates of the cluster are not changed by the ones of machines.
+	assert.Equal(t, "cluster-etcd", string(certs[etcdCrt]), "Cluster certificates were changed")
+}
+
+func TestBucketObjectName(t *testing.T) {
+	s := &Service{}
+	var cluster awstpr.CustomObject
+	cluster.Spec.Cluster.Cluster.ID = "abc12"
+
+	master0 := s.bucketObjectName(cluster, machineID(prefixMaster, 0))
+	master1 := s.bucketObjectName(cluster, machineID(prefixMaster, 1))
+
+	assert.Equal(t, "abc12/cloudconfig/master-0", master0, "Wrong bucket object name")
+	assert.NotEqual(t, master0, master1, "Masters share a bucket object")
+}
@@ -0,0 +1,27 @@
+package create
+
+import (
+	"github.com/giantswarm/awstpr"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// awsEntity returns the AWSEntity of the resources managed by this operator.
+func (s *Service) awsEntity(clients awsutil.Clients) awsresources.AWSEntity {
+	return awsresources.AWSEntity{
+		Clients:    clients,
+		OperatorID: s.operatorID,
+	}
+}
+
+// clusterAWSEntity returns the AWSEntity of the resources created for the
+// given cluster, which get tagged with the cluster's tags, and scoped to the
+// cluster in shared VPCs.
+func (s *Service) clusterAWSEntity(clients awsutil.Clients, cluster awstpr.CustomObject) awsresources.AWSEntity {
+	entity := s.awsEntity(clients)
+	entity.Tags = s.clusterTags(cluster)
+	entity.ClusterID = scopedClusterID(cluster)
+
+	return entity
+}
@@ -6,14 +6,15 @@ import (
 	"github.com/giantswarm/awstpr"
 )
 
-func (s *Service) bucketName(cluster awstpr.CustomObject) string {
-	accountID := s.awsConfig.AccountID()
+// bucketName returns the name of the bucket of the cluster in the AWS account
+// with the given ID.
+func (s *Service) bucketName(accountID string, cluster awstpr.CustomObject) string {
 	customerID := cluster.Spec.Cluster.Customer.ID
 	region := cluster.Spec.AWS.Region
 
 	name := fmt.Sprintf("%s-g8s-%s-%s", accountID, customerID, region)
 
-	return name
+	return s.resourceName(name)
 }
 
 func (s *Service) bucketObjectDirPath(cluster awstpr.CustomObject) string {
@@ -21,14 +22,21 @@ func (s *Service) bucketObjectDirPath(cluster awstpr.CustomObject) string {
 	return fmt.Sprintf("%s/cloudconfig", clusterID)
 }
 
-func (s *Service) bucketObjectFullDirPath(cluster awstpr.CustomObject) string {
-	bucketName := s.bucketName(cluster)
-
+func (s *Service) bucketObjectFullDirPath(bucketName string, cluster awstpr.CustomObject) string {
 	dirPath := s.bucketObjectDirPath(cluster)
 	return fmt.Sprintf("%s/%s", bucketName, dirPath)
 }
 
-func (s *Service) bucketObjectName(cluster awstpr.CustomObject, prefix string) string {
+// bucketObjectName returns the name of the S3 object holding the final
+// cloudconfig of the machine with the given ID. Every machine has its own
+// object, since cloudconfigs differ per node.
+func (s *Service) bucketObjectName(cluster awstpr.CustomObject, machineID string) string {
 	dirPath := s.bucketObjectDirPath(cluster)
-	return fmt.Sprintf("%s/%s", dirPath, prefix)
+	return fmt.Sprintf("%s/%s", dirPath, machineID)
+}
+
+// machineID identifies a machine of a cluster by its prefix and index, e.g.
+// "master-0". It keys the cloudconfig and the TLS assets of the machine.
+func machineID(prefix string, index int) string {
+	return fmt.Sprintf("%s-%d", prefix, index)
 }
@@ -0,0 +1,183 @@
+package create
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/certificatetpr"
+	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// certSecretSelector selects the secrets holding the TLS assets of clusters,
+// which are the only secrets the operator consumes.
+var certSecretSelector = fmt.Sprintf("%s,%s", certificatetpr.ClusterIDLabel, certificatetpr.ComponentLabel)
+
+func (s *Service) newCertSecretListWatch() *cache.ListWatch {
+	secrets := s.k8sClient.Core().Secrets(api.NamespaceDefault)
+
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return secrets.List(v1.ListOptions{
+				LabelSelector:   certSecretSelector,
+				ResourceVersion: options.ResourceVersion,
+			})
+		},
+
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return secrets.Watch(v1.ListOptions{
+				LabelSelector:   certSecretSelector,
+				ResourceVersion: options.ResourceVersion,
+			})
+		},
+	}
+
+	return listWatch
+}
+
+// newCertSecretHandler returns the event handlers of the cert secret
+// informer. When the TLS assets of a cluster change, reencode is called with
+// the cluster, which is looked up in the given cluster store.
+func (s *Service) newCertSecretHandler(clusters cache.Store, reencode func(awstpr.CustomObject) error) cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret, ok := oldObj.(*v1.Secret)
+			if !ok {
+				return
+			}
+			newSecret, ok := newObj.(*v1.Secret)
+			if !ok {
+				return
+			}
+			if !certSecretChanged(oldSecret, newSecret) {
+				return
+			}
+
+			clusterID := newSecret.Labels[certificatetpr.ClusterIDLabel]
+			cluster, ok := clusterByID(clusters, clusterID)
+			if !ok {
+				s.logger.Log("debug", fmt.Sprintf("certificates of unknown cluster '%s' changed, ignoring", clusterID))
+				return
+			}
+
+			s.logger.Log("info", fmt.Sprintf("certificates of cluster '%s' changed, updating cloudconfigs", cluster.Name))
+			if err := reencode(cluster); err != nil {
+				s.logger.Log("error", fmt.Sprintf("could not update cloudconfigs of cluster '%s': %s", cluster.Name, errgo.Details(err)))
+				return
+			}
+			s.logger.Log("info", fmt.Sprintf("updated cloudconfigs of cluster '%s'", cluster.Name))
+		},
+	}
+}
+
+// certSecretChanged tells whether the TLS assets held by a secret changed.
+// Resyncs and metadata updates don't require the cloudconfigs to be updated.
+func certSecretChanged(oldSecret, newSecret *v1.Secret) bool {
+	if len(oldSecret.Data) != len(newSecret.Data) {
+		return true
+	}
+	for k, v := range newSecret.Data {
+		if !bytes.Equal(oldSecret.Data[k], v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clusterByID returns the cluster with the given ID from the cluster store.
+func clusterByID(clusters cache.Store, clusterID string) (awstpr.CustomObject, bool) {
+	for _, obj := range clusters.List() {
+		cluster, ok := obj.(*awstpr.CustomObject)
+		if !ok {
+			continue
+		}
+		if cluster.Spec.Cluster.Cluster.ID == clusterID {
+			return *cluster, true
+		}
+	}
+
+	return awstpr.CustomObject{}, false
+}
+
+// reencodeCloudConfigs encodes the current TLS assets of the cluster with its
+// existing KMS key and uploads the cloudconfigs of all its machines again.
+// Running instances pick up the new cloudconfigs when they are rebooted. It
+// runs concurrently with the reconciliation of clusters, so it works on a copy
+// of the AWS config.
+func (s *Service) reencodeCloudConfigs(cluster awstpr.CustomObject) error {
+	region, err := clusterRegion(cluster.Spec, s.defaultRegion)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	cluster.Spec.AWS.Region = region
+	awsConfig := s.awsConfig
+	awsConfig.Region = region
+	clients := awsutil.NewClients(awsConfig)
+	s.awsRateLimiter.Limit(clients)
+
+	if err := awsConfig.SetAccountID(clients.IAM); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	certs, err := s.certWatcher.SearchCerts(cluster.Spec.Cluster.Cluster.ID)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	// A new key would leave the cloudconfigs which are not uploaded again
+	// undecryptable, so the existing key is required.
+	kmsKey, err := awsresources.NewKMSKeyFromExisting(s.resourceName(cluster.Name), s.awsEntity(clients))
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	tlsAssets, err := s.encodeClusterTLSAssets(certs, nil, clients.KMS, kmsKey.Arn())
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	bucket := &awsresources.Bucket{
+		Name:      s.bucketName(awsConfig.AccountID(), cluster),
+		AWSEntity: s.awsEntity(clients),
+	}
+
+	for i, machine := range cluster.Spec.Cluster.Masters {
+		if err := s.uploadCloudConfig(runMachineInput{
+			clients:   clients,
+			cluster:   cluster,
+			machine:   machine,
+			index:     i,
+			tlsAssets: tlsAssets,
+			bucket:    bucket,
+			prefix:    prefixMaster,
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	for i, machine := range cluster.Spec.Cluster.Workers {
+		if err := s.uploadCloudConfig(runMachineInput{
+			clients:   clients,
+			cluster:   cluster,
+			machine:   machine,
+			index:     i,
+			tlsAssets: tlsAssets,
+			bucket:    bucket,
+			prefix:    prefixWorker,
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/certificatetpr"
+	"github.com/giantswarm/clustertpr"
+	"github.com/giantswarm/clustertpr/cluster"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCertSecretHandler(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+	s := &Service{logger: logger}
+
+	clusters := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	for _, id := range []string{"foo", "bar"} {
+		err := clusters.Add(&awstpr.CustomObject{
+			ObjectMeta: v1.ObjectMeta{
+				Name: id + "-cluster",
+			},
+			Spec: awstpr.Spec{
+				Cluster: clustertpr.Cluster{
+					Cluster: cluster.Cluster{
+						ID: id,
+					},
+				},
+			},
+		})
+		assert.Nil(t, err, "Unexpected error adding a cluster to the store")
+	}
+
+	newSecret := func(clusterID, crt string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Labels: map[string]string{
+					certificatetpr.ClusterIDLabel: clusterID,
+					certificatetpr.ComponentLabel: certificatetpr.APIComponent.String(),
+				},
+			},
+			Data: map[string][]byte{
+				"crt": []byte(crt),
+			},
+		}
+	}
+
+	tests := []struct {
+		desc      string
+		oldSecret *v1.Secret
+		newSecret *v1.Secret
+		res       []string
+	}{
+		{
+			desc:      "rotated certificate updates its cluster",
+			oldSecret: newSecret("bar", "old"),
+			newSecret: newSecret("bar", "new"),
+			res:       []string{"bar-cluster"},
+		},
+		{
+			desc:      "resync doesn't update the cluster",
+			oldSecret: newSecret("bar", "same"),
+			newSecret: newSecret("bar", "same"),
+		},
+		{
+			desc:      "certificate of unknown cluster is ignored",
+			oldSecret: newSecret("baz", "old"),
+			newSecret: newSecret("baz", "new"),
+		},
+	}
+
+	for _, tc := range tests {
+		var reencoded []string
+		handler := s.newCertSecretHandler(clusters, func(cluster awstpr.CustomObject) error {
+			reencoded = append(reencoded, cluster.Name)
+			return nil
+		})
+
+		handler.OnUpdate(tc.oldSecret, tc.newSecret)
+
+		assert.Equal(t, tc.res, reencoded, fmt.Sprintf("[%s] The secret change didn't update the expected clusters", tc.desc))
+	}
+}
@@ -0,0 +1,178 @@
+package create
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// fakeResponse populates the output of a faked AWS API call, or returns the
+// error the call should fail with.
+type fakeResponse func(params, output interface{}) error
+
+// fakeAWS records the AWS API calls made through the clients returned by
+// newFakeClients, without ever reaching AWS. Calls succeed with an empty output
+// unless a response was registered for the operation.
+type fakeAWS struct {
+	mutex      sync.Mutex
+	operations []string
+	responses  map[string]fakeResponse
+}
+
+func newFakeClients() (awsutil.Clients, *fakeAWS) {
+	fake := &fakeAWS{
+		responses: map[string]fakeResponse{},
+	}
+
+	s := session.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Region:      aws.String("eu-central-1"),
+	})
+	clients := awsutil.Clients{
+		EC2:     ec2.New(s),
+		IAM:     iam.New(s),
+		S3:      s3.New(s),
+		KMS:     kms.New(s),
+		ELB:     elb.New(s),
+		Route53: route53.New(s),
+	}
+
+	for _, handlers := range []*request.Handlers{
+		&clients.EC2.Handlers,
+		&clients.IAM.Handlers,
+		&clients.S3.Handlers,
+		&clients.KMS.Handlers,
+		&clients.ELB.Handlers,
+		&clients.Route53.Handlers,
+	} {
+		handlers.Clear()
+		handlers.Send.PushBack(fake.handle)
+	}
+
+	return clients, fake
+}
+
+// on registers the response for all the calls of the given operation.
+func (f *fakeAWS) on(operation string, response fakeResponse) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.responses[operation] = response
+}
+
+func (f *fakeAWS) handle(r *request.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.operations = append(f.operations, r.Operation.Name)
+
+	// Some clients register per operation handlers reading the response, so
+	// we always hand them an empty one.
+	r.HTTPResponse = &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+	}
+
+	if response, ok := f.responses[r.Operation.Name]; ok {
+		if err := response(r.Params, r.Data); err != nil {
+			r.Error = err
+		}
+	}
+}
+
+// fakeInstances are the instances of a fake EC2 API. They are found by name
+// pattern or ID, like FindInstances and Instance.Delete look them up.
+type fakeInstances struct {
+	instances  []*ec2.Instance
+	terminated []string
+}
+
+// newFakeInstances creates running instances with the given names, whose IDs
+// and private IP addresses are derived from their names.
+func newFakeInstances(names ...string) *fakeInstances {
+	f := &fakeInstances{}
+	for _, name := range names {
+		f.instances = append(f.instances, &ec2.Instance{
+			InstanceId:       aws.String("i-" + name),
+			PrivateIpAddress: aws.String("ip-" + name),
+			State:            &ec2.InstanceState{Code: aws.Int64(int64(awsresources.EC2RunningState)), Name: aws.String(ec2.InstanceStateNameRunning)},
+			Tags:             []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String(name)}},
+		})
+	}
+
+	return f
+}
+
+func (f *fakeInstances) describe(params, output interface{}) error {
+	input := params.(*ec2.DescribeInstancesInput)
+
+	reservation := &ec2.Reservation{}
+	for _, instance := range f.instances {
+		if fakeInstanceMatches(instance, input) {
+			reservation.Instances = append(reservation.Instances, instance)
+		}
+	}
+	output.(*ec2.DescribeInstancesOutput).Reservations = []*ec2.Reservation{reservation}
+
+	return nil
+}
+
+func (f *fakeInstances) terminate(params, output interface{}) error {
+	for _, id := range params.(*ec2.TerminateInstancesInput).InstanceIds {
+		for _, instance := range f.instances {
+			if aws.StringValue(instance.InstanceId) == aws.StringValue(id) {
+				instance.State = &ec2.InstanceState{Code: aws.Int64(int64(awsresources.EC2TerminatedState)), Name: aws.String(ec2.InstanceStateNameTerminated)}
+				f.terminated = append(f.terminated, aws.StringValue(id))
+			}
+		}
+	}
+
+	return nil
+}
+
+// fakeInstanceMatches returns whether the instance is selected by the IDs and
+// the name and ID filters of the input. Other filters are ignored.
+func fakeInstanceMatches(instance *ec2.Instance, input *ec2.DescribeInstancesInput) bool {
+	id := aws.StringValue(instance.InstanceId)
+	name := aws.StringValue(instance.Tags[0].Value)
+
+	if len(input.InstanceIds) > 0 && !containsString(aws.StringValueSlice(input.InstanceIds), id) {
+		return false
+	}
+	for _, filter := range input.Filters {
+		values := aws.StringValueSlice(filter.Values)
+		switch aws.StringValue(filter.Name) {
+		case "instance-id":
+			if !containsString(values, id) {
+				return false
+			}
+		case "tag:Name":
+			if strings.HasSuffix(values[0], "*") {
+				if !strings.HasPrefix(name, strings.TrimSuffix(values[0], "*")) {
+					return false
+				}
+			} else if !containsString(values, name) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -329,5 +329,20 @@ func (s *Service) cloudConfig(prefix string, params cloudconfig.CloudConfigTempl
 		return "", microerror.MaskAny(err)
 	}
 
-	return cc.Base64(), nil
+	if s.cloudConfigValidation {
+		raw, err := rawCloudConfig(cc.Base64())
+		if err != nil {
+			return "", microerror.MaskAny(err)
+		}
+		if err := validateCloudConfig(raw); err != nil {
+			return "", microerror.MaskAny(err)
+		}
+	}
+
+	encoded, err := encodeCloudConfig(cc.Base64(), s.cloudConfigEncoding)
+	if err != nil {
+		return "", microerror.MaskAny(err)
+	}
+
+	return encoded, nil
 }
@@ -0,0 +1,91 @@
+package create
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+const (
+	// CloudConfigEncodingGzipBase64 gzips the final cloudconfig before base64
+	// encoding it. This is the default, since it keeps the S3 objects small.
+	CloudConfigEncodingGzipBase64 = "gzip+base64"
+	// CloudConfigEncodingBase64 only base64 encodes the final cloudconfig.
+	CloudConfigEncodingBase64 = "base64"
+)
+
+// gzipMagic are the first bytes of gzipped data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+func validCloudConfigEncoding(encoding string) bool {
+	return encoding == CloudConfigEncodingGzipBase64 || encoding == CloudConfigEncodingBase64
+}
+
+// encodeCloudConfig converts the gzipped and base64 encoded cloudconfig
+// rendered by k8scloudconfig into the given encoding.
+func encodeCloudConfig(gzipBase64 string, encoding string) (string, error) {
+	switch encoding {
+	case CloudConfigEncodingGzipBase64:
+		return gzipBase64, nil
+	case CloudConfigEncodingBase64:
+		raw, err := rawCloudConfig(gzipBase64)
+		if err != nil {
+			return "", microerror.MaskAny(err)
+		}
+
+		return base64.StdEncoding.EncodeToString(raw), nil
+	default:
+		return "", microerror.MaskAnyf(invalidCloudConfigEncodingError, "unknown encoding '%s'", encoding)
+	}
+}
+
+// checkEncodedCloudConfig checks the final cloudconfig can be decoded the way
+// the small cloudconfig decodes it on the nodes, so a malformed one fails the
+// launch instead of the boot of the instance.
+func checkEncodedCloudConfig(encoded string, encoding string) error {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return microerror.MaskAnyf(malformedEncodedCloudConfigError, "invalid base64: %s", err)
+	}
+	if encoding != CloudConfigEncodingGzipBase64 {
+		return nil
+	}
+
+	if !bytes.HasPrefix(decoded, gzipMagic) {
+		return microerror.MaskAnyf(malformedEncodedCloudConfigError, "missing gzip header")
+	}
+	r, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		return microerror.MaskAnyf(malformedEncodedCloudConfigError, "invalid gzip: %s", err)
+	}
+	defer r.Close()
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		return microerror.MaskAnyf(malformedEncodedCloudConfigError, "invalid gzip: %s", err)
+	}
+
+	return nil
+}
+
+// rawCloudConfig returns the raw cloudconfig out of the gzipped and base64
+// encoded one rendered by k8scloudconfig.
+func rawCloudConfig(gzipBase64 string) ([]byte, error) {
+	compressed, err := base64.StdEncoding.DecodeString(gzipBase64)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+	defer r.Close()
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return raw, nil
+}
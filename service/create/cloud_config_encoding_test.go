@@ -0,0 +1,151 @@
+package create
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeCloudConfig decodes the final cloudconfig the same way the small
+// cloudconfig does on the nodes.
+func decodeCloudConfig(t *testing.T, encoded string, gzipped bool) string {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	assert.Nil(t, err, "Unexpected error decoding base64")
+
+	if !gzipped {
+		return string(decoded)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(decoded))
+	assert.Nil(t, err, "Unexpected error reading gzip")
+	raw, err := ioutil.ReadAll(r)
+	assert.Nil(t, err, "Unexpected error reading gzip")
+
+	return string(raw)
+}
+
+func TestEncodeCloudConfig(t *testing.T) {
+	rawCloudConfig := "#cloud-config\nhostname: \"foo\"\n"
+
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write([]byte(rawCloudConfig))
+	w.Close()
+	gzipBase64 := base64.StdEncoding.EncodeToString(b.Bytes())
+
+	tests := []struct {
+		desc         string
+		encoding     string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:     "gzip+base64 round trip",
+			encoding: CloudConfigEncodingGzipBase64,
+		},
+		{
+			desc:     "base64 round trip",
+			encoding: CloudConfigEncodingBase64,
+		},
+		{
+			desc:         "unknown encoding",
+			encoding:     "zstd",
+			errorMatcher: IsInvalidCloudConfigEncoding,
+		},
+	}
+
+	s := &Service{}
+	for _, tc := range tests {
+		encoded, err := encodeCloudConfig(gzipBase64, tc.encoding)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		gzipped := tc.encoding == CloudConfigEncodingGzipBase64
+		assert.Equal(t, rawCloudConfig, decodeCloudConfig(t, encoded, gzipped), fmt.Sprintf("[%s] The cloudconfig didn't round trip", tc.desc))
+
+		smallCloudconfig, err := s.SmallCloudconfig(SmallCloudconfigConfig{
+			Gzip:       gzipped,
+			ObjectName: machineID(prefixWorker, 0),
+			Region:     "eu-central-1",
+			S3DirURI:   "bucket/foo/cloudconfig",
+		})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		script, err := base64.StdEncoding.DecodeString(smallCloudconfig)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		if gzipped {
+			assert.Contains(t, string(script), "base64 -d /var/run/coreos/temp.txt | gunzip >", fmt.Sprintf("[%s] The node doesn't gunzip the cloudconfig", tc.desc))
+		} else {
+			assert.Contains(t, string(script), "base64 -d /var/run/coreos/temp.txt >", fmt.Sprintf("[%s] The node doesn't decode the cloudconfig", tc.desc))
+			assert.NotContains(t, string(script), "gunzip", fmt.Sprintf("[%s] The node gunzips a plain cloudconfig", tc.desc))
+		}
+	}
+}
+
+func TestCheckEncodedCloudConfig(t *testing.T) {
+	rawCloudConfig := "#cloud-config\nhostname: \"foo\"\n"
+
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write([]byte(rawCloudConfig))
+	w.Close()
+	gzipped := b.Bytes()
+
+	tests := []struct {
+		desc         string
+		encoded      string
+		encoding     string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:     "valid gzip",
+			encoded:  base64.StdEncoding.EncodeToString(gzipped),
+			encoding: CloudConfigEncodingGzipBase64,
+		},
+		{
+			desc:         "plain text announced as gzip",
+			encoded:      base64.StdEncoding.EncodeToString([]byte(rawCloudConfig)),
+			encoding:     CloudConfigEncodingGzipBase64,
+			errorMatcher: IsMalformedEncodedCloudConfig,
+		},
+		{
+			desc:         "truncated gzip",
+			encoded:      base64.StdEncoding.EncodeToString(gzipped[:len(gzipped)-8]),
+			encoding:     CloudConfigEncodingGzipBase64,
+			errorMatcher: IsMalformedEncodedCloudConfig,
+		},
+		{
+			desc:         "gzip header only",
+			encoded:      base64.StdEncoding.EncodeToString(gzipped[:2]),
+			encoding:     CloudConfigEncodingGzipBase64,
+			errorMatcher: IsMalformedEncodedCloudConfig,
+		},
+		{
+			desc:         "invalid base64",
+			encoded:      "not base64!",
+			encoding:     CloudConfigEncodingGzipBase64,
+			errorMatcher: IsMalformedEncodedCloudConfig,
+		},
+		{
+			desc:     "plain text",
+			encoded:  base64.StdEncoding.EncodeToString([]byte(rawCloudConfig)),
+			encoding: CloudConfigEncodingBase64,
+		},
+	}
+
+	for _, tc := range tests {
+		err := checkEncodedCloudConfig(tc.encoded, tc.encoding)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+	}
+}
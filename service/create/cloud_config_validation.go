@@ -0,0 +1,56 @@
+package create
+
+import (
+	"bytes"
+
+	microerror "github.com/giantswarm/microkit/error"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// cloudConfigHeader is the first line of every cloudconfig. coreos-cloudinit
+// ignores user data without it.
+const cloudConfigHeader = "#cloud-config"
+
+// cloudConfigSchema holds the parts of a cloudconfig checked before it gets
+// uploaded. Fields of the wrong type fail the parsing.
+type cloudConfigSchema struct {
+	WriteFiles []struct {
+		Path string `yaml:"path"`
+	} `yaml:"write_files"`
+	CoreOS struct {
+		Units []struct {
+			Name string `yaml:"name"`
+		} `yaml:"units"`
+	} `yaml:"coreos"`
+}
+
+// validateCloudConfig checks the rendered cloudconfig can be processed by
+// coreos-cloudinit, so a broken template fails the cluster creation instead
+// of the boot of its instances.
+func validateCloudConfig(raw []byte) error {
+	firstLine := raw
+	if i := bytes.IndexByte(raw, '\n'); i >= 0 {
+		firstLine = raw[:i]
+	}
+	if string(bytes.TrimSpace(firstLine)) != cloudConfigHeader {
+		return microerror.MaskAnyf(invalidCloudConfigError, "first line must be '%s'", cloudConfigHeader)
+	}
+
+	var schema cloudConfigSchema
+	if err := yaml.Unmarshal(raw, &schema); err != nil {
+		return microerror.MaskAnyf(invalidCloudConfigError, "%s", err)
+	}
+
+	for i, file := range schema.WriteFiles {
+		if file.Path == "" {
+			return microerror.MaskAnyf(invalidCloudConfigError, "file %d of write_files has no path", i)
+		}
+	}
+	for i, unit := range schema.CoreOS.Units {
+		if unit.Name == "" {
+			return microerror.MaskAnyf(invalidCloudConfigError, "unit %d of coreos.units has no name", i)
+		}
+	}
+
+	return nil
+}
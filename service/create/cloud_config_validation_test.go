@@ -0,0 +1,83 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCloudConfig(t *testing.T) {
+	tests := []struct {
+		desc         string
+		raw          string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "valid cloudconfig",
+			raw: `#cloud-config
+hostname: "master-0"
+write_files:
+- path: /opt/bin/decrypt-tls-assets
+  owner: root:root
+  permissions: 0700
+  content: |
+    #!/bin/bash
+coreos:
+  units:
+  - name: decrypt-tls-assets.service
+    enable: true
+    command: start
+`,
+		},
+		{
+			desc: "missing header",
+			raw: `hostname: "master-0"
+`,
+			errorMatcher: IsInvalidCloudConfig,
+		},
+		{
+			desc: "malformed YAML",
+			raw: `#cloud-config
+write_files:
+- path: /opt/bin/decrypt-tls-assets
+   owner: root:root
+`,
+			errorMatcher: IsInvalidCloudConfig,
+		},
+		{
+			desc: "write_files is not a list",
+			raw: `#cloud-config
+write_files: /opt/bin/decrypt-tls-assets
+`,
+			errorMatcher: IsInvalidCloudConfig,
+		},
+		{
+			desc: "file without path",
+			raw: `#cloud-config
+write_files:
+- owner: root:root
+  content: foo
+`,
+			errorMatcher: IsInvalidCloudConfig,
+		},
+		{
+			desc: "unit without name",
+			raw: `#cloud-config
+coreos:
+  units:
+  - command: start
+`,
+			errorMatcher: IsInvalidCloudConfig,
+		},
+	}
+
+	for _, tc := range tests {
+		err := validateCloudConfig([]byte(tc.raw))
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+	}
+}
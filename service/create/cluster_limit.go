@@ -0,0 +1,116 @@
+package create
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// clusterLimitExceededReason is the reason of the events of clusters rejected
+// because of the cluster limit.
+const clusterLimitExceededReason = "ClusterLimitExceeded"
+
+// clusterLimiter admits clusters up to a maximum number of clusters, so a
+// shared account is protected from runaway cluster creation. Clusters are
+// admitted in the order they are added, and free their place once deleted.
+type clusterLimiter struct {
+	// max is the maximum number of admitted clusters. All clusters are
+	// admitted when it is zero.
+	max int
+
+	mutex    sync.Mutex
+	admitted map[string]bool
+}
+
+func newClusterLimiter(max int) *clusterLimiter {
+	return &clusterLimiter{
+		max:      max,
+		admitted: make(map[string]bool),
+	}
+}
+
+// admit admits the cluster with the given name, unless it would exceed the
+// limit. Admitted clusters are admitted again, e.g. on resyncs.
+func (l *clusterLimiter) admit(name string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.admitted[name] {
+		return nil
+	}
+	if l.max > 0 && len(l.admitted) >= l.max {
+		return microerror.MaskAnyf(clusterLimitExceededError, "cluster '%s' exceeds the limit of %d clusters", name, l.max)
+	}
+
+	l.admitted[name] = true
+
+	return nil
+}
+
+// release frees the place of the cluster with the given name.
+func (l *clusterLimiter) release(name string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.admitted, name)
+}
+
+// admitCluster admits the given cluster for provisioning. Rejected clusters
+// get a warning event, so the rejection shows up next to the cluster.
+func (s *Service) admitCluster(cluster awstpr.CustomObject) error {
+	err := s.clusterLimiter.admit(cluster.Name)
+	if IsClusterLimitExceeded(err) {
+		if eventErr := s.createWarningEvent(cluster, clusterLimitExceededReason, err.Error()); eventErr != nil {
+			s.logger.Log("error", fmt.Sprintf("could not create event for cluster '%s': %s", cluster.Name, errgo.Details(eventErr)))
+		}
+		return microerror.MaskAny(err)
+	} else if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// createWarningEvent creates a warning event about the given cluster.
+func (s *Service) createWarningEvent(cluster awstpr.CustomObject, reason, message string) error {
+	namespace := cluster.Namespace
+	if namespace == "" {
+		namespace = v1.NamespaceDefault
+	}
+
+	now := unversioned.Now()
+	event := &v1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", cluster.Name),
+			Namespace:    namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion:      awstpr.VersionV1,
+			Kind:            awstpr.Kind,
+			Name:            cluster.Name,
+			Namespace:       namespace,
+			ResourceVersion: cluster.ResourceVersion,
+			UID:             cluster.UID,
+		},
+		Reason:  reason,
+		Message: message,
+		Source: v1.EventSource{
+			Component: "aws-operator",
+		},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           v1.EventTypeWarning,
+	}
+
+	if _, err := s.k8sClient.Core().Events(namespace).Create(event); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package create
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+)
+
+// fakeEventsAPI records the events created through a Kubernetes API.
+type fakeEventsAPI struct {
+	mutex  sync.Mutex
+	events []v1.Event
+}
+
+func (f *fakeEventsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if r.Method != "POST" || r.URL.Path != "/api/v1/namespaces/default/events" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var event v1.Event
+	json.NewDecoder(r.Body).Decode(&event)
+	f.events = append(f.events, event)
+
+	event.Kind = "Event"
+	event.APIVersion = "v1"
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(event)
+}
+
+func TestAdmitCluster(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc        string
+		maxClusters int
+		admitted    []string
+		cluster     string
+		resRejected bool
+	}{
+		{
+			desc:        "unlimited",
+			maxClusters: 0,
+			admitted:    []string{"foo", "bar"},
+			cluster:     "baz",
+		},
+		{
+			desc:        "below the limit",
+			maxClusters: 3,
+			admitted:    []string{"foo", "bar"},
+			cluster:     "baz",
+		},
+		{
+			desc:        "at the limit",
+			maxClusters: 2,
+			admitted:    []string{"foo", "bar"},
+			cluster:     "baz",
+			resRejected: true,
+		},
+		{
+			desc:        "admitted cluster at the limit",
+			maxClusters: 2,
+			admitted:    []string{"foo", "bar"},
+			cluster:     "foo",
+		},
+	}
+
+	for _, tc := range tests {
+		api := &fakeEventsAPI{}
+		server := httptest.NewServer(api)
+
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+		s := &Service{
+			clusterLimiter: newClusterLimiter(tc.maxClusters),
+			k8sClient:      k8sClient,
+			logger:         logger,
+		}
+
+		for _, name := range tc.admitted {
+			err := s.clusterLimiter.admit(name)
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error admitting '%s'", tc.desc, name))
+		}
+
+		var cluster awstpr.CustomObject
+		cluster.Name = tc.cluster
+		err = s.admitCluster(cluster)
+
+		if !tc.resRejected {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+			assert.Empty(t, api.events, fmt.Sprintf("[%s] Unexpected events", tc.desc))
+			server.Close()
+			continue
+		}
+
+		assert.True(t, IsClusterLimitExceeded(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		assert.Len(t, api.events, 1, fmt.Sprintf("[%s] Expected one event", tc.desc))
+		event := api.events[0]
+		assert.Equal(t, v1.EventTypeWarning, event.Type, fmt.Sprintf("[%s] Wrong event type", tc.desc))
+		assert.Equal(t, clusterLimitExceededReason, event.Reason, fmt.Sprintf("[%s] Wrong event reason", tc.desc))
+		assert.Equal(t, tc.cluster, event.InvolvedObject.Name, fmt.Sprintf("[%s] Wrong event object", tc.desc))
+
+		// A deleted cluster frees its place.
+		s.clusterLimiter.release(tc.admitted[0])
+		err = s.admitCluster(cluster)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error after a deletion", tc.desc))
+
+		server.Close()
+	}
+}
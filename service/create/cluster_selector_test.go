@@ -0,0 +1,126 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
+	"k8s.io/client-go/pkg/watch"
+)
+
+func newLabeledCluster(name string, clusterLabels map[string]string) *awstpr.CustomObject {
+	return &awstpr.CustomObject{
+		ObjectMeta: v1.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels,
+		},
+	}
+}
+
+func TestFilterClusters(t *testing.T) {
+	clusters := []*awstpr.CustomObject{
+		newLabeledCluster("foo", map[string]string{"operator": "aws", "env": "prod"}),
+		newLabeledCluster("bar", map[string]string{"operator": "aws", "env": "dev"}),
+		newLabeledCluster("baz", map[string]string{"operator": "kvm"}),
+		newLabeledCluster("qux", nil),
+	}
+
+	tests := []struct {
+		desc     string
+		selector string
+		res      []string
+	}{
+		{
+			desc:     "empty selector matches all clusters",
+			selector: "",
+			res:      []string{"foo", "bar", "baz", "qux"},
+		},
+		{
+			desc:     "equality selector ignores non-matching clusters",
+			selector: "operator=aws",
+			res:      []string{"foo", "bar"},
+		},
+		{
+			desc:     "multiple requirements must all match",
+			selector: "operator=aws,env=prod",
+			res:      []string{"foo"},
+		},
+		{
+			desc:     "set based selector",
+			selector: "operator in (aws,kvm),env!=prod",
+			res:      []string{"bar", "baz"},
+		},
+		{
+			desc:     "no cluster matches",
+			selector: "operator=azure",
+			res:      nil,
+		},
+	}
+
+	for _, tc := range tests {
+		selector, err := labels.Parse(tc.selector)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error parsing the selector", tc.desc))
+
+		var res []string
+		for _, cluster := range filterClusters(clusters, selector) {
+			res = append(res, cluster.Name)
+		}
+
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+	}
+}
+
+func TestClusterEventFilter(t *testing.T) {
+	selector, err := labels.Parse("operator=aws")
+	assert.Nil(t, err, "Unexpected error parsing the selector")
+
+	filter := clusterEventFilter(selector)
+
+	tests := []struct {
+		desc  string
+		event watch.Event
+		keep  bool
+	}{
+		{
+			desc: "matching cluster is kept",
+			event: watch.Event{
+				Type:   watch.Added,
+				Object: newLabeledCluster("foo", map[string]string{"operator": "aws"}),
+			},
+			keep: true,
+		},
+		{
+			desc: "non-matching cluster is ignored",
+			event: watch.Event{
+				Type:   watch.Added,
+				Object: newLabeledCluster("bar", map[string]string{"operator": "kvm"}),
+			},
+			keep: false,
+		},
+		{
+			desc: "deletion of non-matching cluster is ignored",
+			event: watch.Event{
+				Type:   watch.Deleted,
+				Object: newLabeledCluster("baz", nil),
+			},
+			keep: false,
+		},
+		{
+			desc: "non-cluster events are passed through",
+			event: watch.Event{
+				Type:   watch.Error,
+				Object: &unversioned.Status{},
+			},
+			keep: true,
+		},
+	}
+
+	for _, tc := range tests {
+		_, keep := filter(tc.event)
+		assert.Equal(t, tc.keep, keep, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+	}
+}
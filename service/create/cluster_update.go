@@ -0,0 +1,269 @@
+package create
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// validateClusterUpdate checks that an update of a cluster keeps its ID. The
+// AWS resources of a cluster are named and tagged after its ID, so changing it
+// would orphan all of them, including the running instances.
+func validateClusterUpdate(oldCluster, newCluster awstpr.CustomObject) error {
+	oldID := oldCluster.Spec.Cluster.Cluster.ID
+	newID := newCluster.Spec.Cluster.Cluster.ID
+	if oldID != newID {
+		return microerror.MaskAnyf(immutableClusterIDError, "cluster ID cannot be changed from '%s' to '%s'", oldID, newID)
+	}
+
+	return nil
+}
+
+// clusterUpdate is the difference between two versions of a cluster.
+type clusterUpdate struct {
+	oldWorkers int
+	newWorkers int
+	// unsupported describes the changes which cannot be applied to running
+	// clusters.
+	unsupported []string
+}
+
+// diffClusterUpdate compares two versions of a cluster. Only the number of
+// workers can be changed, any other change, including the settings of
+// existing workers, is reported as unsupported.
+func diffClusterUpdate(oldCluster, newCluster awstpr.CustomObject) clusterUpdate {
+	oldSpec := oldCluster.Spec
+	newSpec := newCluster.Spec
+
+	update := clusterUpdate{
+		oldWorkers: len(oldSpec.AWS.Workers),
+		newWorkers: len(newSpec.AWS.Workers),
+	}
+
+	if !reflect.DeepEqual(oldSpec.Cluster.Masters, newSpec.Cluster.Masters) || !reflect.DeepEqual(oldSpec.AWS.Masters, newSpec.AWS.Masters) {
+		update.unsupported = append(update.unsupported, "masters")
+	}
+
+	kept := update.oldWorkers
+	if update.newWorkers < kept {
+		kept = update.newWorkers
+	}
+	for i := 0; i < kept; i++ {
+		if !reflect.DeepEqual(oldSpec.AWS.Workers[i], newSpec.AWS.Workers[i]) || !reflect.DeepEqual(workerAt(oldSpec, i), workerAt(newSpec, i)) {
+			update.unsupported = append(update.unsupported, fmt.Sprintf("worker %d", i))
+		}
+	}
+
+	oldSpec.Cluster.Masters, oldSpec.Cluster.Workers = nil, nil
+	oldSpec.AWS.Masters, oldSpec.AWS.Workers = nil, nil
+	newSpec.Cluster.Masters, newSpec.Cluster.Workers = nil, nil
+	newSpec.AWS.Masters, newSpec.AWS.Workers = nil, nil
+	if !reflect.DeepEqual(oldSpec, newSpec) {
+		update.unsupported = append(update.unsupported, "cluster settings")
+	}
+
+	return update
+}
+
+// workerAt returns the cluster section of the worker with the given index, or
+// nil when the spec has no such worker.
+func workerAt(spec awstpr.Spec, i int) interface{} {
+	if i >= len(spec.Cluster.Workers) {
+		return nil
+	}
+
+	return spec.Cluster.Workers[i]
+}
+
+// surplusWorkerNames returns the names of the workers removed by scaling the
+// given cluster down from oldWorkers to newWorkers.
+func (s *Service) surplusWorkerNames(clusterName string, oldWorkers, newWorkers int) []string {
+	var names []string
+	for i := newWorkers; i < oldWorkers; i++ {
+		names = append(names, instanceName(instanceNameInput{
+			resourcePrefix: s.resourcePrefix,
+			clusterName:    clusterName,
+			prefix:         prefixWorker,
+			no:             i,
+		}))
+	}
+
+	return names
+}
+
+// updateCluster handles the updates of clusters. Changes of the cluster ID are
+// rejected, the cluster must be changed back to its former ID. Changes of the
+// number of workers are reconciled by launching the missing workers or
+// terminating the surplus ones. Other changes are logged, they cannot be
+// applied to running clusters yet.
+func (s *Service) updateCluster(oldObj, newObj interface{}) {
+	oldCluster := *oldObj.(*awstpr.CustomObject)
+	newCluster := *newObj.(*awstpr.CustomObject)
+
+	if err := validateClusterUpdate(oldCluster, newCluster); err != nil {
+		s.logger.Log("error", fmt.Sprintf("rejected update of cluster '%s', revert it to keep managing the cluster's resources: %s", newCluster.Name, errgo.Details(err)))
+		return
+	}
+
+	// Resyncs deliver updates without any change.
+	if reflect.DeepEqual(oldCluster.Spec, newCluster.Spec) {
+		return
+	}
+
+	update := diffClusterUpdate(oldCluster, newCluster)
+	for _, change := range update.unsupported {
+		s.logger.Log("error", fmt.Sprintf("cannot apply change of %s of cluster '%s' yet, recreate the cluster to apply it", change, newCluster.Name))
+	}
+
+	switch {
+	case update.newWorkers > update.oldWorkers:
+		s.logger.Log("info", fmt.Sprintf("scaling up workers of cluster '%s' from %d to %d", newCluster.Name, update.oldWorkers, update.newWorkers))
+		if err := s.addWorkers(newCluster); err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not scale up workers of cluster '%s': %s", newCluster.Name, errgo.Details(err)))
+			return
+		}
+		s.logger.Log("info", fmt.Sprintf("scaled up workers of cluster '%s' to %d", newCluster.Name, update.newWorkers))
+	case update.newWorkers < update.oldWorkers:
+		s.logger.Log("info", fmt.Sprintf("scaling down workers of cluster '%s' from %d to %d", newCluster.Name, update.oldWorkers, update.newWorkers))
+		if err := s.removeWorkers(newCluster, update.oldWorkers, update.newWorkers); err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not scale down workers of cluster '%s': %s", newCluster.Name, errgo.Details(err)))
+			return
+		}
+		s.logger.Log("info", fmt.Sprintf("scaled down workers of cluster '%s' to %d", newCluster.Name, update.newWorkers))
+	}
+}
+
+// addWorkers launches the missing workers of the given cluster and registers
+// them with the Ingress load balancer. Unlike the creation of the cluster, it
+// expects all the other resources of the cluster to exist.
+func (s *Service) addWorkers(cluster awstpr.CustomObject) error {
+	region, err := clusterRegion(cluster.Spec, s.defaultRegion)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	cluster.Spec.AWS.Region = region
+	awsConfig := s.awsConfig
+	awsConfig.Region = region
+	clients := awsutil.NewClients(awsConfig)
+	s.awsRateLimiter.Limit(clients)
+
+	if err := awsConfig.SetAccountID(clients.IAM); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	certs, err := s.certWatcher.SearchCerts(cluster.Spec.Cluster.Cluster.ID)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	kmsKey, err := awsresources.NewKMSKeyFromExisting(s.resourceName(cluster.Name), s.awsEntity(clients))
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	tlsAssets, err := s.encodeClusterTLSAssets(certs, nil, clients.KMS, kmsKey.Arn())
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	securityGroupEntity := s.awsEntity(clients)
+	securityGroupEntity.ClusterID = scopedClusterID(cluster)
+	workersSecurityGroup := &awsresources.SecurityGroup{
+		Description: securityGroupName(cluster.Name, prefixWorker),
+		GroupName:   securityGroupName(cluster.Name, prefixWorker),
+		AWSEntity:   securityGroupEntity,
+	}
+	publicSubnet := &awsresources.Subnet{
+		Name: subnetName(cluster, suffixPublic),
+		// Dependencies.
+		Logger:    s.logger,
+		AWSEntity: s.clusterAWSEntity(clients, cluster),
+	}
+	policy := &awsresources.Policy{
+		ClusterID:  cluster.Spec.Cluster.Cluster.ID,
+		NamePrefix: s.resourcePrefix,
+	}
+
+	_, workerIDs, err := s.runMachines(runMachinesInput{
+		clients:   clients,
+		cluster:   cluster,
+		tlsAssets: tlsAssets,
+		bucket: &awsresources.Bucket{
+			Name:      s.bucketName(awsConfig.AccountID(), cluster),
+			AWSEntity: s.awsEntity(clients),
+		},
+		securityGroup:       workersSecurityGroup,
+		subnet:              publicSubnet,
+		clusterName:         cluster.Name,
+		keyPairName:         cluster.Name,
+		instanceProfileName: policy.GetName(),
+		prefix:              prefixWorker,
+	})
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(workerIDs) == 0 {
+		return nil
+	}
+
+	// runMachines waits for the workers it launches to run, so they can be
+	// registered right away.
+	ingressLBName, err := s.loadBalancerName(cluster.Spec.Cluster.Kubernetes.IngressController.Domain, cluster)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	ingressLB := &awsresources.ELB{
+		Name:   ingressLBName,
+		Client: clients.ELB,
+	}
+	if err := ingressLB.RegisterInstances(workerIDs); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// removeWorkers terminates the workers of the given cluster beyond newWorkers,
+// and deletes their cloudconfigs.
+func (s *Service) removeWorkers(cluster awstpr.CustomObject, oldWorkers, newWorkers int) error {
+	region, err := clusterRegion(cluster.Spec, s.defaultRegion)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	cluster.Spec.AWS.Region = region
+	awsConfig := s.awsConfig
+	awsConfig.Region = region
+	clients := awsutil.NewClients(awsConfig)
+	s.awsRateLimiter.Limit(clients)
+
+	if err := awsConfig.SetAccountID(clients.IAM); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if err := s.deleteMachines(deleteMachinesInput{
+		clients:     clients,
+		clusterName: cluster.Name,
+		prefix:      prefixWorker,
+		names:       s.surplusWorkerNames(cluster.Name, oldWorkers, newWorkers),
+	}); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	var objectNames []string
+	for i := newWorkers; i < oldWorkers; i++ {
+		objectNames = append(objectNames, s.bucketObjectName(cluster, machineID(prefixWorker, i)))
+	}
+	bucket := &awsresources.Bucket{
+		AWSEntity: s.awsEntity(clients),
+		Name:      s.bucketName(awsConfig.AccountID(), cluster),
+	}
+	if err := s.deleteBucketObjects(clients, bucket, objectNames); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
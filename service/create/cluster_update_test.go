@@ -0,0 +1,169 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	awsspec "github.com/giantswarm/awstpr/aws"
+	"github.com/giantswarm/clustertpr"
+	"github.com/giantswarm/clustertpr/cluster"
+	"github.com/giantswarm/clustertpr/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateClusterUpdate(t *testing.T) {
+	newCluster := func(id string, workers int) awstpr.CustomObject {
+		cluster := awstpr.CustomObject{
+			Spec: awstpr.Spec{
+				Cluster: clustertpr.Cluster{
+					Cluster: cluster.Cluster{
+						ID: id,
+					},
+				},
+			},
+		}
+		cluster.Spec.AWS.Workers = make([]awsspec.Node, workers)
+		return cluster
+	}
+
+	tests := []struct {
+		desc         string
+		oldCluster   awstpr.CustomObject
+		newCluster   awstpr.CustomObject
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:       "unchanged cluster",
+			oldCluster: newCluster("foo", 2),
+			newCluster: newCluster("foo", 2),
+		},
+		{
+			desc:       "changes keeping the ID are accepted",
+			oldCluster: newCluster("foo", 2),
+			newCluster: newCluster("foo", 3),
+		},
+		{
+			desc:         "ID change is rejected",
+			oldCluster:   newCluster("foo", 2),
+			newCluster:   newCluster("bar", 2),
+			errorMatcher: IsImmutableClusterID,
+		},
+		{
+			desc:         "removing the ID is rejected",
+			oldCluster:   newCluster("foo", 2),
+			newCluster:   newCluster("", 2),
+			errorMatcher: IsImmutableClusterID,
+		},
+	}
+
+	for _, tc := range tests {
+		err := validateClusterUpdate(tc.oldCluster, tc.newCluster)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+	}
+}
+
+func TestDiffClusterUpdate(t *testing.T) {
+	newCluster := func(instanceTypes ...string) awstpr.CustomObject {
+		var cluster awstpr.CustomObject
+		cluster.Spec.Cluster.Cluster.ID = "foo"
+		cluster.Spec.Cluster.Masters = []node.Node{{Hostname: "master"}}
+		cluster.Spec.AWS.Masters = []awsspec.Node{{InstanceType: "m3.large"}}
+		for _, instanceType := range instanceTypes {
+			cluster.Spec.Cluster.Workers = append(cluster.Spec.Cluster.Workers, node.Node{})
+			cluster.Spec.AWS.Workers = append(cluster.Spec.AWS.Workers, awsspec.Node{InstanceType: instanceType})
+		}
+		return cluster
+	}
+
+	tests := []struct {
+		desc           string
+		oldCluster     awstpr.CustomObject
+		newCluster     func() awstpr.CustomObject
+		resOldWorkers  int
+		resNewWorkers  int
+		resUnsupported []string
+	}{
+		{
+			desc:       "unchanged cluster",
+			oldCluster: newCluster("m3.large", "m3.large"),
+			newCluster: func() awstpr.CustomObject {
+				return newCluster("m3.large", "m3.large")
+			},
+			resOldWorkers: 2,
+			resNewWorkers: 2,
+		},
+		{
+			desc:       "added worker",
+			oldCluster: newCluster("m3.large", "m3.large"),
+			newCluster: func() awstpr.CustomObject {
+				return newCluster("m3.large", "m3.large", "m3.xlarge")
+			},
+			resOldWorkers: 2,
+			resNewWorkers: 3,
+		},
+		{
+			desc:       "removed worker",
+			oldCluster: newCluster("m3.large", "m3.large"),
+			newCluster: func() awstpr.CustomObject {
+				return newCluster("m3.large")
+			},
+			resOldWorkers: 2,
+			resNewWorkers: 1,
+		},
+		{
+			desc:       "changed existing worker",
+			oldCluster: newCluster("m3.large", "m3.large"),
+			newCluster: func() awstpr.CustomObject {
+				return newCluster("m3.large", "m3.xlarge")
+			},
+			resOldWorkers:  2,
+			resNewWorkers:  2,
+			resUnsupported: []string{"worker 1"},
+		},
+		{
+			desc:       "changed masters",
+			oldCluster: newCluster("m3.large"),
+			newCluster: func() awstpr.CustomObject {
+				cluster := newCluster("m3.large")
+				cluster.Spec.AWS.Masters[0].InstanceType = "m3.xlarge"
+				return cluster
+			},
+			resOldWorkers:  1,
+			resNewWorkers:  1,
+			resUnsupported: []string{"masters"},
+		},
+		{
+			desc:       "changed cluster settings while adding a worker",
+			oldCluster: newCluster("m3.large"),
+			newCluster: func() awstpr.CustomObject {
+				cluster := newCluster("m3.large", "m3.large")
+				cluster.Spec.AWS.Region = "eu-west-1"
+				return cluster
+			},
+			resOldWorkers:  1,
+			resNewWorkers:  2,
+			resUnsupported: []string{"cluster settings"},
+		},
+	}
+
+	for _, tc := range tests {
+		update := diffClusterUpdate(tc.oldCluster, tc.newCluster())
+		assert.Equal(t, tc.resOldWorkers, update.oldWorkers, fmt.Sprintf("[%s] Wrong old number of workers", tc.desc))
+		assert.Equal(t, tc.resNewWorkers, update.newWorkers, fmt.Sprintf("[%s] Wrong new number of workers", tc.desc))
+		assert.Equal(t, tc.resUnsupported, update.unsupported, fmt.Sprintf("[%s] Wrong unsupported changes", tc.desc))
+	}
+}
+
+func TestSurplusWorkerNames(t *testing.T) {
+	s := &Service{}
+	assert.Equal(t, []string{"foo-worker-2", "foo-worker-3"}, s.surplusWorkerNames("foo", 4, 2), "Wrong surplus workers")
+	assert.Empty(t, s.surplusWorkerNames("foo", 2, 2), "Unexpected surplus workers")
+
+	s = &Service{resourcePrefix: "acme-"}
+	assert.Equal(t, []string{"acme-foo-worker-2"}, s.surplusWorkerNames("foo", 3, 2), "Wrong prefixed surplus workers")
+}
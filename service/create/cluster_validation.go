@@ -0,0 +1,72 @@
+package create
+
+import (
+	"net"
+	"strings"
+
+	"github.com/giantswarm/awstpr"
+	awsinfo "github.com/giantswarm/awstpr/aws"
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// validateCluster checks the spec of the given cluster before any of its
+// resources is created, so invalid specs don't leave half created clusters
+// behind. The region of the spec must be resolved already.
+func validateCluster(cluster awstpr.CustomObject) error {
+	spec := cluster.Spec
+
+	if err := validateMachines(prefixMaster, len(spec.Cluster.Masters), spec.AWS.Masters); err != nil {
+		return microerror.MaskAny(err)
+	}
+	if err := validateMachines(prefixWorker, len(spec.Cluster.Workers), spec.AWS.Workers); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if spec.AWS.Region == "" {
+		return microerror.MaskAnyf(missingRegionError, "the cluster spec doesn't define a region")
+	}
+	if spec.AWS.AZ == "" {
+		return microerror.MaskAnyf(invalidAvailabilityZoneError, "the cluster spec doesn't define an availability zone")
+	}
+	// Availability zones are named after their region, followed by a letter,
+	// e.g. eu-central-1a.
+	if !strings.HasPrefix(spec.AWS.AZ, spec.AWS.Region) || len(spec.AWS.AZ) != len(spec.AWS.Region)+1 {
+		return microerror.MaskAnyf(invalidAvailabilityZoneError, "availability zone '%s' is not in region '%s'", spec.AWS.AZ, spec.AWS.Region)
+	}
+
+	if err := validateSharedVPC(cluster); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	_, vpcNet, err := net.ParseCIDR(spec.AWS.VPC.CIDR)
+	if err != nil {
+		return microerror.MaskAnyf(invalidCIDRError, "VPC CIDR '%s' is invalid", spec.AWS.VPC.CIDR)
+	}
+	subnetIP, subnetNet, err := net.ParseCIDR(spec.AWS.VPC.PublicSubnetCIDR)
+	if err != nil {
+		return microerror.MaskAnyf(invalidCIDRError, "public subnet CIDR '%s' is invalid", spec.AWS.VPC.PublicSubnetCIDR)
+	}
+	vpcOnes, _ := vpcNet.Mask.Size()
+	subnetOnes, _ := subnetNet.Mask.Size()
+	if !vpcNet.Contains(subnetIP) || subnetOnes < vpcOnes {
+		return microerror.MaskAnyf(invalidCIDRError, "public subnet CIDR '%s' is not within VPC CIDR '%s'", spec.AWS.VPC.PublicSubnetCIDR, spec.AWS.VPC.CIDR)
+	}
+
+	return nil
+}
+
+// validateMachines checks that every machine of the cluster section has its
+// counterpart with an image in the AWS section of the spec.
+func validateMachines(prefix string, count int, awsMachines []awsinfo.Node) error {
+	if count != len(awsMachines) {
+		return microerror.MaskAnyf(mismatchedMachinesError, "mismatched number of %s machines in the 'spec' and 'aws' sections: %d != %d", prefix, count, len(awsMachines))
+	}
+
+	for i, machine := range awsMachines {
+		if machine.ImageID == "" {
+			return microerror.MaskAnyf(missingImageIDError, "%s machine %d has no image ID", prefix, i)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	awsinfo "github.com/giantswarm/awstpr/aws"
+	"github.com/giantswarm/clustertpr/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCluster(t *testing.T) {
+	validCluster := func() awstpr.CustomObject {
+		var cluster awstpr.CustomObject
+		cluster.Spec.Cluster.Masters = []node.Node{{}}
+		cluster.Spec.Cluster.Workers = []node.Node{{}, {}}
+		cluster.Spec.AWS.Masters = []awsinfo.Node{{ImageID: "ami-1"}}
+		cluster.Spec.AWS.Workers = []awsinfo.Node{{ImageID: "ami-1"}, {ImageID: "ami-2"}}
+		cluster.Spec.AWS.Region = "eu-central-1"
+		cluster.Spec.AWS.AZ = "eu-central-1a"
+		cluster.Spec.AWS.VPC.CIDR = "10.0.0.0/16"
+		cluster.Spec.AWS.VPC.PublicSubnetCIDR = "10.0.1.0/24"
+		return cluster
+	}
+
+	tests := []struct {
+		desc         string
+		modify       func(cluster *awstpr.CustomObject)
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:   "valid cluster",
+			modify: func(cluster *awstpr.CustomObject) {},
+		},
+		{
+			desc: "missing AWS master",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.Masters = nil
+			},
+			errorMatcher: IsMismatchedMachines,
+		},
+		{
+			desc: "surplus AWS worker",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.Workers = append(cluster.Spec.AWS.Workers, awsinfo.Node{ImageID: "ami-1"})
+			},
+			errorMatcher: IsMismatchedMachines,
+		},
+		{
+			desc: "worker without image",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.Workers[1].ImageID = ""
+			},
+			errorMatcher: IsMissingImageID,
+		},
+		{
+			desc: "missing region",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.Region = ""
+			},
+			errorMatcher: IsMissingRegion,
+		},
+		{
+			desc: "missing availability zone",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.AZ = ""
+			},
+			errorMatcher: IsInvalidAvailabilityZone,
+		},
+		{
+			desc: "availability zone of another region",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.AZ = "eu-west-1a"
+			},
+			errorMatcher: IsInvalidAvailabilityZone,
+		},
+		{
+			desc: "shared VPC",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Annotations = map[string]string{sharedVPCAnnotationKey: "vpc-abc123"}
+			},
+		},
+		{
+			desc: "shared VPC which isn't a VPC ID",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Annotations = map[string]string{sharedVPCAnnotationKey: "shared"}
+			},
+			errorMatcher: IsInvalidSharedVPC,
+		},
+		{
+			desc: "malformed VPC CIDR",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.VPC.CIDR = "10.0.0.0"
+			},
+			errorMatcher: IsInvalidCIDR,
+		},
+		{
+			desc: "missing public subnet CIDR",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.VPC.PublicSubnetCIDR = ""
+			},
+			errorMatcher: IsInvalidCIDR,
+		},
+		{
+			desc: "public subnet outside of the VPC",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.VPC.PublicSubnetCIDR = "10.1.1.0/24"
+			},
+			errorMatcher: IsInvalidCIDR,
+		},
+		{
+			desc: "public subnet larger than the VPC",
+			modify: func(cluster *awstpr.CustomObject) {
+				cluster.Spec.AWS.VPC.PublicSubnetCIDR = "10.0.0.0/8"
+			},
+			errorMatcher: IsInvalidCIDR,
+		},
+	}
+
+	for _, tc := range tests {
+		cluster := validCluster()
+		tc.modify(&cluster)
+
+		err := validateCluster(cluster)
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+			continue
+		}
+		assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+	}
+}
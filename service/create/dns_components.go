@@ -0,0 +1,82 @@
+package create
+
+import (
+	"github.com/giantswarm/awstpr"
+)
+
+const (
+	// DNSComponentAPI is the component of the records of the API load
+	// balancers, living in the hosted zone of the API domain.
+	DNSComponentAPI = "api"
+	// DNSComponentEtcd is the component of the record of the etcd load
+	// balancer, living in the hosted zone of the etcd domain.
+	DNSComponentEtcd = "etcd"
+	// DNSComponentIngress is the component of the record of the ingress load
+	// balancer, living in the hosted zone of the ingress domain.
+	DNSComponentIngress = "ingress"
+)
+
+// DNSComponents are all the components of a cluster DNS records can be created
+// for.
+var DNSComponents = []string{
+	DNSComponentAPI,
+	DNSComponentEtcd,
+	DNSComponentIngress,
+}
+
+// dnsRecord is a DNS record of a cluster component, pointing to the load
+// balancer created for its domain.
+type dnsRecord struct {
+	Component string
+	Domain    string
+}
+
+// componentDomain returns the domain of the given component of the cluster.
+// The hosted zone of the component is the one of this domain.
+func componentDomain(cluster awstpr.CustomObject, component string) string {
+	switch component {
+	case DNSComponentAPI:
+		return cluster.Spec.Cluster.Kubernetes.API.Domain
+	case DNSComponentEtcd:
+		return cluster.Spec.Cluster.Etcd.Domain
+	case DNSComponentIngress:
+		return cluster.Spec.Cluster.Kubernetes.IngressController.Domain
+	}
+
+	return ""
+}
+
+// dnsRecords returns the DNS records of the enabled components of the cluster.
+// The API component has a record per API load balancer.
+func (s *Service) dnsRecords(cluster awstpr.CustomObject) []dnsRecord {
+	var records []dnsRecord
+
+	for _, component := range s.dnsComponents {
+		if component == DNSComponentAPI {
+			for _, apiLoadBalancer := range s.apiLoadBalancers(cluster) {
+				records = append(records, dnsRecord{
+					Component: component,
+					Domain:    apiLoadBalancer.Domain,
+				})
+			}
+			continue
+		}
+
+		records = append(records, dnsRecord{
+			Component: component,
+			Domain:    componentDomain(cluster, component),
+		})
+	}
+
+	return records
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
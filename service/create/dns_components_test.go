@@ -0,0 +1,83 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/clustertpr"
+	"github.com/giantswarm/clustertpr/etcd"
+	"github.com/giantswarm/clustertpr/kubernetes"
+	"github.com/giantswarm/clustertpr/kubernetes/api"
+	"github.com/giantswarm/clustertpr/kubernetes/ingress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSRecords(t *testing.T) {
+	cluster := awstpr.CustomObject{
+		Spec: awstpr.Spec{
+			Cluster: clustertpr.Cluster{
+				Etcd: etcd.Etcd{
+					Domain: "etcd.foo.example.com",
+				},
+				Kubernetes: kubernetes.Kubernetes{
+					API: api.API{
+						Domain: "api.foo.example.com",
+					},
+					IngressController: ingress.IngressController{
+						Domain: "ingress.foo.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc                    string
+		components              []string
+		internalAPILoadBalancer bool
+		res                     []dnsRecord
+	}{
+		{
+			desc:       "all components",
+			components: DNSComponents,
+			res: []dnsRecord{
+				{Component: DNSComponentAPI, Domain: "api.foo.example.com"},
+				{Component: DNSComponentEtcd, Domain: "etcd.foo.example.com"},
+				{Component: DNSComponentIngress, Domain: "ingress.foo.example.com"},
+			},
+		},
+		{
+			desc:                    "API component with an internal load balancer",
+			components:              []string{DNSComponentAPI},
+			internalAPILoadBalancer: true,
+			res: []dnsRecord{
+				{Component: DNSComponentAPI, Domain: "api.foo.example.com"},
+				{Component: DNSComponentAPI, Domain: "internal-api.foo.example.com"},
+			},
+		},
+		{
+			desc:       "etcd records managed elsewhere",
+			components: []string{DNSComponentAPI, DNSComponentIngress},
+			res: []dnsRecord{
+				{Component: DNSComponentAPI, Domain: "api.foo.example.com"},
+				{Component: DNSComponentIngress, Domain: "ingress.foo.example.com"},
+			},
+		},
+		{
+			desc:       "no components",
+			components: nil,
+			res:        nil,
+		},
+	}
+
+	for _, tc := range tests {
+		s := &Service{
+			dnsComponents:           tc.components,
+			internalAPILoadBalancer: tc.internalAPILoadBalancer,
+		}
+
+		res := s.dnsRecords(cluster)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+	}
+}
@@ -0,0 +1,69 @@
+package create
+
+import (
+	"sync"
+
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// route53RequestsPerSecond is the number of requests per second Route53 allows
+// per account. Requests beyond it are throttled.
+const route53RequestsPerSecond = 5
+
+// waiter paces operations, e.g. *awsutil.RateLimiter.
+type waiter interface {
+	// Wait blocks until the caller is allowed to perform an operation.
+	Wait()
+}
+
+// dnsExecutor runs DNS operations concurrently, while pacing them within the
+// Route53 request limit.
+type dnsExecutor struct {
+	// concurrency is the maximum number of operations running at once.
+	concurrency int
+	// limiter paces the start of every operation. It is shared by all clusters,
+	// since the Route53 limit applies to the whole account. Operations are not
+	// paced when it is nil.
+	limiter waiter
+}
+
+// run runs all the operations and returns the first error. An operation
+// failing does not stop the others.
+func (e dnsExecutor) run(operations []func() error) error {
+	concurrency := e.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	queue := make(chan func() error)
+	errs := make(chan error, len(operations))
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(operations); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for operation := range queue {
+				if e.limiter != nil {
+					e.limiter.Wait()
+				}
+				if err := operation(); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for _, operation := range operations {
+		queue <- operation
+	}
+	close(queue)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
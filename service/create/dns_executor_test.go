@@ -0,0 +1,136 @@
+package create
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/juju/errgo"
+	"github.com/stretchr/testify/assert"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+)
+
+type noopWaiter struct{}
+
+func (noopWaiter) Wait() {}
+
+func TestDNSExecutorPacing(t *testing.T) {
+	// A high rate keeps the test short, the pacing is the same as for the
+	// Route53 limit.
+	rate := 100.0
+	burst := rate
+	executor := dnsExecutor{
+		concurrency: 10,
+		limiter:     awsutil.NewRateLimiter(rate),
+	}
+
+	var mutex sync.Mutex
+	var starts []time.Time
+	var operations []func() error
+	for i := 0; i < 150; i++ {
+		operations = append(operations, func() error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			starts = append(starts, time.Now())
+			return nil
+		})
+	}
+
+	begin := time.Now()
+	err := executor.run(operations)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Len(t, starts, len(operations), "Not all operations ran")
+
+	// The starts are recorded under the mutex, so they are in order.
+	for i, start := range starts {
+		// At most burst operations start at once, then rate per second.
+		allowed := burst + start.Sub(begin).Seconds()*rate
+		// Some slack for the scheduling of the goroutines.
+		assert.True(t, float64(i+1) <= allowed+1, fmt.Sprintf("Operation %d started after %s, exceeding the rate limit", i+1, start.Sub(begin)))
+	}
+}
+
+func TestDNSExecutorConcurrency(t *testing.T) {
+	tests := []struct {
+		desc        string
+		concurrency int
+		res         int
+	}{
+		{
+			desc:        "serial",
+			concurrency: 1,
+			res:         1,
+		},
+		{
+			desc:        "concurrent",
+			concurrency: 3,
+			res:         3,
+		},
+		{
+			desc:        "zero concurrency runs serially",
+			concurrency: 0,
+			res:         1,
+		},
+	}
+
+	for _, tc := range tests {
+		executor := dnsExecutor{
+			concurrency: tc.concurrency,
+			limiter:     noopWaiter{},
+		}
+
+		var mutex sync.Mutex
+		var running, maxRunning int
+		var operations []func() error
+		for i := 0; i < 9; i++ {
+			operations = append(operations, func() error {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return nil
+			})
+		}
+
+		err := executor.run(operations)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, maxRunning, fmt.Sprintf("[%s] Unexpected number of concurrent operations", tc.desc))
+	}
+}
+
+func TestDNSExecutorErrors(t *testing.T) {
+	executor := dnsExecutor{
+		concurrency: 2,
+		limiter:     noopWaiter{},
+	}
+
+	failure := errgo.New("throttled")
+	var mutex sync.Mutex
+	var ran int
+	operation := func(err error) func() error {
+		return func() error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			ran++
+			return err
+		}
+	}
+
+	err := executor.run([]func() error{
+		operation(nil),
+		operation(failure),
+		operation(nil),
+	})
+	assert.Equal(t, failure, errgo.Cause(err), "Expected the failure to be returned")
+	assert.Equal(t, 3, ran, "A failing operation must not stop the others")
+}
@@ -5,10 +5,12 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go/service/route53"
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
 	"github.com/giantswarm/aws-operator/resources"
 	awsresources "github.com/giantswarm/aws-operator/resources/aws"
 	"github.com/giantswarm/awstpr"
 	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
 )
 
 type hostedZoneInput struct {
@@ -50,9 +52,25 @@ func (s *Service) createHostedZone(input hostedZoneInput) (*awsresources.HostedZ
 		s.logger.Log("debug", fmt.Sprintf("hosted zone '%s' already exists, reusing", hz.Name))
 	}
 
+	if s.checkZoneDelegation && !input.Private {
+		s.verifyZoneDelegation(*hz)
+	}
+
 	return hz, nil
 }
 
+// verifyZoneDelegation logs an error when the records of a public hosted zone won't
+// resolve, because its parent zone doesn't delegate to it. The records are
+// created anyway, the delegation can be fixed afterwards.
+func (s *Service) verifyZoneDelegation(hz awsresources.HostedZone) {
+	err := hz.CheckDelegation()
+	if awsresources.IsZoneNotDelegated(err) {
+		s.logger.Log("error", fmt.Sprintf("hosted zone '%s' is not delegated, its records won't resolve: %s", hz.Name, errgo.Details(err)))
+	} else if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not verify the delegation of hosted zone '%s': %s", hz.Name, errgo.Details(err)))
+	}
+}
+
 func (s *Service) deleteRecordSet(input recordSetInput) error {
 	hzName, err := hostedZoneName(input.Domain)
 	if err != nil {
@@ -66,7 +84,6 @@ func (s *Service) deleteRecordSet(input recordSetInput) error {
 
 	rs := &awsresources.RecordSet{
 		Client:       input.Client,
-		Resource:     input.Resource,
 		Domain:       input.Domain,
 		HostedZoneID: hz.GetID(),
 	}
@@ -78,6 +95,34 @@ func (s *Service) deleteRecordSet(input recordSetInput) error {
 	return nil
 }
 
+// deleteRecordSets deletes the record sets of the cluster's DNS components.
+// All of them are attempted, the first error is returned. Record sets which
+// don't exist are skipped.
+func (s *Service) deleteRecordSets(cluster awstpr.CustomObject, clients awsutil.Clients) error {
+	var operations []func() error
+	for _, record := range s.dnsRecords(cluster) {
+		domain := record.Domain
+		operations = append(operations, func() error {
+			err := s.deleteRecordSet(recordSetInput{
+				Cluster: cluster,
+				Client:  clients.Route53,
+				Domain:  domain,
+			})
+			if awsresources.IsAlreadyDeleted(err) {
+				s.logger.Log("info", fmt.Sprintf("record set '%s' already deleted", domain))
+				return nil
+			} else if err != nil {
+				s.logger.Log("error", fmt.Sprintf("could not delete record set '%s': %s", domain, errgo.Details(err)))
+				return microerror.MaskAny(err)
+			}
+
+			return nil
+		})
+	}
+
+	return s.dnsExecutor.run(operations)
+}
+
 func (s *Service) createRecordSet(input recordSetInput) error {
 	// Create DNS records for LB.
 	apiRecordSet := &awsresources.RecordSet{
@@ -111,3 +156,21 @@ func hostedZoneName(domain string) (string, error) {
 
 	return strings.Join(tmp[2:], ""), nil
 }
+
+// vpcDHCPOptions returns the DHCP options set of the VPC of the cluster, which
+// makes the hosted zone of its API the search domain of its instances. It is
+// nil when DHCP options sets are disabled.
+func (s *Service) vpcDHCPOptions(cluster awstpr.CustomObject) (*awsresources.DHCPOptions, error) {
+	if !s.dhcpOptions {
+		return nil, nil
+	}
+
+	domainName, err := hostedZoneName(cluster.Spec.Cluster.Kubernetes.API.Domain)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return &awsresources.DHCPOptions{
+		DomainName: domainName,
+	}, nil
+}
@@ -4,6 +4,11 @@ import (
 	"fmt"
 	"testing"
 
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/clustertpr"
+	"github.com/giantswarm/clustertpr/kubernetes"
+	"github.com/giantswarm/clustertpr/kubernetes/api"
 	"github.com/juju/errgo"
 	"github.com/stretchr/testify/assert"
 )
@@ -50,3 +55,49 @@ func TestHostedZoneName(t *testing.T) {
 		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
 	}
 }
+
+func TestVPCDHCPOptions(t *testing.T) {
+	tests := []struct {
+		desc        string
+		dhcpOptions bool
+		domain      string
+		res         *awsresources.DHCPOptions
+		err         error
+	}{
+		{
+			desc:        "DHCP options search the hosted zone of the API",
+			dhcpOptions: true,
+			domain:      "api.foobar.example.customer.com",
+			res:         &awsresources.DHCPOptions{DomainName: "example.customer.com"},
+		},
+		{
+			desc:   "disabled DHCP options",
+			domain: "api.foobar.example.customer.com",
+		},
+		{
+			desc:        "malformed domain",
+			dhcpOptions: true,
+			domain:      "not a domain",
+			err:         malformedCloudConfigKeyError,
+		},
+	}
+
+	for _, tc := range tests {
+		cluster := awstpr.CustomObject{
+			Spec: awstpr.Spec{
+				Cluster: clustertpr.Cluster{
+					Kubernetes: kubernetes.Kubernetes{
+						API: api.API{
+							Domain: tc.domain,
+						},
+					},
+				},
+			},
+		}
+		s := &Service{dhcpOptions: tc.dhcpOptions}
+
+		res, err := s.vpcDHCPOptions(cluster)
+		assert.Equal(t, tc.err, errgo.Cause(err), fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] Wrong DHCP options", tc.desc))
+	}
+}
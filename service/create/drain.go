@@ -0,0 +1,123 @@
+package create
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	microerror "github.com/giantswarm/microkit/error"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+	policy "k8s.io/client-go/pkg/apis/policy/v1beta1"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+const (
+	// mirrorPodAnnotationKey marks static pods mirrored by the kubelet. They
+	// cannot be evicted through the API server.
+	mirrorPodAnnotationKey = "kubernetes.io/config.mirror"
+	// daemonSetCreatorKind is the creator kind of pods managed by a DaemonSet.
+	// The DaemonSet controller would reschedule them on the same node anyway.
+	daemonSetCreatorKind = `"kind":"DaemonSet"`
+)
+
+// drainNode cordons the Kubernetes node with the given name and evicts all the
+// pods running on it. It waits for the evicted pods to be gone until the
+// configured drain timeout elapses.
+func (s *Service) drainNode(nodeName string) error {
+	if err := s.cordonNode(nodeName); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	pods, err := s.drainablePodsOnNode(nodeName)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	for _, pod := range pods {
+		eviction := &policy.Eviction{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+		}
+		if err := s.k8sClient.Core().Pods(pod.Namespace).Evict(eviction); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	waitOperation := func() error {
+		pods, err := s.drainablePodsOnNode(nodeName)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		if len(pods) > 0 {
+			return microerror.MaskAnyf(nodeNotDrainedError, "%d pods still running on node '%s'", len(pods), nodeName)
+		}
+		return nil
+	}
+	waitNotify := awsresources.NewNotify(s.logger, fmt.Sprintf("draining node '%s'", nodeName))
+	if err := backoff.RetryNotify(waitOperation, newDrainBackoff(s.drainTimeout), waitNotify); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Service) cordonNode(nodeName string) error {
+	node, err := s.k8sClient.Core().Nodes().Get(nodeName)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if node.Spec.Unschedulable {
+		return nil
+	}
+
+	node.Spec.Unschedulable = true
+	if _, err := s.k8sClient.Core().Nodes().Update(node); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Service) drainablePodsOnNode(nodeName string) ([]v1.Pod, error) {
+	podList, err := s.k8sClient.Core().Pods(api.NamespaceAll).List(v1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeName),
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return drainablePods(podList.Items), nil
+}
+
+// drainablePods filters out the pods which must not be evicted when draining a
+// node, i.e. mirror pods and pods managed by a DaemonSet.
+func drainablePods(pods []v1.Pod) []v1.Pod {
+	var drainable []v1.Pod
+
+	for _, pod := range pods {
+		if _, ok := pod.Annotations[mirrorPodAnnotationKey]; ok {
+			continue
+		}
+		if strings.Contains(pod.Annotations[api.CreatedByAnnotation], daemonSetCreatorKind) {
+			continue
+		}
+
+		drainable = append(drainable, pod)
+	}
+
+	return drainable
+}
+
+func newDrainBackoff(timeout time.Duration) backoff.BackOff {
+	b := awsresources.NewCustomExponentialBackoff()
+	b.MaxElapsedTime = timeout
+	b.Reset()
+
+	return b
+}
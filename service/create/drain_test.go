@@ -0,0 +1,194 @@
+package create
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestDrainablePods(t *testing.T) {
+	tests := []struct {
+		desc string
+		pods []v1.Pod
+		res  []string
+	}{
+		{
+			desc: "no pods",
+			pods: nil,
+			res:  nil,
+		},
+		{
+			desc: "regular pods are drained",
+			pods: []v1.Pod{
+				{ObjectMeta: v1.ObjectMeta{Name: "foo"}},
+				{ObjectMeta: v1.ObjectMeta{Name: "bar"}},
+			},
+			res: []string{"foo", "bar"},
+		},
+		{
+			desc: "mirror pods are skipped",
+			pods: []v1.Pod{
+				{ObjectMeta: v1.ObjectMeta{Name: "foo"}},
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name: "kube-proxy",
+						Annotations: map[string]string{
+							mirrorPodAnnotationKey: "abc",
+						},
+					},
+				},
+			},
+			res: []string{"foo"},
+		},
+		{
+			desc: "daemon set pods are skipped",
+			pods: []v1.Pod{
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name: "calico-node",
+						Annotations: map[string]string{
+							api.CreatedByAnnotation: `{"kind":"SerializedReference","reference":{"kind":"DaemonSet","name":"calico-node"}}`,
+						},
+					},
+				},
+				{
+					ObjectMeta: v1.ObjectMeta{
+						Name: "nginx",
+						Annotations: map[string]string{
+							api.CreatedByAnnotation: `{"kind":"SerializedReference","reference":{"kind":"ReplicaSet","name":"nginx"}}`,
+						},
+					},
+				},
+			},
+			res: []string{"nginx"},
+		},
+	}
+
+	for _, tc := range tests {
+		var res []string
+		for _, pod := range drainablePods(tc.pods) {
+			res = append(res, pod.Name)
+		}
+
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+	}
+}
+
+// fakeNodeAPI serves a node running a single pod, which is gone once evicted.
+type fakeNodeAPI struct {
+	record  func(string)
+	evicted bool
+}
+
+func (f *fakeNodeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.record(fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+	var response interface{}
+	switch {
+	case r.URL.Path == "/api/v1/nodes/ip-foo-worker-0":
+		response = &v1.Node{
+			TypeMeta:   unversioned.TypeMeta{Kind: "Node", APIVersion: "v1"},
+			ObjectMeta: v1.ObjectMeta{Name: "ip-foo-worker-0"},
+		}
+	case r.URL.Path == "/api/v1/pods":
+		podList := &v1.PodList{
+			TypeMeta: unversioned.TypeMeta{Kind: "PodList", APIVersion: "v1"},
+		}
+		if !f.evicted {
+			podList.Items = []v1.Pod{{ObjectMeta: v1.ObjectMeta{Name: "web", Namespace: "default"}}}
+		}
+		response = podList
+	case r.URL.Path == "/api/v1/namespaces/default/pods/web/eviction":
+		f.evicted = true
+		response = &unversioned.Status{
+			TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   unversioned.StatusSuccess,
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func TestDeleteMachinesDrainsWorkers(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc       string
+		drainNodes bool
+		res        []string
+	}{
+		{
+			desc:       "node drained before the instance is terminated",
+			drainNodes: true,
+			res: []string{
+				"GET /api/v1/nodes/ip-foo-worker-0",
+				"PUT /api/v1/nodes/ip-foo-worker-0",
+				"GET /api/v1/pods",
+				"POST /api/v1/namespaces/default/pods/web/eviction",
+				"GET /api/v1/pods",
+				"TerminateInstances",
+			},
+		},
+		{
+			desc:       "node not drained",
+			drainNodes: false,
+			res:        []string{"TerminateInstances"},
+		},
+	}
+
+	for _, tc := range tests {
+		var mutex sync.Mutex
+		var events []string
+		record := func(event string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			events = append(events, event)
+		}
+
+		server := httptest.NewServer(&fakeNodeAPI{record: record})
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+
+		clients, fake := newFakeClients()
+		instances := newFakeInstances("foo-worker-0")
+		fake.on("DescribeInstances", instances.describe)
+		fake.on("TerminateInstances", func(params, output interface{}) error {
+			record("TerminateInstances")
+			return instances.terminate(params, output)
+		})
+
+		s := &Service{
+			k8sClient:    k8sClient,
+			logger:       logger,
+			drainNodes:   tc.drainNodes,
+			drainTimeout: time.Minute,
+		}
+
+		err = s.deleteMachines(deleteMachinesInput{
+			clients:     clients,
+			clusterName: "foo",
+			prefix:      prefixWorker,
+		})
+		server.Close()
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, events, fmt.Sprintf("[%s] Wrong order", tc.desc))
+	}
+}
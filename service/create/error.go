@@ -38,3 +38,164 @@ var secretsRetrievalFailedError = errgo.New("secrets retrieval failed")
 func IsSecretsRetrievalFailed(err error) bool {
 	return errgo.Cause(err) == secretsRetrievalFailedError
 }
+
+var nodeNotDrainedError = errgo.New("node not drained")
+
+// IsNodeNotDrained asserts nodeNotDrainedError.
+func IsNodeNotDrained(err error) bool {
+	return errgo.Cause(err) == nodeNotDrainedError
+}
+
+var etcdMemberNotFoundError = errgo.New("etcd member not found")
+
+// IsEtcdMemberNotFound asserts etcdMemberNotFoundError.
+func IsEtcdMemberNotFound(err error) bool {
+	return errgo.Cause(err) == etcdMemberNotFoundError
+}
+
+var etcdUnreachableError = errgo.New("etcd unreachable")
+
+// IsEtcdUnreachable asserts etcdUnreachableError.
+func IsEtcdUnreachable(err error) bool {
+	return errgo.Cause(err) == etcdUnreachableError
+}
+
+var missingRegionError = errgo.New("missing region")
+
+// IsMissingRegion asserts missingRegionError.
+func IsMissingRegion(err error) bool {
+	return errgo.Cause(err) == missingRegionError
+}
+
+var teardownFailedError = errgo.New("teardown failed")
+
+// IsTeardownFailed asserts teardownFailedError.
+func IsTeardownFailed(err error) bool {
+	return errgo.Cause(err) == teardownFailedError
+}
+
+var invalidHostnameError = errgo.New("invalid hostname")
+
+// IsInvalidHostname asserts invalidHostnameError.
+func IsInvalidHostname(err error) bool {
+	return errgo.Cause(err) == invalidHostnameError
+}
+
+var invalidCloudConfigEncodingError = errgo.New("invalid cloudconfig encoding")
+
+// IsInvalidCloudConfigEncoding asserts invalidCloudConfigEncodingError.
+func IsInvalidCloudConfigEncoding(err error) bool {
+	return errgo.Cause(err) == invalidCloudConfigEncodingError
+}
+
+var malformedEncodedCloudConfigError = errgo.New("malformed encoded cloudconfig")
+
+// IsMalformedEncodedCloudConfig asserts malformedEncodedCloudConfigError.
+func IsMalformedEncodedCloudConfig(err error) bool {
+	return errgo.Cause(err) == malformedEncodedCloudConfigError
+}
+
+var immutableClusterIDError = errgo.New("immutable cluster ID")
+
+// IsImmutableClusterID asserts immutableClusterIDError.
+func IsImmutableClusterID(err error) bool {
+	return errgo.Cause(err) == immutableClusterIDError
+}
+
+var mastersNotReadyError = errgo.New("masters not ready")
+
+// IsMastersNotReady asserts mastersNotReadyError.
+func IsMastersNotReady(err error) bool {
+	return errgo.Cause(err) == mastersNotReadyError
+}
+
+var invalidInstanceIDsError = errgo.New("invalid instance IDs")
+
+// IsInvalidInstanceIDs asserts invalidInstanceIDsError.
+func IsInvalidInstanceIDs(err error) bool {
+	return errgo.Cause(err) == invalidInstanceIDsError
+}
+
+var invalidCloudConfigError = errgo.New("invalid cloudconfig")
+
+// IsInvalidCloudConfig asserts invalidCloudConfigError.
+func IsInvalidCloudConfig(err error) bool {
+	return errgo.Cause(err) == invalidCloudConfigError
+}
+
+var clusterLimitExceededError = errgo.New("cluster limit exceeded")
+
+// IsClusterLimitExceeded asserts clusterLimitExceededError.
+func IsClusterLimitExceeded(err error) bool {
+	return errgo.Cause(err) == clusterLimitExceededError
+}
+
+var invalidResourceNameError = errgo.New("invalid resource name")
+
+// IsInvalidResourceName asserts invalidResourceNameError.
+func IsInvalidResourceName(err error) bool {
+	return errgo.Cause(err) == invalidResourceNameError
+}
+
+var mismatchedMachinesError = errgo.New("mismatched machines")
+
+// IsMismatchedMachines asserts mismatchedMachinesError.
+func IsMismatchedMachines(err error) bool {
+	return errgo.Cause(err) == mismatchedMachinesError
+}
+
+var invalidAvailabilityZoneError = errgo.New("invalid availability zone")
+
+// IsInvalidAvailabilityZone asserts invalidAvailabilityZoneError.
+func IsInvalidAvailabilityZone(err error) bool {
+	return errgo.Cause(err) == invalidAvailabilityZoneError
+}
+
+var missingImageIDError = errgo.New("missing image ID")
+
+// IsMissingImageID asserts missingImageIDError.
+func IsMissingImageID(err error) bool {
+	return errgo.Cause(err) == missingImageIDError
+}
+
+var invalidCIDRError = errgo.New("invalid CIDR")
+
+// IsInvalidCIDR asserts invalidCIDRError.
+func IsInvalidCIDR(err error) bool {
+	return errgo.Cause(err) == invalidCIDRError
+}
+
+var instanceBootTimeoutError = errgo.New("instance boot timeout")
+
+// IsInstanceBootTimeout asserts instanceBootTimeoutError.
+func IsInstanceBootTimeout(err error) bool {
+	return errgo.Cause(err) == instanceBootTimeoutError
+}
+
+var invalidSharedVPCError = errgo.New("invalid shared VPC")
+
+// IsInvalidSharedVPC asserts invalidSharedVPCError.
+func IsInvalidSharedVPC(err error) bool {
+	return errgo.Cause(err) == invalidSharedVPCError
+}
+
+var subnetCIDROverlapError = errgo.New("subnet CIDR overlap")
+
+// IsSubnetCIDROverlap asserts subnetCIDROverlapError.
+func IsSubnetCIDROverlap(err error) bool {
+	return errgo.Cause(err) == subnetCIDROverlapError
+}
+
+var deprecatedImageError = errgo.New("deprecated image")
+
+// IsDeprecatedImage asserts deprecatedImageError.
+func IsDeprecatedImage(err error) bool {
+	return errgo.Cause(err) == deprecatedImageError
+}
+
+var clusterNotFoundError = errgo.New("cluster not found")
+
+// IsClusterNotFound asserts clusterNotFoundError.
+func IsClusterNotFound(err error) bool {
+	return errgo.Cause(err) == clusterNotFoundError
+}
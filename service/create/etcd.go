@@ -0,0 +1,123 @@
+package create
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	etcdclient "github.com/coreos/etcd/client"
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/certificatetpr"
+	microerror "github.com/giantswarm/microkit/error"
+	"golang.org/x/net/context"
+)
+
+const (
+	// etcdRequestTimeout is the time we wait for a single etcd API request.
+	etcdRequestTimeout = 10 * time.Second
+)
+
+// etcdMembersAPI creates the etcd members API client of a cluster, see
+// newEtcdMembersAPI. Tests replace it.
+var etcdMembersAPI = (*Service).newEtcdMembersAPI
+
+// newEtcdMembersAPI creates a client for the etcd members API of the cluster
+// with the given spec. It authenticates against etcd using the cluster's etcd
+// certificates.
+func (s *Service) newEtcdMembersAPI(spec awstpr.Spec) (etcdclient.MembersAPI, error) {
+	certs, err := s.certWatcher.SearchCertsForComponent(spec.Cluster.Cluster.ID, certificatetpr.EtcdComponent.String())
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	keyPair, err := tls.X509KeyPair(
+		certs[certificatetpr.AssetsBundleKey{Component: certificatetpr.EtcdComponent, Type: certificatetpr.Crt}],
+		certs[certificatetpr.AssetsBundleKey{Component: certificatetpr.EtcdComponent, Type: certificatetpr.Key}],
+	)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(certs[certificatetpr.AssetsBundleKey{Component: certificatetpr.EtcdComponent, Type: certificatetpr.CA}]) {
+		return nil, microerror.MaskAnyf(secretsRetrievalFailedError, "could not parse etcd CA certificate")
+	}
+
+	client, err := etcdclient.New(etcdclient.Config{
+		Endpoints: []string{
+			fmt.Sprintf("https://%s:%d", spec.Cluster.Etcd.Domain, spec.Cluster.Etcd.Port),
+		},
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				Certificates: []tls.Certificate{keyPair},
+				RootCAs:      caPool,
+			},
+		},
+		HeaderTimeoutPerRequest: etcdRequestTimeout,
+	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return etcdclient.NewMembersAPI(client), nil
+}
+
+// removeEtcdMember removes the etcd member running on one of the given hosts
+// from the etcd cluster. The last remaining member is never removed,
+// since etcd refuses to shrink a cluster to zero members. The client never
+// dials before the first request, so failing to list the members is reported
+// as etcdUnreachableError.
+func removeEtcdMember(membersAPI etcdclient.MembersAPI, hosts ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdRequestTimeout)
+	defer cancel()
+
+	members, err := membersAPI.List(ctx)
+	if err != nil {
+		return microerror.MaskAnyf(etcdUnreachableError, "could not list etcd members: %s", err)
+	}
+
+	if len(members) < 2 {
+		return nil
+	}
+
+	memberID, ok := findEtcdMember(members, hosts...)
+	if !ok {
+		return microerror.MaskAnyf(etcdMemberNotFoundError, "no etcd member running on %v", hosts)
+	}
+
+	if err := membersAPI.Remove(ctx, memberID); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// findEtcdMember returns the ID of the etcd member whose peer or client URLs
+// point to one of the given hosts.
+func findEtcdMember(members []etcdclient.Member, hosts ...string) (string, bool) {
+	for _, member := range members {
+		urls := append(append([]string{}, member.PeerURLs...), member.ClientURLs...)
+		for _, rawURL := range urls {
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				continue
+			}
+			memberHost, _, err := net.SplitHostPort(u.Host)
+			if err != nil {
+				memberHost = u.Host
+			}
+
+			for _, host := range hosts {
+				if host != "" && host == memberHost {
+					return member.ID, true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
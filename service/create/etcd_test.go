@@ -0,0 +1,198 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	etcdclient "github.com/coreos/etcd/client"
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type fakeMembersAPI struct {
+	etcdclient.MembersAPI
+	members   []etcdclient.Member
+	removed   []string
+	listErr   error
+	removeErr error
+	record    func(string)
+}
+
+func (f *fakeMembersAPI) List(ctx context.Context) ([]etcdclient.Member, error) {
+	if f.listErr != nil {
+		return nil, f.listErr
+	}
+	return f.members, nil
+}
+
+func (f *fakeMembersAPI) Remove(ctx context.Context, mID string) error {
+	if f.record != nil {
+		f.record(fmt.Sprintf("remove %s", mID))
+	}
+	if f.removeErr != nil {
+		return f.removeErr
+	}
+	f.removed = append(f.removed, mID)
+	return nil
+}
+
+func TestRemoveEtcdMember(t *testing.T) {
+	tests := []struct {
+		desc    string
+		members []etcdclient.Member
+		hosts   []string
+		removed []string
+		err     error
+	}{
+		{
+			desc: "member matched by peer URL IP",
+			members: []etcdclient.Member{
+				{ID: "a", PeerURLs: []string{"https://10.0.0.1:2380"}},
+				{ID: "b", PeerURLs: []string{"https://10.0.0.2:2380"}},
+			},
+			hosts:   []string{"10.0.0.2", "ip-10-0-0-2.ec2.internal"},
+			removed: []string{"b"},
+		},
+		{
+			desc: "member matched by client URL DNS name",
+			members: []etcdclient.Member{
+				{ID: "a", ClientURLs: []string{"https://ip-10-0-0-1.ec2.internal:2379"}},
+				{ID: "b", ClientURLs: []string{"https://ip-10-0-0-2.ec2.internal:2379"}},
+			},
+			hosts:   []string{"10.0.0.1", "ip-10-0-0-1.ec2.internal"},
+			removed: []string{"a"},
+		},
+		{
+			desc: "last member is never removed",
+			members: []etcdclient.Member{
+				{ID: "a", PeerURLs: []string{"https://10.0.0.1:2380"}},
+			},
+			hosts:   []string{"10.0.0.1"},
+			removed: nil,
+		},
+		{
+			desc: "no matching member",
+			members: []etcdclient.Member{
+				{ID: "a", PeerURLs: []string{"https://10.0.0.1:2380"}},
+				{ID: "b", PeerURLs: []string{"https://10.0.0.2:2380"}},
+			},
+			hosts:   []string{"10.0.0.3", ""},
+			removed: nil,
+			err:     etcdMemberNotFoundError,
+		},
+	}
+
+	for _, tc := range tests {
+		membersAPI := &fakeMembersAPI{members: tc.members}
+
+		err := removeEtcdMember(membersAPI, tc.hosts...)
+		if tc.err != nil {
+			assert.True(t, IsEtcdMemberNotFound(err), fmt.Sprintf("[%s] Expected an etcd member not found error", tc.desc))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+
+		assert.Equal(t, tc.removed, membersAPI.removed, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+	}
+}
+
+func TestDeleteMachinesRemovesEtcdMembers(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	defer func(original func(*Service, awstpr.Spec) (etcdclient.MembersAPI, error)) {
+		etcdMembersAPI = original
+	}(etcdMembersAPI)
+
+	tests := []struct {
+		desc              string
+		removeEtcdMembers bool
+		listErr           error
+		removeErr         error
+		res               []string
+		resTerminated     []string
+		resErr            bool
+	}{
+		{
+			desc:              "members removed before the instances are terminated",
+			removeEtcdMembers: true,
+			res: []string{
+				"remove a",
+				"TerminateInstances",
+				"remove b",
+				"TerminateInstances",
+			},
+			resTerminated: []string{"i-foo-master-0", "i-foo-master-1"},
+		},
+		{
+			desc:              "instances kept when their members cannot be removed",
+			removeEtcdMembers: true,
+			removeErr:         fmt.Errorf("etcd is unhealthy"),
+			res:               []string{"remove a", "remove b"},
+			resTerminated:     nil,
+			resErr:            true,
+		},
+		{
+			desc:              "instances terminated when etcd cannot be reached",
+			removeEtcdMembers: true,
+			listErr:           fmt.Errorf("connection refused"),
+			res:               []string{"TerminateInstances", "TerminateInstances"},
+			resTerminated:     []string{"i-foo-master-0", "i-foo-master-1"},
+		},
+		{
+			desc:              "member removal skipped when the whole cluster is deleted",
+			removeEtcdMembers: false,
+			res:               []string{"TerminateInstances", "TerminateInstances"},
+			resTerminated:     []string{"i-foo-master-0", "i-foo-master-1"},
+		},
+	}
+
+	for _, tc := range tests {
+		var events []string
+		record := func(event string) {
+			events = append(events, event)
+		}
+
+		etcdMembersAPI = func(s *Service, spec awstpr.Spec) (etcdclient.MembersAPI, error) {
+			return &fakeMembersAPI{
+				members: []etcdclient.Member{
+					{ID: "a", PeerURLs: []string{"https://ip-foo-master-0:2380"}},
+					{ID: "b", PeerURLs: []string{"https://ip-foo-master-1:2380"}},
+					{ID: "c", PeerURLs: []string{"https://ip-foo-master-2:2380"}},
+				},
+				listErr:   tc.listErr,
+				removeErr: tc.removeErr,
+				record:    record,
+			}, nil
+		}
+
+		clients, fake := newFakeClients()
+		instances := newFakeInstances("foo-master-0", "foo-master-1")
+		fake.on("DescribeInstances", instances.describe)
+		fake.on("TerminateInstances", func(params, output interface{}) error {
+			record("TerminateInstances")
+			return instances.terminate(params, output)
+		})
+
+		s := &Service{
+			logger: logger,
+		}
+
+		err := s.deleteMachines(deleteMachinesInput{
+			clients:           clients,
+			clusterName:       "foo",
+			prefix:            prefixMaster,
+			removeEtcdMembers: tc.removeEtcdMembers,
+		})
+
+		if tc.resErr {
+			assert.NotNil(t, err, fmt.Sprintf("[%s] Expected an error", tc.desc))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.res, events, fmt.Sprintf("[%s] Wrong order", tc.desc))
+		assert.Equal(t, tc.resTerminated, instances.terminated, fmt.Sprintf("[%s] Wrong instances terminated", tc.desc))
+	}
+}
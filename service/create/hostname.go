@@ -0,0 +1,33 @@
+package create
+
+import (
+	"regexp"
+	"strings"
+
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+const (
+	// maxHostnameLength is the maximum length of a single DNS label.
+	maxHostnameLength = 63
+)
+
+var invalidHostnameChars = regexp.MustCompile("[^a-z0-9-]+")
+
+// instanceHostname turns the given instance name into a valid DNS label, e.g.
+// "Foo_bar-master-0" becomes "foo-bar-master-0".
+func instanceHostname(name string) (string, error) {
+	hostname := invalidHostnameChars.ReplaceAllString(strings.ToLower(name), "-")
+	// Long names are truncated at the front, since the end contains the
+	// machine's role and index which tell instances apart.
+	if len(hostname) > maxHostnameLength {
+		hostname = hostname[len(hostname)-maxHostnameLength:]
+	}
+	hostname = strings.Trim(hostname, "-")
+
+	if hostname == "" {
+		return "", microerror.MaskAnyf(invalidHostnameError, "instance name '%s' has no valid hostname characters", name)
+	}
+
+	return hostname, nil
+}
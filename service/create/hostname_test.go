@@ -0,0 +1,101 @@
+package create
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceHostname(t *testing.T) {
+	tests := []struct {
+		desc         string
+		name         string
+		res          string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc: "instance name is already a valid hostname",
+			name: instanceName(instanceNameInput{clusterName: "foo", prefix: prefixMaster, no: 0}),
+			res:  "foo-master-0",
+		},
+		{
+			desc: "upper case and invalid characters are replaced",
+			name: instanceName(instanceNameInput{clusterName: "Foo_Bar.baz", prefix: prefixWorker, no: 2}),
+			res:  "foo-bar-baz-worker-2",
+		},
+		{
+			desc: "long names are truncated keeping the role and index",
+			name: instanceName(instanceNameInput{clusterName: strings.Repeat("a", 70), prefix: prefixWorker, no: 11}),
+			res:  strings.Repeat("a", 53) + "-worker-11",
+		},
+		{
+			desc: "leading and trailing dashes are removed",
+			name: "-foo-master-0_",
+			res:  "foo-master-0",
+		},
+		{
+			desc:         "name without valid characters",
+			name:         "___",
+			errorMatcher: IsInvalidHostname,
+		},
+	}
+
+	for _, tc := range tests {
+		res, err := instanceHostname(tc.name)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+		assert.True(t, len(res) <= maxHostnameLength, fmt.Sprintf("[%s] Hostname is too long", tc.desc))
+	}
+}
+
+func TestSmallCloudconfigHostname(t *testing.T) {
+	tests := []struct {
+		desc     string
+		hostname string
+		res      []string
+		absent   []string
+	}{
+		{
+			desc:     "hostname is set on the instance",
+			hostname: "foo-master-0",
+			res: []string{
+				"hostnamectl set-hostname foo-master-0",
+				`s/^hostname: .*$/hostname: "foo-master-0"/`,
+			},
+		},
+		{
+			desc:   "hostname of the final cloudconfig is kept",
+			absent: []string{"hostnamectl", "sed -i"},
+		},
+	}
+
+	s := &Service{}
+	for _, tc := range tests {
+		encoded, err := s.SmallCloudconfig(SmallCloudconfigConfig{
+			Hostname:   tc.hostname,
+			ObjectName: machineID(prefixMaster, 0),
+			Region:     "eu-central-1",
+			S3DirURI:   "bucket/foo/cloudconfig",
+		})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error decoding the cloudconfig", tc.desc))
+
+		for _, expected := range tc.res {
+			assert.Contains(t, string(decoded), expected, fmt.Sprintf("[%s] The cloudconfig doesn't set the hostname", tc.desc))
+		}
+		for _, unexpected := range tc.absent {
+			assert.NotContains(t, string(decoded), unexpected, fmt.Sprintf("[%s] The cloudconfig changes the hostname", tc.desc))
+		}
+		assert.True(t, strings.HasSuffix(string(decoded), "exec /usr/bin/coreos-cloudinit --from-file /var/run/coreos/$USERDATA_FILE"), fmt.Sprintf("[%s] The cloudconfig doesn't end with running coreos-cloudinit", tc.desc))
+	}
+}
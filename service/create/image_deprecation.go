@@ -0,0 +1,80 @@
+package create
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+const (
+	// DeprecatedImagesIgnore launches the machines of clusters whatever the
+	// deprecation of their images. This is the default.
+	DeprecatedImagesIgnore = "ignore"
+	// DeprecatedImagesWarn creates a warning event about clusters whose
+	// machines use deprecated images, but still launches them.
+	DeprecatedImagesWarn = "warn"
+	// DeprecatedImagesRefuse creates a warning event about clusters whose
+	// machines use deprecated images, and doesn't create them.
+	DeprecatedImagesRefuse = "refuse"
+)
+
+// deprecatedImageReason is the reason of the events of clusters whose machines
+// use deprecated images.
+const deprecatedImageReason = "DeprecatedImage"
+
+// imageDeprecationTime returns the deprecation time of an image, see
+// awsresources.ImageDeprecationTime. Tests replace it.
+var imageDeprecationTime = awsresources.ImageDeprecationTime
+
+func validDeprecatedImages(policy string) bool {
+	return policy == DeprecatedImagesIgnore || policy == DeprecatedImagesWarn || policy == DeprecatedImagesRefuse
+}
+
+// checkImageDeprecation checks whether the images of the machines of the given
+// cluster are deprecated, e.g. because the CoreOS release reached its end of
+// life, and handles them according to s.deprecatedImages.
+func (s *Service) checkImageDeprecation(clients awsutil.Clients, cluster awstpr.CustomObject) error {
+	if s.deprecatedImages == DeprecatedImagesIgnore {
+		return nil
+	}
+
+	var imageIDs []string
+	for _, machine := range append(cluster.Spec.AWS.Masters, cluster.Spec.AWS.Workers...) {
+		if !containsString(imageIDs, machine.ImageID) {
+			imageIDs = append(imageIDs, machine.ImageID)
+		}
+	}
+
+	var deprecated []string
+	for _, imageID := range imageIDs {
+		deprecatedAt, err := imageDeprecationTime(clients, imageID)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		if !deprecatedAt.IsZero() && !deprecatedAt.After(time.Now()) {
+			deprecated = append(deprecated, fmt.Sprintf("'%s' since %s", imageID, deprecatedAt.Format(time.RFC3339)))
+		}
+	}
+	if len(deprecated) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("cluster '%s' uses deprecated images %s, update them to a supported release", cluster.Name, strings.Join(deprecated, ", "))
+	if err := s.createWarningEvent(cluster, deprecatedImageReason, message); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create event for cluster '%s': %s", cluster.Name, errgo.Details(err)))
+	}
+
+	if s.deprecatedImages == DeprecatedImagesRefuse {
+		return microerror.MaskAnyf(deprecatedImageError, "%s", message)
+	}
+	s.logger.Log("info", message)
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package create
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/awstpr"
+	awsinfo "github.com/giantswarm/awstpr/aws"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+)
+
+func TestCheckImageDeprecation(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	deprecationTimes := map[string]time.Time{
+		"ami-current":    {},
+		"ami-deprecated": time.Date(2020, 5, 26, 0, 0, 0, 0, time.UTC),
+		"ami-upcoming":   time.Now().Add(24 * time.Hour),
+	}
+	defer func(original func(awsutil.Clients, string) (time.Time, error)) {
+		imageDeprecationTime = original
+	}(imageDeprecationTime)
+
+	tests := []struct {
+		desc             string
+		deprecatedImages string
+		imageID          string
+		resDescribed     []string
+		resEvent         bool
+		errorMatcher     func(error) bool
+	}{
+		{
+			desc:             "deprecated image is ignored",
+			deprecatedImages: DeprecatedImagesIgnore,
+			imageID:          "ami-deprecated",
+			resDescribed:     nil,
+		},
+		{
+			desc:             "current image",
+			deprecatedImages: DeprecatedImagesRefuse,
+			imageID:          "ami-current",
+			resDescribed:     []string{"ami-current", "ami-1"},
+		},
+		{
+			desc:             "image deprecated in the future",
+			deprecatedImages: DeprecatedImagesRefuse,
+			imageID:          "ami-upcoming",
+			resDescribed:     []string{"ami-upcoming", "ami-1"},
+		},
+		{
+			desc:             "deprecated image is warned about",
+			deprecatedImages: DeprecatedImagesWarn,
+			imageID:          "ami-deprecated",
+			resDescribed:     []string{"ami-deprecated", "ami-1"},
+			resEvent:         true,
+		},
+		{
+			desc:             "deprecated image is refused",
+			deprecatedImages: DeprecatedImagesRefuse,
+			imageID:          "ami-deprecated",
+			resDescribed:     []string{"ami-deprecated", "ami-1"},
+			resEvent:         true,
+			errorMatcher:     IsDeprecatedImage,
+		},
+	}
+
+	for _, tc := range tests {
+		var described []string
+		imageDeprecationTime = func(clients awsutil.Clients, imageID string) (time.Time, error) {
+			described = append(described, imageID)
+			return deprecationTimes[imageID], nil
+		}
+
+		api := &fakeEventsAPI{}
+		server := httptest.NewServer(api)
+
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+		s := &Service{
+			k8sClient:        k8sClient,
+			logger:           logger,
+			deprecatedImages: tc.deprecatedImages,
+		}
+
+		// The masters share the image, which is only described once.
+		var cluster awstpr.CustomObject
+		cluster.Name = "foo"
+		cluster.Spec.AWS.Masters = []awsinfo.Node{{ImageID: tc.imageID}, {ImageID: tc.imageID}}
+		cluster.Spec.AWS.Workers = []awsinfo.Node{{ImageID: "ami-1"}}
+		deprecationTimes["ami-1"] = time.Time{}
+
+		err = s.checkImageDeprecation(awsutil.Clients{}, cluster)
+		server.Close()
+
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.resDescribed, described, fmt.Sprintf("[%s] Wrong images described", tc.desc))
+
+		if !tc.resEvent {
+			assert.Empty(t, api.events, fmt.Sprintf("[%s] Unexpected event", tc.desc))
+			continue
+		}
+		assert.Len(t, api.events, 1, fmt.Sprintf("[%s] Expected one event", tc.desc))
+		event := api.events[0]
+		assert.Equal(t, v1.EventTypeWarning, event.Type, fmt.Sprintf("[%s] Wrong event type", tc.desc))
+		assert.Equal(t, deprecatedImageReason, event.Reason, fmt.Sprintf("[%s] Wrong event reason", tc.desc))
+		assert.Contains(t, event.Message, "'ami-deprecated' since 2020-05-26T00:00:00Z", fmt.Sprintf("[%s] Wrong event message", tc.desc))
+		assert.NotContains(t, event.Message, "ami-1", fmt.Sprintf("[%s] Current image reported", tc.desc))
+	}
+}
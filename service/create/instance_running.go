@@ -0,0 +1,113 @@
+package create
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// defaultInstanceRunningTimeout is the default maximum time to wait for a new
+// instance to run.
+const defaultInstanceRunningTimeout = 10 * time.Minute
+
+const (
+	// instanceBootTimeoutReason is the reason of the events of instances which
+	// didn't run in time.
+	instanceBootTimeoutReason = "InstanceBootTimeout"
+	// maxEventConsoleOutput is the maximum number of bytes of console output
+	// attached to boot timeout events. The end of the output is kept, since it
+	// shows where the boot got stuck.
+	maxEventConsoleOutput = 2048
+)
+
+// bootingInstance is an instance waited for to run, e.g.
+// *awsresources.Instance.
+type bootingInstance interface {
+	WaitUntilRunning(timeout time.Duration) error
+	BootDiagnostics() (awsresources.InstanceBootDiagnostics, error)
+	Delete() error
+}
+
+// waitForNewInstance blocks until a freshly created instance is running, so it
+// can be registered with the load balancers right away. Reused instances were
+// waited for when they were created. Instances which don't run in time are
+// diagnosed, see handleBootTimeout.
+func (s *Service) waitForNewInstance(cluster awstpr.CustomObject, instance bootingInstance, name string, created bool) error {
+	if !created {
+		return nil
+	}
+
+	s.logger.Log("info", fmt.Sprintf("waiting for instance '%s' to run...", name))
+	if err := instance.WaitUntilRunning(s.instanceRunningTimeout); isBootTimeout(err) {
+		s.handleBootTimeout(cluster, instance, name)
+		return microerror.MaskAnyf(instanceBootTimeoutError, "instance '%s' is not running after %s", name, s.instanceRunningTimeout)
+	} else if err != nil {
+		return microerror.MaskAny(err)
+	}
+	s.logger.Log("info", fmt.Sprintf("instance '%s' is running", name))
+
+	return nil
+}
+
+// handleBootTimeout attaches the state and console output of an instance which
+// didn't run in time to a warning event of its cluster. When configured, the
+// instance is terminated, so the next reconcile launches it again. Failures
+// are only logged, since the instance failed already.
+func (s *Service) handleBootTimeout(cluster awstpr.CustomObject, instance bootingInstance, name string) {
+	message := fmt.Sprintf("instance '%s' is not running after %s", name, s.instanceRunningTimeout)
+
+	diagnostics, err := instance.BootDiagnostics()
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not diagnose instance '%s': %s", name, errgo.Details(err)))
+	} else {
+		message = bootTimeoutMessage(message, diagnostics)
+	}
+
+	if err := s.createWarningEvent(cluster, instanceBootTimeoutReason, message); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create event for cluster '%s': %s", cluster.Name, errgo.Details(err)))
+	}
+
+	if !s.terminateStuckInstances {
+		return
+	}
+	s.logger.Log("info", fmt.Sprintf("terminating stuck instance '%s'", name))
+	if err := instance.Delete(); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not terminate stuck instance '%s': %s", name, errgo.Details(err)))
+		return
+	}
+	s.logger.Log("info", fmt.Sprintf("terminated stuck instance '%s', it is launched again on the next reconcile", name))
+}
+
+// bootTimeoutMessage appends the given diagnostics to the message of a boot
+// timeout event.
+func bootTimeoutMessage(message string, diagnostics awsresources.InstanceBootDiagnostics) string {
+	message = fmt.Sprintf("%s, state: %s", message, diagnostics.State)
+	if diagnostics.StateReason != "" {
+		message = fmt.Sprintf("%s, state reason: %s", message, diagnostics.StateReason)
+	}
+
+	output := strings.TrimSpace(diagnostics.ConsoleOutput)
+	if output == "" {
+		return message + ", no console output"
+	}
+	if len(output) > maxEventConsoleOutput {
+		output = "..." + output[len(output)-maxEventConsoleOutput:]
+	}
+
+	return fmt.Sprintf("%s, console output:\n%s", message, output)
+}
+
+// isBootTimeout checks whether waiting for an instance to run failed because
+// it didn't, as opposed to e.g. failing API calls.
+func isBootTimeout(err error) bool {
+	awserr, ok := errgo.Cause(err).(awserr.Error)
+	return ok && awserr.Code() == request.WaiterResourceNotReadyErrorCode
+}
@@ -0,0 +1,163 @@
+package create
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// fakeBootingInstance records the timeouts it is waited for with, and whether
+// it was deleted.
+type fakeBootingInstance struct {
+	timeouts    []time.Duration
+	err         error
+	diagnostics awsresources.InstanceBootDiagnostics
+	deleted     bool
+}
+
+func (f *fakeBootingInstance) WaitUntilRunning(timeout time.Duration) error {
+	f.timeouts = append(f.timeouts, timeout)
+	return f.err
+}
+
+func (f *fakeBootingInstance) BootDiagnostics() (awsresources.InstanceBootDiagnostics, error) {
+	return f.diagnostics, nil
+}
+
+func (f *fakeBootingInstance) Delete() error {
+	f.deleted = true
+	return nil
+}
+
+func TestWaitForNewInstance(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc        string
+		created     bool
+		waitErr     error
+		resTimeouts []time.Duration
+		resErr      bool
+	}{
+		{
+			desc:        "new instance is waited for",
+			created:     true,
+			resTimeouts: []time.Duration{5 * time.Minute},
+		},
+		{
+			desc:        "reused instance is not waited for",
+			created:     false,
+			resTimeouts: nil,
+		},
+		{
+			desc:        "waiting fails",
+			created:     true,
+			waitErr:     fmt.Errorf("throttled"),
+			resTimeouts: []time.Duration{5 * time.Minute},
+			resErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		s := &Service{
+			logger:                 logger,
+			instanceRunningTimeout: 5 * time.Minute,
+		}
+		instance := &fakeBootingInstance{err: tc.waitErr}
+
+		err := s.waitForNewInstance(awstpr.CustomObject{}, instance, "foo-master-0", tc.created)
+		assert.Equal(t, tc.resErr, err != nil, fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		assert.False(t, IsInstanceBootTimeout(err), fmt.Sprintf("[%s] Unexpected boot timeout", tc.desc))
+		assert.Equal(t, tc.resTimeouts, instance.timeouts, fmt.Sprintf("[%s] Unexpected waits", tc.desc))
+		assert.False(t, instance.deleted, fmt.Sprintf("[%s] Unexpected deletion", tc.desc))
+	}
+}
+
+func TestWaitForNewInstanceBootTimeout(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc                    string
+		terminateStuckInstances bool
+		diagnostics             awsresources.InstanceBootDiagnostics
+		resMessage              []string
+	}{
+		{
+			desc: "diagnostics are attached to the event",
+			diagnostics: awsresources.InstanceBootDiagnostics{
+				State:         "pending",
+				StateReason:   "Server.InternalError",
+				ConsoleOutput: "Booting CoreOS...\nIgnition failed\n",
+			},
+			resMessage: []string{"is not running after 5m0s", "state: pending", "state reason: Server.InternalError", "console output:\nBooting CoreOS...\nIgnition failed"},
+		},
+		{
+			desc:                    "stuck instance is terminated",
+			terminateStuckInstances: true,
+			diagnostics: awsresources.InstanceBootDiagnostics{
+				State: "pending",
+			},
+			resMessage: []string{"state: pending", "no console output"},
+		},
+		{
+			desc: "long console output is cut",
+			diagnostics: awsresources.InstanceBootDiagnostics{
+				State:         "pending",
+				ConsoleOutput: "Booting CoreOS...\n" + strings.Repeat("x", maxEventConsoleOutput) + "\nstuck",
+			},
+			resMessage: []string{"console output:\n...x", "x\nstuck"},
+		},
+	}
+
+	for _, tc := range tests {
+		api := &fakeEventsAPI{}
+		server := httptest.NewServer(api)
+
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+		s := &Service{
+			k8sClient:               k8sClient,
+			logger:                  logger,
+			instanceRunningTimeout:  5 * time.Minute,
+			terminateStuckInstances: tc.terminateStuckInstances,
+		}
+		// The instance never runs.
+		instance := &fakeBootingInstance{
+			err:         awserr.New(request.WaiterResourceNotReadyErrorCode, "exceeded wait attempts", nil),
+			diagnostics: tc.diagnostics,
+		}
+
+		var cluster awstpr.CustomObject
+		cluster.Name = "foo"
+		err = s.waitForNewInstance(cluster, instance, "foo-master-0", true)
+		assert.True(t, IsInstanceBootTimeout(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		assert.Equal(t, tc.terminateStuckInstances, instance.deleted, fmt.Sprintf("[%s] Wrong termination of the stuck instance", tc.desc))
+
+		assert.Len(t, api.events, 1, fmt.Sprintf("[%s] Expected one event", tc.desc))
+		event := api.events[0]
+		assert.Equal(t, v1.EventTypeWarning, event.Type, fmt.Sprintf("[%s] Wrong event type", tc.desc))
+		assert.Equal(t, instanceBootTimeoutReason, event.Reason, fmt.Sprintf("[%s] Wrong event reason", tc.desc))
+		assert.Equal(t, "foo", event.InvolvedObject.Name, fmt.Sprintf("[%s] Wrong event object", tc.desc))
+		for _, part := range tc.resMessage {
+			assert.Contains(t, event.Message, part, fmt.Sprintf("[%s] Missing diagnostics in the event", tc.desc))
+		}
+		assert.NotContains(t, event.Message, "Booting CoreOS...\nxxx", fmt.Sprintf("[%s] Console output wasn't cut", tc.desc))
+
+		server.Close()
+	}
+}
@@ -0,0 +1,34 @@
+package create
+
+import (
+	"fmt"
+
+	"github.com/cenkalti/backoff"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// grantKMSDecrypt allows the cluster's instance role to decrypt with the
+// cluster's KMS key, unless it is allowed already. Freshly created roles take a
+// while to propagate within AWS, so the grant is retried.
+func (s *Service) grantKMSDecrypt(kmsKey *awsresources.KMSKey, policy *awsresources.Policy) error {
+	grantOperation := func() error {
+		roleArn, err := policy.RoleArn()
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		if err := kmsKey.GrantDecrypt(roleArn); err != nil {
+			return microerror.MaskAny(err)
+		}
+
+		return nil
+	}
+	grantNotify := awsresources.NewNotify(s.logger, fmt.Sprintf("granting decrypt access to KMS key '%s'", kmsKey.Name))
+	if err := backoff.RetryNotify(grantOperation, awsresources.NewCustomExponentialBackoff(), grantNotify); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
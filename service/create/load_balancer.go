@@ -10,6 +10,17 @@ import (
 	awsresources "github.com/giantswarm/aws-operator/resources/aws"
 	"github.com/giantswarm/awstpr"
 	microerror "github.com/giantswarm/microkit/error"
+	"github.com/juju/errgo"
+)
+
+const (
+	// internalDomainPrefix is prepended to the domains of internal load
+	// balancers.
+	internalDomainPrefix = "internal-"
+	// DefaultAPIHealthCheckPath is the default path of the health check of the
+	// API load balancers. Unlike /readyz, it is served by all Kubernetes
+	// versions.
+	DefaultAPIHealthCheckPath = "/healthz"
 )
 
 type LoadBalancerInput struct {
@@ -23,6 +34,11 @@ type LoadBalancerInput struct {
 	InstanceIDs []string
 	// PortsToOpen are the ports the ELB should listen to and forward on.
 	PortsToOpen awsresources.PortPairs
+	// Scheme is the scheme of the ELB, it is internet-facing when empty.
+	Scheme string
+	// HealthCheck is the health check of the ELB's instances. It is a TCP check
+	// of the first instance port when empty.
+	HealthCheck awsresources.HealthCheck
 	// SecurityGroupID is the ID of the security group that will be assigned to the ELB.
 	SecurityGroupID string
 	// SubnetID is the ID of the subnet the ELB will be placed in.
@@ -30,17 +46,22 @@ type LoadBalancerInput struct {
 }
 
 func (s *Service) createLoadBalancer(input LoadBalancerInput) (*awsresources.ELB, error) {
-	lbName, err := loadBalancerName(input.Name, input.Cluster)
+	lbName, err := s.loadBalancerName(input.Name, input.Cluster)
 	if err != nil {
 		return nil, microerror.MaskAny(err)
 	}
 
 	lb := &awsresources.ELB{
-		Name:          lbName,
-		SecurityGroup: input.SecurityGroupID,
-		SubnetID:      input.SubnetID,
-		PortsToOpen:   input.PortsToOpen,
-		Client:        input.Clients.ELB,
+		Name:            lbName,
+		Scheme:          input.Scheme,
+		HealthCheck:     input.HealthCheck,
+		SecurityGroupID: input.SecurityGroupID,
+		SubnetID:        input.SubnetID,
+		PortsToOpen:     input.PortsToOpen,
+		Tags:            s.clusterTags(input.Cluster),
+		ClusterName:     input.Cluster.Name,
+		OperatorID:      s.operatorID,
+		Client:          input.Clients.ELB,
 	}
 
 	lbCreated, err := lb.CreateIfNotExists()
@@ -78,7 +99,7 @@ func (s *Service) createLoadBalancer(input LoadBalancerInput) (*awsresources.ELB
 
 func (s *Service) deleteLoadBalancer(input LoadBalancerInput) error {
 	// Delete ELB.
-	lbName, err := loadBalancerName(input.Name, input.Cluster)
+	lbName, err := s.loadBalancerName(input.Name, input.Cluster)
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
@@ -96,9 +117,148 @@ func (s *Service) deleteLoadBalancer(input LoadBalancerInput) error {
 	return nil
 }
 
+// deleteLoadBalancers deletes all the cluster's load balancers. The ones named
+// after the cluster's domains are deleted first, then any other ELB tagged
+// with the cluster, e.g. left behind by a former naming scheme. All of them are
+// attempted, the first error is returned.
+func (s *Service) deleteLoadBalancers(cluster awstpr.CustomObject, clients awsutil.Clients) error {
+	var firstErr error
+
+	for _, domain := range s.clusterLoadBalancerDomains(cluster) {
+		if err := s.deleteLoadBalancer(LoadBalancerInput{
+			Name:    domain,
+			Clients: clients,
+			Cluster: cluster,
+		}); err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not delete ELB for '%s': %s", domain, errgo.Details(err)))
+			if firstErr == nil {
+				firstErr = microerror.MaskAny(err)
+			}
+		}
+	}
+
+	if err := s.deleteTaggedLoadBalancers(cluster, clients); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not delete tagged ELBs of cluster '%s': %s", cluster.Name, errgo.Details(err)))
+		if firstErr == nil {
+			firstErr = microerror.MaskAny(err)
+		}
+	}
+
+	return firstErr
+}
+
+// deleteTaggedLoadBalancers deletes the ELBs tagged with the cluster which are
+// still around.
+func (s *Service) deleteTaggedLoadBalancers(cluster awstpr.CustomObject, clients awsutil.Clients) error {
+	lbs, err := awsresources.FindClusterELBs(clients.ELB, cluster.Name, s.operatorID)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	var firstErr error
+	for _, lb := range lbs {
+		if err := lb.Delete(); err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not delete ELB '%s': %s", lb.Name, errgo.Details(err)))
+			if firstErr == nil {
+				firstErr = microerror.MaskAny(err)
+			}
+			continue
+		}
+		s.logger.Log("debug", fmt.Sprintf("deleted orphaned ELB '%s'", lb.Name))
+	}
+
+	return firstErr
+}
+
+// deregisterFromLoadBalancers deregisters the given instances from all the
+// ELBs tagged with the cluster, so they stop receiving new requests and, with
+// connection draining, get to finish the in-flight ones before terminating.
+// All the ELBs are attempted, the first error is returned.
+func (s *Service) deregisterFromLoadBalancers(clusterName string, clients awsutil.Clients, instanceIDs []string) error {
+	if len(instanceIDs) == 0 {
+		return nil
+	}
+
+	lbs, err := awsresources.FindClusterELBs(clients.ELB, clusterName, s.operatorID)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	var firstErr error
+	for _, lb := range lbs {
+		if err := lb.DeregisterInstances(instanceIDs); err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not deregister instances from ELB '%s': %s", lb.Name, errgo.Details(err)))
+			if firstErr == nil {
+				firstErr = microerror.MaskAny(err)
+			}
+			continue
+		}
+		s.logger.Log("debug", fmt.Sprintf("deregistered instances %v from ELB '%s'", instanceIDs, lb.Name))
+	}
+
+	return firstErr
+}
+
+// apiLoadBalancer is one of the load balancers in front of the API servers.
+type apiLoadBalancer struct {
+	// Domain is the domain of the load balancer's DNS record.
+	Domain string
+	// Scheme is the scheme of the load balancer.
+	Scheme string
+}
+
+// apiLoadBalancers returns the load balancers in front of the API servers of
+// the cluster. The internet-facing one is always there, the internal one only
+// when enabled. The internal domain prefixes the first subdomain of the API
+// domain, so both records live in the same hosted zone,
+// e.g. api.foo.example.com -> internal-api.foo.example.com
+func (s *Service) apiLoadBalancers(cluster awstpr.CustomObject) []apiLoadBalancer {
+	apiDomain := cluster.Spec.Cluster.Kubernetes.API.Domain
+
+	lbs := []apiLoadBalancer{
+		{
+			Domain: apiDomain,
+			Scheme: awsresources.ELBSchemeInternetFacing,
+		},
+	}
+	if s.internalAPILoadBalancer {
+		lbs = append(lbs, apiLoadBalancer{
+			Domain: internalDomainPrefix + apiDomain,
+			Scheme: awsresources.ELBSchemeInternal,
+		})
+	}
+
+	return lbs
+}
+
+// apiHealthCheck returns the health check of the API load balancers. It probes
+// the API servers over HTTPS on their secure port, so the instances only get
+// traffic once their API server is serving.
+func (s *Service) apiHealthCheck(cluster awstpr.CustomObject) awsresources.HealthCheck {
+	return awsresources.HealthCheck{
+		Protocol: awsresources.ELBProtocolHTTPS,
+		Port:     cluster.Spec.Cluster.Kubernetes.API.SecurePort,
+		Path:     s.apiHealthCheckPath,
+	}
+}
+
+// clusterLoadBalancerDomains returns the domains the cluster's load balancers
+// are created for.
+func (s *Service) clusterLoadBalancerDomains(cluster awstpr.CustomObject) []string {
+	var domains []string
+	for _, lb := range s.apiLoadBalancers(cluster) {
+		domains = append(domains, lb.Domain)
+	}
+
+	return append(domains,
+		cluster.Spec.Cluster.Etcd.Domain,
+		cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
+	)
+}
+
 // loadBalancerName produces a unique name for the load balancer.
 // It takes the domain name, extracts the first subdomain, and combines it with the cluster name.
-func loadBalancerName(domainName string, cluster awstpr.CustomObject) (string, error) {
+func (s *Service) loadBalancerName(domainName string, cluster awstpr.CustomObject) (string, error) {
 	if cluster.Spec.Cluster.Cluster.ID == "" {
 		return "", microerror.MaskAnyf(missingCloudConfigKeyError, "spec.cluster.cluster.id")
 	}
@@ -108,7 +268,7 @@ func loadBalancerName(domainName string, cluster awstpr.CustomObject) (string, e
 		return "", microerror.MaskAnyf(malformedCloudConfigKeyError, "spec.cluster.cluster.id")
 	}
 
-	lbName := fmt.Sprintf("%s-%s", cluster.Spec.Cluster.Cluster.ID, componentName)
+	lbName := s.resourceName(fmt.Sprintf("%s-%s", cluster.Spec.Cluster.Cluster.ID, componentName))
 
 	return lbName, nil
 }
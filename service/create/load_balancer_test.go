@@ -4,9 +4,14 @@ import (
 	"fmt"
 	"testing"
 
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
 	"github.com/giantswarm/awstpr"
 	"github.com/giantswarm/clustertpr"
 	"github.com/giantswarm/clustertpr/cluster"
+	"github.com/giantswarm/clustertpr/etcd"
+	"github.com/giantswarm/clustertpr/kubernetes"
+	"github.com/giantswarm/clustertpr/kubernetes/api"
+	"github.com/giantswarm/clustertpr/kubernetes/ingress"
 	"github.com/juju/errgo"
 	"github.com/stretchr/testify/assert"
 )
@@ -95,7 +100,8 @@ func TestLoadBalancerName(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		res, err := loadBalancerName(tc.domainName, tc.tpo)
+		s := &Service{}
+		res, err := s.loadBalancerName(tc.domainName, tc.tpo)
 
 		if err != nil {
 			underlying := errgo.Cause(err)
@@ -147,3 +153,111 @@ func TestComponentName(t *testing.T) {
 		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
 	}
 }
+
+func TestAPILoadBalancers(t *testing.T) {
+	tpo := awstpr.CustomObject{
+		Spec: awstpr.Spec{
+			Cluster: clustertpr.Cluster{
+				Cluster: cluster.Cluster{
+					ID: "foo",
+				},
+				Etcd: etcd.Etcd{
+					Domain: "etcd.foo.example.com",
+				},
+				Kubernetes: kubernetes.Kubernetes{
+					API: api.API{
+						Domain: "api.foo.example.com",
+					},
+					IngressController: ingress.IngressController{
+						Domain: "ingress.foo.example.com",
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc        string
+		internalAPI bool
+		resLBs      []apiLoadBalancer
+		resDomains  []string
+		resLBNames  []string
+	}{
+		{
+			desc: "external API load balancer only",
+			resLBs: []apiLoadBalancer{
+				{Domain: "api.foo.example.com", Scheme: "internet-facing"},
+			},
+			resDomains: []string{"api.foo.example.com", "etcd.foo.example.com", "ingress.foo.example.com"},
+			resLBNames: []string{"foo-api"},
+		},
+		{
+			desc:        "internal and external API load balancers",
+			internalAPI: true,
+			resLBs: []apiLoadBalancer{
+				{Domain: "api.foo.example.com", Scheme: "internet-facing"},
+				{Domain: "internal-api.foo.example.com", Scheme: "internal"},
+			},
+			resDomains: []string{"api.foo.example.com", "internal-api.foo.example.com", "etcd.foo.example.com", "ingress.foo.example.com"},
+			resLBNames: []string{"foo-api", "foo-internal-api"},
+		},
+	}
+
+	for _, tc := range tests {
+		s := &Service{internalAPILoadBalancer: tc.internalAPI}
+
+		lbs := s.apiLoadBalancers(tpo)
+		assert.Equal(t, tc.resLBs, lbs, fmt.Sprintf("[%s] The input values didn't produce the expected API load balancers", tc.desc))
+		assert.Equal(t, tc.resDomains, s.clusterLoadBalancerDomains(tpo), fmt.Sprintf("[%s] The input values didn't produce the expected load balancer domains", tc.desc))
+
+		var lbNames []string
+		for _, lb := range lbs {
+			lbName, err := s.loadBalancerName(lb.Domain, tpo)
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+			lbNames = append(lbNames, lbName)
+
+			// All API records must fit in the API hosted zone.
+			hzName, err := hostedZoneName(lb.Domain)
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+			assert.Equal(t, "example.com", hzName, fmt.Sprintf("[%s] Load balancer '%s' is not in the API hosted zone", tc.desc, lb.Domain))
+		}
+		assert.Equal(t, tc.resLBNames, lbNames, fmt.Sprintf("[%s] The input values didn't produce the expected load balancer names", tc.desc))
+	}
+}
+
+func TestAPIHealthCheck(t *testing.T) {
+	tpo := awstpr.CustomObject{
+		Spec: awstpr.Spec{
+			Cluster: clustertpr.Cluster{
+				Kubernetes: kubernetes.Kubernetes{
+					API: api.API{
+						SecurePort: 6443,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc string
+		path string
+		res  awsresources.HealthCheck
+	}{
+		{
+			desc: "default path",
+			path: DefaultConfig().APIHealthCheckPath,
+			res:  awsresources.HealthCheck{Protocol: "HTTPS", Port: 6443, Path: "/healthz"},
+		},
+		{
+			desc: "readiness endpoint",
+			path: "/readyz",
+			res:  awsresources.HealthCheck{Protocol: "HTTPS", Port: 6443, Path: "/readyz"},
+		},
+	}
+
+	for _, tc := range tests {
+		s := &Service{apiHealthCheckPath: tc.path}
+
+		assert.Equal(t, tc.res, s.apiHealthCheck(tpo), fmt.Sprintf("[%s] The input values didn't produce the expected health check", tc.desc))
+	}
+}
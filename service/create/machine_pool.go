@@ -0,0 +1,76 @@
+package create
+
+import (
+	"sync"
+
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// launchFunc launches the machine with the given index. It returns whether the
+// machine's instance was created, and the ID of the instance.
+type launchFunc func(index int) (bool, string, error)
+
+// launchMachines launches the given number of machines, up to concurrency at
+// once. The instance IDs are returned in the order of the machine indexes,
+// regardless of the order the launches finish in. It returns whether any
+// instance was created, and the error of the failed launch with the lowest
+// index. Launches which haven't started yet are skipped once one fails.
+func launchMachines(count, concurrency int, launch launchFunc) (bool, []string, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		created     = make([]bool, count)
+		instanceIDs = make([]string, count)
+		errs        = make([]error, count)
+
+		mutex  sync.Mutex
+		failed bool
+	)
+
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				mutex.Lock()
+				skip := failed
+				mutex.Unlock()
+				if skip {
+					continue
+				}
+
+				// Every launch writes its own elements only, so the results
+				// don't need to be locked.
+				created[index], instanceIDs[index], errs[index] = launch(index)
+				if errs[index] != nil {
+					mutex.Lock()
+					failed = true
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	for index := 0; index < count; index++ {
+		queue <- index
+	}
+	close(queue)
+	wg.Wait()
+
+	var anyCreated bool
+	for index := 0; index < count; index++ {
+		if errs[index] != nil {
+			return false, nil, microerror.MaskAny(errs[index])
+		}
+		if created[index] {
+			anyCreated = true
+		}
+	}
+
+	return anyCreated, instanceIDs, nil
+}
@@ -0,0 +1,134 @@
+package create
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaunchMachines(t *testing.T) {
+	tests := []struct {
+		desc        string
+		count       int
+		concurrency int
+		// existing are the indexes of the machines whose instances exist.
+		existing []int
+		// failing are the indexes of the machines failing to launch.
+		failing     []int
+		resCreated  bool
+		resIDs      []string
+		resError    string
+		resLaunches int
+	}{
+		{
+			desc:        "sequential launches",
+			count:       3,
+			concurrency: 1,
+			resCreated:  true,
+			resIDs:      []string{"i-0", "i-1", "i-2"},
+			resLaunches: 3,
+		},
+		{
+			desc:        "concurrent launches keep the order of the machines",
+			count:       8,
+			concurrency: 4,
+			resCreated:  true,
+			resIDs:      []string{"i-0", "i-1", "i-2", "i-3", "i-4", "i-5", "i-6", "i-7"},
+			resLaunches: 8,
+		},
+		{
+			desc:        "some instances exist",
+			count:       4,
+			concurrency: 4,
+			existing:    []int{0, 2},
+			resCreated:  true,
+			resIDs:      []string{"i-0", "i-1", "i-2", "i-3"},
+			resLaunches: 4,
+		},
+		{
+			desc:        "all instances exist",
+			count:       4,
+			concurrency: 4,
+			existing:    []int{0, 1, 2, 3},
+			resCreated:  false,
+			resIDs:      []string{"i-0", "i-1", "i-2", "i-3"},
+			resLaunches: 4,
+		},
+		{
+			desc:        "failed concurrent launch",
+			count:       4,
+			concurrency: 4,
+			failing:     []int{1},
+			resError:    "launch 1 failed",
+			resLaunches: 4,
+		},
+		{
+			desc:        "launches are skipped after a failure",
+			count:       3,
+			concurrency: 1,
+			failing:     []int{0},
+			resError:    "launch 0 failed",
+			resLaunches: 1,
+		},
+		{
+			desc:        "no machines",
+			count:       0,
+			concurrency: 4,
+			resIDs:      []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		existing := make(map[int]bool)
+		for _, index := range tc.existing {
+			existing[index] = true
+		}
+		failing := make(map[int]bool)
+		for _, index := range tc.failing {
+			failing[index] = true
+		}
+
+		var mutex sync.Mutex
+		var running, maxRunning, launches int
+		launch := func(index int) (bool, string, error) {
+			mutex.Lock()
+			launches++
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			// Later machines finish first.
+			time.Sleep(time.Duration(tc.count-index) * 5 * time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+
+			if failing[index] {
+				return false, "", fmt.Errorf("launch %d failed", index)
+			}
+			return !existing[index], fmt.Sprintf("i-%d", index), nil
+		}
+
+		created, ids, err := launchMachines(tc.count, tc.concurrency, launch)
+		assert.True(t, maxRunning <= tc.concurrency, fmt.Sprintf("[%s] %d launches ran at once", tc.desc, maxRunning))
+		assert.Equal(t, tc.resLaunches, launches, fmt.Sprintf("[%s] Wrong number of launches", tc.desc))
+		if tc.resError != "" {
+			assert.NotNil(t, err, fmt.Sprintf("[%s] Expected an error", tc.desc))
+			if err != nil {
+				assert.Equal(t, tc.resError, err.Error(), fmt.Sprintf("[%s] Wrong error", tc.desc))
+			}
+			assert.Nil(t, ids, fmt.Sprintf("[%s] Unexpected instance IDs", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resCreated, created, fmt.Sprintf("[%s] Wrong created flag", tc.desc))
+		assert.Equal(t, tc.resIDs, ids, fmt.Sprintf("[%s] Wrong instance IDs", tc.desc))
+	}
+}
@@ -0,0 +1,53 @@
+package create
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// mastersReadyTimeout is the maximum time to wait for the masters to pass the
+// health check of the API load balancer.
+const mastersReadyTimeout = 15 * time.Minute
+
+// instanceHealthChecker reports the instances not passing the health check of
+// a load balancer, e.g. *awsresources.ELB.
+type instanceHealthChecker interface {
+	InstancesNotInService(instanceIDs []string) ([]string, error)
+}
+
+// waitForMastersInService blocks until all the masters are InService behind the API
+// load balancer, i.e. until the API is reachable for the workers joining the
+// cluster.
+func (s *Service) waitForMastersInService(apiLB instanceHealthChecker, masterIDs []string, b backoff.BackOff) error {
+	waitOperation := func() error {
+		notInService, err := apiLB.InstancesNotInService(masterIDs)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		if len(notInService) > 0 {
+			return microerror.MaskAnyf(mastersNotReadyError, "masters %v are not in service", notInService)
+		}
+		return nil
+	}
+	waitNotify := awsresources.NewNotify(s.logger, "waiting for masters")
+	if err := backoff.RetryNotify(waitOperation, b, waitNotify); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	s.logger.Log("info", fmt.Sprintf("masters %v are in service", masterIDs))
+
+	return nil
+}
+
+func newMastersReadyBackoff() backoff.BackOff {
+	b := awsresources.NewCustomExponentialBackoff()
+	b.MaxElapsedTime = mastersReadyTimeout
+	b.Reset()
+
+	return b
+}
@@ -0,0 +1,102 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// limitedBackOff retries immediately, until the given number of retries is
+// exhausted.
+type limitedBackOff struct {
+	retries int
+}
+
+func (b *limitedBackOff) Reset() {}
+
+func (b *limitedBackOff) NextBackOff() time.Duration {
+	if b.retries == 0 {
+		return backoff.Stop
+	}
+	b.retries--
+	return 0
+}
+
+// fakeHealthChecker reports the masters as not in service for the given
+// number of checks, and records the events of the checks.
+type fakeHealthChecker struct {
+	outOfServiceChecks int
+	events             *[]string
+}
+
+func (f *fakeHealthChecker) InstancesNotInService(instanceIDs []string) ([]string, error) {
+	if f.outOfServiceChecks > 0 {
+		f.outOfServiceChecks--
+		*f.events = append(*f.events, "masters out of service")
+		return instanceIDs[1:], nil
+	}
+
+	*f.events = append(*f.events, "masters in service")
+	return nil, nil
+}
+
+func TestWaitForMastersInService(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+	s := &Service{logger: logger}
+
+	tests := []struct {
+		desc               string
+		outOfServiceChecks int
+		retries            int
+		events             []string
+		errorMatcher       func(error) bool
+	}{
+		{
+			desc:               "workers are created once the masters are in service",
+			outOfServiceChecks: 2,
+			retries:            5,
+			events: []string{
+				"masters out of service",
+				"masters out of service",
+				"masters in service",
+				"workers created",
+			},
+		},
+		{
+			desc:               "workers are not created when the masters never get in service",
+			outOfServiceChecks: 10,
+			retries:            2,
+			events: []string{
+				"masters out of service",
+				"masters out of service",
+				"masters out of service",
+			},
+			errorMatcher: IsMastersNotReady,
+		},
+	}
+
+	for _, tc := range tests {
+		var events []string
+		checker := &fakeHealthChecker{
+			outOfServiceChecks: tc.outOfServiceChecks,
+			events:             &events,
+		}
+
+		err := s.waitForMastersInService(checker, []string{"i-1", "i-2", "i-3"}, &limitedBackOff{retries: tc.retries})
+		if err == nil {
+			events = append(events, "workers created")
+		}
+
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.events, events, fmt.Sprintf("[%s] Unexpected order of events", tc.desc))
+	}
+}
@@ -0,0 +1,111 @@
+package create
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// metricsNamespace prefixes the names of the metrics of the operator. They
+	// are exposed on the /metrics endpoint of the server.
+	metricsNamespace = "aws_operator"
+
+	// Results of cluster creations, see clusterCreateTotal. A cluster is
+	// created when machines were launched for it, and reused when they all
+	// existed already.
+	clusterCreated = "created"
+	clusterReused  = "reused"
+	clusterFailed  = "failed"
+	// clusterDeleted is the result of successful cluster deletions, see
+	// clusterDeleteTotal.
+	clusterDeleted = "deleted"
+
+	// Operations of resource errors, see resourceErrorsTotal.
+	operationCreate = "create"
+	operationDelete = "delete"
+)
+
+var (
+	clusterCreateTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cluster_create_total",
+			Help:      "Number of cluster creations, by result.",
+		},
+		[]string{"result"},
+	)
+	clusterDeleteTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "cluster_delete_total",
+			Help:      "Number of cluster deletions, by result.",
+		},
+		[]string{"result"},
+	)
+	resourceErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "resource_errors_total",
+			Help:      "Number of failed creations and deletions of the resources of clusters.",
+		},
+		[]string{"operation", "resource"},
+	)
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Time taken to reconcile a cluster, by event.",
+			// Reconciles take from seconds, when all the resources exist, to
+			// many minutes, when instances are launched and waited for.
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800},
+		},
+		[]string{"event"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(clusterCreateTotal)
+	prometheus.MustRegister(clusterDeleteTotal)
+	prometheus.MustRegister(resourceErrorsTotal)
+	prometheus.MustRegister(reconcileDuration)
+}
+
+// countResourceError counts a failed operation on a resource of a cluster.
+func countResourceError(operation, resource string) {
+	resourceErrorsTotal.WithLabelValues(operation, resource).Inc()
+}
+
+// instrumentReconciles wraps the given cluster handlers, so the durations of
+// their reconciles are observed.
+func instrumentReconciles(handler cache.ResourceEventHandlerFuncs) cache.ResourceEventHandlerFuncs {
+	instrumented := cache.ResourceEventHandlerFuncs{}
+
+	if handler.AddFunc != nil {
+		instrumented.AddFunc = func(obj interface{}) {
+			defer observeReconcile("add", time.Now())
+			handler.AddFunc(obj)
+		}
+	}
+	if handler.UpdateFunc != nil {
+		instrumented.UpdateFunc = func(oldObj, newObj interface{}) {
+			defer observeReconcile("update", time.Now())
+			handler.UpdateFunc(oldObj, newObj)
+		}
+	}
+	if handler.DeleteFunc != nil {
+		instrumented.DeleteFunc = func(obj interface{}) {
+			defer observeReconcile("delete", time.Now())
+			handler.DeleteFunc(obj)
+		}
+	}
+
+	return instrumented
+}
+
+// observeReconcile observes the duration of a reconcile of the given event
+// started at the given time.
+func observeReconcile(event string, start time.Time) {
+	reconcileDuration.WithLabelValues(event).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,182 @@
+package create
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	m := &dto.Metric{}
+	assert.Nil(t, counter.Write(m), "Unexpected error reading the counter")
+	return m.GetCounter().GetValue()
+}
+
+func TestAddClusterCountsFailures(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	s := &Service{logger: logger}
+
+	tests := []struct {
+		desc   string
+		region string
+	}{
+		{
+			desc:   "no region",
+			region: "",
+		},
+		{
+			desc:   "invalid spec",
+			region: "eu-central-1",
+		},
+	}
+
+	for _, tc := range tests {
+		failed := counterValue(t, clusterCreateTotal.WithLabelValues(clusterFailed))
+		created := counterValue(t, clusterCreateTotal.WithLabelValues(clusterCreated))
+
+		cluster := &awstpr.CustomObject{}
+		cluster.Name = "abc12"
+		cluster.Spec.AWS.Region = tc.region
+		s.addCluster(cluster)
+
+		assert.Equal(t, failed+1, counterValue(t, clusterCreateTotal.WithLabelValues(clusterFailed)), fmt.Sprintf("[%s] Failure not counted", tc.desc))
+		assert.Equal(t, created, counterValue(t, clusterCreateTotal.WithLabelValues(clusterCreated)), fmt.Sprintf("[%s] Failure counted as creation", tc.desc))
+	}
+}
+
+func TestRunTeardownCountsResourceErrors(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	vpcErrors := counterValue(t, resourceErrorsTotal.WithLabelValues(operationDelete, "vpc"))
+	mastersErrors := counterValue(t, resourceErrorsTotal.WithLabelValues(operationDelete, "masters"))
+
+	steps := []teardownStep{
+		{
+			name:   "masters",
+			delete: func() error { return nil },
+		},
+		{
+			name:   "vpc",
+			delete: func() error { return fmt.Errorf("vpc is stuck") },
+		},
+	}
+	err = runTeardown(logger, steps)
+	assert.True(t, IsTeardownFailed(err), fmt.Sprintf("Unexpected error: %v", err))
+
+	assert.Equal(t, vpcErrors+1, counterValue(t, resourceErrorsTotal.WithLabelValues(operationDelete, "vpc")), "Failed step not counted")
+	assert.Equal(t, mastersErrors, counterValue(t, resourceErrorsTotal.WithLabelValues(operationDelete, "masters")), "Successful step counted")
+}
+
+func TestTeardownClusterCountsDeletions(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(unversioned.Status{
+			TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   unversioned.StatusSuccess,
+			Code:     http.StatusOK,
+		})
+	}))
+	defer server.Close()
+
+	k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	assert.Nil(t, err, "Unexpected error creating the client")
+	s := &Service{
+		k8sClient: k8sClient,
+		logger:    logger,
+	}
+
+	tests := []struct {
+		desc    string
+		failing bool
+		result  string
+	}{
+		{
+			desc:   "successful deletion",
+			result: clusterDeleted,
+		},
+		{
+			desc:    "failed deletion",
+			failing: true,
+			result:  clusterFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		deleted := counterValue(t, clusterDeleteTotal.WithLabelValues(clusterDeleted))
+		failed := counterValue(t, clusterDeleteTotal.WithLabelValues(clusterFailed))
+
+		steps := []teardownStep{
+			{
+				name: "vpc",
+				delete: func() error {
+					if tc.failing {
+						return fmt.Errorf("vpc is stuck")
+					}
+					return nil
+				},
+			},
+		}
+
+		var cluster awstpr.CustomObject
+		cluster.Name = "abc12"
+		cluster.Spec.Cluster.Cluster.ID = "abc12"
+		s.teardownCluster(cluster, steps, &backoff.StopBackOff{})
+
+		if tc.result == clusterDeleted {
+			deleted++
+		} else {
+			failed++
+		}
+		assert.Equal(t, deleted, counterValue(t, clusterDeleteTotal.WithLabelValues(clusterDeleted)), fmt.Sprintf("[%s] Wrong count of deleted clusters", tc.desc))
+		assert.Equal(t, failed, counterValue(t, clusterDeleteTotal.WithLabelValues(clusterFailed)), fmt.Sprintf("[%s] Wrong count of failed deletions", tc.desc))
+	}
+}
+
+func TestInstrumentReconciles(t *testing.T) {
+	var handled []string
+	handler := instrumentReconciles(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			handled = append(handled, "add")
+		},
+		DeleteFunc: func(obj interface{}) {
+			handled = append(handled, "delete")
+		},
+	})
+
+	sampleCount := func(event string) uint64 {
+		m := &dto.Metric{}
+		err := reconcileDuration.WithLabelValues(event).(prometheus.Histogram).Write(m)
+		assert.Nil(t, err, "Unexpected error reading the histogram")
+		return m.GetHistogram().GetSampleCount()
+	}
+
+	adds := sampleCount("add")
+	deletes := sampleCount("delete")
+
+	handler.AddFunc(&awstpr.CustomObject{})
+	handler.DeleteFunc(&awstpr.CustomObject{})
+
+	assert.Equal(t, []string{"add", "delete"}, handled, "Handlers not called")
+	assert.Nil(t, handler.UpdateFunc, "Unexpected update handler")
+	assert.Equal(t, adds+1, sampleCount("add"), "Add reconcile not observed")
+	assert.Equal(t, deletes+1, sampleCount("delete"), "Delete reconcile not observed")
+}
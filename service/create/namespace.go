@@ -26,9 +26,21 @@ func (s *Service) createClusterNamespace(cluster clustertpr.Cluster) error {
 	if _, err := s.k8sClient.Core().Namespaces().Create(&namespace); err != nil && !errors.IsAlreadyExists(err) {
 		return microerror.MaskAny(err)
 	}
+
+	if s.networkPolicies {
+		if err := s.reconcileNetworkPolicies(namespace.Name); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
 	return nil
 }
 
 func (s *Service) deleteClusterNamespace(cluster clustertpr.Cluster) error {
-	return s.k8sClient.Core().Namespaces().Delete(cluster.Cluster.ID, v1.NewDeleteOptions(0))
+	err := s.k8sClient.Core().Namespaces().Delete(cluster.Cluster.ID, v1.NewDeleteOptions(0))
+	if err != nil && !errors.IsNotFound(err) {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
 }
@@ -0,0 +1,150 @@
+package create
+
+import (
+	"reflect"
+
+	microerror "github.com/giantswarm/microkit/error"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+)
+
+const (
+	// networkPolicyAnnotationKey is the annotation of namespaces configuring
+	// their network isolation. The beta NetworkPolicy API only allows traffic,
+	// pods are isolated through this annotation.
+	networkPolicyAnnotationKey = "net.beta.kubernetes.io/network-policy"
+	// networkPolicyDefaultDeny is the value of networkPolicyAnnotationKey
+	// denying all ingress traffic not allowed by a NetworkPolicy.
+	networkPolicyDefaultDeny = `{"ingress":{"isolation":"DefaultDeny"}}`
+	// networkPoliciesResource is the resource of NetworkPolicies, which have no
+	// typed client.
+	networkPoliciesResource = "networkpolicies"
+)
+
+// clusterNetworkPolicies returns the NetworkPolicies of a cluster namespace.
+// All ingress traffic is denied, but the one between the pods of the
+// namespace.
+func clusterNetworkPolicies(namespace string) []v1beta1.NetworkPolicy {
+	return []v1beta1.NetworkPolicy{
+		{
+			TypeMeta: unversioned.TypeMeta{
+				Kind:       "NetworkPolicy",
+				APIVersion: "extensions/v1beta1",
+			},
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "default-deny",
+				Namespace: namespace,
+			},
+			Spec: v1beta1.NetworkPolicySpec{
+				// Selects all the pods of the namespace, and allows nothing.
+				PodSelector: unversioned.LabelSelector{},
+			},
+		},
+		{
+			TypeMeta: unversioned.TypeMeta{
+				Kind:       "NetworkPolicy",
+				APIVersion: "extensions/v1beta1",
+			},
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "allow-same-namespace",
+				Namespace: namespace,
+			},
+			Spec: v1beta1.NetworkPolicySpec{
+				PodSelector: unversioned.LabelSelector{},
+				Ingress: []v1beta1.NetworkPolicyIngressRule{
+					{
+						From: []v1beta1.NetworkPolicyPeer{
+							{
+								PodSelector: &unversioned.LabelSelector{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// reconcileNetworkPolicies makes the cluster namespace deny ingress traffic
+// from other namespaces. The namespace is isolated, and its NetworkPolicies
+// are created, or updated when they differ.
+func (s *Service) reconcileNetworkPolicies(namespace string) error {
+	if err := s.isolateNamespace(namespace); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	for _, policy := range clusterNetworkPolicies(namespace) {
+		if err := s.reconcileNetworkPolicy(policy); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) isolateNamespace(name string) error {
+	namespace, err := s.k8sClient.Core().Namespaces().Get(name)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if namespace.Annotations[networkPolicyAnnotationKey] == networkPolicyDefaultDeny {
+		return nil
+	}
+
+	if namespace.Annotations == nil {
+		namespace.Annotations = map[string]string{}
+	}
+	namespace.Annotations[networkPolicyAnnotationKey] = networkPolicyDefaultDeny
+
+	if _, err := s.k8sClient.Core().Namespaces().Update(namespace); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+func (s *Service) reconcileNetworkPolicy(policy v1beta1.NetworkPolicy) error {
+	client := s.k8sClient.Extensions().RESTClient()
+
+	err := client.Post().
+		Namespace(policy.Namespace).
+		Resource(networkPoliciesResource).
+		Body(&policy).
+		Do().
+		Error()
+	if err == nil {
+		return nil
+	} else if !errors.IsAlreadyExists(err) {
+		return microerror.MaskAny(err)
+	}
+
+	var existing v1beta1.NetworkPolicy
+	if err := client.Get().
+		Namespace(policy.Namespace).
+		Resource(networkPoliciesResource).
+		Name(policy.Name).
+		Do().
+		Into(&existing); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	if reflect.DeepEqual(existing.Spec, policy.Spec) {
+		return nil
+	}
+
+	policy.ResourceVersion = existing.ResourceVersion
+	if err := client.Put().
+		Namespace(policy.Namespace).
+		Resource(networkPoliciesResource).
+		Name(policy.Name).
+		Body(&policy).
+		Do().
+		Error(); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
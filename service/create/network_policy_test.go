@@ -0,0 +1,176 @@
+package create
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/apis/extensions/v1beta1"
+	"k8s.io/client-go/rest"
+)
+
+// fakeNetworkPolicyAPI serves the namespaces and NetworkPolicies of a
+// Kubernetes API, and records the requests it gets.
+type fakeNetworkPolicyAPI struct {
+	mutex      sync.Mutex
+	namespaces map[string]*v1.Namespace
+	policies   map[string]*v1beta1.NetworkPolicy
+	requests   []string
+}
+
+func (f *fakeNetworkPolicyAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.requests = append(f.requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+	write := func(code int, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(obj)
+	}
+	status := func(code int, reason unversioned.StatusReason) {
+		write(code, unversioned.Status{
+			TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+			Status:   unversioned.StatusFailure,
+			Reason:   reason,
+			Code:     int32(code),
+		})
+	}
+
+	const namespacesPath = "/api/v1/namespaces/"
+	const policiesPath = "/apis/extensions/v1beta1/namespaces/default/networkpolicies"
+
+	switch {
+	case strings.HasPrefix(r.URL.Path, namespacesPath):
+		name := strings.TrimPrefix(r.URL.Path, namespacesPath)
+		namespace, ok := f.namespaces[name]
+		if !ok {
+			status(http.StatusNotFound, unversioned.StatusReasonNotFound)
+			return
+		}
+		if r.Method == "PUT" {
+			namespace = &v1.Namespace{}
+			json.NewDecoder(r.Body).Decode(namespace)
+			f.namespaces[name] = namespace
+		}
+		namespace.Kind = "Namespace"
+		namespace.APIVersion = "v1"
+		write(http.StatusOK, namespace)
+	case r.URL.Path == policiesPath && r.Method == "POST":
+		policy := &v1beta1.NetworkPolicy{}
+		json.NewDecoder(r.Body).Decode(policy)
+		if _, ok := f.policies[policy.Name]; ok {
+			status(http.StatusConflict, unversioned.StatusReasonAlreadyExists)
+			return
+		}
+		f.policies[policy.Name] = policy
+		write(http.StatusCreated, policy)
+	case strings.HasPrefix(r.URL.Path, policiesPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, policiesPath+"/")
+		policy, ok := f.policies[name]
+		if !ok {
+			status(http.StatusNotFound, unversioned.StatusReasonNotFound)
+			return
+		}
+		if r.Method == "PUT" {
+			policy = &v1beta1.NetworkPolicy{}
+			json.NewDecoder(r.Body).Decode(policy)
+			f.policies[name] = policy
+		}
+		policy.Kind = "NetworkPolicy"
+		policy.APIVersion = "extensions/v1beta1"
+		write(http.StatusOK, policy)
+	default:
+		status(http.StatusNotFound, unversioned.StatusReasonNotFound)
+	}
+}
+
+func TestReconcileNetworkPolicies(t *testing.T) {
+	// outdatedPolicies returns the policies of the namespace, with an
+	// allow-same-namespace policy allowing traffic from everywhere.
+	outdatedPolicies := func() map[string]*v1beta1.NetworkPolicy {
+		policies := map[string]*v1beta1.NetworkPolicy{}
+		for _, policy := range clusterNetworkPolicies("default") {
+			policy := policy
+			policies[policy.Name] = &policy
+		}
+		policies["allow-same-namespace"].Spec.Ingress = []v1beta1.NetworkPolicyIngressRule{{}}
+		return policies
+	}
+
+	tests := []struct {
+		desc        string
+		annotations map[string]string
+		policies    map[string]*v1beta1.NetworkPolicy
+		requests    []string
+	}{
+		{
+			desc: "namespace is isolated and policies are created",
+			requests: []string{
+				"GET /api/v1/namespaces/default",
+				"PUT /api/v1/namespaces/default",
+				"POST /apis/extensions/v1beta1/namespaces/default/networkpolicies",
+				"POST /apis/extensions/v1beta1/namespaces/default/networkpolicies",
+			},
+		},
+		{
+			desc:        "outdated policy is updated",
+			annotations: map[string]string{networkPolicyAnnotationKey: networkPolicyDefaultDeny},
+			policies:    outdatedPolicies(),
+			requests: []string{
+				"GET /api/v1/namespaces/default",
+				"POST /apis/extensions/v1beta1/namespaces/default/networkpolicies",
+				"GET /apis/extensions/v1beta1/namespaces/default/networkpolicies/default-deny",
+				"POST /apis/extensions/v1beta1/namespaces/default/networkpolicies",
+				"GET /apis/extensions/v1beta1/namespaces/default/networkpolicies/allow-same-namespace",
+				"PUT /apis/extensions/v1beta1/namespaces/default/networkpolicies/allow-same-namespace",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		if tc.policies == nil {
+			tc.policies = map[string]*v1beta1.NetworkPolicy{}
+		}
+		api := &fakeNetworkPolicyAPI{
+			namespaces: map[string]*v1.Namespace{
+				"default": {ObjectMeta: v1.ObjectMeta{Name: "default", Annotations: tc.annotations}},
+			},
+			policies: tc.policies,
+		}
+		server := httptest.NewServer(api)
+
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+		s := &Service{k8sClient: k8sClient}
+
+		err = s.reconcileNetworkPolicies("default")
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.requests, api.requests, fmt.Sprintf("[%s] Unexpected requests", tc.desc))
+
+		// The policies and the isolation are in place.
+		assert.Equal(t, networkPolicyDefaultDeny, api.namespaces["default"].Annotations[networkPolicyAnnotationKey], fmt.Sprintf("[%s] The namespace isn't isolated", tc.desc))
+		for _, policy := range clusterNetworkPolicies("default") {
+			assert.Equal(t, policy.Spec, api.policies[policy.Name].Spec, fmt.Sprintf("[%s] Policy '%s' wasn't reconciled", tc.desc, policy.Name))
+		}
+
+		// Reconciling again changes nothing.
+		api.requests = nil
+		err = s.reconcileNetworkPolicies("default")
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error reconciling again", tc.desc))
+		for _, request := range api.requests {
+			assert.False(t, strings.HasPrefix(request, "PUT"), fmt.Sprintf("[%s] Reconciling again must not update anything, got '%s'", tc.desc, request))
+		}
+
+		server.Close()
+	}
+}
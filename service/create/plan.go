@@ -0,0 +1,303 @@
+package create
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+const (
+	// ActionCreate plans the creation of a missing resource.
+	ActionCreate = "create"
+	// ActionUpdate plans the update of a resource differing from the spec.
+	ActionUpdate = "update"
+	// ActionDelete plans the deletion of a resource not in the spec anymore.
+	ActionDelete = "delete"
+)
+
+// PlannedAction is a change reconciling a cluster would make to one of its AWS
+// resources.
+type PlannedAction struct {
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	// Reasons explain how an updated resource differs from the spec.
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// planStep is the check of a single resource of a cluster for a plan.
+type planStep struct {
+	resource string
+	name     string
+	// check returns whether the resource exists and how it differs from the
+	// spec.
+	check func() (bool, []string, error)
+}
+
+// resourceCheck turns the lookup of a resource, which fails with a not found
+// error when the resource doesn't exist, into the check of a plan step.
+func resourceCheck(drift func() ([]string, error)) func() (bool, []string, error) {
+	return func() (bool, []string, error) {
+		reasons, err := drift()
+		if awsresources.IsNotFound(err) {
+			return false, nil, nil
+		} else if err != nil {
+			return false, nil, microerror.MaskAny(err)
+		}
+
+		return true, reasons, nil
+	}
+}
+
+// Plan returns the changes reconciling the given cluster would make to its
+// AWS resources, without making them. It compares the spec with the network,
+// the load balancers and the instances of the cluster found in AWS, so the
+// changes of a spec can be reviewed before applying it.
+func (s *Service) Plan(cluster awstpr.CustomObject) ([]PlannedAction, error) {
+	region, err := clusterRegion(cluster.Spec, s.defaultRegion)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+	cluster.Spec.AWS.Region = region
+
+	// Plans run concurrently with the reconciliation of clusters, so they work
+	// on a copy of the shared config.
+	awsConfig := s.awsConfig
+	awsConfig.Region = region
+	clients := awsutil.NewClients(awsConfig)
+
+	steps, err := s.planSteps(cluster, clients)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+	actions, err := runPlan(steps)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	for _, prefix := range []string{prefixMaster, prefixWorker} {
+		instances, err := awsresources.FindInstances(awsresources.FindInstancesInput{
+			Clients:    clients,
+			Logger:     s.logger,
+			OperatorID: s.operatorID,
+			Pattern: clusterPrefix(clusterPrefixInput{
+				resourcePrefix: s.resourcePrefix,
+				clusterName:    cluster.Name,
+				prefix:         prefix,
+			}),
+		})
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+
+		var existing []string
+		for _, instance := range instances {
+			existing = append(existing, instance.Name)
+		}
+
+		desired := len(cluster.Spec.Cluster.Workers)
+		if prefix == prefixMaster {
+			desired = len(cluster.Spec.Cluster.Masters)
+		}
+
+		actions = append(actions, planInstances(s.resourcePrefix, cluster.Name, prefix, desired, existing)...)
+	}
+
+	return actions, nil
+}
+
+// planSteps returns the checks of the resources of the cluster, in the order
+// they are created.
+func (s *Service) planSteps(cluster awstpr.CustomObject, clients awsutil.Clients) ([]planStep, error) {
+	vpc := &awsresources.VPC{
+		CidrBlock:  cluster.Spec.AWS.VPC.CIDR,
+		ExistingID: sharedVPCID(cluster),
+		Name:       cluster.Name,
+		AWSEntity:  s.awsEntity(clients),
+	}
+	gateway := &awsresources.Gateway{
+		Name:      cluster.Name,
+		AWSEntity: s.awsEntity(clients),
+	}
+	routeTable := &awsresources.RouteTable{
+		Name:       cluster.Name,
+		Client:     clients.EC2,
+		OperatorID: s.operatorID,
+		ClusterID:  scopedClusterID(cluster),
+	}
+	publicSubnet := &awsresources.Subnet{
+		AvailabilityZone: cluster.Spec.AWS.AZ,
+		CidrBlock:        cluster.Spec.AWS.VPC.PublicSubnetCIDR,
+		Name:             subnetName(cluster, suffixPublic),
+		AWSEntity:        s.clusterAWSEntity(clients, cluster),
+	}
+
+	steps := []planStep{
+		{
+			resource: string(awsresources.VPCType),
+			name:     vpc.Name,
+			check:    resourceCheck(vpc.Drift),
+		},
+	}
+	// Shared VPCs come with their gateway.
+	if sharedVPCID(cluster) == "" {
+		steps = append(steps, planStep{
+			resource: string(awsresources.GatewayType),
+			name:     gateway.Name,
+			check: resourceCheck(func() ([]string, error) {
+				_, err := gateway.GetID()
+				return nil, err
+			}),
+		})
+	}
+
+	for _, prefix := range []string{prefixMaster, prefixWorker, prefixIngress} {
+		entity := s.awsEntity(clients)
+		entity.ClusterID = scopedClusterID(cluster)
+		securityGroup := &awsresources.SecurityGroup{
+			Description: securityGroupName(cluster.Name, prefix),
+			GroupName:   securityGroupName(cluster.Name, prefix),
+			AWSEntity:   entity,
+		}
+		steps = append(steps, planStep{
+			resource: string(awsresources.SecurityGroupType),
+			name:     securityGroup.GroupName,
+			check: resourceCheck(func() ([]string, error) {
+				_, err := securityGroup.GetID()
+				return nil, err
+			}),
+		})
+	}
+
+	steps = append(steps,
+		planStep{
+			resource: string(awsresources.RouteTableType),
+			name:     routeTable.Name,
+			check: resourceCheck(func() ([]string, error) {
+				// The VPC might have been reused, so its route table is looked up
+				// within the VPC when it exists.
+				vpcID, err := vpc.GetID()
+				if err != nil && !awsresources.IsNotFound(err) {
+					return nil, microerror.MaskAny(err)
+				}
+				routeTable.VpcID = vpcID
+
+				return routeTable.Drift()
+			}),
+		},
+		planStep{
+			resource: string(awsresources.SubnetType),
+			name:     publicSubnet.Name,
+			check:    resourceCheck(publicSubnet.Drift),
+		},
+	)
+
+	for _, domain := range s.clusterLoadBalancerDomains(cluster) {
+		lbName, err := s.loadBalancerName(domain, cluster)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+
+		lb := &awsresources.ELB{
+			Name:   lbName,
+			Client: clients.ELB,
+		}
+		steps = append(steps, planStep{
+			resource: string(awsresources.ELBType),
+			name:     lb.Name,
+			check: resourceCheck(func() ([]string, error) {
+				return nil, lb.Get()
+			}),
+		})
+	}
+
+	return steps, nil
+}
+
+// runPlan runs the checks of the given steps and returns the actions planned
+// for the missing and drifted resources. Resources in sync need no action.
+func runPlan(steps []planStep) ([]PlannedAction, error) {
+	var actions []PlannedAction
+
+	for _, step := range steps {
+		exists, drift, err := step.check()
+		if err != nil {
+			return nil, microerror.MaskAnyf(err, "could not check %s '%s'", step.resource, step.name)
+		}
+
+		if !exists {
+			actions = append(actions, PlannedAction{
+				Action:   ActionCreate,
+				Resource: step.resource,
+				Name:     step.name,
+			})
+			continue
+		}
+
+		if len(drift) > 0 {
+			actions = append(actions, PlannedAction{
+				Action:   ActionUpdate,
+				Resource: step.resource,
+				Name:     step.name,
+				Reasons:  drift,
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+// planInstances returns the actions planned for the instances of the cluster
+// with the given prefix. Instances missing from the given existing ones are
+// created, the ones beyond the desired count are deleted.
+func planInstances(resourcePrefix, clusterName, prefix string, desired int, existing []string) []PlannedAction {
+	var actions []PlannedAction
+
+	found := make(map[string]bool)
+	for _, name := range existing {
+		found[name] = true
+	}
+
+	wanted := make(map[string]bool)
+	for i := 0; i < desired; i++ {
+		name := instanceName(instanceNameInput{
+			resourcePrefix: resourcePrefix,
+			clusterName:    clusterName,
+			prefix:         prefix,
+			no:             i,
+		})
+		wanted[name] = true
+
+		if !found[name] {
+			actions = append(actions, PlannedAction{
+				Action:   ActionCreate,
+				Resource: string(awsresources.InstanceType),
+				Name:     name,
+			})
+		}
+	}
+
+	var surplus []string
+	for name := range found {
+		if !wanted[name] {
+			surplus = append(surplus, name)
+		}
+	}
+	sort.Strings(surplus)
+
+	for _, name := range surplus {
+		actions = append(actions, PlannedAction{
+			Action:   ActionDelete,
+			Resource: string(awsresources.InstanceType),
+			Name:     name,
+			Reasons:  []string{fmt.Sprintf("the cluster spec has %d %s instances", desired, prefix)},
+		})
+	}
+
+	return actions
+}
@@ -0,0 +1,110 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPlan(t *testing.T) {
+	check := func(exists bool, drift []string, err error) func() (bool, []string, error) {
+		return func() (bool, []string, error) {
+			return exists, drift, err
+		}
+	}
+
+	tests := []struct {
+		desc       string
+		steps      []planStep
+		resActions []PlannedAction
+		resErr     bool
+	}{
+		{
+			desc: "resources in sync need no action",
+			steps: []planStep{
+				{resource: "vpc", name: "foo", check: check(true, nil, nil)},
+				{resource: "gateway", name: "foo", check: check(true, nil, nil)},
+			},
+			resActions: nil,
+		},
+		{
+			desc: "missing and drifted resources are planned",
+			steps: []planStep{
+				{resource: "vpc", name: "foo", check: check(true, []string{"CIDR block is '10.0.0.0/16', want '10.1.0.0/16'"}, nil)},
+				{resource: "gateway", name: "foo", check: check(false, nil, nil)},
+				{resource: "subnet", name: "foo-public", check: check(true, nil, nil)},
+			},
+			resActions: []PlannedAction{
+				{
+					Action:   ActionUpdate,
+					Resource: "vpc",
+					Name:     "foo",
+					Reasons:  []string{"CIDR block is '10.0.0.0/16', want '10.1.0.0/16'"},
+				},
+				{
+					Action:   ActionCreate,
+					Resource: "gateway",
+					Name:     "foo",
+				},
+			},
+		},
+		{
+			desc: "failing checks fail the plan",
+			steps: []planStep{
+				{resource: "vpc", name: "foo", check: check(false, nil, fmt.Errorf("throttled"))},
+			},
+			resErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		actions, err := runPlan(tc.steps)
+		if tc.resErr {
+			assert.NotNil(t, err, fmt.Sprintf("[%s] Expected an error", tc.desc))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.resActions, actions, fmt.Sprintf("[%s] Unexpected plan", tc.desc))
+	}
+}
+
+func TestPlanInstances(t *testing.T) {
+	tests := []struct {
+		desc       string
+		desired    int
+		existing   []string
+		resActions []PlannedAction
+	}{
+		{
+			desc:       "all instances exist",
+			desired:    2,
+			existing:   []string{"foo-worker-1", "foo-worker-0"},
+			resActions: nil,
+		},
+		{
+			desc:     "missing instances are created",
+			desired:  3,
+			existing: []string{"foo-worker-1"},
+			resActions: []PlannedAction{
+				{Action: ActionCreate, Resource: "instance", Name: "foo-worker-0"},
+				{Action: ActionCreate, Resource: "instance", Name: "foo-worker-2"},
+			},
+		},
+		{
+			desc:     "instances beyond the spec are deleted",
+			desired:  1,
+			existing: []string{"foo-worker-0", "foo-worker-2", "foo-worker-1"},
+			resActions: []PlannedAction{
+				{Action: ActionDelete, Resource: "instance", Name: "foo-worker-1", Reasons: []string{"the cluster spec has 1 worker instances"}},
+				{Action: ActionDelete, Resource: "instance", Name: "foo-worker-2", Reasons: []string{"the cluster spec has 1 worker instances"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		actions := planInstances("", "foo", prefixWorker, tc.desired, tc.existing)
+		assert.Equal(t, tc.resActions, actions, fmt.Sprintf("[%s] Unexpected plan", tc.desc))
+	}
+}
@@ -0,0 +1,19 @@
+package create
+
+import (
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+)
+
+// clusterRegion returns the AWS region of the cluster with the given spec. The
+// operator's default region is used when the spec does not define one.
+func clusterRegion(spec awstpr.Spec, defaultRegion string) (string, error) {
+	if spec.AWS.Region != "" {
+		return spec.AWS.Region, nil
+	}
+	if defaultRegion != "" {
+		return defaultRegion, nil
+	}
+
+	return "", microerror.MaskAnyf(missingRegionError, "neither the cluster spec nor the operator define a region")
+}
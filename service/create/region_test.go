@@ -0,0 +1,58 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	awsinfo "github.com/giantswarm/awstpr/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterRegion(t *testing.T) {
+	tests := []struct {
+		desc          string
+		specRegion    string
+		defaultRegion string
+		res           string
+		errorMatcher  func(error) bool
+	}{
+		{
+			desc:          "spec region wins over the default region",
+			specRegion:    "eu-central-1",
+			defaultRegion: "us-east-1",
+			res:           "eu-central-1",
+		},
+		{
+			desc:       "spec region without default region",
+			specRegion: "eu-west-1",
+			res:        "eu-west-1",
+		},
+		{
+			desc:          "default region is used when the spec has none",
+			defaultRegion: "us-east-1",
+			res:           "us-east-1",
+		},
+		{
+			desc:         "both regions empty",
+			errorMatcher: IsMissingRegion,
+		},
+	}
+
+	for _, tc := range tests {
+		spec := awstpr.Spec{
+			AWS: awsinfo.AWS{
+				Region: tc.specRegion,
+			},
+		}
+
+		res, err := clusterRegion(spec, tc.defaultRegion)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.desc))
+	}
+}
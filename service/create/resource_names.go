@@ -0,0 +1,106 @@
+package create
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// Maximum lengths of the names of AWS resources.
+const (
+	maxBucketNameLength      = 63
+	maxELBNameLength         = 32
+	maxIAMRoleNameLength     = 64
+	maxIAMPolicyNameLength   = 128
+	maxKMSAliasNameLength    = 256
+	maxInstanceNameTagLength = 255
+)
+
+// resourcePrefixPattern matches the characters allowed in the names of all the
+// prefixed resources. S3 bucket names are the most restrictive, they only allow
+// lowercase letters, digits and hyphens, and must start with a letter or
+// digit.
+var resourcePrefixPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// validResourcePrefix checks that the given prefix fits into the names of all
+// the prefixed resources. The empty prefix keeps the names unchanged.
+func validResourcePrefix(prefix string) bool {
+	return prefix == "" || resourcePrefixPattern.MatchString(prefix)
+}
+
+// resourceName prefixes the given name of an AWS resource with the resource
+// prefix, so the resources fit into existing naming schemes.
+func (s *Service) resourceName(name string) string {
+	return s.resourcePrefix + name
+}
+
+// checkResourceNames checks that the names of the prefixed resources of the
+// given cluster are within the length limits of their services, so a long
+// prefix fails the cluster before anything is created. The cluster's region
+// must be set, the bucket is named after the AWS account with the given ID.
+func (s *Service) checkResourceNames(accountID string, cluster awstpr.CustomObject) error {
+	type limitedName struct {
+		kind string
+		name string
+		max  int
+	}
+
+	clusterID := cluster.Spec.Cluster.Cluster.ID
+	names := []limitedName{
+		{
+			kind: "bucket",
+			name: s.bucketName(accountID, cluster),
+			max:  maxBucketNameLength,
+		},
+		{
+			kind: "IAM role",
+			name: s.resourceName(fmt.Sprintf("%s-%s", clusterID, awsresources.RoleNameTemplate)),
+			max:  maxIAMRoleNameLength,
+		},
+		{
+			kind: "IAM policy",
+			name: s.resourceName(fmt.Sprintf("%s-%s", clusterID, awsresources.PolicyNameTemplate)),
+			max:  maxIAMPolicyNameLength,
+		},
+		{
+			kind: "KMS alias",
+			name: fmt.Sprintf("alias/%s", s.resourceName(cluster.Name)),
+			max:  maxKMSAliasNameLength,
+		},
+		{
+			// No instance of the cluster has a longer name than the next
+			// worker would.
+			kind: "instance",
+			name: instanceName(instanceNameInput{
+				resourcePrefix: s.resourcePrefix,
+				clusterName:    cluster.Name,
+				prefix:         prefixWorker,
+				no:             len(cluster.Spec.AWS.Workers),
+			}),
+			max: maxInstanceNameTagLength,
+		},
+	}
+	for _, domain := range s.clusterLoadBalancerDomains(cluster) {
+		lbName, err := s.loadBalancerName(domain, cluster)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		names = append(names, limitedName{
+			kind: "ELB",
+			name: lbName,
+			max:  maxELBNameLength,
+		})
+	}
+
+	for _, n := range names {
+		if len(n.name) > n.max {
+			return microerror.MaskAnyf(invalidResourceNameError, "%s name '%s' is longer than %d characters", n.kind, n.name, n.max)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package create
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	awsspec "github.com/giantswarm/awstpr/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func newNamedCluster() awstpr.CustomObject {
+	var cluster awstpr.CustomObject
+	cluster.Name = "foo"
+	cluster.Spec.Cluster.Cluster.ID = "abc12"
+	cluster.Spec.Cluster.Customer.ID = "acme"
+	cluster.Spec.AWS.Region = "eu-central-1"
+	cluster.Spec.AWS.Workers = make([]awsspec.Node, 3)
+	cluster.Spec.Cluster.Kubernetes.API.Domain = "api.abc12.example.com"
+	cluster.Spec.Cluster.Etcd.Domain = "etcd.abc12.example.com"
+	cluster.Spec.Cluster.Kubernetes.IngressController.Domain = "ingress.abc12.example.com"
+	return cluster
+}
+
+func TestValidResourcePrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		res    bool
+	}{
+		{prefix: "", res: true},
+		{prefix: "acme-", res: true},
+		{prefix: "team1-prod-", res: true},
+		{prefix: "-acme", res: false},
+		{prefix: "Acme-", res: false},
+		{prefix: "acme_", res: false},
+		{prefix: "acme.", res: false},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.res, validResourcePrefix(tc.prefix), fmt.Sprintf("[%s] Wrong validity", tc.prefix))
+	}
+}
+
+func TestResourcePrefixIsApplied(t *testing.T) {
+	cluster := newNamedCluster()
+
+	for _, prefix := range []string{"", "acme-"} {
+		s := &Service{resourcePrefix: prefix}
+
+		assert.Equal(t, prefix+"-g8s-acme-eu-central-1", s.bucketName("", cluster), fmt.Sprintf("[%s] Wrong bucket name", prefix))
+		assert.Equal(t, prefix+"foo", s.resourceName(cluster.Name), fmt.Sprintf("[%s] Wrong KMS key name", prefix))
+
+		lbName, err := s.loadBalancerName(cluster.Spec.Cluster.Kubernetes.API.Domain, cluster)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", prefix))
+		assert.Equal(t, prefix+"abc12-api", lbName, fmt.Sprintf("[%s] Wrong ELB name", prefix))
+
+		name := instanceName(instanceNameInput{resourcePrefix: prefix, clusterName: cluster.Name, prefix: prefixWorker, no: 1})
+		assert.Equal(t, prefix+"foo-worker-1", name, fmt.Sprintf("[%s] Wrong instance name", prefix))
+
+		// Instances are found by the prefix of their names, so the pattern must
+		// match the instance names.
+		pattern := clusterPrefix(clusterPrefixInput{resourcePrefix: prefix, clusterName: cluster.Name, prefix: prefixWorker})
+		assert.True(t, strings.HasPrefix(name, pattern), fmt.Sprintf("[%s] Instance pattern '%s' doesn't match '%s'", prefix, pattern, name))
+
+		actions := planInstances(prefix, cluster.Name, prefixWorker, 1, nil)
+		assert.Equal(t, prefix+"foo-worker-0", actions[0].Name, fmt.Sprintf("[%s] Wrong planned instance name", prefix))
+	}
+}
+
+func TestCheckResourceNames(t *testing.T) {
+	tests := []struct {
+		desc         string
+		prefix       string
+		cluster      func() awstpr.CustomObject
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:    "no prefix",
+			prefix:  "",
+			cluster: newNamedCluster,
+		},
+		{
+			desc:    "short prefix",
+			prefix:  "acme-",
+			cluster: newNamedCluster,
+		},
+		{
+			// abc12-ingress has 13 characters, ELB names at most 32.
+			desc:         "ELB name too long",
+			prefix:       strings.Repeat("a", 20),
+			cluster:      newNamedCluster,
+			errorMatcher: IsInvalidResourceName,
+		},
+		{
+			desc:   "bucket name too long",
+			prefix: "acme-",
+			cluster: func() awstpr.CustomObject {
+				cluster := newNamedCluster()
+				cluster.Spec.Cluster.Customer.ID = strings.Repeat("c", 50)
+				return cluster
+			},
+			errorMatcher: IsInvalidResourceName,
+		},
+		{
+			desc:   "IAM role name too long",
+			prefix: "acme-",
+			cluster: func() awstpr.CustomObject {
+				cluster := newNamedCluster()
+				cluster.Spec.Cluster.Cluster.ID = strings.Repeat("x", 50)
+				return cluster
+			},
+			errorMatcher: IsInvalidResourceName,
+		},
+	}
+
+	for _, tc := range tests {
+		s := &Service{resourcePrefix: tc.prefix}
+
+		err := s.checkResourceNames("", tc.cluster())
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+	}
+}
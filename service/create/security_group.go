@@ -12,9 +12,14 @@ import (
 )
 
 type securityGroupInput struct {
-	Clients   awsutil.Clients
+	Clients awsutil.Clients
+	// ClusterID scopes the security group to a cluster, see
+	// awsresources.AWSEntity.ClusterID.
+	ClusterID string
 	GroupName string
-	VPCID     string
+	// Tags are added to the security group on creation.
+	Tags  map[string]string
+	VPCID string
 }
 
 type rulesInput struct {
@@ -23,6 +28,9 @@ type rulesInput struct {
 	MastersSecurityGroupID string
 	WorkersSecurityGroupID string
 	IngressSecurityGroupID string
+	// IngressSourceCIDRs are the CIDRs allowed to reach the ingress ELB. It is
+	// reachable from anywhere when empty.
+	IngressSourceCIDRs []string
 }
 
 const (
@@ -38,11 +46,15 @@ const (
 )
 
 func (s *Service) createSecurityGroup(input securityGroupInput) (*awsresources.SecurityGroup, error) {
+	awsEntity := s.awsEntity(input.Clients)
+	awsEntity.ClusterID = input.ClusterID
+	awsEntity.Tags = input.Tags
+
 	securityGroup := &awsresources.SecurityGroup{
 		Description: input.GroupName,
 		GroupName:   input.GroupName,
 		VpcID:       input.VPCID,
-		AWSEntity:   awsresources.AWSEntity{Clients: input.Clients},
+		AWSEntity:   awsEntity,
 	}
 	securityGroupCreated, err := securityGroup.CreateIfNotExists()
 	if err != nil {
@@ -58,11 +70,14 @@ func (s *Service) createSecurityGroup(input securityGroupInput) (*awsresources.S
 }
 
 func (s *Service) deleteSecurityGroup(input securityGroupInput) error {
+	awsEntity := s.awsEntity(input.Clients)
+	awsEntity.ClusterID = input.ClusterID
+
 	var securityGroup resources.ResourceWithID
 	securityGroup = &awsresources.SecurityGroup{
 		Description: input.GroupName,
 		GroupName:   input.GroupName,
-		AWSEntity:   awsresources.AWSEntity{Clients: input.Clients},
+		AWSEntity:   awsEntity,
 	}
 	if err := securityGroup.Delete(); err != nil {
 		return microerror.MaskAny(err)
@@ -127,18 +142,57 @@ func (ri rulesInput) workerRules() []awsresources.SecurityGroupRule {
 
 // ingressRules returns the rules for the ingress ELB security group.
 func (ri rulesInput) ingressRules() []awsresources.SecurityGroupRule {
-	return []awsresources.SecurityGroupRule{
+	elbRules, _ := loadBalancerRules(ingressPortPairs(ri.Cluster), ri.IngressSecurityGroupID, ri.IngressSourceCIDRs)
+	return elbRules
+}
+
+// ingressInstanceRules returns the rules letting the ingress ELB reach the
+// workers. They belong to the workers security group.
+func (ri rulesInput) ingressInstanceRules() []awsresources.SecurityGroupRule {
+	_, instanceRules := loadBalancerRules(ingressPortPairs(ri.Cluster), ri.IngressSecurityGroupID, ri.IngressSourceCIDRs)
+	return instanceRules
+}
+
+// ingressPortPairs returns the ports the ingress ELB listens on, paired with
+// the ports of the ingress controller on the workers.
+func ingressPortPairs(cluster awstpr.CustomObject) awsresources.PortPairs {
+	return awsresources.PortPairs{
 		{
-			Port:       httpPort,
-			SourceCIDR: defaultCIDR,
+			PortELB:      httpsPort,
+			PortInstance: cluster.Spec.Cluster.Kubernetes.IngressController.SecurePort,
 		},
 		{
-			Port:       httpsPort,
-			SourceCIDR: defaultCIDR,
+			PortELB:      httpPort,
+			PortInstance: cluster.Spec.Cluster.Kubernetes.IngressController.InsecurePort,
 		},
 	}
 }
 
+// loadBalancerRules returns the paired rules wiring up an ELB. The ELB
+// security group accepts traffic on the listener ports from the source CIDRs,
+// defaulting to anywhere. The instances security group accepts traffic on the
+// instance ports from the ELB security group.
+func loadBalancerRules(portPairs awsresources.PortPairs, elbSecurityGroupID string, sourceCIDRs []string) (elbRules, instanceRules []awsresources.SecurityGroupRule) {
+	if len(sourceCIDRs) == 0 {
+		sourceCIDRs = []string{defaultCIDR}
+	}
+
+	for _, portPair := range portPairs {
+		for _, sourceCIDR := range sourceCIDRs {
+			elbRules = append(elbRules, awsresources.SecurityGroupRule{
+				Port:       portPair.PortELB,
+				SourceCIDR: sourceCIDR,
+			})
+		}
+		instanceRules = append(instanceRules, awsresources.SecurityGroupRule{
+			Port:            portPair.PortInstance,
+			SecurityGroupID: elbSecurityGroupID,
+		})
+	}
+
+	return elbRules, instanceRules
+}
+
 func securityGroupName(clusterName string, groupName string) string {
 	return fmt.Sprintf("%s-%s", clusterName, groupName)
 }
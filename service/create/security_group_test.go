@@ -0,0 +1,73 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/giantswarm/clustertpr"
+	"github.com/giantswarm/clustertpr/kubernetes"
+	"github.com/giantswarm/clustertpr/kubernetes/ingress"
+	"github.com/stretchr/testify/assert"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+func TestIngressRules(t *testing.T) {
+	cluster := awstpr.CustomObject{
+		Spec: awstpr.Spec{
+			Cluster: clustertpr.Cluster{
+				Kubernetes: kubernetes.Kubernetes{
+					IngressController: ingress.IngressController{
+						SecurePort:   30011,
+						InsecurePort: 30010,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc             string
+		sourceCIDRs      []string
+		resELBRules      []awsresources.SecurityGroupRule
+		resInstanceRules []awsresources.SecurityGroupRule
+	}{
+		{
+			desc: "ELB is reachable from anywhere by default",
+			resELBRules: []awsresources.SecurityGroupRule{
+				{Port: httpsPort, SourceCIDR: defaultCIDR},
+				{Port: httpPort, SourceCIDR: defaultCIDR},
+			},
+			resInstanceRules: []awsresources.SecurityGroupRule{
+				{Port: 30011, SecurityGroupID: "sg-ingress"},
+				{Port: 30010, SecurityGroupID: "sg-ingress"},
+			},
+		},
+		{
+			desc:        "ELB is only reachable from the configured CIDRs",
+			sourceCIDRs: []string{"10.0.0.0/8", "192.168.0.0/16"},
+			resELBRules: []awsresources.SecurityGroupRule{
+				{Port: httpsPort, SourceCIDR: "10.0.0.0/8"},
+				{Port: httpsPort, SourceCIDR: "192.168.0.0/16"},
+				{Port: httpPort, SourceCIDR: "10.0.0.0/8"},
+				{Port: httpPort, SourceCIDR: "192.168.0.0/16"},
+			},
+			resInstanceRules: []awsresources.SecurityGroupRule{
+				{Port: 30011, SecurityGroupID: "sg-ingress"},
+				{Port: 30010, SecurityGroupID: "sg-ingress"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		ri := rulesInput{
+			Cluster:                cluster,
+			IngressSecurityGroupID: "sg-ingress",
+			IngressSourceCIDRs:     tc.sourceCIDRs,
+		}
+
+		assert.Equal(t, tc.resELBRules, ri.ingressRules(), fmt.Sprintf("[%s] The input values didn't produce the expected ELB rules", tc.desc))
+		assert.Equal(t, tc.resInstanceRules, ri.ingressInstanceRules(), fmt.Sprintf("[%s] The input values didn't produce the expected instance rules", tc.desc))
+	}
+}
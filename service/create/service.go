@@ -3,6 +3,8 @@ package create
 import (
 	"encoding/json"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -10,9 +12,9 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/s3"
+	etcdclient "github.com/coreos/etcd/client"
 	"github.com/giantswarm/awstpr"
 	awsinfo "github.com/giantswarm/awstpr/aws"
-	"github.com/giantswarm/certificatetpr"
 	"github.com/giantswarm/clustertpr/node"
 	"github.com/giantswarm/k8scloudconfig"
 	microerror "github.com/giantswarm/microkit/error"
@@ -21,6 +23,8 @@ import (
 	"github.com/juju/errgo"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/labels"
 	"k8s.io/client-go/pkg/runtime"
 	"k8s.io/client-go/pkg/watch"
 	"k8s.io/client-go/tools/cache"
@@ -47,9 +51,6 @@ const (
 	// Suffixes used for subnets
 	suffixPublic  string = "public"
 	suffixPrivate string = "private"
-	// Number of retries of RunInstances to wait for Roles to propagate to
-	// Instance Profiles
-	runInstancesRetries = 10
 )
 
 // Config represents the configuration used to create a version service.
@@ -60,8 +61,106 @@ type Config struct {
 	Logger      micrologger.Logger
 
 	// Settings.
-	AwsConfig  awsutil.Config
+	// APIHealthCheckPath is the path of the HTTPS health check the API load
+	// balancers probe the API servers with, e.g. /readyz on Kubernetes 1.16
+	// and later.
+	APIHealthCheckPath string
+	// AnnotationTags are the keys of the annotations of a cluster's custom
+	// object copied to the tags of the cluster's AWS resources, e.g. for cost
+	// allocation.
+	AnnotationTags []string
+	AwsConfig      awsutil.Config
+	// AwsRateLimit is the number of mutating AWS API calls per second allowed
+	// across all clusters. Calls are not limited when it is zero.
+	AwsRateLimit float64
+	// CheckZoneDelegation makes the operator warn about public hosted zones
+	// whose parent zone doesn't delegate to them.
+	CheckZoneDelegation bool
+	// CloudConfigEncoding is the encoding of the final cloudconfig uploaded
+	// to S3, either CloudConfigEncodingGzipBase64 or CloudConfigEncodingBase64.
+	CloudConfigEncoding string
+	// CloudConfigValidation makes the operator check the rendered cloudconfigs
+	// before uploading them, so broken ones fail the cluster creation instead
+	// of the boot of the instances.
+	CloudConfigValidation bool
+	// ClusterSelector is a label selector restricting the clusters managed by
+	// the operator. All clusters are managed when it is empty.
+	ClusterSelector string
+	// DefaultRegion is the AWS region used for clusters whose spec does not
+	// define one.
+	DefaultRegion string
+	// DeprecatedImages is what the operator does about clusters whose machines
+	// use deprecated images, either DeprecatedImagesIgnore,
+	// DeprecatedImagesWarn or DeprecatedImagesRefuse.
+	DeprecatedImages string
+	// DNSComponents are the components of a cluster whose DNS records are
+	// created, out of DNSComponents. The hosted zones and records of the other
+	// components are left alone, e.g. when they are managed elsewhere.
+	DNSComponents []string
+	// DNSConcurrency is the number of DNS records changed at once. The changes
+	// are paced within the Route53 request limit either way.
+	DNSConcurrency int
+	DrainNodes     bool
+	DrainTimeout   time.Duration
+	// IngressSourceCIDRs are the CIDRs allowed to reach the ingress ELBs.
+	// They are reachable from anywhere when it is empty.
+	IngressSourceCIDRs []string
+	// InstanceHostnames makes instances use their instance name as hostname.
+	InstanceHostnames bool
+	// InstanceRunningTimeout is the maximum time to wait for a new instance to
+	// run, before registering it with the load balancers.
+	InstanceRunningTimeout time.Duration
+	// InternalAPILoadBalancer makes the operator create an internal load
+	// balancer in front of the API servers, next to the internet-facing one.
+	InternalAPILoadBalancer bool
+	// LaunchConcurrency is the maximum number of machines of a cluster
+	// launched at once, out of the masters or the workers.
+	LaunchConcurrency int
+	// MaxClusters is the maximum number of clusters the operator provisions.
+	// Clusters beyond it are rejected with a warning event. The number of
+	// clusters is not limited when it is zero.
+	MaxClusters int
+	// NetworkPolicies makes the operator isolate the cluster namespaces, only
+	// allowing ingress traffic between the pods of the namespace.
+	NetworkPolicies bool
+	// OperatorID identifies this operator amongst the ones sharing an AWS
+	// account. The EC2 resources it creates are tagged with it, and it only
+	// manages resources carrying its tag.
+	OperatorID string
 	PubKeyFile string
+	// ReconcileCertSecrets makes the operator update the cloudconfigs of a
+	// cluster when the secrets holding its certificates change.
+	ReconcileCertSecrets bool
+	// ResourcePrefix is prepended to the names of the buckets, IAM roles and
+	// policies, ELBs, KMS aliases and instances of clusters, so they fit into
+	// existing naming schemes. It may only contain lowercase letters, digits
+	// and hyphens, and must start with a letter or digit. Names are unchanged
+	// when it is empty. Changing it orphans the resources of existing
+	// clusters.
+	ResourcePrefix string
+	// FlowLogs makes the VPCs of clusters log their IP traffic to the S3
+	// buckets of the clusters, for troubleshooting.
+	FlowLogs bool
+	// DHCPOptions makes the VPCs of clusters use a DHCP options set, which
+	// makes the hosted zone of the cluster their search domain.
+	DHCPOptions bool
+	// S3VPCEndpoint makes the operator create an S3 gateway endpoint in the VPC
+	// of clusters, so S3 traffic stays within the AWS network.
+	S3VPCEndpoint bool
+	// ShutdownTimeout is the maximum time a shutdown waits for the active
+	// reconciles to finish before the operator exits.
+	ShutdownTimeout time.Duration
+	// TerminateStuckInstances makes the operator terminate new instances which
+	// don't run within InstanceRunningTimeout, so the next reconcile launches
+	// them again.
+	TerminateStuckInstances bool
+	// WaitForMastersReady makes the operator create the workers only once all
+	// the masters are in service behind the API load balancer.
+	WaitForMastersReady bool
+	// WorkerSpotMaxPrice makes the operator launch the workers as spot
+	// instances, paying at most this price per hour in US dollars, e.g. 0.05.
+	// Workers are on-demand instances when it is empty.
+	WorkerSpotMaxPrice string
 }
 
 // DefaultConfig provides a default configuration to create a new service by
@@ -74,8 +173,38 @@ func DefaultConfig() Config {
 		Logger:      nil,
 
 		// Settings.
-		AwsConfig:  awsutil.Config{},
-		PubKeyFile: "",
+		APIHealthCheckPath:      DefaultAPIHealthCheckPath,
+		AnnotationTags:          nil,
+		AwsConfig:               awsutil.Config{},
+		AwsRateLimit:            0,
+		CheckZoneDelegation:     false,
+		CloudConfigEncoding:     CloudConfigEncodingGzipBase64,
+		CloudConfigValidation:   false,
+		ClusterSelector:         "",
+		DefaultRegion:           "",
+		DeprecatedImages:        DeprecatedImagesIgnore,
+		DNSComponents:           DNSComponents,
+		DNSConcurrency:          1,
+		DrainNodes:              false,
+		DrainTimeout:            0,
+		IngressSourceCIDRs:      nil,
+		InstanceHostnames:       false,
+		InstanceRunningTimeout:  defaultInstanceRunningTimeout,
+		InternalAPILoadBalancer: false,
+		LaunchConcurrency:       1,
+		MaxClusters:             0,
+		NetworkPolicies:         false,
+		OperatorID:              "",
+		PubKeyFile:              "",
+		ReconcileCertSecrets:    false,
+		ResourcePrefix:          "",
+		FlowLogs:                false,
+		DHCPOptions:             false,
+		S3VPCEndpoint:           false,
+		ShutdownTimeout:         defaultShutdownTimeout,
+		TerminateStuckInstances: false,
+		WaitForMastersReady:     false,
+		WorkerSpotMaxPrice:      "",
 	}
 }
 
@@ -93,14 +222,77 @@ func New(config Config) (*Service, error) {
 	}
 
 	// Settings.
+	if !strings.HasPrefix(config.APIHealthCheckPath, "/") {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.APIHealthCheckPath must start with '/'")
+	}
 	var emptyAwsConfig awsutil.Config
 	if config.AwsConfig == emptyAwsConfig {
 		return nil, microerror.MaskAnyf(invalidConfigError, "config.AwsConfig must not be empty")
 	}
+	for _, key := range config.AnnotationTags {
+		if key == "" {
+			return nil, microerror.MaskAnyf(invalidConfigError, "config.AnnotationTags must not contain empty keys")
+		}
+	}
+	if config.AwsRateLimit < 0 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.AwsRateLimit must not be negative")
+	}
+	if !validCloudConfigEncoding(config.CloudConfigEncoding) {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.CloudConfigEncoding must be '%s' or '%s'", CloudConfigEncodingGzipBase64, CloudConfigEncodingBase64)
+	}
+	if !validDeprecatedImages(config.DeprecatedImages) {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.DeprecatedImages must be '%s', '%s' or '%s'", DeprecatedImagesIgnore, DeprecatedImagesWarn, DeprecatedImagesRefuse)
+	}
+	clusterSelector, err := labels.Parse(config.ClusterSelector)
+	if err != nil {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.ClusterSelector must be a valid label selector: %s", err)
+	}
+	for _, component := range config.DNSComponents {
+		if !containsString(DNSComponents, component) {
+			return nil, microerror.MaskAnyf(invalidConfigError, "config.DNSComponents must only contain %v, got '%s'", DNSComponents, component)
+		}
+	}
+	if config.DNSConcurrency < 1 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.DNSConcurrency must be greater than zero")
+	}
+	if config.DrainNodes && config.DrainTimeout <= 0 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.DrainTimeout must be greater than zero when draining nodes")
+	}
+	for _, cidr := range config.IngressSourceCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return nil, microerror.MaskAnyf(invalidConfigError, "config.IngressSourceCIDRs must only contain valid CIDRs: %s", err)
+		}
+	}
+	if config.InstanceRunningTimeout <= 0 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.InstanceRunningTimeout must be greater than zero")
+	}
+	if config.LaunchConcurrency < 1 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.LaunchConcurrency must be greater than zero")
+	}
+	if config.MaxClusters < 0 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.MaxClusters must not be negative")
+	}
+	if !validResourcePrefix(config.ResourcePrefix) {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.ResourcePrefix must only contain lowercase letters, digits and hyphens, and start with a letter or digit, got '%s'", config.ResourcePrefix)
+	}
+	if config.ShutdownTimeout <= 0 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.ShutdownTimeout must be greater than zero")
+	}
+	if config.WorkerSpotMaxPrice != "" {
+		price, err := strconv.ParseFloat(config.WorkerSpotMaxPrice, 64)
+		if err != nil || price <= 0 {
+			return nil, microerror.MaskAnyf(invalidConfigError, "config.WorkerSpotMaxPrice must be a positive price, got '%s'", config.WorkerSpotMaxPrice)
+		}
+	}
 	if config.PubKeyFile == "" {
 		return nil, microerror.MaskAnyf(invalidConfigError, "config.PubKeyFile must not be empty")
 	}
 
+	var awsRateLimiter *awsutil.RateLimiter
+	if config.AwsRateLimit > 0 {
+		awsRateLimiter = awsutil.NewRateLimiter(config.AwsRateLimit)
+	}
+
 	newService := &Service{
 		// Dependencies.
 		certWatcher: config.CertWatcher,
@@ -108,11 +300,47 @@ func New(config Config) (*Service, error) {
 		logger:      config.Logger,
 
 		// Internals
-		bootOnce: sync.Once{},
+		awsRateLimiter: awsRateLimiter,
+		bootOnce:       sync.Once{},
+		clusterLimiter: newClusterLimiter(config.MaxClusters),
+		dnsExecutor: dnsExecutor{
+			concurrency: config.DNSConcurrency,
+			limiter:     awsutil.NewRateLimiter(route53RequestsPerSecond),
+		},
+		reconciles:   newReconcileTracker(),
+		shutdownOnce: sync.Once{},
+		stop:         make(chan struct{}),
 
 		// Settings.
-		awsConfig:  config.AwsConfig,
-		pubKeyFile: config.PubKeyFile,
+		apiHealthCheckPath:      config.APIHealthCheckPath,
+		annotationTags:          config.AnnotationTags,
+		awsConfig:               config.AwsConfig,
+		checkZoneDelegation:     config.CheckZoneDelegation,
+		cloudConfigEncoding:     config.CloudConfigEncoding,
+		cloudConfigValidation:   config.CloudConfigValidation,
+		clusterSelector:         clusterSelector,
+		defaultRegion:           config.DefaultRegion,
+		deprecatedImages:        config.DeprecatedImages,
+		dnsComponents:           config.DNSComponents,
+		drainNodes:              config.DrainNodes,
+		drainTimeout:            config.DrainTimeout,
+		ingressSourceCIDRs:      config.IngressSourceCIDRs,
+		instanceHostnames:       config.InstanceHostnames,
+		instanceRunningTimeout:  config.InstanceRunningTimeout,
+		internalAPILoadBalancer: config.InternalAPILoadBalancer,
+		launchConcurrency:       config.LaunchConcurrency,
+		networkPolicies:         config.NetworkPolicies,
+		operatorID:              config.OperatorID,
+		pubKeyFile:              config.PubKeyFile,
+		reconcileCertSecrets:    config.ReconcileCertSecrets,
+		resourcePrefix:          config.ResourcePrefix,
+		flowLogs:                config.FlowLogs,
+		dhcpOptions:             config.DHCPOptions,
+		s3VPCEndpoint:           config.S3VPCEndpoint,
+		shutdownTimeout:         config.ShutdownTimeout,
+		terminateStuckInstances: config.TerminateStuckInstances,
+		waitForMastersReady:     config.WaitForMastersReady,
+		workerSpotMaxPrice:      config.WorkerSpotMaxPrice,
 	}
 
 	return newService, nil
@@ -126,11 +354,46 @@ type Service struct {
 	logger      micrologger.Logger
 
 	// Internals.
-	bootOnce sync.Once
+	awsRateLimiter *awsutil.RateLimiter
+	bootOnce       sync.Once
+	clusterLimiter *clusterLimiter
+	clusters       cache.Store
+	clustersMutex  sync.Mutex
+	dnsExecutor    dnsExecutor
+	reconciles     *reconcileTracker
+	shutdownOnce   sync.Once
+	stop           chan struct{}
 
 	// Settings.
-	awsConfig  awsutil.Config
-	pubKeyFile string
+	apiHealthCheckPath      string
+	annotationTags          []string
+	awsConfig               awsutil.Config
+	checkZoneDelegation     bool
+	cloudConfigEncoding     string
+	cloudConfigValidation   bool
+	clusterSelector         labels.Selector
+	defaultRegion           string
+	deprecatedImages        string
+	dnsComponents           []string
+	drainNodes              bool
+	drainTimeout            time.Duration
+	ingressSourceCIDRs      []string
+	instanceHostnames       bool
+	instanceRunningTimeout  time.Duration
+	internalAPILoadBalancer bool
+	launchConcurrency       int
+	networkPolicies         bool
+	operatorID              string
+	pubKeyFile              string
+	reconcileCertSecrets    bool
+	resourcePrefix          string
+	flowLogs                bool
+	dhcpOptions             bool
+	s3VPCEndpoint           bool
+	shutdownTimeout         time.Duration
+	terminateStuckInstances bool
+	waitForMastersReady     bool
+	workerSpotMaxPrice      string
 }
 
 type Event struct {
@@ -144,6 +407,9 @@ func (s *Service) newClusterListWatch() *cache.ListWatch {
 	listWatch := &cache.ListWatch{
 		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
 			req := client.Get().AbsPath(ClusterListAPIEndpoint)
+			if !s.clusterSelector.Empty() {
+				req = req.Param("labelSelector", s.clusterSelector.String())
+			}
 			b, err := req.DoRaw()
 			if err != nil {
 				return nil, err
@@ -153,19 +419,56 @@ func (s *Service) newClusterListWatch() *cache.ListWatch {
 			if err := json.Unmarshal(b, &c); err != nil {
 				return nil, err
 			}
+			c.Items = filterClusters(c.Items, s.clusterSelector)
 
 			return &c, nil
 		},
 
 		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
 			req := client.Get().AbsPath(ClusterWatchAPIEndpoint)
-			return req.Watch()
+			if !s.clusterSelector.Empty() {
+				req = req.Param("labelSelector", s.clusterSelector.String())
+			}
+			w, err := req.Watch()
+			if err != nil {
+				return nil, err
+			}
+
+			return watch.Filter(w, clusterEventFilter(s.clusterSelector)), nil
 		},
 	}
 
 	return listWatch
 }
 
+// filterClusters returns the clusters whose labels match the given selector.
+// The API server might not apply label selectors to third party resources, so
+// we filter the clusters on our side as well.
+func filterClusters(clusters []*awstpr.CustomObject, selector labels.Selector) []*awstpr.CustomObject {
+	var filtered []*awstpr.CustomObject
+
+	for _, cluster := range clusters {
+		if selector.Matches(labels.Set(cluster.Labels)) {
+			filtered = append(filtered, cluster)
+		}
+	}
+
+	return filtered
+}
+
+// clusterEventFilter drops watch events of clusters whose labels do not match
+// the given selector.
+func clusterEventFilter(selector labels.Selector) watch.FilterFunc {
+	return func(in watch.Event) (watch.Event, bool) {
+		cluster, ok := in.Object.(*awstpr.CustomObject)
+		if !ok {
+			return in, true
+		}
+
+		return in, selector.Matches(labels.Set(cluster.Labels))
+	}
+}
+
 func (s *Service) Boot() {
 	s.bootOnce.Do(func() {
 		if err := s.createTPR(); err != nil {
@@ -173,833 +476,948 @@ func (s *Service) Boot() {
 		}
 		s.logger.Log("info", "successfully created third-party resource")
 
-		_, clusterInformer := cache.NewInformer(
+		clusterStore, clusterInformer := cache.NewInformer(
 			s.newClusterListWatch(),
 			&awstpr.CustomObject{},
 			resyncPeriod,
-			cache.ResourceEventHandlerFuncs{
-				AddFunc: func(obj interface{}) {
-					cluster := *obj.(*awstpr.CustomObject)
-					s.logger.Log("info", fmt.Sprintf("creating cluster '%s'", cluster.Name))
-
-					if err := s.createClusterNamespace(cluster.Spec.Cluster); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create cluster namespace: %s", errgo.Details(err)))
-						return
-					}
-
-					// Create AWS client
-					s.awsConfig.Region = cluster.Spec.AWS.Region
-					clients := awsutil.NewClients(s.awsConfig)
-
-					err := s.awsConfig.SetAccountID(clients.IAM)
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not retrieve amazon account id: %s", errgo.Details(err)))
-						return
-					}
+			s.trackReconciles(instrumentReconciles(cache.ResourceEventHandlerFuncs{
+				AddFunc:    s.addCluster,
+				UpdateFunc: s.updateCluster,
+				DeleteFunc: func(obj interface{}) {
+					// TODO(nhlfr): Move this to a separate operator.
 
-					// Create keypair
-					var keyPair resources.ReusableResource
-					var keyPairCreated bool
-					{
-						var err error
-						keyPair = &awsresources.KeyPair{
-							ClusterName: cluster.Name,
-							Provider:    awsresources.NewFSKeyPairProvider(s.pubKeyFile),
-							AWSEntity:   awsresources.AWSEntity{Clients: clients},
+					// We can receive an instance of awstpr.CustomObject or cache.DeletedFinalStateUnknown.
+					// We need to assert the type properly and log an error when we cannot do that.
+					// Also, the cache.DeleteFinalStateUnknown object can contain the proper CustomObject,
+					// but doesn't have to.
+					// https://github.com/kubernetes/client-go/blob/7ba6be594966f4bec08a57a60c855d17a9f7000a/tools/cache/delta_fifo.go#L674-L677
+					var cluster awstpr.CustomObject
+					clusterPtr, ok := obj.(*awstpr.CustomObject)
+					if ok {
+						cluster = *clusterPtr
+					} else {
+						deletedObj, ok := obj.(cache.DeletedFinalStateUnknown)
+						if !ok {
+							s.logger.Log("error", "received unknown type of third-party object")
+							return
 						}
-						keyPairCreated, err = keyPair.CreateIfNotExists()
-						if err != nil {
-							s.logger.Log("error", fmt.Sprintf("could not create keypair: %s", errgo.Details(err)))
+						clusterPtr, ok := deletedObj.Obj.(*awstpr.CustomObject)
+						if !ok {
+							s.logger.Log("error", "received the proper delete request, but the type of third-party object is unknown")
 							return
 						}
+						cluster = *clusterPtr
 					}
 
-					if keyPairCreated {
-						s.logger.Log("info", fmt.Sprintf("created keypair '%s'", cluster.Name))
-					} else {
-						s.logger.Log("info", fmt.Sprintf("keypair '%s' already exists, reusing", cluster.Name))
-					}
+					s.clusterLimiter.release(cluster.Name)
 
-					s.logger.Log("info", fmt.Sprintf("waiting for k8s secrets..."))
-					clusterID := cluster.Spec.Cluster.Cluster.ID
-					certs, err := s.certWatcher.SearchCerts(clusterID)
+					region, err := clusterRegion(cluster.Spec, s.defaultRegion)
 					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not get certificates from secrets: %v", errgo.Details(err)))
+						s.logger.Log("error", fmt.Sprintf("could not determine cluster region: %s", errgo.Details(err)))
+						clusterDeleteTotal.WithLabelValues(clusterFailed).Inc()
 						return
 					}
+					cluster.Spec.AWS.Region = region
+					awsConfig := s.awsConfig
+					awsConfig.Region = region
+					clients := awsutil.NewClients(awsConfig)
+					s.awsRateLimiter.Limit(clients)
 
-					// Create KMS key
-					kmsKey := &awsresources.KMSKey{
-						Name:      cluster.Name,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-
-					kmsCreated, kmsKeyErr := kmsKey.CreateIfNotExists()
-					if kmsKeyErr != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create KMS key: %v", errgo.Details(kmsKeyErr)))
+					err = awsConfig.SetAccountID(clients.IAM)
+					if err != nil {
+						s.logger.Log("error", fmt.Sprintf("could not retrieve amazon account id: %s", errgo.Details(err)))
+						clusterDeleteTotal.WithLabelValues(clusterFailed).Inc()
 						return
 					}
 
-					if kmsCreated {
-						s.logger.Log("info", fmt.Sprintf("created KMS key for cluster '%s'", cluster.Name))
-					} else {
-						s.logger.Log("info", fmt.Sprintf("kms key '%s' already exists, reusing", kmsKey.Name))
+					bucketName := s.bucketName(awsConfig.AccountID(), cluster)
+					bucket := &awsresources.Bucket{
+						AWSEntity: s.awsEntity(clients),
+						Name:      bucketName,
 					}
-
-					// Encode TLS assets
-					tlsAssets, err := s.encodeTLSAssets(certs, clients.KMS, kmsKey.Arn())
+					dhcpOptions, err := s.vpcDHCPOptions(cluster)
 					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not encode TLS assets: %s", errgo.Details(err)))
-						return
-					}
+						s.logger.Log("error", fmt.Sprintf("could not determine the DHCP options of the VPC, not deleting them: %s", errgo.Details(err)))
+					}
+					vpc := &awsresources.VPC{
+						DHCPOptions: dhcpOptions,
+						ExistingID:  sharedVPCID(cluster),
+						FlowLogs:    s.flowLogs,
+						Name:        cluster.Name,
+						AWSEntity:   s.awsEntity(clients),
+					}
+					// Shared VPCs, with their gateway and endpoints, outlive the
+					// clusters within them.
+					shared := sharedVPCID(cluster) != ""
+
+					// All the steps are attempted, even when earlier ones fail, so that
+					// a single stuck resource does not leak all the others.
+					steps := []teardownStep{
+						{
+							name: "masters",
+							delete: func() error {
+								return s.deleteMachines(deleteMachinesInput{
+									clients:     clients,
+									clusterName: cluster.Name,
+									prefix:      prefixMaster,
+								})
+							},
+						},
+						{
+							name: "workers",
+							delete: func() error {
+								return s.deleteMachines(deleteMachinesInput{
+									clients:     clients,
+									clusterName: cluster.Name,
+									prefix:      prefixWorker,
+								})
+							},
+						},
+						{
+							name: "record sets",
+							delete: func() error {
+								return s.deleteRecordSets(cluster, clients)
+							},
+						},
+						{
+							name: "load balancers",
+							delete: func() error {
+								return s.deleteLoadBalancers(cluster, clients)
+							},
+						},
+						{
+							name: "S3 VPC endpoint",
+							keep: shared,
+							delete: func() error {
+								if !s.s3VPCEndpoint {
+									return nil
+								}
 
-					// Create policy
-					bucketName := s.bucketName(cluster)
-
-					var policy resources.NamedResource
-					var policyErr error
-					{
-						policy = &awsresources.Policy{
-							ClusterID: cluster.Spec.Cluster.Cluster.ID,
-							KMSKeyArn: kmsKey.Arn(),
-							S3Bucket:  bucketName,
-							AWSEntity: awsresources.AWSEntity{Clients: clients},
-						}
-						policyErr = policy.CreateOrFail()
-					}
-					if policyErr != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create policy: %s", errgo.Details(policyErr)))
-					}
+								vpcID, err := vpc.GetID()
+								if err != nil {
+									return microerror.MaskAny(err)
+								}
 
-					// Create S3 bucket
-					var bucket resources.ReusableResource
-					var bucketCreated bool
-					{
-						var err error
-						bucket = &awsresources.Bucket{
-							Name:      bucketName,
-							AWSEntity: awsresources.AWSEntity{Clients: clients},
-						}
-						bucketCreated, err = bucket.CreateIfNotExists()
-						if err != nil {
-							s.logger.Log("error", fmt.Sprintf("could not create S3 bucket: %s", errgo.Details(err)))
-							return
-						}
+								s3Endpoint := &awsresources.VPCEndpoint{
+									ServiceName: awsresources.S3EndpointServiceName(cluster.Spec.AWS.Region),
+									VpcID:       vpcID,
+									AWSEntity:   s.awsEntity(clients),
+								}
+								return s3Endpoint.Delete()
+							},
+						},
+						{
+							name: "route table",
+							delete: func() error {
+								var routeTable resources.ResourceWithID
+								routeTable = &awsresources.RouteTable{
+									Name:       cluster.Name,
+									Client:     clients.EC2,
+									OperatorID: s.operatorID,
+									ClusterID:  scopedClusterID(cluster),
+								}
+								return routeTable.Delete()
+							},
+						},
+						{
+							name: "gateway",
+							keep: shared,
+							delete: func() error {
+								// The gateway is detached from the VPCs it is attached to, so
+								// it is deleted even when the VPC is gone already.
+								var gateway resources.ResourceWithID
+								gateway = &awsresources.Gateway{
+									Name: cluster.Name,
+									// Dependencies.
+									Logger:    s.logger,
+									AWSEntity: s.awsEntity(clients),
+								}
+								return gateway.Delete()
+							},
+						},
+						{
+							name: "public subnet",
+							delete: func() error {
+								publicSubnet := &awsresources.Subnet{
+									Name: subnetName(cluster, suffixPublic),
+									// Dependencies.
+									Logger:    s.logger,
+									AWSEntity: s.clusterAWSEntity(clients, cluster),
+								}
+								return publicSubnet.Delete()
+							},
+						},
+						{
+							name: "masters security group",
+							delete: func() error {
+								return s.deleteSecurityGroup(securityGroupInput{
+									Clients:   clients,
+									ClusterID: scopedClusterID(cluster),
+									GroupName: securityGroupName(cluster.Name, prefixMaster),
+								})
+							},
+						},
+						{
+							name: "workers security group",
+							delete: func() error {
+								return s.deleteSecurityGroup(securityGroupInput{
+									Clients:   clients,
+									ClusterID: scopedClusterID(cluster),
+									GroupName: securityGroupName(cluster.Name, prefixWorker),
+								})
+							},
+						},
+						{
+							name: "ingress security group",
+							delete: func() error {
+								return s.deleteSecurityGroup(securityGroupInput{
+									Clients:   clients,
+									ClusterID: scopedClusterID(cluster),
+									GroupName: securityGroupName(cluster.Name, prefixIngress),
+								})
+							},
+						},
+						{
+							name:   "vpc",
+							keep:   shared,
+							delete: vpc.Delete,
+						},
+						{
+							name: "master bucket objects",
+							delete: func() error {
+								return s.deleteCloudConfigObjects(clients, bucket, cluster, prefixMaster, len(cluster.Spec.Cluster.Masters))
+							},
+						},
+						{
+							name: "worker bucket objects",
+							delete: func() error {
+								return s.deleteCloudConfigObjects(clients, bucket, cluster, prefixWorker, len(cluster.Spec.Cluster.Workers))
+							},
+						},
+						{
+							name: "roles, policies, instance profiles",
+							delete: func() error {
+								policy := &awsresources.Policy{
+									ClusterID:  cluster.Spec.Cluster.Cluster.ID,
+									NamePrefix: s.resourcePrefix,
+									S3Bucket:   bucketName,
+									AWSEntity:  s.awsEntity(clients),
+								}
+								return policy.Delete()
+							},
+						},
+						{
+							name: "KMS key",
+							delete: func() error {
+								kmsKey := &awsresources.KMSKey{
+									Name:      s.resourceName(cluster.Name),
+									AWSEntity: s.awsEntity(clients),
+								}
+								return kmsKey.Delete()
+							},
+						},
+						{
+							name: "keypair",
+							delete: func() error {
+								keyPair := &awsresources.KeyPair{
+									ClusterName: cluster.Name,
+									AWSEntity:   s.awsEntity(clients),
+								}
+								return keyPair.Delete()
+							},
+						},
 					}
 
-					if bucketCreated {
-						s.logger.Log("info", fmt.Sprintf("created bucket '%s'", bucketName))
-					} else {
-						s.logger.Log("info", fmt.Sprintf("bucket '%s' already exists, reusing", bucketName))
-					}
+					s.queueTeardown(cluster, steps, newTeardownBackoff)
+				},
+			})),
+		)
 
-					// Create VPC
-					var vpc resources.ResourceWithID
-					vpc = &awsresources.VPC{
-						CidrBlock: cluster.Spec.AWS.VPC.CIDR,
-						Name:      cluster.Name,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					vpcCreated, err := vpc.CreateIfNotExists()
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create VPC: %s", errgo.Details(err)))
-						return
-					}
-					if vpcCreated {
-						s.logger.Log("info", fmt.Sprintf("created vpc for cluster '%s'", cluster.Name))
-					} else {
-						s.logger.Log("info", fmt.Sprintf("vpc for cluster '%s' already exists, reusing", cluster.Name))
-					}
-					vpcID, err := vpc.GetID()
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					}
+		s.clustersMutex.Lock()
+		s.clusters = clusterStore
+		s.clustersMutex.Unlock()
 
-					// Create gateway
-					var gateway resources.ResourceWithID
-					gateway = &awsresources.Gateway{
-						Name:  cluster.Name,
-						VpcID: vpcID,
-						// Dependencies.
-						Logger:    s.logger,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					gatewayCreated, err := gateway.CreateIfNotExists()
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create gateway: %s", errgo.Details(err)))
-						return
-					}
-					if gatewayCreated {
-						s.logger.Log("info", fmt.Sprintf("created gateway for cluster '%s'", cluster.Name))
-					} else {
-						s.logger.Log("info", fmt.Sprintf("gateway for cluster '%s' already exists, reusing", cluster.Name))
-					}
+		if s.reconcileCertSecrets {
+			_, certSecretInformer := cache.NewInformer(
+				s.newCertSecretListWatch(),
+				&v1.Secret{},
+				resyncPeriod,
+				s.trackReconciles(s.newCertSecretHandler(clusterStore, s.reencodeCloudConfigs)),
+			)
 
-					// Create masters security group.
-					mastersSGInput := securityGroupInput{
-						Clients:   clients,
-						GroupName: securityGroupName(cluster.Name, prefixMaster),
-						VPCID:     vpcID,
-					}
-					mastersSecurityGroup, err := s.createSecurityGroup(mastersSGInput)
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create security group '%s': %s", mastersSGInput.GroupName, errgo.Details(err)))
-						return
-					}
-					mastersSecurityGroupID, err := mastersSecurityGroup.GetID()
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+			s.logger.Log("info", "starting certificate secrets watch")
 
-					// Create workers security group.
-					workersSGInput := securityGroupInput{
-						Clients:   clients,
-						GroupName: securityGroupName(cluster.Name, prefixWorker),
-						VPCID:     vpcID,
-					}
-					workersSecurityGroup, err := s.createSecurityGroup(workersSGInput)
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create security group '%s': %s", workersSGInput.GroupName, errgo.Details(err)))
-						return
-					}
-					workersSecurityGroupID, err := workersSecurityGroup.GetID()
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+			go certSecretInformer.Run(s.stop)
+		}
 
-					// Create ingress ELB security group.
-					ingressSGInput := securityGroupInput{
-						Clients:   clients,
-						GroupName: securityGroupName(cluster.Name, prefixIngress),
-						VPCID:     vpcID,
-					}
-					ingressSecurityGroup, err := s.createSecurityGroup(ingressSGInput)
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create security group '%s': %s", ingressSGInput.GroupName, errgo.Details(err)))
-						return
-					}
-					ingressSecurityGroupID, err := ingressSecurityGroup.GetID()
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+		s.logger.Log("info", "starting watch")
 
-					// Create rules for the security groups.
-					rulesInput := rulesInput{
-						Cluster:                cluster,
-						MastersSecurityGroupID: mastersSecurityGroupID,
-						WorkersSecurityGroupID: workersSecurityGroupID,
-						IngressSecurityGroupID: ingressSecurityGroupID,
-					}
+		// The cluster informer runs until the service shuts down.
+		s.runInformer(clusterInformer)
+	})
+}
 
-					if err := mastersSecurityGroup.ApplyRules(rulesInput.masterRules()); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", mastersSecurityGroup.GroupName, errgo.Details(err)))
-						return
-					}
+// addCluster creates the AWS resources of the given cluster. Resources which
+// exist already are reused, so it also completes partially created clusters.
+func (s *Service) addCluster(obj interface{}) {
+	cluster := *obj.(*awstpr.CustomObject)
 
-					if err := workersSecurityGroup.ApplyRules(rulesInput.workerRules()); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", workersSecurityGroup.GroupName, errgo.Details(err)))
-						return
-					}
+	// The creation failed unless it gets through all the resources.
+	result := clusterFailed
+	defer func() {
+		clusterCreateTotal.WithLabelValues(result).Inc()
+	}()
 
-					if err := ingressSecurityGroup.ApplyRules(rulesInput.ingressRules()); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", ingressSecurityGroup.GroupName, errgo.Details(err)))
-						return
-					}
+	region, err := clusterRegion(cluster.Spec, s.defaultRegion)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not determine cluster region: %s", errgo.Details(err)))
+		return
+	}
+	cluster.Spec.AWS.Region = region
 
-					// Create route table.
-					routeTable := &awsresources.RouteTable{
-						Name:   cluster.Name,
-						VpcID:  vpcID,
-						Client: clients.EC2,
-					}
-					routeTableCreated, err := routeTable.CreateIfNotExists()
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create route table: %s", errgo.Details(err)))
-						return
-					}
-					if routeTableCreated {
-						s.logger.Log("info", "created route table")
-					} else {
-						s.logger.Log("info", "route table already exists, reusing")
-					}
+	if err := validateCluster(cluster); err != nil {
+		s.logger.Log("error", fmt.Sprintf("not creating cluster '%s': invalid spec: %s", cluster.Name, errgo.Details(err)))
+		return
+	}
 
-					if err := routeTable.MakePublic(); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not make route table public: %s", errgo.Details(err)))
-						return
-					}
+	if err := s.admitCluster(cluster); err != nil {
+		s.logger.Log("error", fmt.Sprintf("not creating cluster '%s': %s", cluster.Name, errgo.Details(err)))
+		return
+	}
 
-					// Create public subnet for the masters
-					publicSubnet := &awsresources.Subnet{
-						AvailabilityZone: cluster.Spec.AWS.AZ,
-						CidrBlock:        cluster.Spec.AWS.VPC.PublicSubnetCIDR,
-						Name:             subnetName(cluster, suffixPublic),
-						VpcID:            vpcID,
-						// Dependencies.
-						Logger:    s.logger,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					publicSubnetCreated, err := publicSubnet.CreateIfNotExists()
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not create public subnet: %s", errgo.Details(err)))
-						return
-					}
-					if publicSubnetCreated {
-						s.logger.Log("info", fmt.Sprintf("created public subnet for cluster '%s'", cluster.Name))
-					} else {
-						s.logger.Log("info", fmt.Sprintf("public subnet for cluster '%s' already exists, reusing", cluster.Name))
-					}
-					publicSubnetID, err := publicSubnet.GetID()
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	s.logger.Log("info", fmt.Sprintf("creating cluster '%s'", cluster.Name))
 
-					if err := publicSubnet.MakePublic(routeTable); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not make subnet public, %s", errgo.Details(err)))
-						return
-					}
+	if err := s.createClusterNamespace(cluster.Spec.Cluster); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create cluster namespace: %s", errgo.Details(err)))
+		return
+	}
 
-					// Run masters
-					anyMastersCreated, masterIDs, err := s.runMachines(runMachinesInput{
-						clients:             clients,
-						cluster:             cluster,
-						tlsAssets:           tlsAssets,
-						clusterName:         cluster.Name,
-						bucket:              bucket,
-						securityGroup:       mastersSecurityGroup,
-						subnet:              publicSubnet,
-						keyPairName:         cluster.Name,
-						instanceProfileName: policy.GetName(),
-						prefix:              prefixMaster,
-					})
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					}
+	// Create AWS client. Clusters are reconciled concurrently, so the shared
+	// config is copied rather than changed.
+	awsConfig := s.awsConfig
+	awsConfig.Region = region
+	clients := awsutil.NewClients(awsConfig)
+	s.awsRateLimiter.Limit(clients)
 
-					if !validateIDs(masterIDs) {
-						s.logger.Log("error", fmt.Sprintf("master nodes had invalid instance IDs: %v", masterIDs))
-						return
-					}
+	err = awsConfig.SetAccountID(clients.IAM)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not retrieve amazon account id: %s", errgo.Details(err)))
+		return
+	}
 
-					// Create apiserver load balancer.
-					lbInput := LoadBalancerInput{
-						Name:        cluster.Spec.Cluster.Kubernetes.API.Domain,
-						Clients:     clients,
-						Cluster:     cluster,
-						InstanceIDs: masterIDs,
-						PortsToOpen: awsresources.PortPairs{
-							{
-								PortELB:      cluster.Spec.Cluster.Kubernetes.API.SecurePort,
-								PortInstance: cluster.Spec.Cluster.Kubernetes.API.SecurePort,
-							},
-						},
-						SecurityGroupID: mastersSecurityGroupID,
-						SubnetID:        publicSubnetID,
-					}
+	if err := s.checkResourceNames(awsConfig.AccountID(), cluster); err != nil {
+		s.logger.Log("error", fmt.Sprintf("not creating cluster '%s': %s", cluster.Name, errgo.Details(err)))
+		return
+	}
 
-					apiLB, err := s.createLoadBalancer(lbInput)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	if err := s.checkImageDeprecation(clients, cluster); err != nil {
+		s.logger.Log("error", fmt.Sprintf("not creating cluster '%s': %s", cluster.Name, errgo.Details(err)))
+		return
+	}
 
-					// Assign the ProxyProtocol policy to the apiserver load balancer.
-					if err := apiLB.AssignProxyProtocolPolicy(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	// Create keypair
+	var keyPair resources.ReusableResource
+	var keyPairCreated bool
+	{
+		var err error
+		keyPair = &awsresources.KeyPair{
+			ClusterName: cluster.Name,
+			Provider:    awsresources.NewFSKeyPairProvider(s.pubKeyFile),
+			AWSEntity:   s.awsEntity(clients),
+		}
+		keyPairCreated, err = keyPair.CreateIfNotExists()
+		if err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not create keypair: %s", errgo.Details(err)))
+			countResourceError(operationCreate, "keypair")
+			return
+		}
+	}
 
-					// Create etcd load balancer.
-					lbInput = LoadBalancerInput{
-						Name:        cluster.Spec.Cluster.Etcd.Domain,
-						Clients:     clients,
-						Cluster:     cluster,
-						InstanceIDs: masterIDs,
-						PortsToOpen: awsresources.PortPairs{
-							{
-								PortELB:      cluster.Spec.Cluster.Etcd.Port,
-								PortInstance: cluster.Spec.Cluster.Etcd.Port,
-							},
-						},
-						SecurityGroupID: mastersSecurityGroupID,
-						SubnetID:        publicSubnetID,
-					}
+	if keyPairCreated {
+		s.logger.Log("info", fmt.Sprintf("created keypair '%s'", cluster.Name))
+	} else {
+		s.logger.Log("info", fmt.Sprintf("keypair '%s' already exists, reusing", cluster.Name))
+	}
 
-					etcdLB, err := s.createLoadBalancer(lbInput)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	s.logger.Log("info", fmt.Sprintf("waiting for k8s secrets..."))
+	clusterID := cluster.Spec.Cluster.Cluster.ID
+	certs, err := s.certWatcher.SearchCerts(clusterID)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not get certificates from secrets: %v", errgo.Details(err)))
+		return
+	}
 
-					// Create public Hosted Zone for the API.
-					apiHZInput := hostedZoneInput{
-						Cluster: cluster,
-						Domain:  cluster.Spec.Cluster.Kubernetes.API.Domain,
-						Client:  clients.Route53,
-					}
+	// Create KMS key
+	kmsKey := &awsresources.KMSKey{
+		Name:      s.resourceName(cluster.Name),
+		AWSEntity: s.awsEntity(clients),
+	}
 
-					apiHZ, err := s.createHostedZone(apiHZInput)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
-					apiHZID := apiHZ.GetID()
+	kmsCreated, kmsKeyErr := kmsKey.CreateIfNotExists()
+	if kmsKeyErr != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create KMS key: %v", errgo.Details(kmsKeyErr)))
+		countResourceError(operationCreate, "kms key")
+		return
+	}
 
-					// Create private Hosted Zone for etcd traffic.
-					etcdHZInput := hostedZoneInput{
-						Cluster: cluster,
-						Domain:  cluster.Spec.Cluster.Etcd.Domain,
-						Client:  clients.Route53,
-					}
+	if kmsCreated {
+		s.logger.Log("info", fmt.Sprintf("created KMS key for cluster '%s'", cluster.Name))
+	} else {
+		s.logger.Log("info", fmt.Sprintf("kms key '%s' already exists, reusing", kmsKey.Name))
+	}
 
-					etcdHZ, err := s.createHostedZone(etcdHZInput)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
-					etcdHZID := etcdHZ.GetID()
+	// Encode TLS assets. The certificate secrets hold no certificates
+	// of single nodes yet, so all machines share the cluster's.
+	tlsAssets, err := s.encodeClusterTLSAssets(certs, nil, clients.KMS, kmsKey.Arn())
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not encode TLS assets: %s", errgo.Details(err)))
+		return
+	}
 
-					// Create public Hosted Zone for customer traffic.
-					ingressHZInput := hostedZoneInput{
-						Cluster: cluster,
-						Domain:  cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
-						Client:  clients.Route53,
-					}
+	// Create policy
+	bucketName := s.bucketName(awsConfig.AccountID(), cluster)
 
-					ingressHZ, err := s.createHostedZone(ingressHZInput)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
-					ingressHZID := ingressHZ.GetID()
-
-					// Run workers
-					anyWorkersCreated, workerIDs, err := s.runMachines(runMachinesInput{
-						clients:             clients,
-						cluster:             cluster,
-						tlsAssets:           tlsAssets,
-						bucket:              bucket,
-						securityGroup:       workersSecurityGroup,
-						subnet:              publicSubnet,
-						clusterName:         cluster.Name,
-						keyPairName:         cluster.Name,
-						instanceProfileName: policy.GetName(),
-						prefix:              prefixWorker,
-					})
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	var policy *awsresources.Policy
+	var policyCreated bool
+	var policyErr error
+	{
+		policy = &awsresources.Policy{
+			ClusterID:  cluster.Spec.Cluster.Cluster.ID,
+			KMSKeyArn:  kmsKey.Arn(),
+			NamePrefix: s.resourcePrefix,
+			S3Bucket:   bucketName,
+			AWSEntity:  s.awsEntity(clients),
+		}
+		policyCreated, policyErr = policy.CreateIfNotExists()
+	}
+	if policyErr != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create policy: %s", errgo.Details(policyErr)))
+		countResourceError(operationCreate, "policy")
+	} else if policyCreated {
+		s.logger.Log("info", fmt.Sprintf("created roles, policies, instance profiles for cluster '%s'", cluster.Name))
+	} else {
+		s.logger.Log("info", fmt.Sprintf("roles, policies, instance profiles for cluster '%s' already exist, reusing", cluster.Name))
+	}
 
-					// If the policy couldn't be created and some instances didn't exist before, that means that the cluster
-					// is inconsistent and most problably its deployment broke in the middle during the previous run of
-					// aws-operator.
-					if (anyMastersCreated || anyWorkersCreated) && (kmsKeyErr != nil || policyErr != nil) {
-						s.logger.Log("error", fmt.Sprintf("cluster '%s' is inconsistent, KMS keys and policies were not created, but EC2 instances were missing, please consider deleting this cluster", cluster.Name))
-						return
-					}
+	// Allow the instance role to decrypt the TLS assets. The role
+	// might not have been created by us, so this is done regardless of
+	// the policy error.
+	if err := s.grantKMSDecrypt(kmsKey, policy); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not grant the instance role access to KMS key '%s': %s", kmsKey.Name, errgo.Details(err)))
+		countResourceError(operationCreate, "kms key")
+		return
+	}
 
-					// Create Ingress load balancer.
-					lbInput = LoadBalancerInput{
-						Name:        cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
-						Clients:     clients,
-						Cluster:     cluster,
-						InstanceIDs: workerIDs,
-						PortsToOpen: awsresources.PortPairs{
-							{
-								PortELB:      httpsPort,
-								PortInstance: cluster.Spec.Cluster.Kubernetes.IngressController.SecurePort,
-							},
-							{
-								PortELB:      httpPort,
-								PortInstance: cluster.Spec.Cluster.Kubernetes.IngressController.InsecurePort,
-							},
-						},
-						SecurityGroupID: ingressSecurityGroupID,
-						SubnetID:        publicSubnetID,
-					}
+	// Create S3 bucket
+	var bucket resources.ReusableResource
+	var bucketCreated bool
+	{
+		var err error
+		bucket = &awsresources.Bucket{
+			Name:      bucketName,
+			AWSEntity: s.awsEntity(clients),
+		}
+		bucketCreated, err = bucket.CreateIfNotExists()
+		if err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not create S3 bucket: %s", errgo.Details(err)))
+			countResourceError(operationCreate, "bucket")
+			return
+		}
+	}
 
-					ingressLB, err := s.createLoadBalancer(lbInput)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	if bucketCreated {
+		s.logger.Log("info", fmt.Sprintf("created bucket '%s'", bucketName))
+	} else {
+		s.logger.Log("info", fmt.Sprintf("bucket '%s' already exists, reusing", bucketName))
+	}
 
-					// Assign the ProxyProtocol policy to the Ingress load balancer.
-					if err := ingressLB.AssignProxyProtocolPolicy(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-						return
-					}
+	if err := bucket.(*awsresources.Bucket).CheckRegion(region); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not use S3 bucket: %s", errgo.Details(err)))
+		countResourceError(operationCreate, "bucket")
+		return
+	}
 
-					s.logger.Log("info", fmt.Sprintf("created ingress load balancer"))
+	// Create VPC, unless the cluster shares an existing one.
+	dhcpOptions, err := s.vpcDHCPOptions(cluster)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not determine the DHCP options of the VPC: %s", errgo.Details(err)))
+		countResourceError(operationCreate, "vpc")
+		return
+	}
+	var vpc resources.ResourceWithID
+	vpc = &awsresources.VPC{
+		CidrBlock:        cluster.Spec.AWS.VPC.CIDR,
+		DHCPOptions:      dhcpOptions,
+		ExistingID:       sharedVPCID(cluster),
+		FlowLogs:         s.flowLogs,
+		FlowLogsS3Bucket: bucketName,
+		Name:             cluster.Name,
+		AWSEntity:        s.clusterAWSEntity(clients, cluster),
+	}
+	vpcCreated, err := vpc.CreateIfNotExists()
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create VPC: %s", errgo.Details(err)))
+		countResourceError(operationCreate, "vpc")
+		return
+	}
+	if vpcCreated {
+		s.logger.Log("info", fmt.Sprintf("created vpc for cluster '%s'", cluster.Name))
+	} else if sharedVPCID(cluster) != "" {
+		s.logger.Log("info", fmt.Sprintf("using shared vpc '%s' for cluster '%s'", sharedVPCID(cluster), cluster.Name))
+	} else {
+		s.logger.Log("info", fmt.Sprintf("vpc for cluster '%s' already exists, reusing", cluster.Name))
+	}
+	vpcID, err := vpc.GetID()
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+	}
 
-					// Create Record Sets for the Load Balancers.
-					recordSetInputs := []recordSetInput{
-						recordSetInput{
-							Cluster:      cluster,
-							Client:       clients.Route53,
-							Resource:     apiLB,
-							Domain:       cluster.Spec.Cluster.Kubernetes.API.Domain,
-							HostedZoneID: apiHZID,
-						},
-						recordSetInput{
-							Cluster:      cluster,
-							Client:       clients.Route53,
-							Resource:     etcdLB,
-							Domain:       cluster.Spec.Cluster.Etcd.Domain,
-							HostedZoneID: etcdHZID,
-						},
-						recordSetInput{
-							Cluster:      cluster,
-							Client:       clients.Route53,
-							Resource:     ingressLB,
-							Domain:       cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
-							HostedZoneID: ingressHZID,
-						},
-					}
+	if sharedVPCID(cluster) != "" {
+		if err := s.checkSharedVPCSubnets(clients, cluster, vpcID); err != nil {
+			s.logger.Log("error", fmt.Sprintf("not creating cluster '%s': %s", cluster.Name, errgo.Details(err)))
+			return
+		}
+	}
 
-					var rsErr error
-					for _, input := range recordSetInputs {
-						if rsErr = s.createRecordSet(input); rsErr != nil {
-							s.logger.Log("error", errgo.Details(rsErr))
-							return
-						}
-					}
-					if rsErr == nil {
-						s.logger.Log("info", fmt.Sprintf("created DNS records for load balancers"))
-					}
+	// Create gateway. Shared VPCs come with their gateway, which the route
+	// table finds by its attachment.
+	if sharedVPCID(cluster) == "" {
+		var gateway resources.ResourceWithID
+		gateway = &awsresources.Gateway{
+			Name:  cluster.Name,
+			VpcID: vpcID,
+			// Dependencies.
+			Logger:    s.logger,
+			AWSEntity: s.clusterAWSEntity(clients, cluster),
+		}
+		gatewayCreated, err := gateway.CreateIfNotExists()
+		if err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not create gateway: %s", errgo.Details(err)))
+			countResourceError(operationCreate, "gateway")
+			return
+		}
+		if gatewayCreated {
+			s.logger.Log("info", fmt.Sprintf("created gateway for cluster '%s'", cluster.Name))
+		} else {
+			s.logger.Log("info", fmt.Sprintf("gateway for cluster '%s' already exists, reusing", cluster.Name))
+		}
+	}
 
-					s.logger.Log("info", fmt.Sprintf("cluster '%s' processed", cluster.Name))
-				},
-				DeleteFunc: func(obj interface{}) {
-					// TODO(nhlfr): Move this to a separate operator.
+	// Create masters security group.
+	mastersSGInput := securityGroupInput{
+		Clients:   clients,
+		ClusterID: scopedClusterID(cluster),
+		GroupName: securityGroupName(cluster.Name, prefixMaster),
+		Tags:      s.clusterTags(cluster),
+		VPCID:     vpcID,
+	}
+	mastersSecurityGroup, err := s.createSecurityGroup(mastersSGInput)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create security group '%s': %s", mastersSGInput.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
+	mastersSecurityGroupID, err := mastersSecurityGroup.GetID()
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		return
+	}
 
-					// We can receive an instance of awstpr.CustomObject or cache.DeletedFinalStateUnknown.
-					// We need to assert the type properly and log an error when we cannot do that.
-					// Also, the cache.DeleteFinalStateUnknown object can contain the proper CustomObject,
-					// but doesn't have to.
-					// https://github.com/kubernetes/client-go/blob/7ba6be594966f4bec08a57a60c855d17a9f7000a/tools/cache/delta_fifo.go#L674-L677
-					var cluster awstpr.CustomObject
-					clusterPtr, ok := obj.(*awstpr.CustomObject)
-					if ok {
-						cluster = *clusterPtr
-					} else {
-						deletedObj, ok := obj.(cache.DeletedFinalStateUnknown)
-						if !ok {
-							s.logger.Log("error", "received unknown type of third-party object")
-							return
-						}
-						clusterPtr, ok := deletedObj.Obj.(*awstpr.CustomObject)
-						if !ok {
-							s.logger.Log("error", "received the proper delete request, but the type of third-party object is unknown")
-							return
-						}
-						cluster = *clusterPtr
-					}
+	// Create workers security group.
+	workersSGInput := securityGroupInput{
+		Clients:   clients,
+		ClusterID: scopedClusterID(cluster),
+		GroupName: securityGroupName(cluster.Name, prefixWorker),
+		Tags:      s.clusterTags(cluster),
+		VPCID:     vpcID,
+	}
+	workersSecurityGroup, err := s.createSecurityGroup(workersSGInput)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create security group '%s': %s", workersSGInput.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
+	workersSecurityGroupID, err := workersSecurityGroup.GetID()
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		return
+	}
 
-					if err := s.deleteClusterNamespace(cluster.Spec.Cluster); err != nil {
-						s.logger.Log("error", "could not delete cluster namespace:", err)
-					}
+	// Create ingress ELB security group.
+	ingressSGInput := securityGroupInput{
+		Clients:   clients,
+		ClusterID: scopedClusterID(cluster),
+		GroupName: securityGroupName(cluster.Name, prefixIngress),
+		Tags:      s.clusterTags(cluster),
+		VPCID:     vpcID,
+	}
+	ingressSecurityGroup, err := s.createSecurityGroup(ingressSGInput)
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create security group '%s': %s", ingressSGInput.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
+	ingressSecurityGroupID, err := ingressSecurityGroup.GetID()
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		return
+	}
 
-					clients := awsutil.NewClients(s.awsConfig)
+	// Create rules for the security groups.
+	rulesInput := rulesInput{
+		Cluster:                cluster,
+		MastersSecurityGroupID: mastersSecurityGroupID,
+		WorkersSecurityGroupID: workersSecurityGroupID,
+		IngressSecurityGroupID: ingressSecurityGroupID,
+		IngressSourceCIDRs:     s.ingressSourceCIDRs,
+	}
 
-					err := s.awsConfig.SetAccountID(clients.IAM)
-					if err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not retrieve amazon account id: %s", errgo.Details(err)))
-						return
-					}
+	if err := mastersSecurityGroup.ApplyRules(rulesInput.masterRules()); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", mastersSecurityGroup.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
 
-					// Delete masters.
-					s.logger.Log("info", "deleting masters...")
-					if err := s.deleteMachines(deleteMachinesInput{
-						clients:     clients,
-						clusterName: cluster.Name,
-						prefix:      prefixMaster,
-					}); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					} else {
-						s.logger.Log("info", "deleted masters")
-					}
+	if err := workersSecurityGroup.ApplyRules(rulesInput.workerRules()); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", workersSecurityGroup.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
 
-					// Delete workers.
-					s.logger.Log("info", "deleting workers...")
-					if err := s.deleteMachines(deleteMachinesInput{
-						clients:     clients,
-						clusterName: cluster.Name,
-						prefix:      prefixWorker,
-					}); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					} else {
-						s.logger.Log("info", "deleted workers")
-					}
+	if err := ingressSecurityGroup.ApplyRules(rulesInput.ingressRules()); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", ingressSecurityGroup.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
 
-					// Delete Record Sets.
-					apiLBName, err := loadBalancerName(cluster.Spec.Cluster.Kubernetes.API.Domain, cluster)
-					etcdLBName, err := loadBalancerName(cluster.Spec.Cluster.Etcd.Domain, cluster)
-					ingressLBName, err := loadBalancerName(cluster.Spec.Cluster.Kubernetes.IngressController.Domain, cluster)
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					} else {
-						apiLB, err := awsresources.NewELBFromExisting(apiLBName, clients.ELB)
-						etcdLB, err := awsresources.NewELBFromExisting(etcdLBName, clients.ELB)
-						ingressLB, err := awsresources.NewELBFromExisting(ingressLBName, clients.ELB)
-						if err != nil {
-							s.logger.Log("error", errgo.Details(err))
-						} else {
-							recordSetInputs := []recordSetInput{
-								recordSetInput{
-									Cluster:  cluster,
-									Client:   clients.Route53,
-									Resource: apiLB,
-									Domain:   cluster.Spec.Cluster.Kubernetes.API.Domain,
-								},
-								recordSetInput{
-									Cluster:  cluster,
-									Client:   clients.Route53,
-									Resource: etcdLB,
-									Domain:   cluster.Spec.Cluster.Etcd.Domain,
-								},
-								recordSetInput{
-									Cluster:  cluster,
-									Client:   clients.Route53,
-									Resource: ingressLB,
-									Domain:   cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
-								},
-							}
-
-							var rsErr error
-							for _, input := range recordSetInputs {
-								if rsErr = s.deleteRecordSet(input); rsErr != nil {
-									s.logger.Log("error", errgo.Details(rsErr))
-								}
-							}
-							if rsErr == nil {
-								s.logger.Log("info", "deleted API record sets")
-							}
-						}
-					}
+	// Let the ingress ELB reach the workers.
+	if err := workersSecurityGroup.ApplyRules(rulesInput.ingressInstanceRules()); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create rules for security group '%s': %s", workersSecurityGroup.GroupName, errgo.Details(err)))
+		countResourceError(operationCreate, "security group")
+		return
+	}
 
-					// Delete Load Balancers.
-					loadBalancerInputs := []LoadBalancerInput{
-						LoadBalancerInput{
-							Name:    cluster.Spec.Cluster.Kubernetes.API.Domain,
-							Clients: clients,
-							Cluster: cluster,
-						},
-						LoadBalancerInput{
-							Name:    cluster.Spec.Cluster.Etcd.Domain,
-							Clients: clients,
-							Cluster: cluster,
-						},
-						LoadBalancerInput{
-							Name:    cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
-							Clients: clients,
-							Cluster: cluster,
-						},
-					}
+	// Create route table.
+	routeTable := &awsresources.RouteTable{
+		Name:       cluster.Name,
+		VpcID:      vpcID,
+		Client:     clients.EC2,
+		OperatorID: s.operatorID,
+		Tags:       s.clusterTags(cluster),
+		ClusterID:  scopedClusterID(cluster),
+	}
+	routeTableCreated, err := routeTable.CreateIfNotExists()
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create route table: %s", errgo.Details(err)))
+		countResourceError(operationCreate, "route table")
+		return
+	}
+	if routeTableCreated {
+		s.logger.Log("info", "created route table")
+	} else {
+		s.logger.Log("info", "route table already exists, reusing")
+	}
 
-					var elbErr error
-					for _, lbInput := range loadBalancerInputs {
-						if elbErr = s.deleteLoadBalancer(lbInput); elbErr != nil {
-							s.logger.Log("error", errgo.Details(elbErr))
-						}
-					}
-					if elbErr == nil {
-						s.logger.Log("info", "deleted ELBs")
-					}
+	if err := routeTable.MakePublic(); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not make route table public: %s", errgo.Details(err)))
+		countResourceError(operationCreate, "route table")
+		return
+	}
 
-					// Delete route table.
-					var routeTable resources.ResourceWithID
-					routeTable = &awsresources.RouteTable{
-						Name:   cluster.Name,
-						Client: clients.EC2,
-					}
-					if err := routeTable.Delete(); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete route table: %s", errgo.Details(err)))
-					} else {
-						s.logger.Log("info", "deleted route table")
-					}
+	// Create S3 endpoint, so instances fetch their cloudconfig without
+	// leaving the AWS network.
+	if s.s3VPCEndpoint {
+		routeTableID, err := routeTable.GetID()
+		if err != nil {
+			s.logger.Log("error", errgo.Details(err))
+			return
+		}
 
-					// Sync VPC
-					var vpc resources.ResourceWithID
-					vpc = &awsresources.VPC{
-						Name:      cluster.Name,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					vpcID, err := vpc.GetID()
-					if err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					}
+		s3Endpoint := &awsresources.VPCEndpoint{
+			ServiceName:   awsresources.S3EndpointServiceName(cluster.Spec.AWS.Region),
+			VpcID:         vpcID,
+			RouteTableIDs: []string{routeTableID},
+			AWSEntity:     s.awsEntity(clients),
+		}
+		s3EndpointCreated, err := s3Endpoint.CreateIfNotExists()
+		if err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not create S3 VPC endpoint: %s", errgo.Details(err)))
+			countResourceError(operationCreate, "vpc endpoint")
+			return
+		}
+		if s3EndpointCreated {
+			s.logger.Log("info", fmt.Sprintf("created S3 VPC endpoint for cluster '%s'", cluster.Name))
+		} else {
+			s.logger.Log("info", fmt.Sprintf("S3 VPC endpoint for cluster '%s' already exists, reusing", cluster.Name))
+		}
+	}
 
-					// Delete gateway.
-					var gateway resources.ResourceWithID
-					gateway = &awsresources.Gateway{
-						Name:  cluster.Name,
-						VpcID: vpcID,
-						// Dependencies.
-						Logger:    s.logger,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					if err := gateway.Delete(); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete gateway: %s", errgo.Details(err)))
-					} else {
-						s.logger.Log("info", "deleted gateway")
-					}
+	// Create public subnet for the masters
+	publicSubnet := &awsresources.Subnet{
+		AvailabilityZone: cluster.Spec.AWS.AZ,
+		CidrBlock:        cluster.Spec.AWS.VPC.PublicSubnetCIDR,
+		Name:             subnetName(cluster, suffixPublic),
+		VpcID:            vpcID,
+		// Dependencies.
+		Logger:    s.logger,
+		AWSEntity: s.clusterAWSEntity(clients, cluster),
+	}
+	publicSubnetCreated, err := publicSubnet.CreateIfNotExists()
+	if err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not create public subnet: %s", errgo.Details(err)))
+		countResourceError(operationCreate, "subnet")
+		return
+	}
+	if publicSubnetCreated {
+		s.logger.Log("info", fmt.Sprintf("created public subnet for cluster '%s'", cluster.Name))
+	} else {
+		s.logger.Log("info", fmt.Sprintf("public subnet for cluster '%s' already exists, reusing", cluster.Name))
+	}
+	publicSubnetID, err := publicSubnet.GetID()
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		return
+	}
 
-					// Delete public subnet.
-					publicSubnet := &awsresources.Subnet{
-						Name: subnetName(cluster, suffixPublic),
-						// Dependencies.
-						Logger:    s.logger,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					if err := publicSubnet.Delete(); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete public subnet: %s", errgo.Details(err)))
-					} else {
-						s.logger.Log("info", "deleted public subnet")
-					}
+	if err := publicSubnet.MakePublic(routeTable); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not make subnet public, %s", errgo.Details(err)))
+		countResourceError(operationCreate, "subnet")
+		return
+	}
 
-					// Delete masters security group.
-					mastersSGInput := securityGroupInput{
-						Clients:   clients,
-						GroupName: securityGroupName(cluster.Name, prefixMaster),
-					}
-					if err := s.deleteSecurityGroup(mastersSGInput); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete security group '%s': %s", mastersSGInput.GroupName, errgo.Details(err)))
-					}
+	// Run masters
+	anyMastersCreated, masterIDs, err := s.runMachines(runMachinesInput{
+		clients:             clients,
+		cluster:             cluster,
+		tlsAssets:           tlsAssets,
+		clusterName:         cluster.Name,
+		bucket:              bucket,
+		securityGroup:       mastersSecurityGroup,
+		subnet:              publicSubnet,
+		keyPairName:         cluster.Name,
+		instanceProfileName: policy.GetName(),
+		prefix:              prefixMaster,
+	})
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		countResourceError(operationCreate, "instance")
+	}
 
-					// Delete workers security group.
-					workersSGInput := securityGroupInput{
-						Clients:   clients,
-						GroupName: securityGroupName(cluster.Name, prefixWorker),
-					}
-					if err := s.deleteSecurityGroup(workersSGInput); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete security group '%s': %s", workersSGInput.GroupName, errgo.Details(err)))
-					}
+	if !validateIDs(masterIDs) {
+		s.logger.Log("error", fmt.Sprintf("master nodes had invalid instance IDs: %v", masterIDs))
+		return
+	}
 
-					// Delete ingress security group.
-					ingressSGInput := securityGroupInput{
-						Clients:   clients,
-						GroupName: securityGroupName(cluster.Name, prefixIngress),
-					}
-					if err := s.deleteSecurityGroup(ingressSGInput); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete security group '%s': %s", ingressSGInput.GroupName, errgo.Details(err)))
-					}
+	// Create apiserver load balancers.
+	apiLBs := make(map[string]*awsresources.ELB)
+	for _, apiLoadBalancer := range s.apiLoadBalancers(cluster) {
+		lbInput := LoadBalancerInput{
+			Name:        apiLoadBalancer.Domain,
+			Clients:     clients,
+			Cluster:     cluster,
+			InstanceIDs: masterIDs,
+			PortsToOpen: awsresources.PortPairs{
+				{
+					PortELB:      cluster.Spec.Cluster.Kubernetes.API.SecurePort,
+					PortInstance: cluster.Spec.Cluster.Kubernetes.API.SecurePort,
+				},
+			},
+			HealthCheck:     s.apiHealthCheck(cluster),
+			Scheme:          apiLoadBalancer.Scheme,
+			SecurityGroupID: mastersSecurityGroupID,
+			SubnetID:        publicSubnetID,
+		}
 
-					// Delete VPC.
-					if err := vpc.Delete(); err != nil {
-						s.logger.Log("error", fmt.Sprintf("could not delete vpc: %s", errgo.Details(err)))
-					} else {
-						s.logger.Log("info", "deleted vpc")
-					}
+		apiLB, err := s.createLoadBalancer(lbInput)
+		if err != nil {
+			s.logger.Log("error", errgo.Details(err))
+			countResourceError(operationCreate, "elb")
+			return
+		}
 
-					// Delete S3 bucket objects.
-					bucketName := s.bucketName(cluster)
+		// Assign the ProxyProtocol policy to the apiserver load balancer.
+		if err := apiLB.AssignProxyProtocolPolicy(); err != nil {
+			s.logger.Log("error", errgo.Details(err))
+			countResourceError(operationCreate, "elb")
+			return
+		}
 
-					var bucket resources.Resource
-					bucket = &awsresources.Bucket{
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-						Name:      bucketName,
-					}
+		apiLBs[apiLoadBalancer.Domain] = apiLB
+	}
 
-					var masterBucketObject resources.Resource
-					masterBucketObject = &awsresources.BucketObject{
-						Name:      s.bucketObjectName(cluster, prefixMaster),
-						Bucket:    bucket.(*awsresources.Bucket),
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					if err := masterBucketObject.Delete(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					}
+	// Create etcd load balancer.
+	lbInput := LoadBalancerInput{
+		Name:        cluster.Spec.Cluster.Etcd.Domain,
+		Clients:     clients,
+		Cluster:     cluster,
+		InstanceIDs: masterIDs,
+		PortsToOpen: awsresources.PortPairs{
+			{
+				PortELB:      cluster.Spec.Cluster.Etcd.Port,
+				PortInstance: cluster.Spec.Cluster.Etcd.Port,
+			},
+		},
+		SecurityGroupID: mastersSecurityGroupID,
+		SubnetID:        publicSubnetID,
+	}
 
-					var workerBucketObject resources.Resource
-					workerBucketObject = &awsresources.BucketObject{
-						Name:      s.bucketObjectName(cluster, prefixWorker),
-						Bucket:    bucket.(*awsresources.Bucket),
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					if err := workerBucketObject.Delete(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					}
+	etcdLB, err := s.createLoadBalancer(lbInput)
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		countResourceError(operationCreate, "elb")
+		return
+	}
 
-					s.logger.Log("info", "deleted bucket objects")
+	// Create the Hosted Zones of the DNS components.
+	hostedZoneIDs := make(map[string]string)
+	for _, component := range s.dnsComponents {
+		hz, err := s.createHostedZone(hostedZoneInput{
+			Cluster: cluster,
+			Domain:  componentDomain(cluster, component),
+			Client:  clients.Route53,
+		})
+		if err != nil {
+			s.logger.Log("error", errgo.Details(err))
+			countResourceError(operationCreate, "hosted zone")
+			return
+		}
+		hostedZoneIDs[component] = hz.GetID()
+	}
 
-					// Delete policy.
-					var policy resources.NamedResource
-					policy = &awsresources.Policy{
-						ClusterID: cluster.Spec.Cluster.Cluster.ID,
-						S3Bucket:  bucketName,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					if err := policy.Delete(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					} else {
-						s.logger.Log("info", "deleted roles, policies, instance profiles")
-					}
+	// Workers only join the cluster once the API is reachable.
+	if s.waitForMastersReady {
+		apiLB := apiLBs[cluster.Spec.Cluster.Kubernetes.API.Domain]
+		if err := s.waitForMastersInService(apiLB, masterIDs, newMastersReadyBackoff()); err != nil {
+			s.logger.Log("error", fmt.Sprintf("masters are not ready, not creating workers: %s", errgo.Details(err)))
+			return
+		}
+	}
 
-					// Delete KMS key.
-					var kmsKey resources.ArnResource
-					kmsKey = &awsresources.KMSKey{
-						Name:      cluster.Name,
-						AWSEntity: awsresources.AWSEntity{Clients: clients},
-					}
-					if err := kmsKey.Delete(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					} else {
-						s.logger.Log("info", "deleted KMS key")
-					}
+	// Run workers
+	anyWorkersCreated, workerIDs, err := s.runMachines(runMachinesInput{
+		clients:             clients,
+		cluster:             cluster,
+		tlsAssets:           tlsAssets,
+		bucket:              bucket,
+		securityGroup:       workersSecurityGroup,
+		subnet:              publicSubnet,
+		clusterName:         cluster.Name,
+		keyPairName:         cluster.Name,
+		instanceProfileName: policy.GetName(),
+		prefix:              prefixWorker,
+	})
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		countResourceError(operationCreate, "instance")
+		return
+	}
 
-					// Delete keypair.
-					var keyPair resources.Resource
-					keyPair = &awsresources.KeyPair{
-						ClusterName: cluster.Name,
-						AWSEntity:   awsresources.AWSEntity{Clients: clients},
-					}
-					if err := keyPair.Delete(); err != nil {
-						s.logger.Log("error", errgo.Details(err))
-					} else {
-						s.logger.Log("info", "deleted keypair")
-					}
+	// If the policy couldn't be created and some instances didn't exist before, that means that the cluster
+	// is inconsistent and most problably its deployment broke in the middle during the previous run of
+	// aws-operator.
+	if (anyMastersCreated || anyWorkersCreated) && (kmsKeyErr != nil || policyErr != nil) {
+		s.logger.Log("error", fmt.Sprintf("cluster '%s' is inconsistent, KMS keys and policies were not created, but EC2 instances were missing, please consider deleting this cluster", cluster.Name))
+		return
+	}
 
-					s.logger.Log("info", fmt.Sprintf("cluster '%s' deleted", cluster.Name))
-				},
-			},
-		)
+	// Create Ingress load balancer.
+	lbInput = LoadBalancerInput{
+		Name:            cluster.Spec.Cluster.Kubernetes.IngressController.Domain,
+		Clients:         clients,
+		Cluster:         cluster,
+		InstanceIDs:     workerIDs,
+		PortsToOpen:     ingressPortPairs(cluster),
+		SecurityGroupID: ingressSecurityGroupID,
+		SubnetID:        publicSubnetID,
+	}
 
-		s.logger.Log("info", "starting watch")
+	ingressLB, err := s.createLoadBalancer(lbInput)
+	if err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		countResourceError(operationCreate, "elb")
+		return
+	}
 
-		// Cluster informer lifecycle can be interrupted by putting a value into a "stop channel".
-		// We aren't currently using that functionality, so we are passing a nil here.
-		clusterInformer.Run(nil)
-	})
+	// Assign the ProxyProtocol policy to the Ingress load balancer.
+	if err := ingressLB.AssignProxyProtocolPolicy(); err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		countResourceError(operationCreate, "elb")
+		return
+	}
+
+	s.logger.Log("info", fmt.Sprintf("created ingress load balancer"))
+
+	// Create Record Sets for the Load Balancers of the DNS components.
+	loadBalancers := map[string]resources.DNSNamedResource{
+		cluster.Spec.Cluster.Etcd.Domain:                         etcdLB,
+		cluster.Spec.Cluster.Kubernetes.IngressController.Domain: ingressLB,
+	}
+	for domain, apiLB := range apiLBs {
+		loadBalancers[domain] = apiLB
+	}
+
+	var recordSetInputs []recordSetInput
+	for _, record := range s.dnsRecords(cluster) {
+		recordSetInputs = append(recordSetInputs, recordSetInput{
+			Cluster:      cluster,
+			Client:       clients.Route53,
+			Resource:     loadBalancers[record.Domain],
+			Domain:       record.Domain,
+			HostedZoneID: hostedZoneIDs[record.Component],
+		})
+	}
+
+	var recordSetOperations []func() error
+	for _, input := range recordSetInputs {
+		input := input
+		recordSetOperations = append(recordSetOperations, func() error {
+			return s.createRecordSet(input)
+		})
+	}
+	if err := s.dnsExecutor.run(recordSetOperations); err != nil {
+		s.logger.Log("error", errgo.Details(err))
+		countResourceError(operationCreate, "record set")
+		return
+	}
+	s.logger.Log("info", fmt.Sprintf("created DNS records for load balancers"))
+
+	s.logger.Log("info", fmt.Sprintf("cluster '%s' processed", cluster.Name))
+
+	result = clusterReused
+	if anyMastersCreated || anyWorkersCreated {
+		result = clusterCreated
+	}
 }
 
 type instanceNameInput struct {
-	clusterName string
-	prefix      string
-	no          int
+	// resourcePrefix is prepended to the instance name, see
+	// Config.ResourcePrefix.
+	resourcePrefix string
+	clusterName    string
+	prefix         string
+	no             int
 }
 
 func instanceName(input instanceNameInput) string {
-	return fmt.Sprintf(instanceNameFormat, input.clusterName, input.prefix, input.no)
+	return input.resourcePrefix + fmt.Sprintf(instanceNameFormat, input.clusterName, input.prefix, input.no)
 }
 
 type clusterPrefixInput struct {
-	clusterName string
-	prefix      string
+	// resourcePrefix is prepended to the instance names, see
+	// Config.ResourcePrefix.
+	resourcePrefix string
+	clusterName    string
+	prefix         string
 }
 
 func clusterPrefix(input clusterPrefixInput) string {
-	return fmt.Sprintf(instanceClusterPrefixFormat, input.clusterName, input.prefix)
+	return input.resourcePrefix + fmt.Sprintf(instanceClusterPrefixFormat, input.clusterName, input.prefix)
 }
 
 type runMachinesInput struct {
 	clients             awsutil.Clients
 	cluster             awstpr.CustomObject
-	tlsAssets           *certificatetpr.CompactTLSAssets
+	tlsAssets           nodeTLSAssets
 	bucket              resources.Resource
 	securityGroup       resources.ResourceWithID
 	subnet              *awsresources.Subnet
@@ -1011,11 +1429,8 @@ type runMachinesInput struct {
 
 func (s *Service) runMachines(input runMachinesInput) (bool, []string, error) {
 	var (
-		anyCreated bool
-
 		machines    []node.Node
 		awsMachines []awsinfo.Node
-		instanceIDs []string
 	)
 
 	switch input.prefix {
@@ -1027,25 +1442,27 @@ func (s *Service) runMachines(input runMachinesInput) (bool, []string, error) {
 		awsMachines = input.cluster.Spec.AWS.Workers
 	}
 
-	// TODO(nhlfr): Create a separate module for validating specs and execute on the earlier stages.
-	if len(machines) != len(awsMachines) {
-		return false, nil, microerror.MaskAny(fmt.Errorf("mismatched number of %s machines in the 'spec' and 'aws' sections: %d != %d",
-			input.prefix,
-			len(machines),
-			len(awsMachines)))
+	// The specs are checked by validateCluster before any resource is
+	// created, this only guards against calls with unvalidated ones.
+	if err := validateMachines(input.prefix, len(machines), awsMachines); err != nil {
+		return false, nil, microerror.MaskAny(err)
 	}
 
-	for i := 0; i < len(machines); i++ {
+	// Machines are launched concurrently, but named after their index, so
+	// every machine keeps its instance, cloudconfig and TLS assets.
+	return launchMachines(len(machines), s.launchConcurrency, func(i int) (bool, string, error) {
 		name := instanceName(instanceNameInput{
-			clusterName: input.clusterName,
-			prefix:      input.prefix,
-			no:          i,
+			resourcePrefix: s.resourcePrefix,
+			clusterName:    input.clusterName,
+			prefix:         input.prefix,
+			no:             i,
 		})
 		created, instanceID, err := s.runMachine(runMachineInput{
 			clients:             input.clients,
 			cluster:             input.cluster,
 			machine:             machines[i],
 			awsNode:             awsMachines[i],
+			index:               i,
 			tlsAssets:           input.tlsAssets,
 			bucket:              input.bucket,
 			securityGroup:       input.securityGroup,
@@ -1057,19 +1474,17 @@ func (s *Service) runMachines(input runMachinesInput) (bool, []string, error) {
 			prefix:              input.prefix,
 		})
 		if err != nil {
-			return false, nil, microerror.MaskAny(err)
-		}
-		if created {
-			anyCreated = true
+			return false, "", microerror.MaskAny(err)
 		}
 
-		instanceIDs = append(instanceIDs, instanceID)
-	}
-	return anyCreated, instanceIDs, nil
+		return created, instanceID, nil
+	})
 }
 
 // if the instance already exists, return (instanceID, false)
 // otherwise (nil, true)
+// Terminated and shutting down instances are gone for good, so they are
+// treated as absent and can be replaced.
 func allExistingInstancesMatch(instances *ec2.DescribeInstancesOutput, state awsresources.EC2StateCode) (*string, bool) {
 	// If the instance doesn't exist, then the Reservations field should be nil.
 	// Otherwise, it will contain a slice of instances (which is going to contain our one instance we queried for).
@@ -1078,6 +1493,10 @@ func allExistingInstancesMatch(instances *ec2.DescribeInstancesOutput, state aws
 	if instances.Reservations != nil {
 		for _, r := range instances.Reservations {
 			for _, i := range r.Instances {
+				switch awsresources.EC2StateCode(*i.State.Code) {
+				case awsresources.EC2TerminatedState, awsresources.EC2ShuttingDownState:
+					continue
+				}
 				if *i.State.Code != int64(state) {
 					return i.InstanceId, false
 				}
@@ -1105,7 +1524,8 @@ type runMachineInput struct {
 	cluster             awstpr.CustomObject
 	machine             node.Node
 	awsNode             awsinfo.Node
-	tlsAssets           *certificatetpr.CompactTLSAssets
+	index               int
+	tlsAssets           nodeTLSAssets
 	bucket              resources.Resource
 	securityGroup       resources.ResourceWithID
 	subnet              *awsresources.Subnet
@@ -1117,35 +1537,26 @@ type runMachineInput struct {
 }
 
 func (s *Service) runMachine(input runMachineInput) (bool, string, error) {
-	cloudConfigParams := cloudconfig.CloudConfigTemplateParams{
-		Cluster: input.cluster.Spec.Cluster,
-		Node:    input.machine,
-	}
-
-	cloudConfig, err := s.cloudConfig(input.prefix, cloudConfigParams, input.cluster.Spec, input.tlsAssets)
-	if err != nil {
+	if err := s.uploadCloudConfig(input); err != nil {
 		return false, "", microerror.MaskAny(err)
 	}
 
-	// We now upload the instance cloudconfig to S3 and create a "small
+	// We have uploaded the instance cloudconfig to S3 and now create a "small
 	// cloudconfig" that just fetches the previously uploaded "final
 	// cloudconfig" and executes coreos-cloudinit with it as argument.
 	// We do this to circumvent the 16KB limit on user-data for EC2 instances.
 	cloudconfigConfig := SmallCloudconfigConfig{
-		MachineType: input.prefix,
-		Region:      input.cluster.Spec.AWS.Region,
-		S3DirURI:    s.bucketObjectFullDirPath(input.cluster),
-	}
-
-	var cloudconfigS3 resources.Resource
-	cloudconfigS3 = &awsresources.BucketObject{
-		Name:      s.bucketObjectName(input.cluster, input.prefix),
-		Data:      cloudConfig,
-		Bucket:    input.bucket.(*awsresources.Bucket),
-		AWSEntity: awsresources.AWSEntity{Clients: input.clients},
+		ObjectName: machineID(input.prefix, input.index),
+		Region:     input.cluster.Spec.AWS.Region,
+		S3DirURI:   s.bucketObjectFullDirPath(input.bucket.(*awsresources.Bucket).Name, input.cluster),
+		Gzip:       s.cloudConfigEncoding == CloudConfigEncodingGzipBase64,
 	}
-	if err := cloudconfigS3.CreateOrFail(); err != nil {
-		return false, "", microerror.MaskAny(err)
+	if s.instanceHostnames {
+		hostname, err := instanceHostname(input.name)
+		if err != nil {
+			return false, "", microerror.MaskAny(err)
+		}
+		cloudconfigConfig.Hostname = hostname
 	}
 
 	smallCloudconfig, err := s.SmallCloudconfig(cloudconfigConfig)
@@ -1163,6 +1574,18 @@ func (s *Service) runMachine(input runMachineInput) (bool, string, error) {
 		return false, "", microerror.MaskAny(err)
 	}
 
+	// Masters run etcd, which needs dedicated bandwidth to its volume.
+	var ebsOptimized *bool
+	if input.prefix == prefixMaster && awsresources.EBSOptimizable(input.awsNode.InstanceType) {
+		ebsOptimized = aws.Bool(true)
+	}
+
+	// Masters run etcd, so terminating them by accident loses its data.
+	protected := input.prefix == prefixMaster
+
+	// Workers run stateless pods, so they can be spot instances.
+	spot := input.prefix == prefixWorker && s.workerSpotMaxPrice != ""
+
 	var instance *awsresources.Instance
 	var instanceCreated bool
 	{
@@ -1177,11 +1600,15 @@ func (s *Service) runMachine(input runMachineInput) (bool, string, error) {
 			MaxCount:               1,
 			SmallCloudconfig:       smallCloudconfig,
 			IamInstanceProfileName: input.instanceProfileName,
+			EBSOptimized:           ebsOptimized,
+			DisableAPITermination:  protected,
+			Spot:                   spot,
+			SpotMaxPrice:           s.workerSpotMaxPrice,
 			PlacementAZ:            input.cluster.Spec.AWS.AZ,
 			SecurityGroupID:        securityGroupID,
 			SubnetID:               subnetID,
 			Logger:                 s.logger,
-			AWSEntity:              awsresources.AWSEntity{Clients: input.clients},
+			AWSEntity:              s.clusterAWSEntity(input.clients, input.cluster),
 		}
 		instanceCreated, err = instance.CreateIfNotExists()
 		if err != nil {
@@ -1195,39 +1622,248 @@ func (s *Service) runMachine(input runMachineInput) (bool, string, error) {
 		s.logger.Log("info", fmt.Sprintf("instance '%s' already exists, reusing", input.name))
 	}
 
+	// The instance profile of the cluster might have changed since the instance
+	// was launched, e.g. when it was recreated. The profile is unknown when the
+	// policy couldn't be created.
+	if !instanceCreated && input.instanceProfileName != "" {
+		replaced, err := instance.ReplaceInstanceProfile(input.instanceProfileName)
+		if err != nil {
+			return false, "", microerror.MaskAnyf(err, "could not reconcile the instance profile of instance '%s'", input.name)
+		}
+		if replaced {
+			s.logger.Log("info", fmt.Sprintf("associated instance '%s' with instance profile '%s'", input.name, input.instanceProfileName))
+		}
+	}
+
+	if err := s.waitForNewInstance(input.cluster, instance, input.name, instanceCreated); err != nil {
+		return false, "", microerror.MaskAny(err)
+	}
+
 	s.logger.Log("info", fmt.Sprintf("instance '%s' tagged", input.name))
 
 	return instanceCreated, instance.ID(), nil
 }
 
+// uploadCloudConfig renders the final cloudconfig of a machine and uploads it
+// to S3, where the small cloudconfig of its instance fetches it from.
+func (s *Service) uploadCloudConfig(input runMachineInput) error {
+	cloudConfigParams := cloudconfig.CloudConfigTemplateParams{
+		Cluster: input.cluster.Spec.Cluster,
+		Node:    input.machine,
+	}
+
+	id := machineID(input.prefix, input.index)
+	cloudConfig, err := s.cloudConfig(input.prefix, cloudConfigParams, input.cluster.Spec, input.tlsAssets.forMachine(id))
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if err := checkEncodedCloudConfig(cloudConfig, s.cloudConfigEncoding); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	var cloudconfigS3 resources.Resource
+	cloudconfigS3 = &awsresources.BucketObject{
+		Name:      s.bucketObjectName(input.cluster, id),
+		Data:      cloudConfig,
+		Bucket:    input.bucket.(*awsresources.Bucket),
+		AWSEntity: s.awsEntity(input.clients),
+	}
+	if err := cloudconfigS3.CreateOrFail(); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
+
+// deleteCloudConfigObjects deletes the S3 objects holding the final
+// cloudconfigs of the given number of machines with the given prefix. The
+// object shared by all the machines of the prefix before they had their own is
+// deleted as well.
+func (s *Service) deleteCloudConfigObjects(clients awsutil.Clients, bucket *awsresources.Bucket, cluster awstpr.CustomObject, prefix string, count int) error {
+	names := []string{s.bucketObjectName(cluster, prefix)}
+	for i := 0; i < count; i++ {
+		names = append(names, s.bucketObjectName(cluster, machineID(prefix, i)))
+	}
+
+	return s.deleteBucketObjects(clients, bucket, names)
+}
+
+// deleteBucketObjects deletes the S3 objects with the given names.
+func (s *Service) deleteBucketObjects(clients awsutil.Clients, bucket *awsresources.Bucket, names []string) error {
+	for _, name := range names {
+		bucketObject := &awsresources.BucketObject{
+			Name:      name,
+			Bucket:    bucket,
+			AWSEntity: s.awsEntity(clients),
+		}
+		if err := bucketObject.Delete(); err != nil {
+			return microerror.MaskAnyf(err, "could not delete bucket object '%s'", name)
+		}
+	}
+
+	return nil
+}
+
 type deleteMachinesInput struct {
 	clients     awsutil.Clients
 	spec        awstpr.Spec
 	clusterName string
 	prefix      string
+	// removeEtcdMembers removes masters from the etcd cluster of spec before
+	// terminating them. It is pointless when the whole cluster is deleted.
+	removeEtcdMembers bool
+	// names restricts the deletion to the instances with the given names. All
+	// the instances of the prefix are deleted when it is empty.
+	names []string
+	// ids restricts the deletion to the instances with the given IDs, like
+	// names does.
+	ids []string
 }
 
-func (s *Service) deleteMachines(input deleteMachinesInput) error {
+// findMachines returns the instances of the cluster with the given prefix which
+// aren't terminated yet.
+func (s *Service) findMachines(clients awsutil.Clients, clusterName, prefix string) ([]*awsresources.Instance, error) {
 	pattern := clusterPrefix(clusterPrefixInput{
-		clusterName: input.clusterName,
-		prefix:      input.prefix,
+		resourcePrefix: s.resourcePrefix,
+		clusterName:    clusterName,
+		prefix:         prefix,
 	})
+	// Stopped instances are deleted as well, terminated ones are already gone.
 	instances, err := awsresources.FindInstances(awsresources.FindInstancesInput{
-		Clients: input.clients,
-		Logger:  s.logger,
-		Pattern: pattern,
+		Clients:    clients,
+		Logger:     s.logger,
+		OperatorID: s.operatorID,
+		Pattern:    pattern,
+		States: []awsresources.EC2StateCode{
+			awsresources.EC2PendingState,
+			awsresources.EC2RunningState,
+			awsresources.EC2StoppingState,
+			awsresources.EC2StoppedState,
+		},
 	})
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return instances, nil
+}
+
+func (s *Service) deleteMachines(input deleteMachinesInput) error {
+	instances, err := s.findMachines(input.clients, input.clusterName, input.prefix)
 	if err != nil {
 		return microerror.MaskAny(err)
 	}
+	if len(input.names) > 0 {
+		instances = instancesNamed(instances, input.names)
+	}
+	if len(input.ids) > 0 {
+		instances = instancesWithIDs(instances, input.ids)
+	}
+
+	// Masters get removed from the etcd cluster before being terminated, so the
+	// remaining members keep a healthy quorum. Connecting is best effort, since
+	// etcd might be unreachable on broken clusters.
+	var membersAPI etcdclient.MembersAPI
+	if input.removeEtcdMembers && input.prefix == prefixMaster {
+		membersAPI, err = etcdMembersAPI(s, input.spec)
+		if err != nil {
+			s.logger.Log("error", fmt.Sprintf("could not connect to etcd, skipping member removal: %s", errgo.Details(err)))
+		}
+	}
+
+	// Instances are taken out of the load balancers before being terminated,
+	// so their connections aren't dropped abruptly. This is best effort, the
+	// load balancers might be gone already.
+	var instanceIDs []string
+	for _, instance := range instances {
+		instanceIDs = append(instanceIDs, instance.ID())
+	}
+	if err := s.deregisterFromLoadBalancers(input.clusterName, input.clients, instanceIDs); err != nil {
+		s.logger.Log("error", fmt.Sprintf("could not deregister instances from the load balancers: %s", errgo.Details(err)))
+	}
 
+	// All instances are attempted, the first error is returned.
+	var firstErr error
 	for _, instance := range instances {
-		if err := instance.Delete(); err != nil {
-			return microerror.MaskAny(err)
+		// A master whose member cannot be removed from a reachable etcd is kept,
+		// terminating it would leave etcd with a member which is gone for good.
+		// Masters without a member have nothing to remove, and an unreachable
+		// etcd has no quorum left to protect.
+		if membersAPI != nil {
+			err := removeEtcdMember(membersAPI, instance.PrivateIPAddress(), instance.PrivateDNSName())
+			if IsEtcdMemberNotFound(err) {
+				s.logger.Log("info", fmt.Sprintf("no etcd member of instance '%s' to remove", instance.ID()))
+			} else if IsEtcdUnreachable(err) {
+				s.logger.Log("error", fmt.Sprintf("could not reach etcd, terminating instance '%s' without removing its member: %s", instance.ID(), errgo.Details(err)))
+			} else if err != nil {
+				s.logger.Log("error", fmt.Sprintf("could not remove etcd member of instance '%s', keeping it: %s", instance.ID(), errgo.Details(err)))
+				if firstErr == nil {
+					firstErr = microerror.MaskAny(err)
+				}
+				continue
+			} else {
+				s.logger.Log("info", fmt.Sprintf("removed etcd member of instance '%s'", instance.ID()))
+			}
+		}
+
+		// Workers get drained before being terminated, so their pods can be
+		// rescheduled gracefully. Draining is best effort, a node which cannot be
+		// drained in time is terminated anyway.
+		if s.drainNodes && input.prefix == prefixWorker {
+			// The kubelet registers nodes under their private IP address.
+			nodeName := instance.PrivateIPAddress()
+			s.logger.Log("info", fmt.Sprintf("draining node '%s'", nodeName))
+			if err := s.drainNode(nodeName); err != nil {
+				s.logger.Log("error", fmt.Sprintf("could not drain node '%s': %s", nodeName, errgo.Details(err)))
+			} else {
+				s.logger.Log("info", fmt.Sprintf("drained node '%s'", nodeName))
+			}
+		}
+
+		// The instance might have been terminated since it was found.
+		if err := instance.Delete(); err != nil && !awsresources.IsAlreadyDeleted(err) {
+			s.logger.Log("error", fmt.Sprintf("could not delete instance '%s': %s", instance.ID(), errgo.Details(err)))
+			if firstErr == nil {
+				firstErr = microerror.MaskAny(err)
+			}
 		}
 	}
 
-	return nil
+	return firstErr
+}
+
+// instancesNamed returns the instances with the given names.
+func instancesNamed(instances []*awsresources.Instance, names []string) []*awsresources.Instance {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	var named []*awsresources.Instance
+	for _, instance := range instances {
+		if wanted[instance.Name] {
+			named = append(named, instance)
+		}
+	}
+
+	return named
+}
+
+// instancesWithIDs returns the instances with the given IDs.
+func instancesWithIDs(instances []*awsresources.Instance, ids []string) []*awsresources.Instance {
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	var matching []*awsresources.Instance
+	for _, instance := range instances {
+		if wanted[instance.ID()] {
+			matching = append(matching, instance)
+		}
+	}
+
+	return matching
 }
 
 type deleteMachineInput struct {
@@ -6,8 +6,10 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+	micrologger "github.com/giantswarm/microkit/logger"
 	"github.com/stretchr/testify/assert"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
 )
 
 func TestAllExistingInstancesMatch(t *testing.T) {
@@ -239,6 +241,45 @@ func TestAllExistingInstancesMatch(t *testing.T) {
 	}
 }
 
+func TestAllExistingInstancesMatchStates(t *testing.T) {
+	tests := []struct {
+		state awsresources.EC2StateCode
+		res   bool
+	}{
+		{state: awsresources.EC2PendingState, res: false},
+		{state: awsresources.EC2RunningState, res: true},
+		{state: awsresources.EC2ShuttingDownState, res: true},
+		{state: awsresources.EC2TerminatedState, res: true},
+		{state: awsresources.EC2StoppingState, res: false},
+		{state: awsresources.EC2StoppedState, res: false},
+	}
+
+	for _, tc := range tests {
+		instances := &ec2.DescribeInstancesOutput{
+			Reservations: []*ec2.Reservation{
+				{
+					Instances: []*ec2.Instance{
+						{
+							InstanceId: aws.String("i-123"),
+							State: &ec2.InstanceState{
+								Code: aws.Int64(int64(tc.state)),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		id, res := allExistingInstancesMatch(instances, awsresources.EC2RunningState)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[state %d] Wrong match of a running instance", tc.state))
+		if tc.res {
+			assert.Nil(t, id, fmt.Sprintf("[state %d] Unexpected instance", tc.state))
+		} else {
+			assert.Equal(t, "i-123", aws.StringValue(id), fmt.Sprintf("[state %d] Wrong instance", tc.state))
+		}
+	}
+}
+
 func TestAllInstancesPresent(t *testing.T) {
 	tests := []struct {
 		name string
@@ -288,3 +329,26 @@ func TestAllInstancesPresent(t *testing.T) {
 		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected output", tc.name))
 	}
 }
+
+func TestDeleteMachinesTerminatesStoppedInstances(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	clients, fake := newFakeClients()
+	instances := newFakeInstances("foo-worker-0", "foo-worker-1")
+	instances.instances[1].State = &ec2.InstanceState{Code: aws.Int64(int64(awsresources.EC2StoppedState)), Name: aws.String(ec2.InstanceStateNameStopped)}
+	fake.on("DescribeInstances", instances.describe)
+	fake.on("TerminateInstances", instances.terminate)
+
+	s := &Service{
+		logger: logger,
+	}
+
+	err = s.deleteMachines(deleteMachinesInput{
+		clients:     clients,
+		clusterName: "foo",
+		prefix:      prefixWorker,
+	})
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"i-foo-worker-0", "i-foo-worker-1"}, instances.terminated, "Wrong instances terminated")
+}
@@ -0,0 +1,61 @@
+package create
+
+import (
+	"strings"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// sharedVPCAnnotationKey is the annotation of clusters referencing the ID of an
+// existing VPC, which is shared with other clusters, instead of getting a VPC
+// of their own.
+const sharedVPCAnnotationKey = "aws-operator.giantswarm.io/shared-vpc-id"
+
+// sharedVPCID returns the ID of the shared VPC of the given cluster. It is
+// empty for clusters with a VPC of their own.
+func sharedVPCID(cluster awstpr.CustomObject) string {
+	return cluster.Annotations[sharedVPCAnnotationKey]
+}
+
+// scopedClusterID returns the ID the subnets, route tables and security groups
+// of the given cluster are scoped to, see awsresources.AWSEntity.ClusterID.
+// Only resources in shared VPCs are scoped, so the resources of existing
+// clusters are still found.
+func scopedClusterID(cluster awstpr.CustomObject) string {
+	if sharedVPCID(cluster) == "" {
+		return ""
+	}
+
+	return cluster.Spec.Cluster.Cluster.ID
+}
+
+// validateSharedVPC checks the shared VPC referenced by the given cluster, if
+// any, looks like a VPC ID.
+func validateSharedVPC(cluster awstpr.CustomObject) error {
+	vpcID, ok := cluster.Annotations[sharedVPCAnnotationKey]
+	if ok && !strings.HasPrefix(vpcID, "vpc-") {
+		return microerror.MaskAnyf(invalidSharedVPCError, "annotation %s must be a VPC ID, got '%s'", sharedVPCAnnotationKey, vpcID)
+	}
+
+	return nil
+}
+
+// checkSharedVPCSubnets checks the subnets of the given cluster don't overlap
+// the ones of other clusters in its shared VPC. AWS only rejects overlapping
+// subnets once they are created, by which time the cluster is half created.
+func (s *Service) checkSharedVPCSubnets(clients awsutil.Clients, cluster awstpr.CustomObject, vpcID string) error {
+	cidrBlock := cluster.Spec.AWS.VPC.PublicSubnetCIDR
+	overlapping, err := awsresources.OverlappingSubnets(clients, vpcID, scopedClusterID(cluster), cidrBlock)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if len(overlapping) > 0 {
+		return microerror.MaskAnyf(subnetCIDROverlapError, "public subnet CIDR '%s' overlaps subnets of shared VPC '%s': %s", cidrBlock, vpcID, strings.Join(overlapping, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	"github.com/stretchr/testify/assert"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+)
+
+func TestScopedClusterID(t *testing.T) {
+	tests := []struct {
+		desc        string
+		annotations map[string]string
+		res         string
+	}{
+		{
+			desc:        "cluster with a VPC of its own",
+			annotations: nil,
+			res:         "",
+		},
+		{
+			desc:        "cluster in a shared VPC",
+			annotations: map[string]string{sharedVPCAnnotationKey: "vpc-abc123"},
+			res:         "abc12",
+		},
+	}
+
+	for _, tc := range tests {
+		var cluster awstpr.CustomObject
+		cluster.Annotations = tc.annotations
+		cluster.Spec.Cluster.Cluster.ID = "abc12"
+
+		assert.Equal(t, tc.res, scopedClusterID(cluster), fmt.Sprintf("[%s] Wrong scope", tc.desc))
+
+		s := &Service{operatorID: "prod"}
+		entity := s.clusterAWSEntity(awsutil.Clients{}, cluster)
+		assert.Equal(t, tc.res, entity.ClusterID, fmt.Sprintf("[%s] Wrong scope of the cluster's resources", tc.desc))
+	}
+}
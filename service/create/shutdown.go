@@ -0,0 +1,173 @@
+package create
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// defaultShutdownTimeout is the default maximum time a shutdown waits for the
+// active reconciles to finish.
+const defaultShutdownTimeout = 5 * time.Minute
+
+// reconcileTracker keeps track of the active reconciles, so a shutdown can
+// refuse new ones and wait for the active ones to finish.
+type reconcileTracker struct {
+	mutex    sync.Mutex
+	active   map[string]int
+	stopping bool
+	wg       sync.WaitGroup
+}
+
+func newReconcileTracker() *reconcileTracker {
+	return &reconcileTracker{
+		active: make(map[string]int),
+	}
+}
+
+// start registers a reconcile of the given object. It returns false once the
+// tracker is stopping, in which case the reconcile must not run.
+func (t *reconcileTracker) start(name string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.stopping {
+		return false
+	}
+
+	t.active[name]++
+	t.wg.Add(1)
+
+	return true
+}
+
+// done unregisters a reconcile registered with start.
+func (t *reconcileTracker) done(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.active[name]--
+	if t.active[name] <= 0 {
+		delete(t.active, name)
+	}
+	t.wg.Done()
+}
+
+// stop refuses new reconciles and waits for the active ones to finish, up to
+// the given timeout. It returns the objects whose reconciles are still running
+// at the timeout.
+func (t *reconcileTracker) stop(timeout time.Duration) []string {
+	t.mutex.Lock()
+	t.stopping = true
+	t.mutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-time.After(timeout):
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var running []string
+	for name := range t.active {
+		running = append(running, name)
+	}
+	sort.Strings(running)
+
+	return running
+}
+
+// reconcileName returns the name a reconcile of the given informer object is
+// tracked with.
+func reconcileName(obj interface{}) string {
+	name, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+
+	return name
+}
+
+// trackReconciles wraps the given informer handlers, so their reconciles are
+// tracked and skipped once the service is shutting down.
+func (s *Service) trackReconciles(handler cache.ResourceEventHandlerFuncs) cache.ResourceEventHandlerFuncs {
+	tracked := cache.ResourceEventHandlerFuncs{}
+
+	if handler.AddFunc != nil {
+		tracked.AddFunc = func(obj interface{}) {
+			name := reconcileName(obj)
+			if !s.reconciles.start(name) {
+				s.logger.Log("info", fmt.Sprintf("shutting down, skipping reconcile of '%s'", name))
+				return
+			}
+			defer s.reconciles.done(name)
+
+			handler.AddFunc(obj)
+		}
+	}
+	if handler.UpdateFunc != nil {
+		tracked.UpdateFunc = func(oldObj, newObj interface{}) {
+			name := reconcileName(newObj)
+			if !s.reconciles.start(name) {
+				s.logger.Log("info", fmt.Sprintf("shutting down, skipping reconcile of '%s'", name))
+				return
+			}
+			defer s.reconciles.done(name)
+
+			handler.UpdateFunc(oldObj, newObj)
+		}
+	}
+	if handler.DeleteFunc != nil {
+		tracked.DeleteFunc = func(obj interface{}) {
+			name := reconcileName(obj)
+			if !s.reconciles.start(name) {
+				s.logger.Log("info", fmt.Sprintf("shutting down, skipping reconcile of '%s'", name))
+				return
+			}
+			defer s.reconciles.done(name)
+
+			handler.DeleteFunc(obj)
+		}
+	}
+
+	return tracked
+}
+
+// runInformer runs the given informer until the service shuts down. Closing
+// the stop channel stops the informer's watch, but its process loop stays
+// blocked on its empty queue, so runInformer returns on the stop channel
+// instead of waiting for the informer to return.
+func (s *Service) runInformer(informer *cache.Controller) {
+	go informer.Run(s.stop)
+	<-s.stop
+}
+
+// Shutdown stops the watches and waits for the active reconciles to finish, up
+// to the shutdown timeout. Reconciles still running at the timeout are logged
+// and left to be interrupted by the exit of the operator.
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		s.logger.Log("info", "shutting down, waiting for active reconciles to finish")
+		close(s.stop)
+
+		running := s.reconciles.stop(s.shutdownTimeout)
+		if len(running) > 0 {
+			s.logger.Log("error", fmt.Sprintf("shutdown timed out after %s, reconciles still running: %s", s.shutdownTimeout, strings.Join(running, ", ")))
+			return
+		}
+
+		s.logger.Log("info", "all reconciles finished")
+	})
+}
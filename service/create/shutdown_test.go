@@ -0,0 +1,148 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/pkg/api"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/pkg/runtime"
+	"k8s.io/client-go/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestShutdown(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc string
+		// reconcileDuration is how long the active reconcile takes.
+		reconcileDuration time.Duration
+		shutdownTimeout   time.Duration
+		resRunning        []string
+	}{
+		{
+			desc:              "active reconcile finishes",
+			reconcileDuration: 10 * time.Millisecond,
+			shutdownTimeout:   time.Second,
+			resRunning:        nil,
+		},
+		{
+			desc:              "active reconcile outlives the timeout",
+			reconcileDuration: time.Second,
+			shutdownTimeout:   10 * time.Millisecond,
+			resRunning:        []string{"foo"},
+		},
+	}
+
+	for _, tc := range tests {
+		s := &Service{
+			logger:          logger,
+			reconciles:      newReconcileTracker(),
+			shutdownTimeout: tc.shutdownTimeout,
+			stop:            make(chan struct{}),
+		}
+
+		started := make(chan struct{})
+		finished := make(chan struct{})
+		var added []string
+		handler := s.trackReconciles(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				added = append(added, obj.(*awstpr.CustomObject).Name)
+				if len(added) == 1 {
+					close(started)
+					time.Sleep(tc.reconcileDuration)
+					close(finished)
+				}
+			},
+		})
+
+		go handler.AddFunc(&awstpr.CustomObject{ObjectMeta: v1.ObjectMeta{Name: "foo"}})
+		<-started
+
+		running := s.reconciles.stop(s.shutdownTimeout)
+		assert.Equal(t, tc.resRunning, running, fmt.Sprintf("[%s] Wrong running reconciles", tc.desc))
+
+		// New reconciles are refused once shutting down.
+		handler.AddFunc(&awstpr.CustomObject{ObjectMeta: v1.ObjectMeta{Name: "bar"}})
+		<-finished
+		assert.Equal(t, []string{"foo"}, added, fmt.Sprintf("[%s] Wrong reconciles run", tc.desc))
+	}
+}
+
+func TestServiceShutdownStopsWatches(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	s := &Service{
+		logger:          logger,
+		reconciles:      newReconcileTracker(),
+		shutdownTimeout: time.Second,
+		stop:            make(chan struct{}),
+	}
+
+	s.Shutdown()
+	// Shutting down twice must not close the stop channel again.
+	s.Shutdown()
+
+	select {
+	case <-s.stop:
+	default:
+		t.Fatal("Expected the watches to be stopped")
+	}
+}
+
+func TestServiceShutdownStopsInformer(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	s := &Service{
+		logger:          logger,
+		reconciles:      newReconcileTracker(),
+		shutdownTimeout: time.Second,
+		stop:            make(chan struct{}),
+	}
+
+	watcher := watch.NewFake()
+	watching := make(chan struct{})
+	_, informer := cache.NewInformer(
+		&cache.ListWatch{
+			ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+				return &v1.SecretList{}, nil
+			},
+			WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+				close(watching)
+				return watcher, nil
+			},
+		},
+		&v1.Secret{},
+		0,
+		cache.ResourceEventHandlerFuncs{},
+	)
+
+	stopped := make(chan struct{})
+	go func() {
+		s.runInformer(informer)
+		close(stopped)
+	}()
+	<-watching
+
+	s.Shutdown()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the informer to stop")
+	}
+
+	// The reflector stops the watch asynchronously.
+	for i := 0; i < 100 && !watcher.IsStopped(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, watcher.IsStopped(), "Expected the watch to be stopped")
+}
@@ -9,9 +9,17 @@ import (
 )
 
 type SmallCloudconfigConfig struct {
-	MachineType string
-	Region      string
-	S3DirURI    string
+	// Gzip tells whether the final cloudconfig is gzipped before being base64
+	// encoded.
+	Gzip bool
+	// Hostname is the hostname set on the instance. The hostname of the final
+	// cloudconfig is kept when it is empty.
+	Hostname string
+	// ObjectName is the name of the final cloudconfig of the machine within
+	// S3DirURI.
+	ObjectName string
+	Region     string
+	S3DirURI   string
 }
 
 func (s *Service) SmallCloudconfig(config SmallCloudconfigConfig) (string, error) {
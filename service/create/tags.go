@@ -0,0 +1,54 @@
+package create
+
+import (
+	"strings"
+
+	"github.com/giantswarm/awstpr"
+)
+
+const (
+	// awsTagKeyMaxLength is the maximum number of characters of AWS tag keys.
+	awsTagKeyMaxLength = 128
+	// awsTagValueMaxLength is the maximum number of characters of AWS tag
+	// values.
+	awsTagValueMaxLength = 256
+	// awsTagKeyReservedPrefix prefixes the tag keys reserved by AWS.
+	awsTagKeyReservedPrefix = "aws:"
+)
+
+// clusterTags returns the tags of the cluster's AWS resources, copied from the
+// annotations of its custom object allowed by the configured annotation tags.
+func (s *Service) clusterTags(cluster awstpr.CustomObject) map[string]string {
+	return annotationTags(cluster.Annotations, s.annotationTags)
+}
+
+// annotationTags returns the annotations with the given keys as AWS tags.
+// Keys and values exceeding the AWS limits are truncated, annotations whose
+// keys are reserved by AWS are left out.
+func annotationTags(annotations map[string]string, keys []string) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string)
+	for _, key := range keys {
+		value, ok := annotations[key]
+		if !ok || strings.HasPrefix(strings.ToLower(key), awsTagKeyReservedPrefix) {
+			continue
+		}
+
+		tags[truncate(key, awsTagKeyMaxLength)] = truncate(value, awsTagValueMaxLength)
+	}
+
+	return tags
+}
+
+// truncate returns the first max characters of s.
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+
+	return string(runes[:max])
+}
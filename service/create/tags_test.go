@@ -0,0 +1,64 @@
+package create
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnotationTags(t *testing.T) {
+	tests := []struct {
+		desc        string
+		annotations map[string]string
+		keys        []string
+		res         map[string]string
+	}{
+		{
+			desc:        "no annotations are propagated by default",
+			annotations: map[string]string{"owner": "alice"},
+			keys:        nil,
+			res:         nil,
+		},
+		{
+			desc: "only allowed annotations are propagated",
+			annotations: map[string]string{
+				"owner":   "alice",
+				"team":    "platform",
+				"comment": "not a tag",
+			},
+			keys: []string{"owner", "team", "cost-center"},
+			res: map[string]string{
+				"owner": "alice",
+				"team":  "platform",
+			},
+		},
+		{
+			desc: "keys reserved by AWS are left out",
+			annotations: map[string]string{
+				"aws:createdBy": "alice",
+				"AWS:owner":     "alice",
+			},
+			keys: []string{"aws:createdBy", "AWS:owner"},
+			res:  map[string]string{},
+		},
+		{
+			desc: "long keys and values are truncated",
+			annotations: map[string]string{
+				strings.Repeat("k", 130): strings.Repeat("v", 300),
+				"owner":                  strings.Repeat("ü", 257),
+			},
+			keys: []string{strings.Repeat("k", 130), "owner"},
+			res: map[string]string{
+				strings.Repeat("k", 128): strings.Repeat("v", 256),
+				"owner":                  strings.Repeat("ü", 256),
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		res := annotationTags(tc.annotations, tc.keys)
+		assert.Equal(t, tc.res, res, fmt.Sprintf("[%s] The input values didn't produce the expected tags", tc.desc))
+	}
+}
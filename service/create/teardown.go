@@ -0,0 +1,132 @@
+package create
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/juju/errgo"
+
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// teardownTimeout is the maximum time to retry the teardown of a cluster whose
+// resources cannot all be deleted, e.g. because of dependencies which are still
+// being deleted.
+const teardownTimeout = 10 * time.Minute
+
+// teardownStep is a single resource deletion of a cluster teardown.
+type teardownStep struct {
+	name   string
+	delete func() error
+	// keep leaves the resource in place, e.g. when it is shared with other
+	// clusters.
+	keep bool
+}
+
+// runTeardown runs all the given steps, even when some of them fail, so as
+// many resources as possible get cleaned up. Failures are logged as they happen
+// and reported together in the returned error. Resources which don't exist
+// count as deleted, so teardowns of partially created clusters and retried
+// teardowns converge.
+func runTeardown(logger micrologger.Logger, steps []teardownStep) error {
+	var failures []string
+
+	for _, step := range steps {
+		if step.keep {
+			logger.Log("info", fmt.Sprintf("keeping %s, it is shared with other clusters", step.name))
+			continue
+		}
+
+		logger.Log("info", fmt.Sprintf("deleting %s...", step.name))
+		err := step.delete()
+		if awsresources.IsAlreadyDeleted(err) {
+			logger.Log("info", fmt.Sprintf("%s already deleted", step.name))
+			continue
+		} else if err != nil {
+			logger.Log("error", fmt.Sprintf("could not delete %s: %s", step.name, errgo.Details(err)))
+			countResourceError(operationDelete, step.name)
+			failures = append(failures, fmt.Sprintf("%s: %s", step.name, err))
+			continue
+		}
+		logger.Log("info", fmt.Sprintf("deleted %s", step.name))
+	}
+
+	if len(failures) > 0 {
+		return microerror.MaskAnyf(teardownFailedError, "%d of %d steps failed: %s", len(failures), len(steps), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// teardownRequeueDelay is the time to wait before retrying the teardown of a
+// cluster which gave up. Tests replace it.
+var teardownRequeueDelay = 5 * time.Minute
+
+// queueTeardown tears the cluster down off the informer's goroutine, so a stuck
+// cluster doesn't hold up the events of the other clusters. Deletions are not
+// redelivered, so a teardown which gives up is requeued until it succeeds or
+// the service shuts down.
+func (s *Service) queueTeardown(cluster awstpr.CustomObject, steps []teardownStep, newBackOff func() backoff.BackOff) {
+	name := reconcileName(&cluster)
+	if !s.reconciles.start(name) {
+		s.logger.Log("info", fmt.Sprintf("shutting down, skipping teardown of cluster '%s'", cluster.Name))
+		return
+	}
+
+	go func() {
+		defer s.reconciles.done(name)
+
+		for {
+			err := s.teardownCluster(cluster, steps, newBackOff())
+			if err == nil {
+				s.logger.Log("info", fmt.Sprintf("cluster '%s' deleted", cluster.Name))
+				return
+			}
+			s.logger.Log("error", fmt.Sprintf("cluster '%s' partially deleted, retrying in %s: %s", cluster.Name, teardownRequeueDelay, errgo.Details(err)))
+
+			select {
+			case <-s.stop:
+				s.logger.Log("error", fmt.Sprintf("shutting down, teardown of cluster '%s' left unfinished", cluster.Name))
+				return
+			case <-time.After(teardownRequeueDelay):
+			}
+		}
+	}()
+}
+
+// teardownCluster deletes the AWS resources of the cluster with the given
+// steps, retrying the teardown with the given backoff while steps fail, and
+// then its namespace. The namespace holds the certificates of the cluster, so
+// it is kept as long as AWS resources are left.
+func (s *Service) teardownCluster(cluster awstpr.CustomObject, steps []teardownStep, b backoff.BackOff) error {
+	teardownOperation := func() error {
+		return runTeardown(s.logger, steps)
+	}
+	teardownNotify := awsresources.NewNotify(s.logger, fmt.Sprintf("tearing down cluster '%s'", cluster.Name))
+	if err := backoff.RetryNotify(teardownOperation, b, teardownNotify); err != nil {
+		clusterDeleteTotal.WithLabelValues(clusterFailed).Inc()
+		return microerror.MaskAny(err)
+	}
+
+	if err := s.deleteClusterNamespace(cluster.Spec.Cluster); err != nil {
+		clusterDeleteTotal.WithLabelValues(clusterFailed).Inc()
+		return microerror.MaskAny(err)
+	}
+	s.logger.Log("info", fmt.Sprintf("deleted namespace of cluster '%s'", cluster.Name))
+	clusterDeleteTotal.WithLabelValues(clusterDeleted).Inc()
+
+	return nil
+}
+
+func newTeardownBackoff() backoff.BackOff {
+	b := awsresources.NewCustomExponentialBackoff()
+	b.MaxElapsedTime = teardownTimeout
+	b.Reset()
+
+	return b
+}
@@ -0,0 +1,343 @@
+package create
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/cenkalti/backoff"
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/rest"
+)
+
+func TestRunTeardown(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc         string
+		failing      []string
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:    "all steps succeed",
+			failing: nil,
+		},
+		{
+			desc:         "early failure doesn't stop later steps",
+			failing:      []string{"masters"},
+			errorMatcher: IsTeardownFailed,
+		},
+		{
+			desc:         "all failures are reported",
+			failing:      []string{"masters", "vpc", "keypair"},
+			errorMatcher: IsTeardownFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		failing := map[string]bool{}
+		for _, name := range tc.failing {
+			failing[name] = true
+		}
+
+		var run []string
+		var steps []teardownStep
+		for _, name := range []string{"masters", "workers", "vpc", "KMS key", "keypair"} {
+			name := name
+			steps = append(steps, teardownStep{
+				name: name,
+				delete: func() error {
+					run = append(run, name)
+					if failing[name] {
+						return fmt.Errorf("%s is stuck", name)
+					}
+					return nil
+				},
+			})
+		}
+
+		err := runTeardown(logger, steps)
+
+		assert.Equal(t, []string{"masters", "workers", "vpc", "KMS key", "keypair"}, run, fmt.Sprintf("[%s] Not all steps were attempted", tc.desc))
+		if tc.errorMatcher == nil {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+			continue
+		}
+
+		assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		for _, name := range tc.failing {
+			assert.Contains(t, err.Error(), fmt.Sprintf("%s is stuck", name), fmt.Sprintf("[%s] Failure of '%s' not reported", tc.desc, name))
+		}
+	}
+}
+
+func TestRunTeardownSkipsDeletedResources(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	// The cluster never fully came up, its load balancers and VPC don't exist.
+	gone := map[string]error{
+		"load balancers": awserr.New(elb.ErrCodeAccessPointNotFoundException, "no such load balancer", nil),
+		"vpc":            awserr.New("InvalidVpcID.NotFound", "no such VPC", nil),
+	}
+
+	var run []string
+	var steps []teardownStep
+	for _, name := range []string{"masters", "load balancers", "vpc", "KMS key", "keypair"} {
+		name := name
+		steps = append(steps, teardownStep{
+			name: name,
+			delete: func() error {
+				run = append(run, name)
+				return gone[name]
+			},
+		})
+	}
+
+	errors := counterValue(t, resourceErrorsTotal.WithLabelValues(operationDelete, "load balancers"))
+
+	err = runTeardown(logger, steps)
+	assert.Nil(t, err, "Deleted resources were reported as failures")
+	assert.Equal(t, []string{"masters", "load balancers", "vpc", "KMS key", "keypair"}, run, "Not all steps were attempted")
+	assert.Equal(t, errors, counterValue(t, resourceErrorsTotal.WithLabelValues(operationDelete, "load balancers")), "Deleted resource counted as error")
+}
+
+func TestRunTeardownKeepsSharedResources(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	var run []string
+	var steps []teardownStep
+	for _, name := range []string{"masters", "gateway", "public subnet", "vpc"} {
+		name := name
+		steps = append(steps, teardownStep{
+			name: name,
+			delete: func() error {
+				run = append(run, name)
+				return nil
+			},
+			keep: name == "gateway" || name == "vpc",
+		})
+	}
+
+	err = runTeardown(logger, steps)
+	assert.Nil(t, err, "Unexpected error")
+	assert.Equal(t, []string{"masters", "public subnet"}, run, "Shared resources were deleted")
+}
+
+func TestTeardownCluster(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc string
+		// failing is the name of the failing step, if any.
+		failing string
+		// failures is how often the failing step fails.
+		failures int
+		// b is the backoff the teardown is retried with. It is not retried when
+		// empty.
+		b backoff.BackOff
+		// namespaceGone makes the API report the namespace as already deleted.
+		namespaceGone bool
+		res           []string
+		errorMatcher  func(error) bool
+	}{
+		{
+			desc: "namespace deleted after the AWS resources",
+			res:  []string{"masters", "vpc", "DELETE /api/v1/namespaces/abc12"},
+		},
+		{
+			desc:          "namespace already deleted",
+			namespaceGone: true,
+			res:           []string{"masters", "vpc", "DELETE /api/v1/namespaces/abc12"},
+		},
+		{
+			desc:     "failed teardown retried",
+			failing:  "vpc",
+			failures: 1,
+			b:        &backoff.ZeroBackOff{},
+			res:      []string{"masters", "vpc", "masters", "vpc", "DELETE /api/v1/namespaces/abc12"},
+		},
+		{
+			desc:         "namespace kept when the AWS teardown gives up",
+			failing:      "vpc",
+			failures:     1,
+			res:          []string{"masters", "vpc"},
+			errorMatcher: IsTeardownFailed,
+		},
+	}
+
+	for _, tc := range tests {
+		var mutex sync.Mutex
+		var events []string
+		record := func(event string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			events = append(events, event)
+		}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			record(fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+			status := unversioned.Status{
+				TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   unversioned.StatusSuccess,
+				Code:     http.StatusOK,
+			}
+			if tc.namespaceGone {
+				status.Status = unversioned.StatusFailure
+				status.Reason = unversioned.StatusReasonNotFound
+				status.Code = http.StatusNotFound
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(int(status.Code))
+			json.NewEncoder(w).Encode(status)
+		}))
+
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+		s := &Service{
+			k8sClient: k8sClient,
+			logger:    logger,
+		}
+
+		var failures int
+		var steps []teardownStep
+		for _, name := range []string{"masters", "vpc"} {
+			name := name
+			steps = append(steps, teardownStep{
+				name: name,
+				delete: func() error {
+					record(name)
+					if name == tc.failing && failures < tc.failures {
+						failures++
+						return fmt.Errorf("%s is stuck", name)
+					}
+					return nil
+				},
+			})
+		}
+
+		var cluster awstpr.CustomObject
+		cluster.Name = "abc12"
+		cluster.Spec.Cluster.Cluster.ID = "abc12"
+		b := tc.b
+		if b == nil {
+			b = &backoff.StopBackOff{}
+		}
+		err = s.teardownCluster(cluster, steps, b)
+		server.Close()
+
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.res, events, fmt.Sprintf("[%s] Wrong teardown order", tc.desc))
+	}
+}
+
+func TestQueueTeardown(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	defer func(original time.Duration) {
+		teardownRequeueDelay = original
+	}(teardownRequeueDelay)
+
+	tests := []struct {
+		desc string
+		// failures is how often the teardown fails, -1 makes it fail for good.
+		failures int
+		// stopped shuts the service down before the teardown is queued.
+		stopped      bool
+		requeueDelay time.Duration
+		res          []string
+	}{
+		{
+			desc:     "teardown requeued until the AWS resources are gone",
+			failures: 2,
+			res:      []string{"vpc", "vpc", "vpc", "DELETE /api/v1/namespaces/abc12"},
+		},
+		{
+			desc:         "teardown stops requeueing on shutdown, keeping the namespace",
+			failures:     -1,
+			stopped:      true,
+			requeueDelay: time.Hour,
+			res:          []string{"vpc"},
+		},
+	}
+
+	for _, tc := range tests {
+		teardownRequeueDelay = tc.requeueDelay
+
+		var mutex sync.Mutex
+		var events []string
+		record := func(event string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			events = append(events, event)
+		}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			record(fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(unversioned.Status{
+				TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+				Status:   unversioned.StatusSuccess,
+				Code:     http.StatusOK,
+			})
+		}))
+
+		k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the client", tc.desc))
+		s := &Service{
+			k8sClient:  k8sClient,
+			logger:     logger,
+			reconciles: newReconcileTracker(),
+			stop:       make(chan struct{}),
+		}
+		if tc.stopped {
+			close(s.stop)
+		}
+
+		var failures int
+		steps := []teardownStep{
+			{
+				name: "vpc",
+				delete: func() error {
+					record("vpc")
+					if tc.failures < 0 || failures < tc.failures {
+						failures++
+						return fmt.Errorf("vpc is stuck")
+					}
+					return nil
+				},
+			},
+		}
+
+		var cluster awstpr.CustomObject
+		cluster.Name = "abc12"
+		cluster.Spec.Cluster.Cluster.ID = "abc12"
+		s.queueTeardown(cluster, steps, func() backoff.BackOff { return &backoff.StopBackOff{} })
+
+		running := s.reconciles.stop(time.Second)
+		server.Close()
+
+		assert.Empty(t, running, fmt.Sprintf("[%s] Teardown still running", tc.desc))
+		assert.Equal(t, tc.res, events, fmt.Sprintf("[%s] Wrong teardown", tc.desc))
+	}
+}
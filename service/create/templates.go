@@ -59,7 +59,7 @@ WantedBy=multi-user.target`
 # with it as an argument.
 
 . /etc/environment
-USERDATA_FILE={{.MachineType}}
+USERDATA_FILE={{.ObjectName}}
 
 /usr/bin/rkt run \
     --net=host \
@@ -67,7 +67,18 @@ USERDATA_FILE={{.MachineType}}
     --volume=awsenv,kind=host,source=/var/run/coreos,readOnly=false --mount volume=awsenv,target=/var/run/coreos \
     --trust-keys-from-https \
     quay.io/coreos/awscli:025a357f05242fdad6a81e8a6b520098aa65a600 -- aws s3 --region {{.Region}} cp s3://{{.S3DirURI}}/$USERDATA_FILE /var/run/coreos/temp.txt
+{{- if .Gzip}}
 base64 -d /var/run/coreos/temp.txt | gunzip > /var/run/coreos/$USERDATA_FILE
+{{- else}}
+base64 -d /var/run/coreos/temp.txt > /var/run/coreos/$USERDATA_FILE
+{{- end}}
+{{- if .Hostname}}
+
+# The final cloudconfig doesn't know the instance name, so the hostname of this
+# instance is set here and enforced in the final cloudconfig.
+hostnamectl set-hostname {{.Hostname}}
+sed -i 's/^hostname: .*$/hostname: "{{.Hostname}}"/' /var/run/coreos/$USERDATA_FILE
+{{- end}}
 exec /usr/bin/coreos-cloudinit --from-file /var/run/coreos/$USERDATA_FILE`
 
 	createCalicoEnvFileScriptTemplate = `#!/bin/bash
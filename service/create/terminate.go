@@ -0,0 +1,106 @@
+package create
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/awstpr"
+	microerror "github.com/giantswarm/microkit/error"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+)
+
+// TerminateInstances terminates the instances with the given IDs of the cluster
+// with the given ID, in the region of the cluster. Unlike the teardown of a
+// cluster it only touches the given instances, but they go through the same
+// steps: they are deregistered from the ELBs, masters are removed from etcd and
+// workers are drained first. IDs which don't belong to the cluster are
+// rejected, in which case none of the instances is terminated.
+func (s *Service) TerminateInstances(clusterID string, ids []string) error {
+	if !validateIDs(ids) {
+		return microerror.MaskAnyf(invalidInstanceIDsError, "%v", ids)
+	}
+
+	cluster, ok := s.knownCluster(clusterID)
+	if !ok {
+		return microerror.MaskAnyf(clusterNotFoundError, "%s", clusterID)
+	}
+
+	region, err := clusterRegion(cluster.Spec, s.defaultRegion)
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	awsConfig := s.awsConfig
+	awsConfig.Region = region
+	clients := awsutil.NewClients(awsConfig)
+	s.awsRateLimiter.Limit(clients)
+
+	if err := s.terminateClusterInstances(clients, cluster, ids); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	s.logger.Log("info", fmt.Sprintf("terminated instances %v of cluster '%s'", ids, clusterID))
+
+	return nil
+}
+
+func (s *Service) terminateClusterInstances(clients awsutil.Clients, cluster awstpr.CustomObject, ids []string) error {
+	// All the IDs are checked before anything is touched.
+	prefixes := make(map[string]string)
+	for _, prefix := range []string{prefixMaster, prefixWorker} {
+		instances, err := s.findMachines(clients, cluster.Name, prefix)
+		if err != nil {
+			return microerror.MaskAny(err)
+		}
+		for _, instance := range instances {
+			prefixes[instance.ID()] = prefix
+		}
+	}
+	var foreign []string
+	for _, id := range ids {
+		if _, ok := prefixes[id]; !ok {
+			foreign = append(foreign, id)
+		}
+	}
+	if len(foreign) > 0 {
+		return microerror.MaskAnyf(invalidInstanceIDsError, "%v are no instances of cluster '%s'", foreign, cluster.Name)
+	}
+
+	for _, prefix := range []string{prefixMaster, prefixWorker} {
+		var prefixIDs []string
+		for _, id := range ids {
+			if prefixes[id] == prefix {
+				prefixIDs = append(prefixIDs, id)
+			}
+		}
+		if len(prefixIDs) == 0 {
+			continue
+		}
+
+		if err := s.deleteMachines(deleteMachinesInput{
+			clients:           clients,
+			spec:              cluster.Spec,
+			clusterName:       cluster.Name,
+			prefix:            prefix,
+			ids:               prefixIDs,
+			removeEtcdMembers: true,
+		}); err != nil {
+			return microerror.MaskAny(err)
+		}
+	}
+
+	return nil
+}
+
+// knownCluster returns the cluster with the given ID from the store of the
+// cluster informer.
+func (s *Service) knownCluster(clusterID string) (awstpr.CustomObject, bool) {
+	s.clustersMutex.Lock()
+	clusters := s.clusters
+	s.clustersMutex.Unlock()
+
+	if clusters == nil {
+		return awstpr.CustomObject{}, false
+	}
+
+	return clusterByID(clusters, clusterID)
+}
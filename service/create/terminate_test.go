@@ -0,0 +1,84 @@
+package create
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/giantswarm/awstpr"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestTerminateClusterInstances(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc          string
+		ids           []string
+		resTerminated []string
+		errorMatcher  func(error) bool
+	}{
+		{
+			desc:          "only the given instances are terminated",
+			ids:           []string{"i-foo-worker-1"},
+			resTerminated: []string{"i-foo-worker-1"},
+		},
+		{
+			desc:          "instances of other clusters are rejected",
+			ids:           []string{"i-foo-worker-0", "i-bar-worker-0"},
+			resTerminated: nil,
+			errorMatcher:  IsInvalidInstanceIDs,
+		},
+		{
+			desc:          "instances which are gone already are rejected",
+			ids:           []string{"i-foo-worker-2"},
+			resTerminated: nil,
+			errorMatcher:  IsInvalidInstanceIDs,
+		},
+	}
+
+	for _, tc := range tests {
+		clients, fake := newFakeClients()
+		instances := newFakeInstances("foo-worker-0", "foo-worker-1", "bar-worker-0")
+		fake.on("DescribeInstances", instances.describe)
+		fake.on("TerminateInstances", instances.terminate)
+
+		s := &Service{
+			logger: logger,
+		}
+
+		var cluster awstpr.CustomObject
+		cluster.Name = "foo"
+		err := s.terminateClusterInstances(clients, cluster, tc.ids)
+
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+		assert.Equal(t, tc.resTerminated, instances.terminated, fmt.Sprintf("[%s] Wrong instances terminated", tc.desc))
+	}
+}
+
+func TestTerminateInstancesOfUnknownCluster(t *testing.T) {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	var cluster awstpr.CustomObject
+	cluster.Spec.Cluster.Cluster.ID = "abc12"
+	clusters := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	clusters.Add(&cluster)
+
+	s := &Service{
+		logger:   logger,
+		clusters: clusters,
+	}
+
+	err = s.TerminateInstances("def34", []string{"i-1"})
+	assert.True(t, IsClusterNotFound(err), fmt.Sprintf("Unexpected error: %v", err))
+
+	err = s.TerminateInstances("abc12", []string{""})
+	assert.True(t, IsInvalidInstanceIDs(err), fmt.Sprintf("Unexpected error: %v", err))
+}
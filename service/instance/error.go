@@ -0,0 +1,19 @@
+package instance
+
+import (
+	"github.com/juju/errgo"
+)
+
+var invalidConfigError = errgo.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return errgo.Cause(err) == invalidConfigError
+}
+
+var invalidRequestError = errgo.New("invalid request")
+
+// IsInvalidRequest asserts invalidRequestError.
+func IsInvalidRequest(err error) bool {
+	return errgo.Cause(err) == invalidRequestError
+}
@@ -0,0 +1,17 @@
+package instance
+
+// Request is the configuration for the service action.
+type Request struct {
+	InstanceID string
+	// Region is the AWS region of the instance. The default region is used when
+	// it is empty.
+	Region string
+}
+
+// DefaultRequest provides a default request object by best effort.
+func DefaultRequest() Request {
+	return Request{
+		InstanceID: "",
+		Region:     "",
+	}
+}
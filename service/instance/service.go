@@ -0,0 +1,83 @@
+package instance
+
+import (
+	microerror "github.com/giantswarm/microkit/error"
+	"golang.org/x/net/context"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+	awsresources "github.com/giantswarm/aws-operator/resources/aws"
+)
+
+// Config represents the configuration used to create an instance service.
+type Config struct {
+	// Settings.
+	AwsConfig awsutil.Config
+	// DefaultRegion is the AWS region used for requests which do not define
+	// one.
+	DefaultRegion string
+}
+
+// DefaultConfig provides a default configuration to create a new instance
+// service by best effort.
+func DefaultConfig() Config {
+	return Config{
+		// Settings.
+		AwsConfig:     awsutil.Config{},
+		DefaultRegion: "",
+	}
+}
+
+// New creates a new configured instance service.
+func New(config Config) (*Service, error) {
+	// Settings.
+	var emptyAwsConfig awsutil.Config
+	if config.AwsConfig == emptyAwsConfig {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.AwsConfig must not be empty")
+	}
+
+	newService := &Service{
+		Config: config,
+
+		// Internals.
+		newClients: awsutil.NewClients,
+	}
+
+	return newService, nil
+}
+
+// Service implements the instance service interface.
+type Service struct {
+	Config
+
+	// Internals.
+	newClients func(awsutil.Config) awsutil.Clients
+}
+
+// GetInstanceConsoleOutput returns the console output of an instance, which
+// helps diagnosing instances failing to boot.
+func (s *Service) GetInstanceConsoleOutput(ctx context.Context, request Request) (*Response, error) {
+	if request.InstanceID == "" {
+		return nil, microerror.MaskAnyf(invalidRequestError, "instance ID must not be empty")
+	}
+	region := request.Region
+	if region == "" {
+		region = s.DefaultRegion
+	}
+	if region == "" {
+		return nil, microerror.MaskAnyf(invalidRequestError, "region must not be empty")
+	}
+
+	awsConfig := s.AwsConfig
+	awsConfig.Region = region
+
+	output, err := awsresources.InstanceConsoleOutput(s.newClients(awsConfig), request.InstanceID)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	response := DefaultResponse()
+	response.InstanceID = request.InstanceID
+	response.Output = output
+
+	return response, nil
+}
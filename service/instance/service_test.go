@@ -0,0 +1,102 @@
+package instance
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+
+	awsutil "github.com/giantswarm/aws-operator/client/aws"
+)
+
+// fakeConsoleOutputClients returns clients answering GetConsoleOutput with the
+// given output, and records the region they were created for.
+func fakeConsoleOutputClients(output string, region *string) func(awsutil.Config) awsutil.Clients {
+	return func(config awsutil.Config) awsutil.Clients {
+		*region = config.Region
+
+		client := ec2.New(session.New(&aws.Config{
+			Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+			Region:      aws.String(config.Region),
+		}))
+		client.Handlers.Clear()
+		client.Handlers.Send.PushBack(func(r *request.Request) {
+			r.HTTPResponse = &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			}
+			r.Data.(*ec2.GetConsoleOutputOutput).InstanceId = r.Params.(*ec2.GetConsoleOutputInput).InstanceId
+			r.Data.(*ec2.GetConsoleOutputOutput).Output = aws.String(base64.StdEncoding.EncodeToString([]byte(output)))
+		})
+
+		return awsutil.Clients{EC2: client}
+	}
+}
+
+func TestGetInstanceConsoleOutput(t *testing.T) {
+	tests := []struct {
+		desc          string
+		request       Request
+		defaultRegion string
+		resOutput     string
+		resRegion     string
+		errorMatcher  func(error) bool
+	}{
+		{
+			desc:          "default region",
+			request:       Request{InstanceID: "i-123"},
+			defaultRegion: "eu-central-1",
+			resOutput:     "Ignition failed",
+			resRegion:     "eu-central-1",
+		},
+		{
+			desc:          "requested region wins over the default region",
+			request:       Request{InstanceID: "i-123", Region: "us-east-1"},
+			defaultRegion: "eu-central-1",
+			resOutput:     "Ignition failed",
+			resRegion:     "us-east-1",
+		},
+		{
+			desc:         "missing instance ID",
+			request:      Request{Region: "us-east-1"},
+			errorMatcher: IsInvalidRequest,
+		},
+		{
+			desc:         "missing region",
+			request:      Request{InstanceID: "i-123"},
+			errorMatcher: IsInvalidRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		config := DefaultConfig()
+		config.AwsConfig = awsutil.Config{AccessKeyID: "id", AccessKeySecret: "secret"}
+		config.DefaultRegion = tc.defaultRegion
+		s, err := New(config)
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error creating the service", tc.desc))
+
+		var region string
+		s.newClients = fakeConsoleOutputClients("Ignition failed", &region)
+
+		response, err := s.GetInstanceConsoleOutput(context.Background(), tc.request)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+			continue
+		}
+
+		assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		assert.Equal(t, tc.request.InstanceID, response.InstanceID, fmt.Sprintf("[%s] Wrong instance", tc.desc))
+		assert.Equal(t, tc.resOutput, response.Output, fmt.Sprintf("[%s] The console output wasn't decoded", tc.desc))
+		assert.Equal(t, tc.resRegion, region, fmt.Sprintf("[%s] Wrong region", tc.desc))
+	}
+}
@@ -0,0 +1,19 @@
+package leaderelection
+
+import (
+	"github.com/juju/errgo"
+)
+
+var invalidConfigError = errgo.New("invalid config")
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return errgo.Cause(err) == invalidConfigError
+}
+
+var leadershipLostError = errgo.New("leadership lost")
+
+// IsLeadershipLost asserts leadershipLostError.
+func IsLeadershipLost(err error) bool {
+	return errgo.Cause(err) == leadershipLostError
+}
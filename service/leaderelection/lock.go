@@ -0,0 +1,87 @@
+package leaderelection
+
+import (
+	"encoding/json"
+
+	microerror "github.com/giantswarm/microkit/error"
+	"k8s.io/client-go/pkg/api/errors"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+)
+
+// leaderAnnotationKey is the annotation of the lock config map holding the
+// leader election record. It is the one of the leader election of Kubernetes'
+// own components.
+const leaderAnnotationKey = "control-plane.alpha.kubernetes.io/leader"
+
+// leaderElectionRecord is the lease of the leader, as stored in the lock config
+// map.
+type leaderElectionRecord struct {
+	// HolderIdentity is the identity of the leader. The lease is free when it
+	// is empty, e.g. once the leader released it.
+	HolderIdentity       string           `json:"holderIdentity"`
+	LeaseDurationSeconds int              `json:"leaseDurationSeconds"`
+	AcquireTime          unversioned.Time `json:"acquireTime"`
+	RenewTime            unversioned.Time `json:"renewTime"`
+	LeaderTransitions    int              `json:"leaderTransitions"`
+}
+
+// getLock returns the lock config map and the leader election record it holds.
+// The config map is nil when it doesn't exist.
+func (s *Service) getLock() (*v1.ConfigMap, leaderElectionRecord, error) {
+	var record leaderElectionRecord
+
+	lock, err := s.K8sClient.Core().ConfigMaps(s.LockNamespace).Get(s.LockName)
+	if errors.IsNotFound(err) {
+		return nil, record, nil
+	} else if err != nil {
+		return nil, record, microerror.MaskAny(err)
+	}
+
+	if raw, ok := lock.Annotations[leaderAnnotationKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &record); err != nil {
+			return nil, record, microerror.MaskAny(err)
+		}
+	}
+
+	return lock, record, nil
+}
+
+// writeLock stores the given record in the given lock config map, or in a new
+// one when it is nil, and returns the written config map. The update fails
+// with a conflict when another replica changed the config map since it was
+// read.
+func (s *Service) writeLock(lock *v1.ConfigMap, record leaderElectionRecord) (*v1.ConfigMap, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	if lock == nil {
+		lock, err := s.K8sClient.Core().ConfigMaps(s.LockNamespace).Create(&v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      s.LockName,
+				Namespace: s.LockNamespace,
+				Annotations: map[string]string{
+					leaderAnnotationKey: string(raw),
+				},
+			},
+		})
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+
+		return lock, nil
+	}
+
+	if lock.Annotations == nil {
+		lock.Annotations = map[string]string{}
+	}
+	lock.Annotations[leaderAnnotationKey] = string(raw)
+	lock, err = s.K8sClient.Core().ConfigMaps(s.LockNamespace).Update(lock)
+	if err != nil {
+		return nil, microerror.MaskAny(err)
+	}
+
+	return lock, nil
+}
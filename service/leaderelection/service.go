@@ -0,0 +1,275 @@
+// Package leaderelection elects a leader amongst the replicas of the operator,
+// so only one of them reconciles the clusters at a time.
+package leaderelection
+
+import (
+	"fmt"
+	"time"
+
+	microerror "github.com/giantswarm/microkit/error"
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/juju/errgo"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/util/wait"
+)
+
+// jitterFactor spreads the attempts of the replicas to acquire or renew the
+// lease over up to 20% more than the retry period.
+const jitterFactor = 1.2
+
+// Config represents the configuration used to create a leader election
+// service.
+type Config struct {
+	// Dependencies.
+	K8sClient kubernetes.Interface
+	Logger    micrologger.Logger
+
+	// Settings.
+	// Identity identifies the replica amongst the ones taking part in the
+	// election, e.g. its pod name.
+	Identity string
+	// LeaseDuration is how long standby replicas wait for the leader to renew
+	// its lease before taking over.
+	LeaseDuration time.Duration
+	// LockName is the name of the config map holding the lease.
+	LockName string
+	// LockNamespace is the namespace of the config map holding the lease.
+	LockNamespace string
+	// RenewDeadline is how long the leader retries renewing its lease before it
+	// gives up its leadership. It must be shorter than LeaseDuration, so the
+	// leader stops before another replica takes over.
+	RenewDeadline time.Duration
+	// RetryPeriod is the delay between the attempts to acquire or renew the
+	// lease.
+	RetryPeriod time.Duration
+}
+
+// DefaultConfig provides a default configuration to create a new leader
+// election service by best effort.
+func DefaultConfig() Config {
+	return Config{
+		// Dependencies.
+		K8sClient: nil,
+		Logger:    nil,
+
+		// Settings.
+		Identity:      "",
+		LeaseDuration: 15 * time.Second,
+		LockName:      "aws-operator",
+		LockNamespace: "default",
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	}
+}
+
+// New creates a new configured leader election service.
+func New(config Config) (*Service, error) {
+	// Dependencies.
+	if config.K8sClient == nil {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.K8sClient must not be empty")
+	}
+	if config.Logger == nil {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.Logger must not be empty")
+	}
+
+	// Settings.
+	if config.Identity == "" {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.Identity must not be empty")
+	}
+	if config.LockName == "" {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.LockName must not be empty")
+	}
+	if config.LockNamespace == "" {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.LockNamespace must not be empty")
+	}
+	if config.RetryPeriod <= 0 {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.RetryPeriod must be greater than zero")
+	}
+	if config.RenewDeadline <= time.Duration(jitterFactor*float64(config.RetryPeriod)) {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.RenewDeadline must be greater than %v times config.RetryPeriod", jitterFactor)
+	}
+	if config.LeaseDuration <= config.RenewDeadline {
+		return nil, microerror.MaskAnyf(invalidConfigError, "config.LeaseDuration must be greater than config.RenewDeadline")
+	}
+
+	newService := &Service{
+		Config: config,
+
+		// Internals.
+		now: time.Now,
+	}
+
+	return newService, nil
+}
+
+// Service elects a leader amongst the replicas of the operator. The leader
+// holds a lease stored in a config map, which it renews periodically. The
+// other replicas stand by, and take over once the lease expires.
+type Service struct {
+	Config
+
+	// Internals.
+	now func() time.Time
+	// observedRecord is the last record read from the lock, observedVersion
+	// the resource version of the lock, and observedTime the time the lock was
+	// seen changing. A lease expires LeaseDuration after that, so the clocks of
+	// the replicas don't need to be in sync. The resource version changes on
+	// every renewal, unlike the record which only holds seconds.
+	observedRecord  leaderElectionRecord
+	observedVersion string
+	observedTime    time.Time
+}
+
+// Callbacks are the functions Run calls as the leadership changes.
+type Callbacks struct {
+	// OnStartedLeading is called in its own goroutine once the replica is
+	// elected. The given channel is closed when the leadership ends.
+	OnStartedLeading func(stop <-chan struct{})
+	// OnStoppedLeading is called when the replica lost its leadership, because
+	// it couldn't renew its lease in time.
+	OnStoppedLeading func()
+}
+
+// Run stands by until the replica is elected, and leads until it loses its
+// leadership or the given stop channel is closed. The lease is released when
+// the stop channel is closed, so another replica takes over right away.
+func (s *Service) Run(stop <-chan struct{}, callbacks Callbacks) {
+	s.Logger.Log("info", fmt.Sprintf("standing by until '%s' acquires the lease of %s/%s", s.Identity, s.LockNamespace, s.LockName))
+	if !s.acquire(stop) {
+		return
+	}
+	s.Logger.Log("info", fmt.Sprintf("'%s' acquired the lease of %s/%s, leading", s.Identity, s.LockNamespace, s.LockName))
+
+	leading := make(chan struct{})
+	go callbacks.OnStartedLeading(leading)
+
+	err := s.renew(stop)
+	close(leading)
+
+	if IsLeadershipLost(err) {
+		s.Logger.Log("error", fmt.Sprintf("'%s' lost the lease of %s/%s: %s", s.Identity, s.LockNamespace, s.LockName, errgo.Details(err)))
+		callbacks.OnStoppedLeading()
+		return
+	}
+
+	if err := s.release(); err != nil {
+		s.Logger.Log("error", fmt.Sprintf("could not release the lease of %s/%s: %s", s.LockNamespace, s.LockName, errgo.Details(err)))
+		return
+	}
+	s.Logger.Log("info", fmt.Sprintf("'%s' released the lease of %s/%s", s.Identity, s.LockNamespace, s.LockName))
+}
+
+// acquire tries to acquire the lease until it succeeds, or the given stop
+// channel is closed. It returns whether the lease was acquired.
+func (s *Service) acquire(stop <-chan struct{}) bool {
+	for {
+		if s.tryAcquireOrRenew() {
+			return true
+		}
+
+		select {
+		case <-stop:
+			return false
+		case <-time.After(wait.Jitter(s.RetryPeriod, jitterFactor-1)):
+		}
+	}
+}
+
+// renew renews the lease periodically until the given stop channel is closed.
+// It returns a leadershipLostError when the lease couldn't be renewed within
+// the renew deadline.
+func (s *Service) renew(stop <-chan struct{}) error {
+	lastRenew := s.now()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(s.RetryPeriod):
+		}
+
+		if s.tryAcquireOrRenew() {
+			lastRenew = s.now()
+			continue
+		}
+		if s.observedRecord.HolderIdentity != s.Identity {
+			return microerror.MaskAnyf(leadershipLostError, "'%s' holds the lease", s.observedRecord.HolderIdentity)
+		}
+		if s.now().Sub(lastRenew) > s.RenewDeadline {
+			return microerror.MaskAnyf(leadershipLostError, "the lease wasn't renewed within %s", s.RenewDeadline)
+		}
+	}
+}
+
+// tryAcquireOrRenew acquires the lease when it is free or expired, or renews
+// it when the replica holds it already. It returns whether the replica holds
+// the lease.
+func (s *Service) tryAcquireOrRenew() bool {
+	now := s.now()
+
+	lock, record, err := s.getLock()
+	if err != nil {
+		s.Logger.Log("error", fmt.Sprintf("could not get the lease of %s/%s: %s", s.LockNamespace, s.LockName, errgo.Details(err)))
+		return false
+	}
+
+	var version string
+	if lock != nil {
+		version = lock.ResourceVersion
+	}
+	if version != s.observedVersion {
+		s.observedRecord = record
+		s.observedVersion = version
+		s.observedTime = now
+	}
+
+	expired := s.observedTime.Add(s.LeaseDuration).Before(now)
+	if record.HolderIdentity != "" && record.HolderIdentity != s.Identity && !expired {
+		return false
+	}
+
+	newRecord := leaderElectionRecord{
+		HolderIdentity:       s.Identity,
+		LeaseDurationSeconds: int(s.LeaseDuration / time.Second),
+		AcquireTime:          unversioned.NewTime(now),
+		RenewTime:            unversioned.NewTime(now),
+		LeaderTransitions:    record.LeaderTransitions,
+	}
+	if record.HolderIdentity == s.Identity {
+		newRecord.AcquireTime = record.AcquireTime
+	} else {
+		newRecord.LeaderTransitions++
+	}
+
+	lock, err = s.writeLock(lock, newRecord)
+	if err != nil {
+		s.Logger.Log("error", fmt.Sprintf("could not write the lease of %s/%s: %s", s.LockNamespace, s.LockName, errgo.Details(err)))
+		return false
+	}
+
+	s.observedRecord = newRecord
+	s.observedVersion = lock.ResourceVersion
+	s.observedTime = now
+
+	return true
+}
+
+// release gives up the lease held by the replica, so another one can acquire
+// it without waiting for it to expire.
+func (s *Service) release() error {
+	lock, record, err := s.getLock()
+	if err != nil {
+		return microerror.MaskAny(err)
+	}
+	if lock == nil || record.HolderIdentity != s.Identity {
+		return nil
+	}
+
+	record.HolderIdentity = ""
+	if _, err := s.writeLock(lock, record); err != nil {
+		return microerror.MaskAny(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+package leaderelection
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	micrologger "github.com/giantswarm/microkit/logger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/pkg/api/unversioned"
+	"k8s.io/client-go/pkg/api/v1"
+	"k8s.io/client-go/rest"
+)
+
+// fakeConfigMapsAPI serves the config maps of a Kubernetes API, with the
+// optimistic concurrency of updates.
+type fakeConfigMapsAPI struct {
+	mutex      sync.Mutex
+	configMaps map[string]v1.ConfigMap
+	version    int
+	// failing makes all the requests fail, e.g. when the API is unreachable.
+	failing bool
+}
+
+func newFakeConfigMapsAPI() *fakeConfigMapsAPI {
+	return &fakeConfigMapsAPI{
+		configMaps: map[string]v1.ConfigMap{},
+	}
+}
+
+func (f *fakeConfigMapsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.failing {
+		writeStatus(w, http.StatusInternalServerError, unversioned.StatusReasonInternalError)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		configMap, ok := f.configMaps[path.Base(r.URL.Path)]
+		if !ok {
+			writeStatus(w, http.StatusNotFound, unversioned.StatusReasonNotFound)
+			return
+		}
+		writeConfigMap(w, http.StatusOK, configMap)
+	case "POST", "PUT":
+		var configMap v1.ConfigMap
+		json.NewDecoder(r.Body).Decode(&configMap)
+
+		existing, ok := f.configMaps[configMap.Name]
+		if r.Method == "POST" && ok {
+			writeStatus(w, http.StatusConflict, unversioned.StatusReasonAlreadyExists)
+			return
+		}
+		if r.Method == "PUT" && (!ok || existing.ResourceVersion != configMap.ResourceVersion) {
+			writeStatus(w, http.StatusConflict, unversioned.StatusReasonConflict)
+			return
+		}
+
+		f.version++
+		configMap.ResourceVersion = strconv.Itoa(f.version)
+		f.configMaps[configMap.Name] = configMap
+		writeConfigMap(w, http.StatusOK, configMap)
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, unversioned.StatusReasonMethodNotAllowed)
+	}
+}
+
+// record returns the leader election record of the given lock config map.
+func (f *fakeConfigMapsAPI) record(t *testing.T, name string) leaderElectionRecord {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var record leaderElectionRecord
+	if configMap, ok := f.configMaps[name]; ok {
+		err := json.Unmarshal([]byte(configMap.Annotations[leaderAnnotationKey]), &record)
+		assert.Nil(t, err, "Unexpected error decoding the leader election record")
+	}
+
+	return record
+}
+
+func (f *fakeConfigMapsAPI) setFailing(failing bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.failing = failing
+}
+
+func writeConfigMap(w http.ResponseWriter, code int, configMap v1.ConfigMap) {
+	configMap.Kind = "ConfigMap"
+	configMap.APIVersion = "v1"
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(configMap)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason unversioned.StatusReason) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(unversioned.Status{
+		TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   unversioned.StatusFailure,
+		Reason:   reason,
+		Code:     int32(code),
+	})
+}
+
+// newTestService returns a leader election service with the given identity,
+// electing through the given API with short durations.
+func newTestService(t *testing.T, server *httptest.Server, identity string) *Service {
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+	// The client must not throttle the frequent requests of the short
+	// durations.
+	k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL, QPS: 1000, Burst: 1000})
+	assert.Nil(t, err, "Unexpected error creating the client")
+
+	config := DefaultConfig()
+	config.Identity = identity
+	config.K8sClient = k8sClient
+	config.LeaseDuration = 400 * time.Millisecond
+	config.Logger = logger
+	config.RenewDeadline = 200 * time.Millisecond
+	config.RetryPeriod = 20 * time.Millisecond
+
+	s, err := New(config)
+	assert.Nil(t, err, "Unexpected error creating the service")
+
+	return s
+}
+
+// replica runs the leader election of a service, recording its leadership.
+type replica struct {
+	started chan struct{}
+	lost    chan struct{}
+	done    chan struct{}
+	stop    chan struct{}
+}
+
+func runReplica(s *Service) *replica {
+	r := &replica{
+		started: make(chan struct{}),
+		lost:    make(chan struct{}),
+		done:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.done)
+		s.Run(r.stop, Callbacks{
+			OnStartedLeading: func(stop <-chan struct{}) { close(r.started) },
+			OnStoppedLeading: func() { close(r.lost) },
+		})
+	}()
+
+	return r
+}
+
+// closedWithin checks whether the given channel gets closed within the given
+// timeout.
+func closedWithin(c <-chan struct{}, timeout time.Duration) bool {
+	select {
+	case <-c:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestNew(t *testing.T) {
+	server := httptest.NewServer(newFakeConfigMapsAPI())
+	defer server.Close()
+	k8sClient, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	assert.Nil(t, err, "Unexpected error creating the client")
+	logger, err := micrologger.New(micrologger.DefaultConfig())
+	assert.Nil(t, err, "Unexpected error creating the logger")
+
+	tests := []struct {
+		desc         string
+		configure    func(config *Config)
+		errorMatcher func(error) bool
+	}{
+		{
+			desc:      "default config",
+			configure: func(config *Config) {},
+		},
+		{
+			desc:         "missing identity",
+			configure:    func(config *Config) { config.Identity = "" },
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			desc:         "missing lock namespace",
+			configure:    func(config *Config) { config.LockNamespace = "" },
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			desc:         "renew deadline not shorter than lease duration",
+			configure:    func(config *Config) { config.RenewDeadline = config.LeaseDuration },
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			desc:         "retry period not shorter than renew deadline",
+			configure:    func(config *Config) { config.RetryPeriod = config.RenewDeadline },
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range tests {
+		config := DefaultConfig()
+		config.Identity = "aws-operator-1"
+		config.K8sClient = k8sClient
+		config.Logger = logger
+		tc.configure(&config)
+
+		_, err := New(config)
+		if tc.errorMatcher != nil {
+			assert.True(t, tc.errorMatcher(err), fmt.Sprintf("[%s] Unexpected error: %v", tc.desc, err))
+		} else {
+			assert.Nil(t, err, fmt.Sprintf("[%s] Unexpected error", tc.desc))
+		}
+	}
+}
+
+func TestRunStandsByAndTakesOver(t *testing.T) {
+	api := newFakeConfigMapsAPI()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	first := runReplica(newTestService(t, server, "aws-operator-1"))
+	assert.True(t, closedWithin(first.started, time.Second), "The first replica wasn't elected")
+
+	// The second replica stands by while the first one renews its lease.
+	second := runReplica(newTestService(t, server, "aws-operator-2"))
+	assert.False(t, closedWithin(second.started, time.Second), "Both replicas lead")
+	assert.Equal(t, "aws-operator-1", api.record(t, "aws-operator").HolderIdentity, "Wrong leader")
+
+	// The first replica releases its lease when stopped, the second one takes
+	// over without waiting for the lease to expire.
+	close(first.stop)
+	assert.True(t, closedWithin(first.done, time.Second), "The first replica didn't stop")
+	assert.True(t, closedWithin(second.started, 200*time.Millisecond), "The second replica didn't take over")
+
+	record := api.record(t, "aws-operator")
+	assert.Equal(t, "aws-operator-2", record.HolderIdentity, "Wrong leader")
+	assert.Equal(t, 2, record.LeaderTransitions, "Wrong number of leader transitions")
+
+	close(second.stop)
+	assert.True(t, closedWithin(second.done, time.Second), "The second replica didn't stop")
+	assert.False(t, closedWithin(first.lost, 0), "The released leadership was reported as lost")
+}
+
+func TestTryAcquireOrRenewExpiredLease(t *testing.T) {
+	api := newFakeConfigMapsAPI()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	// The first replica acquires the lease, and stops renewing it, e.g. because
+	// its node went down.
+	first := newTestService(t, server, "aws-operator-1")
+	assert.True(t, first.tryAcquireOrRenew(), "The first replica wasn't elected")
+
+	now := time.Now()
+	second := newTestService(t, server, "aws-operator-2")
+	second.now = func() time.Time { return now }
+	assert.False(t, second.tryAcquireOrRenew(), "The lease was acquired before it expired")
+
+	now = now.Add(second.LeaseDuration - time.Millisecond)
+	assert.False(t, second.tryAcquireOrRenew(), "The lease was acquired before it expired")
+
+	now = now.Add(2 * time.Millisecond)
+	assert.True(t, second.tryAcquireOrRenew(), "The expired lease wasn't acquired")
+	assert.Equal(t, "aws-operator-2", api.record(t, "aws-operator").HolderIdentity, "Wrong leader")
+
+	// The first replica notices it lost the lease.
+	assert.False(t, first.tryAcquireOrRenew(), "The first replica renewed the lost lease")
+}
+
+func TestRunLosesLeadership(t *testing.T) {
+	api := newFakeConfigMapsAPI()
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	s := newTestService(t, server, "aws-operator-1")
+	r := runReplica(s)
+	assert.True(t, closedWithin(r.started, time.Second), "The replica wasn't elected")
+
+	// The lease can't be renewed anymore, the leadership ends within the renew
+	// deadline, before other replicas may take over.
+	api.setFailing(true)
+	start := time.Now()
+	assert.True(t, closedWithin(r.lost, time.Second), "The lost leadership wasn't reported")
+	assert.True(t, time.Since(start) < s.LeaseDuration, "The leadership ended after the lease expired")
+	assert.True(t, closedWithin(r.done, time.Second), "The replica didn't stop")
+}
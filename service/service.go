@@ -4,7 +4,9 @@ package service
 
 import (
 	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	microerror "github.com/giantswarm/microkit/error"
 	micrologger "github.com/giantswarm/microkit/logger"
@@ -14,6 +16,8 @@ import (
 	awsutil "github.com/giantswarm/aws-operator/client/aws"
 	k8sutil "github.com/giantswarm/aws-operator/client/k8s"
 	"github.com/giantswarm/aws-operator/service/create"
+	"github.com/giantswarm/aws-operator/service/instance"
+	"github.com/giantswarm/aws-operator/service/leaderelection"
 	"github.com/giantswarm/aws-operator/service/version"
 )
 
@@ -30,8 +34,66 @@ type Config struct {
 	AwsConfig awsutil.Config
 	K8sConfig k8sutil.Config
 
+	// AWS API rate limiting options.
+	AwsRateLimit float64
+
 	// AWS cerfificates options.
-	PubKeyFile string
+	PubKeyFile           string
+	ReconcileCertSecrets bool
+
+	// Cluster selection options.
+	ClusterSelector string
+	DefaultRegion   string
+	MaxClusters     int
+	OperatorID      string
+
+	// Tagging options.
+	AnnotationTags []string
+
+	// Naming options.
+	ResourcePrefix string
+
+	// Node draining options.
+	DrainNodes   bool
+	DrainTimeout time.Duration
+
+	// Instance options.
+	CloudConfigEncoding     string
+	CloudConfigValidation   bool
+	DeprecatedImages        string
+	InstanceHostnames       bool
+	InstanceRunningTimeout  time.Duration
+	LaunchConcurrency       int
+	TerminateStuckInstances bool
+	WaitForMastersReady     bool
+	WorkerSpotMaxPrice      string
+
+	// DNS options.
+	CheckZoneDelegation bool
+	DNSComponents       []string
+	DNSConcurrency      int
+
+	// Network options.
+	APIHealthCheckPath      string
+	IngressSourceCIDRs      []string
+	InternalAPILoadBalancer bool
+	NetworkPolicies         bool
+	S3VPCEndpoint           bool
+	FlowLogs                bool
+	DHCPOptions             bool
+
+	// Shutdown options.
+	ShutdownTimeout time.Duration
+
+	// Leader election options. Without leader election, every replica of the
+	// operator reconciles the clusters.
+	LeaderElection              bool
+	LeaderElectionIdentity      string
+	LeaderElectionLeaseDuration time.Duration
+	LeaderElectionLockName      string
+	LeaderElectionLockNamespace string
+	LeaderElectionRenewDeadline time.Duration
+	LeaderElectionRetryPeriod   time.Duration
 
 	Description string
 	GitCommit   string
@@ -66,8 +128,65 @@ func DefaultConfig() Config {
 		AwsConfig: awsutil.Config{},
 		K8sConfig: k8sutil.Config{},
 
+		// AWS API rate limiting options.
+		AwsRateLimit: 0,
+
 		// AWS certificates optionts.
-		PubKeyFile: "",
+		PubKeyFile:           "",
+		ReconcileCertSecrets: false,
+
+		// Cluster selection options.
+		ClusterSelector: "",
+		DefaultRegion:   "",
+		MaxClusters:     0,
+		OperatorID:      "",
+
+		// Tagging options.
+		AnnotationTags: nil,
+
+		// Naming options.
+		ResourcePrefix: "",
+
+		// Node draining options.
+		DrainNodes:   false,
+		DrainTimeout: 0,
+
+		// Instance options.
+		CloudConfigEncoding:     create.CloudConfigEncodingGzipBase64,
+		CloudConfigValidation:   false,
+		DeprecatedImages:        create.DeprecatedImagesIgnore,
+		InstanceHostnames:       false,
+		InstanceRunningTimeout:  10 * time.Minute,
+		LaunchConcurrency:       1,
+		TerminateStuckInstances: false,
+		WaitForMastersReady:     false,
+		WorkerSpotMaxPrice:      "",
+
+		// DNS options.
+		CheckZoneDelegation: false,
+		DNSComponents:       create.DNSComponents,
+		DNSConcurrency:      1,
+
+		// Network options.
+		APIHealthCheckPath:      create.DefaultAPIHealthCheckPath,
+		IngressSourceCIDRs:      nil,
+		InternalAPILoadBalancer: false,
+		NetworkPolicies:         false,
+		S3VPCEndpoint:           false,
+		FlowLogs:                false,
+		DHCPOptions:             false,
+
+		// Shutdown options.
+		ShutdownTimeout: 5 * time.Minute,
+
+		// Leader election options.
+		LeaderElection:              false,
+		LeaderElectionIdentity:      "",
+		LeaderElectionLeaseDuration: 15 * time.Second,
+		LeaderElectionLockName:      "aws-operator",
+		LeaderElectionLockNamespace: "default",
+		LeaderElectionRenewDeadline: 10 * time.Second,
+		LeaderElectionRetryPeriod:   2 * time.Second,
 
 		Description: "",
 		GitCommit:   "",
@@ -110,11 +229,41 @@ func New(config Config) (*Service, error) {
 	{
 		createConfig := create.DefaultConfig()
 
+		createConfig.APIHealthCheckPath = config.APIHealthCheckPath
+		createConfig.AnnotationTags = config.AnnotationTags
 		createConfig.AwsConfig = config.AwsConfig
+		createConfig.AwsRateLimit = config.AwsRateLimit
 		createConfig.CertWatcher = certWatcher
+		createConfig.CheckZoneDelegation = config.CheckZoneDelegation
+		createConfig.CloudConfigEncoding = config.CloudConfigEncoding
+		createConfig.CloudConfigValidation = config.CloudConfigValidation
+		createConfig.ClusterSelector = config.ClusterSelector
+		createConfig.DefaultRegion = config.DefaultRegion
+		createConfig.DeprecatedImages = config.DeprecatedImages
+		createConfig.DNSComponents = config.DNSComponents
+		createConfig.DNSConcurrency = config.DNSConcurrency
+		createConfig.DrainNodes = config.DrainNodes
+		createConfig.DrainTimeout = config.DrainTimeout
+		createConfig.IngressSourceCIDRs = config.IngressSourceCIDRs
+		createConfig.InstanceHostnames = config.InstanceHostnames
+		createConfig.InstanceRunningTimeout = config.InstanceRunningTimeout
+		createConfig.InternalAPILoadBalancer = config.InternalAPILoadBalancer
+		createConfig.LaunchConcurrency = config.LaunchConcurrency
 		createConfig.K8sClient = k8sClient
 		createConfig.Logger = config.Logger
+		createConfig.MaxClusters = config.MaxClusters
+		createConfig.NetworkPolicies = config.NetworkPolicies
+		createConfig.OperatorID = config.OperatorID
 		createConfig.PubKeyFile = config.PubKeyFile
+		createConfig.ReconcileCertSecrets = config.ReconcileCertSecrets
+		createConfig.ResourcePrefix = config.ResourcePrefix
+		createConfig.FlowLogs = config.FlowLogs
+		createConfig.DHCPOptions = config.DHCPOptions
+		createConfig.S3VPCEndpoint = config.S3VPCEndpoint
+		createConfig.ShutdownTimeout = config.ShutdownTimeout
+		createConfig.TerminateStuckInstances = config.TerminateStuckInstances
+		createConfig.WaitForMastersReady = config.WaitForMastersReady
+		createConfig.WorkerSpotMaxPrice = config.WorkerSpotMaxPrice
 
 		createService, err = create.New(createConfig)
 		if err != nil {
@@ -122,6 +271,38 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	var instanceService *instance.Service
+	{
+		instanceConfig := instance.DefaultConfig()
+
+		instanceConfig.AwsConfig = config.AwsConfig
+		instanceConfig.DefaultRegion = config.DefaultRegion
+
+		instanceService, err = instance.New(instanceConfig)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+	}
+
+	var leaderElectionService *leaderelection.Service
+	if config.LeaderElection {
+		leaderElectionConfig := leaderelection.DefaultConfig()
+
+		leaderElectionConfig.Identity = config.LeaderElectionIdentity
+		leaderElectionConfig.K8sClient = k8sClient
+		leaderElectionConfig.LeaseDuration = config.LeaderElectionLeaseDuration
+		leaderElectionConfig.LockName = config.LeaderElectionLockName
+		leaderElectionConfig.LockNamespace = config.LeaderElectionLockNamespace
+		leaderElectionConfig.Logger = config.Logger
+		leaderElectionConfig.RenewDeadline = config.LeaderElectionRenewDeadline
+		leaderElectionConfig.RetryPeriod = config.LeaderElectionRetryPeriod
+
+		leaderElectionService, err = leaderelection.New(leaderElectionConfig)
+		if err != nil {
+			return nil, microerror.MaskAny(err)
+		}
+	}
+
 	var versionService *version.Service
 	{
 		versionConfig := version.DefaultConfig()
@@ -139,11 +320,23 @@ func New(config Config) (*Service, error) {
 
 	newService := &Service{
 		// Dependencies.
-		Create:  createService,
-		Version: versionService,
+		Create:         createService,
+		Instance:       instanceService,
+		LeaderElection: leaderElectionService,
+		Version:        versionService,
+		logger:         config.Logger,
 
 		// Internals
-		bootOnce: sync.Once{},
+		bootOnce:     sync.Once{},
+		elections:    sync.WaitGroup{},
+		shutdownOnce: sync.Once{},
+		stop:         make(chan struct{}),
+	}
+
+	// Boot runs concurrently with Shutdown, so the election is accounted for
+	// before either of them starts.
+	if leaderElectionService != nil {
+		newService.elections.Add(1)
 	}
 
 	return newService, nil
@@ -151,15 +344,56 @@ func New(config Config) (*Service, error) {
 
 type Service struct {
 	// Dependencies.
-	Create  *create.Service
-	Version *version.Service
+	Create   *create.Service
+	Instance *instance.Service
+	// LeaderElection is nil when leader election is disabled.
+	LeaderElection *leaderelection.Service
+	Version        *version.Service
+	logger         micrologger.Logger
 
 	// Internals.
 	bootOnce sync.Once
+	// elections waits for the leader election to release the lease on
+	// shutdown.
+	elections    sync.WaitGroup
+	shutdownOnce sync.Once
+	stop         chan struct{}
 }
 
+// Boot starts reconciling the clusters. With leader election, the replica
+// stands by until it is elected.
 func (s *Service) Boot() {
 	s.bootOnce.Do(func() {
-		s.Create.Boot()
+		if s.LeaderElection == nil {
+			s.Create.Boot()
+			return
+		}
+
+		defer s.elections.Done()
+
+		s.LeaderElection.Run(s.stop, leaderelection.Callbacks{
+			OnStartedLeading: func(stop <-chan struct{}) {
+				s.Create.Boot()
+			},
+			OnStoppedLeading: func() {
+				// Another replica takes over as soon as the lease expires, so the
+				// operator exits right away instead of waiting for its reconciles to
+				// finish, which would race with the new leader. The watches of the
+				// clusters can't be restarted, it stands by once restarted.
+				s.logger.Log("error", "lost the leadership, exiting")
+				os.Exit(1)
+			},
+		})
+	})
+}
+
+// Shutdown stops the services, waiting for their active work to finish. The
+// lease of the leader is released afterwards, so another replica takes over
+// right away.
+func (s *Service) Shutdown() {
+	s.shutdownOnce.Do(func() {
+		s.Create.Shutdown()
+		close(s.stop)
+		s.elections.Wait()
 	})
 }